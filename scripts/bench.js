@@ -0,0 +1,178 @@
+#!/usr/bin/env node
+/**
+ * kimdb 벤치마크
+ * - 테스트 데이터 적재 후 쓰기/읽기/쿼리/WebSocket 왕복 측정
+ * - 처리량(ops/sec)과 지연 백분위(p50/p90/p99) 출력
+ * - --json: CI 비교용 JSON 출력
+ *
+ * 사용: node scripts/bench.js --url http://127.0.0.1:40000 --docs 1000 --concurrency 16 [--json]
+ */
+
+const args = process.argv.slice(2);
+
+function arg(name, fallback) {
+  const i = args.indexOf(`--${name}`);
+  return i >= 0 && args[i + 1] !== undefined ? args[i + 1] : fallback;
+}
+
+const URL_BASE = arg('url', process.env.KIMDB_URL || 'http://127.0.0.1:40000').replace(/\/$/, '');
+const API_KEY = arg('api-key', process.env.KIMDB_API_KEY || '');
+const COLLECTION = arg('collection', 'bench');
+const DOCS = parseInt(arg('docs', '1000'));
+const CONCURRENCY = parseInt(arg('concurrency', '16'));
+const WS_PINGS = parseInt(arg('pings', '500'));
+const JSON_OUTPUT = args.includes('--json');
+
+const headers = { 'Content-Type': 'application/json' };
+if (API_KEY) headers['X-API-Key'] = API_KEY;
+
+// ===== 측정 =====
+function percentile(sorted, p) {
+  if (sorted.length === 0) return 0;
+  const idx = Math.min(sorted.length - 1, Math.ceil((p / 100) * sorted.length) - 1);
+  return sorted[Math.max(0, idx)];
+}
+
+function summarize(name, latencies, errors, elapsedMs) {
+  const sorted = [...latencies].sort((a, b) => a - b);
+  const round = (n) => Math.round(n * 100) / 100;
+  return {
+    name,
+    ops: latencies.length,
+    errors,
+    opsPerSec: round(latencies.length / (elapsedMs / 1000)),
+    latencyMs: {
+      p50: round(percentile(sorted, 50)),
+      p90: round(percentile(sorted, 90)),
+      p99: round(percentile(sorted, 99)),
+      max: round(sorted[sorted.length - 1] || 0)
+    }
+  };
+}
+
+async function runPhase(name, count, fn) {
+  const latencies = [];
+  let errors = 0;
+  let next = 0;
+  const start = performance.now();
+
+  async function worker() {
+    while (next < count) {
+      const i = next++;
+      const t = performance.now();
+      try {
+        await fn(i);
+        latencies.push(performance.now() - t);
+      } catch {
+        errors++;
+      }
+    }
+  }
+
+  await Promise.all(Array.from({ length: Math.min(CONCURRENCY, count) }, worker));
+  return summarize(name, latencies, errors, performance.now() - start);
+}
+
+async function http(method, path, body) {
+  const res = await fetch(`${URL_BASE}${path}`, {
+    method,
+    headers,
+    body: body ? JSON.stringify(body) : undefined
+  });
+  if (!res.ok) throw new Error(`HTTP ${res.status}`);
+  return res.json();
+}
+
+// ===== WebSocket 왕복 =====
+async function wsPhase() {
+  if (typeof WebSocket === 'undefined') {
+    return { name: 'ws_ping', skipped: 'global WebSocket not available (Node 22+ required)' };
+  }
+
+  const wsUrl = URL_BASE.replace(/^http/, 'ws') + '/ws' + (API_KEY ? `?api_key=${encodeURIComponent(API_KEY)}` : '');
+  const ws = new WebSocket(wsUrl);
+  const pending = new Map();
+
+  await new Promise((resolve, reject) => {
+    ws.onerror = () => reject(new Error('WebSocket error'));
+    ws.onmessage = (event) => {
+      const msg = JSON.parse(event.data);
+      if (msg.type === 'connected') resolve();
+      if (msg.type === 'pong' && pending.has(msg.time)) {
+        pending.get(msg.time)();
+        pending.delete(msg.time);
+      }
+    };
+  });
+
+  let seq = 0;
+  const result = await runPhase('ws_ping', WS_PINGS, () => new Promise((resolve, reject) => {
+    const time = ++seq;
+    const timer = setTimeout(() => {
+      pending.delete(time);
+      reject(new Error('timeout'));
+    }, 5000);
+    pending.set(time, () => {
+      clearTimeout(timer);
+      resolve();
+    });
+    ws.send(JSON.stringify({ type: 'ping', time }));
+  }));
+
+  ws.close();
+  return result;
+}
+
+// ===== 실행 =====
+async function main() {
+  const health = await http('GET', '/health');
+  const results = [];
+
+  results.push(await runPhase('write', DOCS, (i) =>
+    http('PUT', `/api/c/${COLLECTION}/bench_${i}`, { data: { n: i, group: i % 10, payload: 'x'.repeat(64) } })
+  ));
+
+  results.push(await runPhase('read', DOCS, (i) =>
+    http('GET', `/api/c/${COLLECTION}/bench_${i}`)
+  ));
+
+  const queries = Math.max(1, Math.floor(DOCS / 10));
+  results.push(await runPhase('query', queries, (i) =>
+    http('POST', '/api/sql', { sql: `SELECT * FROM ${COLLECTION} WHERE group = ? LIMIT 100`, params: [i % 10], collection: COLLECTION })
+  ));
+
+  results.push(await wsPhase());
+
+  const report = {
+    server: { url: URL_BASE, version: health.version, serverId: health.serverId },
+    config: { docs: DOCS, concurrency: CONCURRENCY, pings: WS_PINGS },
+    timestamp: new Date().toISOString(),
+    results
+  };
+
+  if (JSON_OUTPUT) {
+    console.log(JSON.stringify(report, null, 2));
+    return;
+  }
+
+  console.log(`\nkimdb benchmark - ${URL_BASE} (v${health.version})`);
+  console.log('='.repeat(72));
+  console.log('phase      ops     err   ops/sec     p50      p90      p99      max');
+  for (const r of results) {
+    if (r.skipped) {
+      console.log(`${r.name.padEnd(10)} skipped: ${r.skipped}`);
+      continue;
+    }
+    const l = r.latencyMs;
+    console.log(
+      `${r.name.padEnd(10)} ${String(r.ops).padStart(6)} ${String(r.errors).padStart(5)} ${String(r.opsPerSec).padStart(9)} ` +
+      `${l.p50.toFixed(2).padStart(8)} ${l.p90.toFixed(2).padStart(8)} ${l.p99.toFixed(2).padStart(8)} ${l.max.toFixed(2).padStart(8)}`
+    );
+  }
+  console.log('='.repeat(72) + '\n');
+}
+
+main().then(() => process.exit(0)).catch((e) => {
+  console.error('[bench] Failed:', e.message);
+  process.exit(1);
+});
@@ -0,0 +1,272 @@
+#!/usr/bin/env node
+/**
+ * kimdb 프로토콜 호환성 검사
+ * - 대상 서버에 클라이언트가 쓰는 REST/WebSocket 동작을 차례로 실행
+ * - 항목별 지원(pass) / 미지원(unsupported: 404 등) / 응답 불일치(fail) 보고
+ * - --json: CI용 JSON 출력, fail이 하나라도 있으면 종료 코드 1
+ *
+ * 사용: node scripts/conformance.js --url http://127.0.0.1:40000 [--api-key KEY] [--json]
+ */
+
+const args = process.argv.slice(2);
+
+function arg(name, fallback) {
+  const i = args.indexOf(`--${name}`);
+  return i >= 0 && args[i + 1] !== undefined ? args[i + 1] : fallback;
+}
+
+const URL_BASE = arg('url', process.env.KIMDB_URL || 'http://127.0.0.1:40000').replace(/\/$/, '');
+const API_KEY = arg('api-key', process.env.KIMDB_API_KEY || '');
+const COLLECTION = arg('collection', `conformance_${Date.now().toString(36)}`);
+const JSON_OUTPUT = args.includes('--json');
+
+const headers = { 'Content-Type': 'application/json' };
+if (API_KEY) headers['X-API-Key'] = API_KEY;
+
+class Unsupported extends Error {}
+
+// PUT이 미지원이면 문서를 전제로 하는 이후 항목도 미지원 처리
+let seeded = false;
+
+function requireSeed() {
+  if (!seeded) throw new Unsupported('requires PUT /api/c/:col/:id');
+}
+
+function expect(condition, message) {
+  if (!condition) throw new Error(message);
+}
+
+async function http(method, path, body) {
+  const res = await fetch(`${URL_BASE}${path}`, {
+    method,
+    headers,
+    body: body ? JSON.stringify(body) : undefined,
+    signal: AbortSignal.timeout(5000)
+  });
+  const text = await res.text();
+  // 라우트 없음(fastify 기본 404)과 문서 없음({error: 'Not found'}) 구분
+  if (res.status === 404 && !text.includes('"Not found"')) {
+    throw new Unsupported(`${method} ${path} → route not found`);
+  }
+  let parsed;
+  try {
+    parsed = JSON.parse(text);
+  } catch {
+    parsed = text;
+  }
+  return { status: res.status, body: parsed };
+}
+
+// ===== WebSocket =====
+async function loadWebSocket() {
+  if (typeof globalThis.WebSocket !== 'undefined') return globalThis.WebSocket;
+  try {
+    return (await import('ws')).default;
+  } catch {
+    throw new Unsupported('no WebSocket implementation (Node 22+ or ws package required)');
+  }
+}
+
+async function openSocket() {
+  const WebSocketImpl = await loadWebSocket();
+  const wsUrl = URL_BASE.replace(/^http/, 'ws') + '/ws' + (API_KEY ? `?api_key=${encodeURIComponent(API_KEY)}` : '');
+
+  return new Promise((resolve, reject) => {
+    const ws = new WebSocketImpl(wsUrl);
+    const waiters = [];
+    const timer = setTimeout(() => reject(new Error('no connected message within 5s')), 5000);
+
+    ws.onerror = () => reject(new Error('WebSocket error'));
+    ws.onmessage = (event) => {
+      const msg = JSON.parse(String(event.data));
+      if (msg.type === 'connected') {
+        clearTimeout(timer);
+        resolve({ ws, connected: msg, request });
+      }
+      for (const w of [...waiters]) {
+        if (w.match(msg)) {
+          waiters.splice(waiters.indexOf(w), 1);
+          w.resolve(msg);
+        }
+      }
+    };
+
+    function request(msg, match, timeoutMs = 3000) {
+      return new Promise((res, rej) => {
+        const t = setTimeout(() => rej(new Error(`no reply to ${msg.type} within ${timeoutMs}ms`)), timeoutMs);
+        waiters.push({ match, resolve: (m) => { clearTimeout(t); res(m); } });
+        ws.send(JSON.stringify(msg));
+      });
+    }
+  });
+}
+
+// ===== 검사 항목 =====
+const checks = [
+  ['GET /health', async () => {
+    const { status, body } = await http('GET', '/health');
+    expect(status === 200, `status ${status}`);
+    expect(body.status === 'ok', `status field ${JSON.stringify(body.status)}`);
+    expect(typeof body.version === 'string', 'missing version');
+  }],
+
+  ['PUT /api/c/:col/:id (upsert + merge)', async () => {
+    let r = await http('PUT', `/api/c/${COLLECTION}/doc1`, { data: { a: 1, b: 1 } });
+    expect(r.status === 200 && r.body._version === 1, `create → ${r.status} ${JSON.stringify(r.body)}`);
+    seeded = true;
+    r = await http('PUT', `/api/c/${COLLECTION}/doc1`, { data: { b: 2 } });
+    expect(r.body._version === 2, `second PUT _version ${r.body._version}`);
+  }],
+
+  ['GET /api/c/:col/:id', async () => {
+    requireSeed();
+    const { status, body } = await http('GET', `/api/c/${COLLECTION}/doc1`);
+    expect(status === 200, `status ${status}`);
+    expect(body.data && body.data.a === 1 && body.data.b === 2, `merged data ${JSON.stringify(body.data)}`);
+  }],
+
+  ['GET /api/c/:col/:id missing → 404', async () => {
+    const { status } = await http('GET', `/api/c/${COLLECTION}/missing`);
+    expect(status === 404, `status ${status}`);
+  }],
+
+  ['GET /api/c/:col (list shape)', async () => {
+    requireSeed();
+    const { body } = await http('GET', `/api/c/${COLLECTION}`);
+    expect(Array.isArray(body.data), 'data is not an array');
+    expect(body.count === body.data.length, 'count does not match data length');
+    const doc = body.data.find((d) => d.id === 'doc1');
+    expect(doc && doc._version === 2 && doc.a === 1, 'documents are not flattened {id, ...data, _version}');
+  }],
+
+  ['PATCH /api/c/:col/:id', async () => {
+    requireSeed();
+    const { status, body } = await http('PATCH', `/api/c/${COLLECTION}/doc1`, { data: { c: 3 } });
+    expect(status === 200 && body._version === 3, `${status} ${JSON.stringify(body)}`);
+  }],
+
+  ['POST /api/sql SELECT', async () => {
+    requireSeed();
+    const { status, body } = await http('POST', '/api/sql', { sql: `SELECT * FROM ${COLLECTION} WHERE a = ?`, params: [1], collection: COLLECTION });
+    expect(status === 200 && Array.isArray(body.rows), `${status} ${JSON.stringify(body)}`);
+    expect(body.rows.length === 1, `rows ${body.rows.length}`);
+  }],
+
+  ['POST /api/sql UPDATE (param order WHERE, SET)', async () => {
+    requireSeed();
+    const { status, body } = await http('POST', '/api/sql', { sql: `UPDATE ${COLLECTION} SET c = ? WHERE a = ?`, params: [1, 9], collection: COLLECTION });
+    if (status === 500 && /unsupported/i.test(JSON.stringify(body))) throw new Unsupported('UPDATE not supported');
+    expect(status === 200 && body.updated === 1, `${status} ${JSON.stringify(body)}`);
+  }],
+
+  ['GET /api/sample/:col', async () => {
+    requireSeed();
+    const { status, body } = await http('GET', `/api/sample/${COLLECTION}?n=1`);
+    expect(status === 200 && body.data.length === 1, `${status} ${JSON.stringify(body)}`);
+  }],
+
+  ['POST /api/c/:col/versions', async () => {
+    requireSeed();
+    const { status, body } = await http('POST', `/api/c/${COLLECTION}/versions`, { versions: { doc1: 1, ghost: 1 } });
+    expect(status === 200, `status ${status}`);
+    expect(body.stale.length === 1 && body.stale[0].id === 'doc1', `stale ${JSON.stringify(body.stale)}`);
+    expect(body.missing.length === 1 && body.missing[0] === 'ghost', `missing ${JSON.stringify(body.missing)}`);
+  }],
+
+  ['DELETE /api/c/:col/:id', async () => {
+    requireSeed();
+    const { status } = await http('DELETE', `/api/c/${COLLECTION}/doc1`);
+    expect(status === 200, `status ${status}`);
+    expect((await http('GET', `/api/c/${COLLECTION}/doc1`)).status === 404, 'document still readable after delete');
+  }],
+
+  ['WS connected / ping', async () => {
+    const { ws, connected, request } = await openSocket();
+    try {
+      expect(typeof connected.clientId === 'string', 'connected without clientId');
+      const pong = await request({ type: 'ping', time: 42 }, (m) => m.type === 'pong');
+      expect(pong.time === 42, `pong.time ${pong.time}`);
+    } finally {
+      ws.close();
+    }
+  }],
+
+  ['WS requestId echo', async () => {
+    const { ws, request } = await openSocket();
+    try {
+      const pong = await request({ type: 'ping', requestId: 'r1' }, (m) => m.type === 'pong');
+      if (pong.requestId === undefined) throw new Unsupported('server does not echo requestId');
+      expect(pong.requestId === 'r1', `requestId ${pong.requestId}`);
+    } finally {
+      ws.close();
+    }
+  }],
+
+  ['WS update_batch', async () => {
+    const { ws, request } = await openSocket();
+    try {
+      const reply = await request(
+        { type: 'update_batch', requestId: 'b1', ops: [{ collection: COLLECTION, id: 'b1', data: { x: 1 } }, { collection: COLLECTION, id: 'b2', data: { x: 2 } }] },
+        (m) => m.requestId === 'b1' || (m.type === 'error' && /update_batch/.test(m.message))
+      );
+      if (reply.type === 'error' && /Unknown message type/.test(reply.message)) throw new Unsupported('update_batch');
+      expect(reply.type === 'batch_ack' && reply.results.length === 2, JSON.stringify(reply));
+    } finally {
+      ws.close();
+    }
+  }],
+
+  ['WS crdt_get', async () => {
+    const { ws, request } = await openSocket();
+    try {
+      const state = await request({ type: 'crdt_get', collection: COLLECTION, docId: 'crdt1' }, (m) => m.type === 'crdt_state' || m.type === 'error');
+      expect(state.type === 'crdt_state' && state.docId === 'crdt1', JSON.stringify(state));
+    } finally {
+      ws.close();
+    }
+  }]
+];
+
+// ===== 실행 =====
+async function main() {
+  const results = [];
+  for (const [name, fn] of checks) {
+    try {
+      await fn();
+      results.push({ name, result: 'pass' });
+    } catch (e) {
+      // 첫 항목에서 연결 자체가 안 되면 나머지 항목은 의미 없음
+      if (results.length === 0 && e instanceof TypeError) {
+        console.error(`[conformance] Cannot reach ${URL_BASE}: ${e.message}`);
+        process.exit(1);
+      }
+      results.push({ name, result: e instanceof Unsupported ? 'unsupported' : 'fail', detail: e.message });
+    }
+  }
+
+  const summary = {
+    pass: results.filter((r) => r.result === 'pass').length,
+    unsupported: results.filter((r) => r.result === 'unsupported').length,
+    fail: results.filter((r) => r.result === 'fail').length
+  };
+
+  if (JSON_OUTPUT) {
+    console.log(JSON.stringify({ url: URL_BASE, collection: COLLECTION, timestamp: new Date().toISOString(), summary, results }, null, 2));
+  } else {
+    console.log(`\nkimdb conformance - ${URL_BASE}`);
+    console.log('='.repeat(72));
+    for (const r of results) {
+      const mark = r.result === 'pass' ? 'PASS' : r.result === 'unsupported' ? 'N/A ' : 'FAIL';
+      console.log(`${mark}  ${r.name}${r.detail ? `\n      ${r.detail}` : ''}`);
+    }
+    console.log('='.repeat(72));
+    console.log(`pass ${summary.pass}, unsupported ${summary.unsupported}, fail ${summary.fail}\n`);
+  }
+
+  process.exit(summary.fail > 0 ? 1 : 0);
+}
+
+main().catch((e) => {
+  console.error('[conformance] Failed:', e.message);
+  process.exit(1);
+});
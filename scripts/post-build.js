@@ -1,10 +1,11 @@
 /**
  * Post-build script
  * - Copy CRDT v2 JS files to dist
+ * - Copy shared JS modules (+ .d.ts) to dist
  * - Add shebang to CLI
  */
 
-import { copyFileSync, readFileSync, writeFileSync, existsSync, mkdirSync } from 'fs';
+import { copyFileSync, readFileSync, writeFileSync, existsSync, mkdirSync, readdirSync } from 'fs';
 import { join, dirname } from 'path';
 import { fileURLToPath } from 'url';
 
@@ -23,7 +24,18 @@ if (existsSync(crdtSrc)) {
   console.log('[post-build] Copied CRDT v2 files');
 }
 
-// 2. Add shebang to CLI
+// 2. Copy shared JS modules (api-server.js가 빌드 없이 쓰므로 JS + 타입 선언)
+const sharedSrc = join(root, 'src/shared');
+const sharedDest = join(root, 'dist/shared');
+const sharedFiles = readdirSync(sharedSrc).filter((f) => f.endsWith('.js') || f.endsWith('.d.ts'));
+
+if (sharedFiles.length > 0) {
+  mkdirSync(sharedDest, { recursive: true });
+  for (const file of sharedFiles) copyFileSync(join(sharedSrc, file), join(sharedDest, file));
+  console.log(`[post-build] Copied ${sharedFiles.length} shared files`);
+}
+
+// 3. Add shebang to CLI
 const cliPath = join(root, 'dist/cli/index.js');
 if (existsSync(cliPath)) {
   const content = readFileSync(cliPath, 'utf-8');
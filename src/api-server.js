@@ -37,6 +37,17 @@ import {
   UndoManager,
   PresenceManager
 } from "./crdt/v2/index.js";
+import { parseSql, matchesWhere, selectRows } from "./shared/sql.js";
+import { negotiateProtocol, SERVER_PROTOCOLS } from "./shared/protocol.js";
+import { checkCollation, collator } from "./shared/collation.js";
+import { ChannelRooms, channelNameError, publishError, channelMessage } from "./shared/channels.js";
+import { snapshotLabelError, restoreState } from "./shared/snapshots.js";
+import { sampleSizeError } from "./shared/sample.js";
+import { versionsRequestError, compareVersions, VERSION_QUERY_CHUNK } from "./shared/versions.js";
+import { freshUndoOps } from "./shared/undo.js";
+import { applyUpdateOps, UpdateOpError } from "./shared/update-ops.js";
+import { checksumTree } from "./shared/checksum.js";
+import { retentionPolicyError, getRetentionPolicy, applyRetention } from "./shared/retention.js";
 
 // ===== Configuration =====
 const __dirname = dirname(fileURLToPath(import.meta.url));
@@ -500,7 +511,7 @@ async function initRedis() {
     });
 
     // 채널 구독
-    await redisSub.subscribe("kimdb:broadcast", "kimdb:presence", "kimdb:sync");
+    await redisSub.subscribe("kimdb:broadcast", "kimdb:presence", "kimdb:sync", "kimdb:channel", "kimdb:restore");
 
     redisSub.on("message", (channel, message) => {
       try {
@@ -553,6 +564,22 @@ function handleRedisMessage(channel, data) {
         }, null);
       }
       break;
+
+    case "kimdb:restore": {
+      // 다른 서버에서 스냅샷 복원 → 캐시를 버리고 저장된 상태로 다시 읽어 로컬 구독자에게
+      const key = `${data.collection}:${data.docId}`;
+      if (crdtDocs.has(key)) {
+        crdtDocs.delete(key);
+        const restored = getCRDTDoc(data.collection, data.docId);
+        localBroadcastToDoc(data.collection, data.docId, restoredMessage(data.collection, data.docId, restored, data.label), null);
+      }
+      break;
+    }
+
+    case "kimdb:channel":
+      // 다른 서버 클라이언트가 보낸 채널 메시지
+      localChannelDeliver(data.channel, data.payload, data.from);
+      break;
   }
 }
 
@@ -694,6 +721,8 @@ const crdtDocs = new LRUCache(config.cache.maxDocs);
 const presenceManagers = new Map(); // collection:docId -> { pm, lastAccess }
 const clientPresence = new Map(); // clientId -> { collection, docId, nodeId }
 const clientUndoManagers = new Map(); // clientId:collection:docId -> { um, lastAccess }
+const channels = new ChannelRooms(); // pub/sub 채널 구독 (저장 없음)
+const processedOps = new LRUCache(10000); // msgId -> 보낸 crdt_ops_ok (클라이언트 outbox 재전송 중복 제거)
 
 // ===== Helper Functions =====
 function generateClientId() {
@@ -836,6 +865,23 @@ function broadcast(collection, event, data, excludeClientId) {
   publishToRedis("kimdb:broadcast", { collection, event, payload: data });
 }
 
+// 채널 메시지 → 이 서버의 구독자에게 (전달한 수 반환)
+function localChannelDeliver(channel, payload, from) {
+  const msg = JSON.stringify(channelMessage(channel, payload, from));
+  let count = 0;
+
+  for (const clientId of channels.recipients(channel, from)) {
+    const client = clients.get(clientId);
+    if (client && client.socket.readyState === 1) {
+      client.socket.send(msg);
+      count++;
+    }
+  }
+
+  metrics.websocket.broadcasts++;
+  return count;
+}
+
 function broadcastOp(collection, docId, operations, excludeClientId) {
   const msg = {
     type: "crdt_sync",
@@ -886,6 +932,50 @@ function runCleanup() {
   if (docsRemoved + presenceRemoved + undoRemoved > 0) {
     console.log(`[kimdb] Cleanup: docs=${docsRemoved}, presence=${presenceRemoved}, undo=${undoRemoved}`);
   }
+
+  // 4. 보존 정책 적용
+  for (const { collection } of db.prepare(`SELECT collection FROM _retention`).all()) {
+    try {
+      const removed = applyRetention(db, collection, ensureCollection, now);
+      for (const id of removed) {
+        localBroadcast(collection, "delete", { collection, id }, null);
+      }
+      if (removed.length > 0) console.log(`[kimdb] Retention: ${collection} removed=${removed.length}`);
+    } catch (e) {
+      console.error(`[kimdb] Retention failed for ${collection}:`, e.message);
+    }
+  }
+}
+
+// ===== Snapshots =====
+// 협업 문서의 이름 붙인 체크포인트 (규칙은 src/shared/snapshots.js, 저장은 _snapshots 테이블)
+// 같은 라벨이 이미 있으면 null
+function createSnapshot(collection, docId, label, state) {
+  const createdAt = Date.now();
+  const version = state.version || 0;
+  const result = db.prepare(`
+    INSERT INTO _snapshots (collection, doc_id, label, state, version, created_at)
+    VALUES (?, ?, ?, ?, ?, ?)
+    ON CONFLICT(collection, doc_id, label) DO NOTHING
+  `).run(collection, docId, label, JSON.stringify(state), version, createdAt);
+  return result.changes > 0 ? { label, version, createdAt } : null;
+}
+
+function listSnapshots(collection, docId) {
+  return db.prepare(`
+    SELECT label, version, created_at AS createdAt FROM _snapshots
+    WHERE collection = ? AND doc_id = ? ORDER BY created_at DESC, rowid DESC
+  `).all(collection, docId);
+}
+
+function getSnapshotState(collection, docId, label) {
+  const row = db.prepare(`SELECT state FROM _snapshots WHERE collection = ? AND doc_id = ? AND label = ?`)
+    .get(collection, docId, label);
+  return row ? JSON.parse(row.state) : null;
+}
+
+function restoredMessage(collection, docId, doc, label) {
+  return { type: "crdt_state", collection, docId, state: doc.toJSON(), data: doc.toObject(), restored: label };
 }
 
 // ===== Schema Setup =====
@@ -915,6 +1005,22 @@ function ensureSchema() {
     );
     CREATE INDEX IF NOT EXISTS idx_op_logs_ts ON operation_logs(timestamp);
     CREATE INDEX IF NOT EXISTS idx_op_logs_type ON operation_logs(type);
+    CREATE TABLE IF NOT EXISTS _retention (
+      collection TEXT PRIMARY KEY,
+      max_age_ms INTEGER,
+      max_documents INTEGER,
+      archive_to TEXT,
+      updated_at TEXT DEFAULT CURRENT_TIMESTAMP
+    );
+    CREATE TABLE IF NOT EXISTS _snapshots (
+      collection TEXT NOT NULL,
+      doc_id TEXT NOT NULL,
+      label TEXT NOT NULL,
+      state TEXT NOT NULL,
+      version INTEGER NOT NULL,
+      created_at INTEGER NOT NULL,
+      PRIMARY KEY (collection, doc_id, label)
+    );
   `);
 
   // Check if _sync_log exists and has ts column
@@ -1035,6 +1141,14 @@ function requireAuth(req, reply) {
 // ===== WebSocket Handler =====
 fastify.register(async function (fastify) {
   fastify.get("/ws", { websocket: true }, (socket, req) => {
+    const requested = req.query?.protocol;
+    const protocol = negotiateProtocol(requested);
+    if (!protocol) {
+      socket.send(JSON.stringify({ type: "error", message: `Unsupported protocol: ${requested}`, protocol: SERVER_PROTOCOLS[0] }));
+      socket.close(1002, "Unsupported protocol");
+      return;
+    }
+
     const clientId = generateClientId();
     clients.set(clientId, {
       socket,
@@ -1048,7 +1162,7 @@ fastify.register(async function (fastify) {
       metrics.websocket.peak = metrics.websocket.connections;
     }
 
-    socket.send(JSON.stringify({ type: "connected", clientId, serverId: config.serverId }));
+    socket.send(JSON.stringify({ type: "connected", clientId, serverId: config.serverId, protocol }));
 
     socket.on("message", (raw) => {
       try {
@@ -1070,10 +1184,48 @@ fastify.register(async function (fastify) {
   });
 });
 
+// update_batch의 expectedVersion 불일치 - 트랜잭션 안에서 던지므로 배치 전체가 롤백됨
+class BatchVersionConflict extends Error {
+  constructor(collection, id, expected, current) {
+    super(`Version conflict on ${collection}/${id}: expected ${expected}, current ${current}`);
+    this.collection = collection;
+    this.id = id;
+    this.expected = expected;
+    this.current = current;
+  }
+}
+
+function runUpdateBatch(ops) {
+  const apply = db.transaction(() => ops.map(op => {
+    const col = ensureCollection(op.collection);
+    const existing = db.prepare(`SELECT data, _version FROM ${col} WHERE id = ? AND _deleted = 0`).get(op.id);
+    const current = existing ? existing._version : 0;
+    if (op.expectedVersion !== undefined && op.expectedVersion !== current) {
+      throw new BatchVersionConflict(op.collection, op.id, op.expectedVersion, current);
+    }
+
+    if (existing) {
+      const merged = { ...JSON.parse(existing.data), ...op.data };
+      db.prepare(`UPDATE ${col} SET data = ?, _version = _version + 1, updated_at = CURRENT_TIMESTAMP WHERE id = ?`)
+        .run(JSON.stringify(merged), op.id);
+      return { collection: col, id: op.id, data: merged, _version: existing._version + 1 };
+    }
+
+    db.prepare(`INSERT INTO ${col} (id, data, _version, _deleted, updated_at) VALUES (?, ?, 1, 0, CURRENT_TIMESTAMP)
+      ON CONFLICT(id) DO UPDATE SET data = excluded.data, _version = 1, _deleted = 0, updated_at = CURRENT_TIMESTAMP`)
+      .run(op.id, JSON.stringify(op.data));
+    return { collection: col, id: op.id, data: { ...op.data }, _version: 1 };
+  }));
+  const results = apply();
+  metrics.writes.total += ops.length;
+  return results;
+}
+
 function handleWebSocketMessage(clientId, socket, msg) {
+  // 요청에 requestId가 있으면 응답에 그대로 붙여 클라이언트가 짝을 맞출 수 있게 함
   const send = (data) => {
     if (socket.readyState === 1) {
-      socket.send(JSON.stringify(data));
+      socket.send(JSON.stringify(msg.requestId !== undefined ? { ...data, requestId: msg.requestId } : data));
       metrics.websocket.messages.sent++;
     }
   };
@@ -1139,13 +1291,23 @@ function handleWebSocketMessage(clientId, socket, msg) {
     }
 
     case "crdt_ops": {
+      // 재전송된 메시지는 다시 적용하지 않고 처음 보낸 확인만 다시 보냄
+      const msgId = typeof msg.msgId === "string" ? msg.msgId : null;
+      const previous = msgId ? processedOps.get(msgId) : null;
+      if (previous) {
+        send(previous);
+        break;
+      }
+
       const doc = getCRDTDoc(msg.collection, msg.docId);
       const applied = doc.applyRemoteBatch(msg.operations);
       saveCRDTToDB(msg.collection, msg.docId, doc);
       broadcastOp(msg.collection, msg.docId, msg.operations, clientId);
       metrics.sync.operations += applied;
       logOperation("crdt_ops", msg.collection, msg.docId, clientId, true, `applied:${applied}`);
-      send({ type: "crdt_ops_ok", docId: msg.docId, applied, version: doc.version });
+      const ack = { type: "crdt_ops_ok", docId: msg.docId, applied, version: doc.version, ...(msgId && { msgId }) };
+      if (msgId) processedOps.set(msgId, ack);
+      send(ack);
       break;
     }
 
@@ -1284,6 +1446,8 @@ function handleWebSocketMessage(clientId, socket, msg) {
     // ===== Undo/Redo =====
     case "undo_capture": {
       const um = getClientUndoManager(clientId, msg.collection, msg.docId);
+      // boundary: 이전 편집과 묶지 않고 새 Undo 단위로 시작 (클라이언트 transact)
+      if (msg.boundary) um.stopCapturing();
       um.capture(msg.op, msg.previousValue);
       metrics.undo.captures++;
       send({ type: "undo_capture_ok", state: um.state });
@@ -1292,17 +1456,16 @@ function handleWebSocketMessage(clientId, socket, msg) {
 
     case "undo": {
       const um = getClientUndoManager(clientId, msg.collection, msg.docId);
-      const inverseOps = um.undo();
+      const stored = um.undo();
 
-      if (!inverseOps || inverseOps.length === 0) {
-        send({ type: "undo_empty" });
+      if (!stored || stored.length === 0) {
+        send({ type: "undo_empty", state: um.state });
         break;
       }
 
       const doc = getCRDTDoc(msg.collection, msg.docId);
+      const inverseOps = freshUndoOps(doc, stored, "undo");
       for (const op of inverseOps) {
-        if (!op.clock) op.clock = doc.clock.tick().toJSON();
-        if (!op.opId) op.opId = `undo_${Date.now()}_${Math.random().toString(36).slice(2, 8)}`;
         doc.applyRemote(op);
       }
       saveCRDTToDB(msg.collection, msg.docId, doc);
@@ -1321,17 +1484,16 @@ function handleWebSocketMessage(clientId, socket, msg) {
 
     case "redo": {
       const um = getClientUndoManager(clientId, msg.collection, msg.docId);
-      const ops = um.redo();
+      const stored = um.redo();
 
-      if (!ops || ops.length === 0) {
-        send({ type: "redo_empty" });
+      if (!stored || stored.length === 0) {
+        send({ type: "redo_empty", state: um.state });
         break;
       }
 
       const doc = getCRDTDoc(msg.collection, msg.docId);
+      const ops = freshUndoOps(doc, stored, "redo");
       for (const op of ops) {
-        if (!op.clock) op.clock = doc.clock.tick().toJSON();
-        if (!op.opId) op.opId = `redo_${Date.now()}_${Math.random().toString(36).slice(2, 8)}`;
         doc.applyRemote(op);
       }
       saveCRDTToDB(msg.collection, msg.docId, doc);
@@ -1464,9 +1626,10 @@ function handleWebSocketMessage(clientId, socket, msg) {
     }
 
     case "presence_get": {
-      const pm = getPresenceManager(msg.collection, msg.docId);
-      pm.cleanup();
-      const users = [...pm.users.values()];
+      // 참여자 없는 문서는 조회만으로 목록을 만들지 않음
+      const pm = presenceManagers.has(`${msg.collection}:${msg.docId}`) ? getPresenceManager(msg.collection, msg.docId) : null;
+      pm?.cleanup();
+      const users = pm ? [...pm.users.values()] : [];
       send({
         type: "presence_users",
         collection: msg.collection,
@@ -1477,6 +1640,106 @@ function handleWebSocketMessage(clientId, socket, msg) {
       break;
     }
 
+    // ===== Channels (저장 없는 pub/sub, src/shared/channels.js) =====
+    case "channel_subscribe":
+    case "channel_unsubscribe": {
+      const problem = channelNameError(msg.channel);
+      if (problem) {
+        send({ type: "error", message: problem });
+        break;
+      }
+      if (msg.type === "channel_subscribe") channels.join(clientId, msg.channel);
+      else channels.leave(clientId, msg.channel);
+      send({ type: `${msg.type}d`, channel: msg.channel });
+      break;
+    }
+
+    case "channel_publish": {
+      const problem = publishError(msg.channel, msg.payload);
+      if (problem) {
+        send({ type: "error", message: problem });
+        break;
+      }
+      const delivered = localChannelDeliver(msg.channel, msg.payload, clientId);
+      // 다른 서버 구독자에게는 Redis로 (delivered에는 포함되지 않음)
+      publishToRedis("kimdb:channel", { channel: msg.channel, payload: msg.payload ?? null, from: clientId });
+      send({ type: "channel_published", channel: msg.channel, delivered });
+      break;
+    }
+
+    // ===== Snapshots (협업 문서의 이름 붙인 체크포인트, src/shared/snapshots.js) =====
+    case "snapshot_create": {
+      const problem = snapshotLabelError(msg.label);
+      if (problem) {
+        send({ type: "error", message: problem });
+        break;
+      }
+      const doc = getCRDTDoc(msg.collection, msg.docId);
+      const info = createSnapshot(msg.collection, msg.docId, msg.label, doc.toJSON());
+      if (!info) {
+        send({ type: "error", message: `Snapshot already exists: ${msg.label}` });
+        break;
+      }
+      logOperation("snapshot_create", msg.collection, msg.docId, clientId, true, msg.label);
+      send({ type: "snapshot_created", collection: msg.collection, docId: msg.docId, ...info });
+      break;
+    }
+
+    case "snapshot_list": {
+      send({ type: "snapshots", collection: msg.collection, docId: msg.docId, snapshots: listSnapshots(msg.collection, msg.docId) });
+      break;
+    }
+
+    case "snapshot_restore": {
+      const state = typeof msg.label === "string" ? getSnapshotState(msg.collection, msg.docId, msg.label) : null;
+      if (!state) {
+        send({ type: "error", message: `Snapshot not found: ${msg.label}` });
+        break;
+      }
+      const doc = restoreState(getCRDTDoc(msg.collection, msg.docId), state);
+      saveCRDTToDB(msg.collection, msg.docId, doc);
+      // 요청한 클라이언트도 구독 중이면 응답보다 먼저 새 상태를 받음
+      localBroadcastToDoc(msg.collection, msg.docId, restoredMessage(msg.collection, msg.docId, doc, msg.label), null);
+      publishToRedis("kimdb:restore", { collection: msg.collection, docId: msg.docId, label: msg.label });
+      logOperation("snapshot_restore", msg.collection, msg.docId, clientId, true, msg.label);
+      send({ type: "snapshot_restored", collection: msg.collection, docId: msg.docId, label: msg.label, version: doc.version });
+      break;
+    }
+
+    // ===== Batch Update =====
+    // 여러 문서를 한 트랜잭션으로 병합 업데이트, 하나라도 실패하면 전체 롤백
+    // op.expectedVersion이 있으면 현재 버전이 같을 때만 (0 = 문서가 없어야 함), 아니면 code: "version_conflict"
+    case "update_batch": {
+      const ops = msg.ops;
+      const valid = Array.isArray(ops) && ops.length > 0 && ops.length <= 500 && ops.every(op =>
+        op && typeof op.collection === "string" && typeof op.id === "string" && op.id !== "" &&
+        typeof op.data === "object" && op.data !== null && !Array.isArray(op.data) &&
+        (op.expectedVersion === undefined || (Number.isInteger(op.expectedVersion) && op.expectedVersion >= 0)));
+      if (!valid) {
+        send({ type: "error", message: "ops must be 1-500 items of { collection, id, data, expectedVersion? }" });
+        break;
+      }
+
+      try {
+        const results = runUpdateBatch(ops);
+        send({ type: "batch_ack", results: results.map(({ collection, id, _version }) => ({ collection, id, _version })) });
+        for (const r of results) {
+          localBroadcast(r.collection, "update", { collection: r.collection, id: r.id, data: r.data, _version: r._version }, clientId);
+        }
+      } catch (e) {
+        if (e instanceof BatchVersionConflict) {
+          metrics.sync.conflicts++;
+          send({
+            type: "error", message: `Batch rolled back: ${e.message}`, code: "version_conflict",
+            collection: e.collection, id: e.id, expected: e.expected, _version: e.current
+          });
+        } else {
+          send({ type: "error", message: `Batch rolled back: ${e.message}` });
+        }
+      }
+      break;
+    }
+
     // ===== Ping =====
     case "ping": {
       send({ type: "pong", time: msg.time || Date.now() });
@@ -1530,6 +1793,7 @@ function handleClientDisconnect(clientId) {
 
   // Presence 정리
   handlePresenceLeave(clientId);
+  channels.leaveAll(clientId);
 
   // Undo 매니저는 TTL로 자동 정리되므로 여기서 안 함
 
@@ -1611,13 +1875,29 @@ fastify.get("/api/collections", async () => {
   return { success: true, collections: collections.map(c => c.name) };
 });
 
-fastify.get("/api/c/:collection", async (req) => {
+// 문서 목록 (limit: 1~1000, skip: 0~) - total/hasMore/nextSkip으로 다음 페이지 안내
+fastify.get("/api/c/:collection", async (req, reply) => {
   const col = ensureCollection(req.params.collection);
-  const rows = db.prepare(`SELECT id, data, _version FROM ${col} WHERE _deleted = 0 LIMIT 1000`).all();
+  const limit = parseInt(req.query.limit ?? "1000", 10);
+  const skip = parseInt(req.query.skip ?? "0", 10);
+  if (!Number.isInteger(limit) || limit < 1 || limit > 1000) {
+    return reply.code(400).send({ error: "limit must be between 1 and 1000" });
+  }
+  if (!Number.isInteger(skip) || skip < 0) {
+    return reply.code(400).send({ error: "skip must be a non-negative integer" });
+  }
+  const rows = db.prepare(`SELECT id, data, _version FROM ${col} WHERE _deleted = 0 ORDER BY rowid LIMIT ? OFFSET ?`).all(limit, skip);
+  const total = db.prepare(`SELECT COUNT(*) AS n FROM ${col} WHERE _deleted = 0`).get().n;
+  const hasMore = skip + rows.length < total;
   return {
     success: true,
     collection: col,
     count: rows.length,
+    total,
+    skip,
+    limit,
+    hasMore,
+    nextSkip: hasMore ? skip + rows.length : null,
     data: rows.map(r => ({ id: r.id, ...JSON.parse(r.data), _version: r._version }))
   };
 });
@@ -1631,6 +1911,165 @@ fastify.get("/api/c/:collection/:id", async (req, reply) => {
   return { success: true, id: row.id, data: JSON.parse(row.data), _version: row._version };
 });
 
+// 버전 비교 - 클라이언트가 가진 버전과 다른 문서/삭제된 문서만 반환 (최대 1000개)
+fastify.post("/api/c/:collection/versions", async (req, reply) => {
+  const col = ensureCollection(req.params.collection);
+  const { versions } = req.body || {};
+  const problem = versionsRequestError(versions);
+  if (problem) {
+    return reply.code(400).send({ error: problem });
+  }
+
+  const ids = Object.keys(versions);
+  const current = new Map();
+  for (let i = 0; i < ids.length; i += VERSION_QUERY_CHUNK) {
+    const chunk = ids.slice(i, i + VERSION_QUERY_CHUNK);
+    const rows = db.prepare(`SELECT id, _version FROM ${col} WHERE _deleted = 0 AND id IN (${chunk.map(() => '?').join(',')})`).all(...chunk);
+    for (const row of rows) current.set(row.id, row._version);
+  }
+  return { success: true, collection: col, ...compareVersions(versions, current) };
+});
+
+// 변경 목록 (updated_at 기준, 삭제 포함) - since는 포함, after는 같은 시각 안에서 이어받을 ID
+fastify.get("/api/changes/:collection", async (req, reply) => {
+  const col = ensureCollection(req.params.collection);
+  const limit = parseInt(req.query.limit ?? "500", 10);
+  if (!Number.isInteger(limit) || limit < 1 || limit > 1000) {
+    return reply.code(400).send({ error: "limit must be between 1 and 1000" });
+  }
+  const since = req.query.since ?? "";
+  const rows = db.prepare(`
+    SELECT id, data, _version, _deleted, updated_at FROM ${col}
+    WHERE id != '_index' AND (updated_at > ? OR (updated_at = ? AND id > ?))
+    ORDER BY updated_at, id LIMIT ?
+  `).all(since, since, req.query.after || "", limit);
+  const last = rows[rows.length - 1];
+  return {
+    success: true,
+    collection: col,
+    changes: rows.map(r => ({
+      id: r.id,
+      data: r._deleted ? null : JSON.parse(r.data),
+      _version: r._version,
+      deleted: r._deleted === 1,
+      updatedAt: r.updated_at
+    })),
+    next: rows.length === limit ? { since: last.updated_at, after: last.id } : null
+  };
+});
+
+// 보존 정책 (maxAgeMs / maxDocuments / archiveTo) - 정리 주기마다 적용
+fastify.get("/api/retention/:collection", async (req) => {
+  const col = ensureCollection(req.params.collection);
+  return { success: true, collection: col, policy: getRetentionPolicy(db, col) };
+});
+
+fastify.put("/api/retention/:collection", async (req, reply) => {
+  const col = ensureCollection(req.params.collection);
+  const { policy } = req.body || {};
+  const error = retentionPolicyError(col, policy);
+  if (error) {
+    return reply.code(400).send({ error });
+  }
+  if (policy.archiveTo) {
+    try {
+      ensureCollection(policy.archiveTo);
+    } catch (e) {
+      return reply.code(400).send({ error: e.message });
+    }
+  }
+
+  db.prepare(`
+    INSERT INTO _retention (collection, max_age_ms, max_documents, archive_to, updated_at)
+    VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP)
+    ON CONFLICT(collection) DO UPDATE SET
+      max_age_ms = excluded.max_age_ms,
+      max_documents = excluded.max_documents,
+      archive_to = excluded.archive_to,
+      updated_at = CURRENT_TIMESTAMP
+  `).run(col, policy.maxAgeMs ?? null, policy.maxDocuments ?? null, policy.archiveTo ?? null);
+  return { success: true, collection: col, policy: getRetentionPolicy(db, col) };
+});
+
+fastify.delete("/api/retention/:collection", async (req) => {
+  const col = ensureCollection(req.params.collection);
+  db.prepare(`DELETE FROM _retention WHERE collection = ?`).run(col);
+  return { success: true, collection: col };
+});
+
+// 무작위 샘플 (n: 1~1000)
+fastify.get("/api/sample/:collection", async (req, reply) => {
+  const col = ensureCollection(req.params.collection);
+  const n = Number(req.query.n ?? 1);
+  const problem = sampleSizeError(n);
+  if (problem) {
+    return reply.code(400).send(validationBody("n", "range", problem));
+  }
+  const rows = db.prepare(`SELECT id, data, _version FROM ${col} WHERE _deleted = 0 AND id != '_index' ORDER BY RANDOM() LIMIT ?`).all(n);
+  return {
+    success: true,
+    collection: col,
+    count: rows.length,
+    data: rows.map(r => ({ id: r.id, ...JSON.parse(r.data), _version: r._version }))
+  };
+});
+
+// 체크섬 - 클라이언트 로컬 상태와 비교 (루트/버킷 해시, buckets를 주면 그 버킷의 문서 해시)
+fastify.get("/api/checksum/:collection", async (req) => {
+  const col = ensureCollection(req.params.collection);
+  const rows = db.prepare(`SELECT id, data FROM ${col} WHERE _deleted = 0 AND id != '_index'`).all();
+  const tree = await checksumTree(
+    rows.map(r => [r.id, JSON.parse(r.data)]),
+    (text) => createHash('sha256').update(text).digest('hex')
+  );
+
+  if (req.query.buckets) {
+    const docs = {};
+    for (const bucket of req.query.buckets.split(',')) Object.assign(docs, tree.docs[bucket]);
+    return { success: true, collection: col, docs };
+  }
+  return { success: true, collection: col, count: rows.length, root: tree.root, buckets: tree.buckets };
+});
+
+// ===== Dry Run =====
+// X-KimDB-Dry-Run: 1 이면 쓰기를 트랜잭션 안에서 실행한 뒤 롤백하고 결과만 반환 (마이그레이션 리허설용)
+function rehearse(req, fn) {
+  if (req.headers["x-kimdb-dry-run"] !== "1") return fn();
+  let result;
+  const rollback = new Error("dry run");
+  try {
+    db.transaction(() => {
+      result = fn();
+      throw rollback;
+    })();
+  } catch (e) {
+    if (e !== rollback) throw e;
+  }
+  return { ...result, dryRun: true };
+}
+
+// ===== Expected Version =====
+// X-KimDB-Expected-Version: n 이면 현재 버전이 n일 때만 쓰기 (0 = 문서가 없어야 함), 아니면 409
+// 확인과 쓰기 사이에 await가 없으므로 다른 요청이 끼어들 수 없음 (리스/큐/트랜잭션의 CAS)
+function expectedVersionError(req, existing) {
+  const header = req.headers["x-kimdb-expected-version"];
+  if (header === undefined) return null;
+  const expected = Number(header);
+  if (!Number.isInteger(expected) || expected < 0) {
+    return [400, { error: "X-KimDB-Expected-Version must be a non-negative integer" }];
+  }
+  const current = existing ? existing._version : 0;
+  if (expected === current) return null;
+  metrics.sync.conflicts++;
+  return [409, { error: "Version conflict", expected, _version: current }];
+}
+
+// ===== Validation =====
+// 400 응답 본문 - errors[]가 있으면 클라이언트가 ValidationError로 받음
+function validationBody(path, rule, message) {
+  return { error: message, errors: [{ path, rule, message }] };
+}
+
 // PUT - 데이터 저장 (upsert)
 fastify.put("/api/c/:collection/:id", async (req, reply) => {
   const col = ensureCollection(req.params.collection);
@@ -1638,25 +2077,32 @@ fastify.put("/api/c/:collection/:id", async (req, reply) => {
   const { data } = req.body || {};
 
   if (!data) {
-    return reply.code(400).send({ error: "data is required" });
+    return reply.code(400).send(validationBody("data", "required", "data is required"));
   }
 
   const existing = db.prepare(`SELECT * FROM ${col} WHERE id = ? AND _deleted = 0`).get(id);
+  const conflict = expectedVersionError(req, existing);
+  if (conflict) {
+    return reply.code(conflict[0]).send(conflict[1]);
+  }
 
-  if (existing) {
-    // UPDATE
-    const merged = { ...JSON.parse(existing.data), ...data };
-    db.prepare(`UPDATE ${col} SET data = ?, _version = _version + 1, updated_at = CURRENT_TIMESTAMP WHERE id = ?`)
-      .run(JSON.stringify(merged), id);
-    metrics.writes.total++;
-    return { success: true, id, _version: existing._version + 1 };
-  } else {
-    // INSERT
-    db.prepare(`INSERT INTO ${col} (id, data, _version, _deleted, updated_at) VALUES (?, ?, 1, 0, CURRENT_TIMESTAMP)`)
-      .run(id, JSON.stringify(data));
-    metrics.writes.total++;
+  const result = rehearse(req, () => {
+    if (existing) {
+      // UPDATE
+      const merged = { ...JSON.parse(existing.data), ...data };
+      db.prepare(`UPDATE ${col} SET data = ?, _version = _version + 1, updated_at = CURRENT_TIMESTAMP WHERE id = ?`)
+        .run(JSON.stringify(merged), id);
+      return { success: true, id, _version: existing._version + 1 };
+    }
+    // INSERT - soft delete된 행이 남아 있을 수 있으므로 upsert (버전은 1부터 다시)
+    db.prepare(`
+      INSERT INTO ${col} (id, data, _version, _deleted, updated_at) VALUES (?, ?, 1, 0, CURRENT_TIMESTAMP)
+      ON CONFLICT(id) DO UPDATE SET data = excluded.data, _version = 1, _deleted = 0, updated_at = CURRENT_TIMESTAMP
+    `).run(id, JSON.stringify(data));
     return { success: true, id, _version: 1 };
-  }
+  });
+  if (!result.dryRun) metrics.writes.total++;
+  return result;
 });
 
 // POST - 새 문서 생성 (ID 자동 생성)
@@ -1665,36 +2111,59 @@ fastify.post("/api/c/:collection", async (req, reply) => {
   const { data } = req.body || {};
 
   if (!data) {
-    return reply.code(400).send({ error: "data is required" });
+    return reply.code(400).send(validationBody("data", "required", "data is required"));
   }
 
   const id = crypto.randomUUID().replace(/-/g, '').slice(0, 16);
-  db.prepare(`INSERT INTO ${col} (id, data, _version, _deleted, updated_at) VALUES (?, ?, 1, 0, CURRENT_TIMESTAMP)`)
-    .run(id, JSON.stringify(data));
-
-  return { success: true, id, _version: 1 };
+  return rehearse(req, () => {
+    db.prepare(`INSERT INTO ${col} (id, data, _version, _deleted, updated_at) VALUES (?, ?, 1, 0, CURRENT_TIMESTAMP)`)
+      .run(id, JSON.stringify(data));
+    return { success: true, id, _version: 1 };
+  });
 });
 
-// PATCH - 부분 업데이트
+// PATCH - 부분 업데이트 (data: 최상위 필드 병합, ops: 배열/중첩 필드/카운터 연산)
 fastify.patch("/api/c/:collection/:id", async (req, reply) => {
   const col = ensureCollection(req.params.collection);
   const id = req.params.id;
-  const { data } = req.body || {};
+  const { data, ops } = req.body || {};
 
-  if (!data) {
-    return reply.code(400).send({ error: "data is required" });
+  if (!data && !ops) {
+    return reply.code(400).send(validationBody("data", "required", "data or ops is required"));
   }
 
+  // ops만 있으면 없는 문서도 {}에서 시작해 생성 (increment/addToSet이 첫 쓰기여도 되도록)
   const existing = db.prepare(`SELECT * FROM ${col} WHERE id = ? AND _deleted = 0`).get(id);
-  if (!existing) {
+  if (!existing && !ops) {
     return reply.code(404).send({ error: "Not found" });
   }
+  const conflict = expectedVersionError(req, existing);
+  if (conflict) {
+    return reply.code(conflict[0]).send(conflict[1]);
+  }
 
-  const merged = { ...JSON.parse(existing.data), ...data };
-  db.prepare(`UPDATE ${col} SET data = ?, _version = _version + 1, updated_at = CURRENT_TIMESTAMP WHERE id = ?`)
-    .run(JSON.stringify(merged), id);
-
-  return { success: true, id, _version: existing._version + 1 };
+  let merged = { ...(existing ? JSON.parse(existing.data) : {}), ...data };
+  if (ops) {
+    try {
+      merged = applyUpdateOps(merged, ops);
+    } catch (e) {
+      if (!(e instanceof UpdateOpError)) throw e;
+      return reply.code(400).send({ error: e.message });
+    }
+  }
+  return rehearse(req, () => {
+    if (existing) {
+      db.prepare(`UPDATE ${col} SET data = ?, _version = _version + 1, updated_at = CURRENT_TIMESTAMP WHERE id = ?`)
+        .run(JSON.stringify(merged), id);
+      return { success: true, id, _version: existing._version + 1 };
+    }
+    // soft delete된 행이 남아 있을 수 있으므로 upsert
+    db.prepare(`
+      INSERT INTO ${col} (id, data, _version, _deleted, updated_at) VALUES (?, ?, 1, 0, CURRENT_TIMESTAMP)
+      ON CONFLICT(id) DO UPDATE SET data = excluded.data, _version = 1, _deleted = 0, updated_at = CURRENT_TIMESTAMP
+    `).run(id, JSON.stringify(merged));
+    return { success: true, id, _version: 1 };
+  });
 });
 
 // DELETE - 소프트 삭제
@@ -1706,238 +2175,26 @@ fastify.delete("/api/c/:collection/:id", async (req, reply) => {
   if (!existing) {
     return reply.code(404).send({ error: "Not found" });
   }
+  const conflict = expectedVersionError(req, existing);
+  if (conflict) {
+    return reply.code(conflict[0]).send(conflict[1]);
+  }
 
-  db.prepare(`UPDATE ${col} SET _deleted = 1, updated_at = CURRENT_TIMESTAMP WHERE id = ?`).run(id);
-
-  return { success: true, deleted: true };
+  return rehearse(req, () => {
+    db.prepare(`UPDATE ${col} SET _deleted = 1, updated_at = CURRENT_TIMESTAMP WHERE id = ?`).run(id);
+    return { success: true, deleted: true };
+  });
 });
 
 // ===== SQL Engine =====
-/**
- * SQL 엔진 - kimdb에서 SQL 쿼리 직접 실행
- *
- * 지원하는 쿼리:
- * - SELECT * FROM table WHERE ... ORDER BY ... LIMIT ...
- * - SELECT COUNT(*) FROM table WHERE ...
- * - INSERT INTO table (col1, col2) VALUES (?, ?)
- * - UPDATE table SET col1 = ? WHERE ...
- * - DELETE FROM table WHERE ...
- */
-
-function parseSql(sql, params = []) {
-  const sqlLower = sql.toLowerCase().trim();
-  const result = { type: null, table: null, columns: '*', where: [], orderBy: null, orderDir: 'ASC', limit: null, offset: null, values: {}, paramIndex: 0 };
-
-  // 쿼리 타입 판별
-  if (sqlLower.startsWith('select')) {
-    result.type = 'SELECT';
-  } else if (sqlLower.startsWith('insert')) {
-    result.type = 'INSERT';
-  } else if (sqlLower.startsWith('update')) {
-    result.type = 'UPDATE';
-  } else if (sqlLower.startsWith('delete')) {
-    result.type = 'DELETE';
-  } else {
-    throw new Error(`Unsupported SQL: ${sql}`);
-  }
-
-  // 테이블명 추출
-  const tableMatch = sqlLower.match(/(?:from|into|update)\s+([a-z_][a-z0-9_]*)/i);
-  if (tableMatch) {
-    result.table = tableMatch[1];
-  }
-
-  // SELECT 컬럼 추출
-  if (result.type === 'SELECT') {
-    const colMatch = sql.match(/select\s+(.+?)\s+from/i);
-    if (colMatch) {
-      result.columns = colMatch[1].trim();
-    }
-  }
-
-  // WHERE 절 파싱
-  const whereMatch = sql.match(/where\s+(.+?)(?:\s+order\s+by|\s+limit|\s+offset|$)/i);
-  if (whereMatch) {
-    const wherePart = whereMatch[1].trim();
-
-    // OR 조건도 파싱 (OR로 분리 후 각각 AND 조건 처리)
-    const orGroups = wherePart.split(/\s+or\s+/i);
-    result.orGroups = [];
-
-    for (const orGroup of orGroups) {
-      const conditions = orGroup.split(/\s+and\s+/i);
-      const andConditions = [];
-
-      for (const cond of conditions) {
-        // GLOB 패턴: field GLOB 'pattern'
-        const globParts = cond.match(/([a-z_][a-z0-9_]*)\s+glob\s+['"]?([^'"]+)['"]?/i);
-        if (globParts) {
-          andConditions.push({ field: globParts[1].trim(), op: 'GLOB', value: globParts[2].trim() });
-          continue;
-        }
-
-        // 범위/비교 연산자 (>=, <= 먼저 체크!)
-        const parts = cond.match(/([a-z_][a-z0-9_]*)\s*(>=|<=|!=|<>|>|<|=|like)\s*(.+)/i);
-        if (parts) {
-          const field = parts[1].trim();
-          const op = parts[2].trim().toUpperCase();
-          let value = parts[3].trim();
-
-          if (value === '?') {
-            value = params[result.paramIndex++];
-          } else if (value.match(/^['"].*['"]$/)) {
-            value = value.slice(1, -1);
-          } else if (!isNaN(value)) {
-            value = Number(value);
-          }
-
-          andConditions.push({ field, op, value });
-        }
-      }
-
-      if (andConditions.length > 0) {
-        result.orGroups.push(andConditions);
-      }
-    }
-
-    // 단일 AND 조건만 있을 경우 기존 호환성 유지
-    if (result.orGroups.length === 1) {
-      result.where = result.orGroups[0];
-    }
-  }
-
-  // ORDER BY
-  const orderMatch = sql.match(/order\s+by\s+([a-z_][a-z0-9_]*)(?:\s+(asc|desc))?/i);
-  if (orderMatch) {
-    result.orderBy = orderMatch[1];
-    result.orderDir = (orderMatch[2] || 'ASC').toUpperCase();
-  }
-
-  // LIMIT
-  const limitMatch = sql.match(/limit\s+(\?|\d+)/i);
-  if (limitMatch) {
-    result.limit = limitMatch[1] === '?' ? params[result.paramIndex++] : parseInt(limitMatch[1]);
-  }
-
-  // OFFSET
-  const offsetMatch = sql.match(/offset\s+(\?|\d+)/i);
-  if (offsetMatch) {
-    result.offset = offsetMatch[1] === '?' ? params[result.paramIndex++] : parseInt(offsetMatch[1]);
-  }
-
-  // INSERT VALUES
-  if (result.type === 'INSERT') {
-    const colsMatch = sql.match(/\(([^)]+)\)\s*values/i);
-    const valsMatch = sql.match(/values\s*\(([^)]+)\)/i);
-    if (colsMatch && valsMatch) {
-      const cols = colsMatch[1].split(',').map(c => c.trim());
-      const vals = valsMatch[1].split(',').map(v => v.trim());
-      for (let i = 0; i < cols.length; i++) {
-        let val = vals[i];
-        if (val === '?') {
-          val = params[result.paramIndex++];
-        } else if (val.match(/^['"].*['"]$/)) {
-          val = val.slice(1, -1);
-        } else if (!isNaN(val)) {
-          val = Number(val);
-        }
-        result.values[cols[i]] = val;
-      }
-    }
-  }
-
-  // UPDATE SET
-  if (result.type === 'UPDATE') {
-    const setMatch = sql.match(/set\s+(.+?)(?:\s+where|$)/i);
-    if (setMatch) {
-      const setParts = setMatch[1].split(',');
-      for (const part of setParts) {
-        const [col, val] = part.split('=').map(s => s.trim());
-        let value = val;
-        if (val === '?') {
-          value = params[result.paramIndex++];
-        } else if (val.match(/^['"].*['"]$/)) {
-          value = val.slice(1, -1);
-        } else if (!isNaN(val)) {
-          value = Number(val);
-        }
-        result.values[col] = value;
-      }
-    }
-  }
-
-  return result;
-}
-
-function matchesCondition(doc, { field, op, value }) {
-  let docVal = doc[field];
-
-  // is_active 기본값
-  if (docVal === undefined && field === 'is_active') {
-    docVal = 1;
-  }
-
-  switch (op) {
-    case '=':
-      return docVal == value;
-    case '!=':
-    case '<>':
-      return docVal != value;
-    case '>':
-      return docVal > value;
-    case '<':
-      return docVal < value;
-    case '>=':
-      return docVal >= value;
-    case '<=':
-      return docVal <= value;
-    case 'LIKE':
-      const likePattern = value.replace(/%/g, '.*').replace(/_/g, '.');
-      return new RegExp(`^${likePattern}$`, 'i').test(docVal || '');
-    case 'GLOB':
-      // GLOB: * → .*, ? → ., [abc] → [abc]
-      const globPattern = value
-        .replace(/\*/g, '.*')
-        .replace(/\?/g, '.')
-        .replace(/\[!/g, '[^');
-      return new RegExp(`^${globPattern}$`).test(docVal || '');
-    default:
-      return true;
-  }
-}
-
-function matchesWhere(doc, where, orGroups = null) {
-  // OR 조건 그룹이 있으면 하나라도 만족하면 true
-  if (orGroups && orGroups.length > 1) {
-    return orGroups.some(andGroup =>
-      andGroup.every(cond => matchesCondition(doc, cond))
-    );
-  }
-
-  // AND 조건만 있는 경우
-  for (const cond of where) {
-    if (!matchesCondition(doc, cond)) return false;
-  }
-  return true;
-}
+// 파싱과 WHERE/ORDER BY는 src/shared/sql.js (TS 서버와 공유), 여기서는 저장만
 
 function executeSelect(parsed, collection) {
   const col = ensureCollection(collection);
-  const colsLower = parsed.columns.toLowerCase();
-  const isCount = colsLower.includes('count(*)');
-
-  // COUNT(*) alias 추출 (SELECT COUNT(*) as cnt → alias = 'cnt')
-  let countAlias = 'COUNT(*)';
-  if (isCount) {
-    const aliasMatch = parsed.columns.match(/count\s*\(\s*\*\s*\)\s+(?:as\s+)?([a-z_][a-z0-9_]*)/i);
-    if (aliasMatch) {
-      countAlias = aliasMatch[1];
-    }
-  }
 
   // 전체 문서 조회 (_index 제외)
   const rows = db.prepare(`SELECT id, data FROM ${col} WHERE _deleted = 0 AND id != '_index'`).all();
-  let docs = rows.map(r => {
+  const docs = rows.map(r => {
     let data = JSON.parse(r.data);
     // aiosqlite_compat에서 {data: {...}} 형태로 저장된 경우 처리
     if (data.data && typeof data.data === 'object') {
@@ -1946,58 +2203,7 @@ function executeSelect(parsed, collection) {
     return { ...data, id: parseInt(r.id) || r.id };
   });
 
-  // WHERE 필터링 (OR 조건 지원)
-  if (parsed.where.length > 0 || (parsed.orGroups && parsed.orGroups.length > 0)) {
-    docs = docs.filter(doc => matchesWhere(doc, parsed.where, parsed.orGroups));
-  }
-
-  // COUNT(*)
-  if (isCount) {
-    const result = {};
-    result[countAlias] = docs.length;
-    // 호환성을 위해 둘 다 제공
-    if (countAlias !== 'COUNT(*)') {
-      result['COUNT(*)'] = docs.length;
-    }
-    return [result];
-  }
-
-  // ORDER BY
-  if (parsed.orderBy) {
-    docs.sort((a, b) => {
-      const aVal = a[parsed.orderBy];
-      const bVal = b[parsed.orderBy];
-      if (aVal < bVal) return parsed.orderDir === 'ASC' ? -1 : 1;
-      if (aVal > bVal) return parsed.orderDir === 'ASC' ? 1 : -1;
-      return 0;
-    });
-  }
-
-  // OFFSET
-  if (parsed.offset) {
-    docs = docs.slice(parsed.offset);
-  }
-
-  // LIMIT
-  if (parsed.limit) {
-    docs = docs.slice(0, parsed.limit);
-  }
-
-  // 특정 컬럼만 선택
-  if (parsed.columns !== '*' && !isCount) {
-    const cols = parsed.columns.split(',').map(c => c.trim());
-    docs = docs.map(doc => {
-      const result = {};
-      for (const col of cols) {
-        if (doc.hasOwnProperty(col)) {
-          result[col] = doc[col];
-        }
-      }
-      return result;
-    });
-  }
-
-  return docs;
+  return selectRows(docs, parsed);
 }
 
 function executeInsert(parsed, collection) {
@@ -2034,7 +2240,7 @@ function executeUpdate(parsed, collection) {
 
   for (const row of rows) {
     const doc = { id: row.id, ...JSON.parse(row.data) };
-    if (matchesWhere(doc, parsed.where, parsed.orGroups)) {
+    if (matchesWhere(doc, parsed)) {
       // 업데이트 적용
       const newDoc = { ...doc, ...parsed.values };
       db.prepare(`UPDATE ${col} SET data = ?, _version = _version + 1, updated_at = CURRENT_TIMESTAMP WHERE id = ?`)
@@ -2055,7 +2261,7 @@ function executeDelete(parsed, collection) {
 
   for (const row of rows) {
     const doc = { id: row.id, ...JSON.parse(row.data) };
-    if (matchesWhere(doc, parsed.where, parsed.orGroups)) {
+    if (matchesWhere(doc, parsed)) {
       // soft delete
       db.prepare(`UPDATE ${col} SET _deleted = 1, updated_at = CURRENT_TIMESTAMP WHERE id = ?`)
         .run(row.id);
@@ -2077,7 +2283,7 @@ function executeDelete(parsed, collection) {
 
 // SQL API 엔드포인트
 fastify.post("/api/sql", async (req, reply) => {
-  const { sql, params = [], collection } = req.body;
+  const { sql, params = [], collection, collation } = req.body;
 
   if (!sql) {
     return reply.code(400).send({ error: "sql is required" });
@@ -2085,9 +2291,14 @@ fastify.post("/api/sql", async (req, reply) => {
   if (!collection) {
     return reply.code(400).send({ error: "collection is required" });
   }
+  const collationError = collation === undefined ? null : checkCollation(collation);
+  if (collationError) {
+    return reply.code(400).send({ error: collationError });
+  }
 
   try {
     const parsed = parseSql(sql, params);
+    parsed.collator = collation ? collator(collation) : null;
     let result;
 
     switch (parsed.type) {
@@ -2095,13 +2306,13 @@ fastify.post("/api/sql", async (req, reply) => {
         result = executeSelect(parsed, collection);
         return { success: true, rows: result, rowcount: result.length };
       case 'INSERT':
-        result = executeInsert(parsed, collection);
-        return { success: true, row: result, lastrowid: result.id };
+        result = rehearse(req, () => ({ row: executeInsert(parsed, collection) }));
+        return { success: true, ...result, lastrowid: result.row.id };
       case 'UPDATE':
-        result = executeUpdate(parsed, collection);
+        result = rehearse(req, () => executeUpdate(parsed, collection));
         return { success: true, ...result };
       case 'DELETE':
-        result = executeDelete(parsed, collection);
+        result = rehearse(req, () => executeDelete(parsed, collection));
         return { success: true, ...result };
       default:
         return reply.code(400).send({ error: "Unsupported query type" });
@@ -4,11 +4,14 @@
  *
  * npx kimdb init  - 프로젝트 초기화
  * npx kimdb start - 서버 시작
+ * npx kimdb sync  - 두 서버 컬렉션 비교/반영 (스테이징 → 운영)
+ * npx kimdb lint  - 규칙 파일로 컬렉션 데이터 품질 검사 (위반이 있으면 exit 1)
  */
 
 import { writeFileSync, existsSync } from 'fs';
 import { join } from 'path';
 import { generateEnvExample } from '../server/config.js';
+import type { LintRules } from '../client/lint.js';
 
 const VERSION = '7.0.0';
 
@@ -18,11 +21,24 @@ kimdb v${VERSION} - High-performance document database with CRDT
 Usage:
   kimdb init     Initialize kimdb in current directory
   kimdb start    Start kimdb server
+  kimdb sync <source-url> <target-url> [options]
+                 Diff collections between two servers (dry run by default)
+      --collections a,b   Only these collections (default: all on source)
+      --apply             Write the differences to the target
+      --delete            Also delete documents that exist only on the target
+      --source-key KEY    Source API key (default: KIMDB_SOURCE_API_KEY)
+      --target-key KEY    Target API key (default: KIMDB_TARGET_API_KEY)
+  kimdb lint <url> <rules.json> [options]
+                 Check collections against data quality rules (exit 1 on issues)
+      --sample N          Check N random documents per collection
+      --key KEY           API key (default: KIMDB_API_KEY)
   kimdb help     Show this help message
 
 Examples:
   npx kimdb init
   npx kimdb start
+  npx kimdb sync http://staging:40000 http://prod:40000 --collections configs
+  npx kimdb lint http://localhost:40000 quality.json --sample 500
 
 Environment Variables:
   KIMDB_API_KEY     Required API key (auto-generated in dev mode)
@@ -146,6 +162,78 @@ async function start(): Promise<void> {
   await server.start();
 }
 
+/** http(s)://host 또는 ws(s)://host/ws → 클라이언트 URL */
+function toClientUrl(url: string): string {
+  const base = url.replace(/\/+$/, '').replace(/\/ws$/, '');
+  return `${base.replace(/^http:/, 'ws:').replace(/^https:/, 'wss:')}/ws`;
+}
+
+async function sync(args: string[]): Promise<void> {
+  const flag = (name: string): string | undefined => {
+    const i = args.indexOf(`--${name}`);
+    return i >= 0 ? args[i + 1] : undefined;
+  };
+  const positional = args.filter((a, i) => !a.startsWith('--') && !['--collections', '--source-key', '--target-key'].includes(args[i - 1]));
+  const [sourceUrl, targetUrl] = positional;
+  if (!sourceUrl || !targetUrl) throw new Error('Usage: kimdb sync <source-url> <target-url> [--apply] [--delete]');
+
+  const { KimDBClient } = await import('../client/index.js');
+  const { promote } = await import('../client/promote.js');
+  const source = new KimDBClient({ url: toClientUrl(sourceUrl), apiKey: flag('source-key') ?? process.env.KIMDB_SOURCE_API_KEY });
+  const target = new KimDBClient({ url: toClientUrl(targetUrl), apiKey: flag('target-key') ?? process.env.KIMDB_TARGET_API_KEY });
+  const apply = args.includes('--apply');
+
+  console.log(`kimdb sync ${sourceUrl} -> ${targetUrl}${apply ? '' : ' (dry run)'}`);
+  let total = 0;
+  await promote(source, target, {
+    collections: flag('collections')?.split(',').filter(Boolean),
+    apply,
+    deleteMissing: args.includes('--delete'),
+    onCollection: (d) => {
+      const count = d.added.length + d.changed.length + d.removed.length + (d.retention ? 1 : 0);
+      total += count;
+      if (count === 0) return;
+      console.log(`\n  ${d.collection}: +${d.added.length} ~${d.changed.length} -${d.removed.length}${d.retention ? ' retention' : ''}`);
+      for (const id of d.added) console.log(`    + ${id}`);
+      for (const c of d.changed) console.log(`    ~ ${c.id} (${c.patch.map(p => `${p.op} ${p.path}`).join(', ')})`);
+      for (const id of d.removed) console.log(`    - ${id}${args.includes('--delete') ? '' : ' (kept, use --delete)'}`);
+      if (d.retention) console.log(`    retention ${JSON.stringify(d.retention.target)} -> ${JSON.stringify(d.retention.source)}`);
+    },
+  });
+
+  console.log(total === 0 ? '\n  No differences' : `\n  ${total} difference(s)${apply ? ' applied' : ', re-run with --apply to write'}`);
+}
+
+/** 규칙 파일: { "<collection>": { required, types, enums, refs } } */
+async function lint(args: string[]): Promise<void> {
+  const flag = (name: string): string | undefined => {
+    const i = args.indexOf(`--${name}`);
+    return i >= 0 ? args[i + 1] : undefined;
+  };
+  const positional = args.filter((a, i) => !a.startsWith('--') && !['--sample', '--key'].includes(args[i - 1]));
+  const [url, rulesPath] = positional;
+  if (!url || !rulesPath) throw new Error('Usage: kimdb lint <url> <rules.json> [--sample N]');
+
+  const { readFileSync } = await import('fs');
+  const { KimDBClient } = await import('../client/index.js');
+  const rules = JSON.parse(readFileSync(rulesPath, 'utf-8')) as Record<string, LintRules>;
+  const client = new KimDBClient({ url: toClientUrl(url), apiKey: flag('key') ?? process.env.KIMDB_API_KEY });
+  const sample = flag('sample') !== undefined ? parseInt(flag('sample')!, 10) : undefined;
+
+  let total = 0;
+  for (const [collection, collectionRules] of Object.entries(rules)) {
+    const report = await client.lintCollection(collection, collectionRules, { sample });
+    const count = Object.values(report.counts).reduce((a, b) => a + b, 0);
+    total += count;
+    console.log(`\n  ${collection}: ${report.checked} checked${report.sampled ? ' (sampled)' : ''}, ${count} issue(s)`);
+    for (const issue of report.issues) console.log(`    ${issue.rule} ${issue.id}: ${issue.message}`);
+    if (report.issues.length < count) console.log(`    ... ${count - report.issues.length} more`);
+  }
+
+  console.log(total === 0 ? '\n  No issues' : `\n  ${total} issue(s)`);
+  if (total > 0) process.exitCode = 1;
+}
+
 // ===== Main =====
 const command = process.argv[2];
 
@@ -164,6 +252,20 @@ switch (command) {
     });
     break;
 
+  case 'sync':
+    sync(process.argv.slice(3)).catch((e) => {
+      console.error('Error:', e.message);
+      process.exit(1);
+    });
+    break;
+
+  case 'lint':
+    lint(process.argv.slice(3)).catch((e) => {
+      console.error('Error:', e.message);
+      process.exit(1);
+    });
+    break;
+
   case 'help':
   case '--help':
   case '-h':
@@ -0,0 +1,72 @@
+/**
+ * kimdb Client Interfaces
+ *
+ * 테스트에서 KimDBClient를 대체할 수 있도록 공개 API만 추린 타입
+ * - 클래스에서 파생하므로 메서드가 추가/변경되면 자동으로 따라감
+ * - KimDBRestAPI: HTTP 호출만 (네트워크 없이 목/페이크로 대체하기 쉬움)
+ * - KimDBSocketAPI: WebSocket 연결/구독/문서 편집
+ */
+
+import type { KimDBClient } from './index.js';
+
+/** KimDBClient의 모든 공개 멤버 */
+export type KimDBClientAPI = { [K in keyof KimDBClient]: KimDBClient[K] };
+
+export type KimDBRestAPI = Pick<
+  KimDBClient,
+  | 'collections'
+  | 'list'
+  | 'listPage'
+  | 'listRaw'
+  | 'sample'
+  | 'getDoc'
+  | 'getDocRaw'
+  | 'create'
+  | 'save'
+  | 'update'
+  | 'remove'
+  | 'sql'
+  | 'sqlRaw'
+>;
+
+export type KimDBSocketAPI = Pick<
+  KimDBClient,
+  | 'connect'
+  | 'connectWithRetry'
+  | 'disconnect'
+  | 'run'
+  | 'recycle'
+  | 'on'
+  | 'onEvent'
+  | 'off'
+  | 'use'
+  | 'subscribe'
+  | 'unsubscribe'
+  | 'watch'
+  | 'resync'
+  | 'call'
+  | 'openDocument'
+  | 'closeDocument'
+  | 'set'
+  | 'updateBatch'
+  | 'flush'
+  | 'get'
+  | 'undo'
+  | 'redo'
+  | 'transact'
+  | 'getUndoState'
+  | 'clearUndo'
+  | 'snapshot'
+  | 'listSnapshots'
+  | 'restoreSnapshot'
+  | 'joinPresence'
+  | 'leavePresence'
+  | 'getPresence'
+  | 'updatePresence'
+  | 'getStats'
+  | 'ping'
+  | 'isConnected'
+  | 'connectionStatus'
+  | 'clientId'
+  | 'serverId'
+>;
@@ -0,0 +1,177 @@
+/**
+ * kimdb Collection Archive
+ *
+ * 오래된 문서를 외부 저장소(sink)로 내보낸 뒤 삭제 (운영 컬렉션을 작게 유지)
+ * - 기준 시각: 문서의 timeField 값 (epoch ms 또는 ISO 문자열), 값이 없거나 잘못되면 건너뜀
+ * - 배치 단위: sink에 쓰기 → 체크포인트 저장 → 삭제 → 체크포인트 갱신
+ * - 삭제 전에 죽으면 재시작 시 체크포인트의 배치를 다시 쓰지 않고 삭제부터 이어감
+ *   (sink 쓰기 후 체크포인트 저장 전에 죽으면 같은 문서가 한 번 더 쓰일 수 있음)
+ * - 컬렉션을 1000개씩 페이지로 마지막까지 훑음 (페이지 안에서 오래된 순으로 배치)
+ *   지운 문서만큼 뒤 문서가 앞으로 당겨지므로 다음 페이지 offset에서 그 수를 뺌
+ */
+
+import type { KimDBRestAPI } from './api.js';
+import type { KVStore } from './kv.js';
+import { KimDBHttpError } from './errors.js';
+import { mapLimit } from './fanout.js';
+
+export interface ArchivedDoc {
+  id: string;
+  _version: number;
+  [key: string]: unknown;
+}
+
+export interface ArchiveSink {
+  /** 배치 저장 - 완료(resolve)된 뒤에만 원본을 삭제 */
+  write(collection: string, docs: ArchivedDoc[]): Promise<void>;
+}
+
+export interface ArchiveCheckpoint {
+  /** sink에 썼지만 아직 삭제하지 않은 문서 ID */
+  pending: string[];
+  exported: number;
+  deleted: number;
+}
+
+export interface CheckpointStore {
+  load(name: string): Promise<ArchiveCheckpoint | null>;
+  save(name: string, checkpoint: ArchiveCheckpoint): Promise<void>;
+}
+
+/** 프로세스 메모리 저장소 (테스트용, 재시작하면 사라짐) */
+export class MemoryCheckpointStore implements CheckpointStore {
+  private data = new Map<string, ArchiveCheckpoint>();
+
+  async load(name: string): Promise<ArchiveCheckpoint | null> {
+    const checkpoint = this.data.get(name);
+    return checkpoint ? structuredClone(checkpoint) : null;
+  }
+
+  async save(name: string, checkpoint: ArchiveCheckpoint): Promise<void> {
+    this.data.set(name, structuredClone(checkpoint));
+  }
+}
+
+/** KV 컬렉션 기반 저장소 - 재시작 사이에 공유 */
+export class KVCheckpointStore implements CheckpointStore {
+  private kv: KVStore;
+
+  constructor(kv: KVStore) {
+    this.kv = kv;
+  }
+
+  async load(name: string): Promise<ArchiveCheckpoint | null> {
+    return this.kv.get<ArchiveCheckpoint>(name);
+  }
+
+  async save(name: string, checkpoint: ArchiveCheckpoint): Promise<void> {
+    await this.kv.set(name, checkpoint);
+  }
+}
+
+/** 다른 kimdb 컬렉션으로 복사하는 sink (같은 ID로 저장) */
+export function collectionSink(client: KimDBRestAPI, target: string): ArchiveSink {
+  return {
+    async write(_collection, docs) {
+      for (const { id, _version: _v, ...data } of docs) {
+        await client.save(target, id, data);
+      }
+    },
+  };
+}
+
+export interface ArchiveOptions {
+  /** 문서 시각 필드 (epoch ms 또는 ISO 문자열) */
+  timeField: string;
+  /** 배치 크기 (기본 100) */
+  batchSize?: number;
+  /** 동시에 보낼 삭제 요청 수 (기본 4) */
+  concurrency?: number;
+  /** 체크포인트 저장소 (기본: 메모리 - 재시작하면 이어받지 않음) */
+  checkpoint?: CheckpointStore;
+  /** 체크포인트 이름 (기본 'archive:<collection>') */
+  name?: string;
+  signal?: AbortSignal;
+  /** 배치마다 누적 진행 상황 */
+  onBatch?: (progress: { exported: number; deleted: number }) => void;
+}
+
+/** epoch ms 또는 ISO 문자열 → ms (그 외 null) */
+export function docTime(value: unknown): number | null {
+  if (typeof value === 'number' && Number.isFinite(value)) return value;
+  if (typeof value === 'string') {
+    const t = Date.parse(value);
+    return Number.isNaN(t) ? null : t;
+  }
+  return null;
+}
+
+/** 이미 지워진 문서는 성공으로 취급 (재시작 후 같은 배치를 다시 지우는 경우) */
+async function removeIfExists(client: KimDBRestAPI, collection: string, id: string): Promise<void> {
+  try {
+    await client.remove(collection, id);
+  } catch (e) {
+    if (!(e instanceof KimDBHttpError && e.status === 404)) throw e;
+  }
+}
+
+/**
+ * timeField가 before보다 이른 문서를 sink로 내보내고 삭제
+ *
+ *   await archiveCollection(client, 'events', Date.now() - 30 * 86400_000,
+ *     collectionSink(client, 'events_archive'), { timeField: 'createdAt' });
+ */
+export async function archiveCollection(
+  client: KimDBRestAPI,
+  collection: string,
+  before: Date | number,
+  sink: ArchiveSink,
+  options: ArchiveOptions,
+): Promise<{ exported: number; deleted: number }> {
+  const cutoff = before instanceof Date ? before.getTime() : before;
+  const batchSize = options.batchSize ?? 100;
+  const concurrency = options.concurrency ?? 4;
+  const store = options.checkpoint ?? new MemoryCheckpointStore();
+  const name = options.name ?? `archive:${collection}`;
+  const checkpoint: ArchiveCheckpoint = (await store.load(name)) ?? { pending: [], exported: 0, deleted: 0 };
+
+  const deletePending = async (): Promise<void> => {
+    await mapLimit(checkpoint.pending, concurrency, id => removeIfExists(client, collection, id), options.signal);
+    checkpoint.deleted += checkpoint.pending.length;
+    checkpoint.pending = [];
+    await store.save(name, checkpoint);
+  };
+
+  // 이전 실행이 삭제 전에 멈췄으면 그 배치부터 마무리
+  if (checkpoint.pending.length > 0) await deletePending();
+
+  let skip = 0;
+  for (;;) {
+    options.signal?.throwIfAborted();
+
+    const page = await client.listPage<ArchivedDoc>(collection, { limit: 1000, skip });
+    const old = page.data
+      .map(doc => ({ doc, t: docTime(doc[options.timeField]) }))
+      .filter((e): e is { doc: ArchivedDoc; t: number } => e.t !== null && e.t < cutoff)
+      .sort((a, b) => a.t - b.t)
+      .map(e => e.doc);
+
+    for (let i = 0; i < old.length; i += batchSize) {
+      options.signal?.throwIfAborted();
+      const batch = old.slice(i, i + batchSize);
+
+      await sink.write(collection, batch);
+      checkpoint.pending = batch.map(doc => doc.id);
+      checkpoint.exported += batch.length;
+      await store.save(name, checkpoint);
+
+      await deletePending();
+      options.onBatch?.({ exported: checkpoint.exported, deleted: checkpoint.deleted });
+    }
+
+    if (page.nextSkip === null) break;
+    skip = page.nextSkip - old.length;
+  }
+
+  return { exported: checkpoint.exported, deleted: checkpoint.deleted };
+}
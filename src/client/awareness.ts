@@ -0,0 +1,110 @@
+/**
+ * kimdb Awareness
+ *
+ * 문서 데이터와 분리된 휘발성 상태 (입력 중, 선택 영역, 뷰포트 등)
+ * - presence_update 메시지의 user.awareness 필드로 전파
+ * - 서버는 presence_join한 문서에만 전달 (먼저 client.joinPresence 필요, 서버 쪽은 src/server/presence.ts)
+ * - 저장하지 않음, ttl 이후 자동 만료
+ */
+
+export type AwarenessState = Record<string, unknown>;
+
+interface RemoteEntry {
+  state: AwarenessState;
+  updatedAt: number;
+}
+
+type Sender = (msg: unknown) => void;
+
+export interface AwarenessOptions {
+  /** 원격 상태 만료 시간 (ms) */
+  ttl?: number;
+}
+
+export class Awareness {
+  readonly collection: string;
+  readonly docId: string;
+  private ttl: number;
+  private sendMessage: Sender;
+  private local: AwarenessState = {};
+  private remote = new Map<string, RemoteEntry>();
+
+  onChange?: (nodeId: string, state: AwarenessState | null) => void;
+  onDestroy?: () => void;
+
+  constructor(collection: string, docId: string, send: Sender, options: AwarenessOptions = {}) {
+    this.collection = collection;
+    this.docId = docId;
+    this.sendMessage = send;
+    this.ttl = options.ttl ?? 30000;
+  }
+
+  /** 로컬 상태 필드 설정 후 전파 */
+  set(key: string, value: unknown): void {
+    this.local = { ...this.local, [key]: value };
+    this.broadcast();
+  }
+
+  /** 로컬 상태 필드 제거 후 전파 */
+  delete(key: string): void {
+    const { [key]: _removed, ...rest } = this.local;
+    this.local = rest;
+    this.broadcast();
+  }
+
+  getLocal(): AwarenessState {
+    return { ...this.local };
+  }
+
+  /** 원격 참여자 상태 (nodeId → state), 만료된 항목 제외 */
+  getAll(): Map<string, AwarenessState> {
+    this.expire();
+    const result = new Map<string, AwarenessState>();
+    for (const [nodeId, entry] of this.remote) {
+      result.set(nodeId, entry.state);
+    }
+    return result;
+  }
+
+  get<T = unknown>(nodeId: string, key: string): T | undefined {
+    this.expire();
+    return this.remote.get(nodeId)?.state[key] as T | undefined;
+  }
+
+  /** 클라이언트가 presence 메시지를 넘겨줌 */
+  handleMessage(msg: { type: string; [key: string]: unknown }): void {
+    if (msg.collection !== this.collection || msg.docId !== this.docId) return;
+
+    const nodeId = msg.nodeId as string;
+    if (msg.type === 'presence_updated') {
+      const user = msg.user as { awareness?: AwarenessState } | undefined;
+      if (!user?.awareness) return;
+      this.remote.set(nodeId, { state: user.awareness, updatedAt: Date.now() });
+      this.onChange?.(nodeId, user.awareness);
+    } else if (msg.type === 'presence_left') {
+      if (this.remote.delete(nodeId)) this.onChange?.(nodeId, null);
+    }
+  }
+
+  /** 메시지 핸들러 해제 */
+  destroy(): void {
+    this.remote.clear();
+    this.onDestroy?.();
+  }
+
+  private broadcast(): void {
+    this.sendMessage({ type: 'presence_update', user: { awareness: this.local } });
+  }
+
+  private expire(): void {
+    const cutoff = Date.now() - this.ttl;
+    for (const [nodeId, entry] of this.remote) {
+      if (entry.updatedAt < cutoff) {
+        this.remote.delete(nodeId);
+        this.onChange?.(nodeId, null);
+      }
+    }
+  }
+}
+
+export default Awareness;
@@ -0,0 +1,45 @@
+/**
+ * kimdb Backoff Strategies
+ *
+ * 재연결/재시도 대기 시간 정책
+ * - nextDelay가 null을 반환하면 재시도 중단
+ */
+
+export interface BackoffStrategy {
+  /** attempt는 1부터 시작, error는 마지막 실패 원인 (있으면) */
+  nextDelay(attempt: number, error?: Error): number | null;
+}
+
+/** interval * attempt (기존 재연결 동작) */
+export function linearBackoff(interval: number, maxAttempts: number): BackoffStrategy {
+  return {
+    nextDelay: (attempt) => (attempt > maxAttempts ? null : interval * attempt),
+  };
+}
+
+export interface ExponentialBackoffOptions {
+  initial?: number;
+  max?: number;
+  factor?: number;
+  /** 0~1, 대기 시간에 무작위 편차 적용 */
+  jitter?: number;
+  maxAttempts?: number;
+}
+
+/** initial * factor^(attempt-1), max로 제한 */
+export function exponentialBackoff(options: ExponentialBackoffOptions = {}): BackoffStrategy {
+  const initial = options.initial ?? 500;
+  const max = options.max ?? 30000;
+  const factor = options.factor ?? 2;
+  const jitter = options.jitter ?? 0.2;
+  const maxAttempts = options.maxAttempts ?? Infinity;
+
+  return {
+    nextDelay: (attempt) => {
+      if (attempt > maxAttempts) return null;
+      const base = Math.min(max, initial * Math.pow(factor, attempt - 1));
+      const spread = base * jitter;
+      return Math.max(0, Math.round(base - spread + Math.random() * spread * 2));
+    },
+  };
+}
@@ -0,0 +1,139 @@
+/**
+ * kimdb Document Binding
+ *
+ * 문서 하나를 로컬 객체로 유지하는 반응형 바인딩 (데몬/설정 감시용)
+ * - value: 서버 상태 + 아직 커밋하지 않은 로컬 수정
+ * - 원격 변경이 오면 로컬 수정을 새 서버 상태 위에 다시 적용 (충돌 시 원격 우선)
+ * - commit(): 바뀐 최상위 필드만 저장, 삭제한 필드는 null로 저장 (서버 PUT은 병합)
+ */
+
+import type { KimDBClient } from './index.js';
+import { diff, applyPatch, fieldPatch, PatchError } from './diff.js';
+
+type Doc = Record<string, unknown>;
+
+export type BindingClient = Pick<KimDBClient, 'backfillAndTail' | 'save'>;
+
+export interface BindingChange {
+  source: 'remote' | 'commit';
+  /** 원격 변경과 겹쳐 버려진 로컬 수정이 있으면 true */
+  dropped: boolean;
+}
+
+export class DocumentBinding<T extends Doc = Doc> {
+  readonly collection: string;
+  readonly docId: string;
+  /** 현재 값 (직접 수정 후 commit) */
+  value: T;
+  /** 마지막으로 확인한 서버 버전 (문서가 없으면 0) */
+  version = 0;
+
+  private client: BindingClient;
+  private base: T;
+  private handlers = new Set<(value: T, change: BindingChange) => void>();
+  private stopTail: (() => void) | null = null;
+
+  constructor(client: BindingClient, collection: string, docId: string, initial: T) {
+    this.client = client;
+    this.collection = collection;
+    this.docId = docId;
+    this.base = structuredClone(initial);
+    this.value = structuredClone(initial);
+  }
+
+  /** backfill + 실시간 변경 구독 시작 (bindDocument에서 호출) */
+  async start(): Promise<void> {
+    this.stopTail = await this.client.backfillAndTail(this.collection, (event) => {
+      if (event.id !== this.docId) return;
+      if (event.event === 'delete') {
+        this.applyRemote({} as T, 0);
+        return;
+      }
+      const { id: _id, _version: _v, ...data } = (event.data ?? {}) as Doc;
+      // backfill/resync는 전체 문서, live 이벤트는 서버처럼 병합
+      const next = event.source === 'live' ? { ...this.base, ...data } : data;
+      this.applyRemote(next as T, event._version);
+    });
+  }
+
+  /** 값이 바뀔 때마다 호출 (해제 함수 반환) */
+  onChange(handler: (value: T, change: BindingChange) => void): () => void {
+    this.handlers.add(handler);
+    return () => this.handlers.delete(handler);
+  }
+
+  /** 커밋하지 않은 로컬 수정이 있는지 */
+  get dirty(): boolean {
+    return diff(this.base, this.value).length > 0;
+  }
+
+  /** 로컬 수정 저장, 바뀐 것이 없으면 false */
+  async commit(): Promise<boolean> {
+    const patch = fieldPatch(this.base, this.value);
+    if (Object.keys(patch).length === 0) return false;
+
+    const snapshot = structuredClone(this.value);
+    const res = await this.client.save(this.collection, this.docId, patch);
+
+    this.base = snapshot;
+    this.version = Math.max(this.version, res._version);
+    this.emit({ source: 'commit', dropped: false });
+    return true;
+  }
+
+  /** 커밋하지 않은 로컬 수정 버리기 */
+  reset(): void {
+    this.value = structuredClone(this.base);
+  }
+
+  close(): void {
+    this.stopTail?.();
+    this.stopTail = null;
+    this.handlers.clear();
+  }
+
+  private applyRemote(next: T, version: number): void {
+    if (version !== 0 && version <= this.version) return;
+
+    const pending = diff(this.base, this.value);
+    let dropped = false;
+    let value: T;
+    try {
+      value = applyPatch(next, pending) as T;
+    } catch (e) {
+      if (!(e instanceof PatchError)) throw e;
+      value = structuredClone(next);
+      dropped = true;
+    }
+
+    this.base = structuredClone(next);
+    this.value = value;
+    this.version = version;
+    this.emit({ source: 'remote', dropped });
+  }
+
+  private emit(change: BindingChange): void {
+    for (const handler of this.handlers) handler(this.value, change);
+  }
+}
+
+/**
+ * 문서를 로컬 객체에 바인딩
+ *
+ *   const cfg = await bindDocument(client, 'configs', 'worker', { concurrency: 4 });
+ *   cfg.onChange((v) => pool.resize(v.concurrency));
+ *   cfg.value.concurrency = 8;
+ *   await cfg.commit();
+ *
+ * initial: 문서가 아직 없을 때의 값. 반환 시점에는 backfill이 끝나 서버 상태가 반영되어 있다.
+ */
+export async function bindDocument<T extends Doc = Doc>(
+  client: BindingClient,
+  collection: string,
+  docId: string,
+  initial: T = {} as T,
+): Promise<DocumentBinding<T>> {
+  const binding = new DocumentBinding(client, collection, docId, initial);
+  await binding.start();
+  return binding;
+}
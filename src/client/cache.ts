@@ -0,0 +1,81 @@
+/**
+ * kimdb Offline Read Cache
+ *
+ * 서버에 연결할 수 없을 때 마지막으로 읽은 결과로 응답 (읽기 경로 장애 내성)
+ * - 성공한 list/getDoc 결과를 저장, 쓰기 성공 시 해당 항목 무효화
+ * - 연결 실패(fetch 예외, 502/503/504)에서만 사용, 404 등은 그대로 실패
+ * - 캐시에서 나간 결과에는 staleness(저장 시각, 경과 시간, 원래 에러)를 붙임
+ */
+
+import { KimDBHttpError } from './errors.js';
+
+export interface Staleness {
+  /** 캐시에 저장된 시각 (epoch ms) */
+  cachedAt: number;
+  ageMs: number;
+  /** 서버 요청이 실패한 원인 */
+  error: Error;
+}
+
+export interface OfflineReadOptions {
+  /** 최대 저장 항목 수 (기본 1000, 넘치면 가장 오래된 것부터 버림) */
+  maxEntries?: number;
+  /** 이보다 오래된 항목은 사용하지 않음 (ms, 기본 0 = 무제한) */
+  maxAgeMs?: number;
+}
+
+/** 서버에 닿지 못한 실패인지 (캐시로 대신 응답해도 되는 경우) */
+export function isUnreachable(e: unknown): boolean {
+  if (e instanceof KimDBHttpError) return e.status === 502 || e.status === 503 || e.status === 504;
+  // fetch는 네트워크 실패를 TypeError로, 타임아웃을 TimeoutError로 던짐
+  return e instanceof TypeError || (e instanceof Error && e.name === 'TimeoutError');
+}
+
+export class ReadCache {
+  private entries = new Map<string, { value: unknown; cachedAt: number }>();
+  private maxEntries: number;
+  private maxAgeMs: number;
+
+  constructor(options: OfflineReadOptions = {}) {
+    this.maxEntries = options.maxEntries ?? 1000;
+    this.maxAgeMs = options.maxAgeMs ?? 0;
+  }
+
+  set(key: string, value: unknown): void {
+    this.entries.delete(key);
+    this.entries.set(key, { value: structuredClone(value), cachedAt: Date.now() });
+    if (this.entries.size > this.maxEntries) {
+      const [oldest] = this.entries.keys();
+      this.entries.delete(oldest);
+    }
+  }
+
+  /** 저장된 값 + staleness (없거나 maxAgeMs를 넘으면 null) */
+  get<T>(key: string, error: Error): (T & { staleness: Staleness }) | null {
+    const entry = this.entries.get(key);
+    if (!entry) return null;
+
+    const ageMs = Date.now() - entry.cachedAt;
+    if (this.maxAgeMs > 0 && ageMs > this.maxAgeMs) return null;
+    return { ...(structuredClone(entry.value) as T), staleness: { cachedAt: entry.cachedAt, ageMs, error } };
+  }
+
+  delete(key: string): void {
+    this.entries.delete(key);
+  }
+
+  /** key가 prefix로 시작하는 항목 모두 제거 */
+  deletePrefix(prefix: string): void {
+    for (const key of [...this.entries.keys()]) {
+      if (key.startsWith(prefix)) this.entries.delete(key);
+    }
+  }
+
+  clear(): void {
+    this.entries.clear();
+  }
+
+  get size(): number {
+    return this.entries.size;
+  }
+}
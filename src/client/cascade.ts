@@ -0,0 +1,84 @@
+/**
+ * kimdb Cascade Delete
+ *
+ * 다른 컬렉션에서 참조하는 문서를 함께 정리하며 삭제
+ * - RefSpec: 어느 컬렉션의 어느 필드가 대상 ID를 가리키는지
+ * - action 'delete': 참조 문서 삭제, 'nullify': 필드를 null로 (배열이면 ID만 제거)
+ * - dryRun: 실제 변경 없이 영향받는 문서만 반환
+ * - 한 단계만 따라감 (참조 문서를 참조하는 문서는 별도 호출)
+ * - 참조 컬렉션은 마지막 페이지까지 읽음 (첫 1000개 뒤의 참조도 정리)
+ */
+
+import type { KimDBRestAPI } from './api.js';
+import { listAll } from './page.js';
+
+export interface RefSpec {
+  collection: string;
+  field: string;
+  action: 'delete' | 'nullify';
+}
+
+export interface CascadeOptions {
+  dryRun?: boolean;
+  /** 동시에 보낼 요청 수 (기본: 10) */
+  batchSize?: number;
+}
+
+export interface CascadeResult {
+  deleted: Array<{ collection: string; id: string }>;
+  nullified: Array<{ collection: string; id: string; field: string }>;
+}
+
+function references(value: unknown, id: string): boolean {
+  return value === id || (Array.isArray(value) && value.includes(id));
+}
+
+/** 배치 단위로 순차 실행 (서버에 한꺼번에 몰리지 않도록) */
+async function inBatches<T>(items: T[], size: number, fn: (item: T) => Promise<unknown>): Promise<void> {
+  for (let i = 0; i < items.length; i += size) {
+    await Promise.all(items.slice(i, i + size).map(fn));
+  }
+}
+
+export async function deleteCascade(
+  client: KimDBRestAPI,
+  collection: string,
+  id: string,
+  refs: RefSpec[],
+  options: CascadeOptions = {},
+): Promise<CascadeResult> {
+  const batchSize = options.batchSize ?? 10;
+  const result: CascadeResult = { deleted: [], nullified: [] };
+  const updates: Array<{ collection: string; id: string; patch: Record<string, unknown> }> = [];
+
+  // 대상이 없으면 404로 실패 (참조만 지우는 일이 없도록)
+  await client.getDoc(collection, id);
+
+  for (const ref of refs) {
+    for (const doc of await listAll(client, ref.collection)) {
+      const value = doc[ref.field];
+      if (!references(value, id)) continue;
+
+      if (ref.action === 'delete') {
+        result.deleted.push({ collection: ref.collection, id: doc.id });
+      } else {
+        result.nullified.push({ collection: ref.collection, id: doc.id, field: ref.field });
+        updates.push({
+          collection: ref.collection,
+          id: doc.id,
+          patch: { [ref.field]: Array.isArray(value) ? value.filter(v => v !== id) : null },
+        });
+      }
+    }
+  }
+  result.deleted.push({ collection, id });
+
+  if (options.dryRun) return result;
+
+  await inBatches(updates, batchSize, u => client.update(u.collection, u.id, u.patch));
+  // 참조 문서부터 지우고 대상은 마지막에
+  await inBatches(result.deleted.slice(0, -1), batchSize, d => client.remove(d.collection, d.id));
+  await client.remove(collection, id);
+
+  return result;
+}
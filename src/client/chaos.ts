@@ -0,0 +1,103 @@
+/**
+ * kimdb Chaos Transport
+ *
+ * 장애 주입용 fetch / WebSocket 래퍼 (복원력 테스트 전용)
+ * - HTTP: 지연, 네트워크 오류, 5xx 연속 응답
+ * - WebSocket: 연결 강제 종료, 잘린 프레임
+ *
+ *   const client = new KimDBClient({
+ *     url,
+ *     fetch: chaosFetch({ latency: [10, 200], errorRate: 0.1, errorBurst: 3 }),
+ *     WebSocketImpl: chaosWebSocket({ dropAfter: 5000, truncateRate: 0.05 }),
+ *   });
+ */
+
+export interface ChaosScenario {
+  /** 요청마다 [최소, 최대] ms 지연 */
+  latency?: [number, number];
+  /** 5xx 버스트 시작 확률 (0~1) */
+  errorRate?: number;
+  /** 버스트 시작 시 연속 5xx 응답 수 */
+  errorBurst?: number;
+  /** 5xx 상태 코드 */
+  errorStatus?: number;
+  /** 네트워크 오류(TypeError) 확률 */
+  dropRate?: number;
+  /** 난수 함수 (시나리오 재현용) */
+  random?: () => number;
+}
+
+export interface ChaosSocketScenario {
+  /** 연결 후 ms 뒤 강제 종료 */
+  dropAfter?: number;
+  /** 수신 프레임을 절반으로 자를 확률 (0~1) */
+  truncateRate?: number;
+  random?: () => number;
+}
+
+const sleep = (ms: number) => new Promise((r) => setTimeout(r, ms));
+
+export function chaosFetch(scenario: ChaosScenario, base: typeof fetch = (i, o) => fetch(i, o)): typeof fetch {
+  const random = scenario.random ?? Math.random;
+  let burstLeft = 0;
+
+  return (async (input: Parameters<typeof fetch>[0], init?: RequestInit) => {
+    if (scenario.latency) {
+      const [min, max] = scenario.latency;
+      await sleep(min + random() * (max - min));
+    }
+
+    if (scenario.dropRate && random() < scenario.dropRate) {
+      throw new TypeError('chaos: network error');
+    }
+
+    if (burstLeft === 0 && scenario.errorRate && random() < scenario.errorRate) {
+      burstLeft = scenario.errorBurst ?? 1;
+    }
+    if (burstLeft > 0) {
+      burstLeft--;
+      return new Response(JSON.stringify({ error: 'chaos: injected failure' }), {
+        status: scenario.errorStatus ?? 503,
+        headers: { 'Content-Type': 'application/json' },
+      });
+    }
+
+    return base(input, init);
+  }) as typeof fetch;
+}
+
+export function chaosWebSocket(scenario: ChaosSocketScenario, Base: typeof WebSocket = WebSocket): typeof WebSocket {
+  const random = scenario.random ?? Math.random;
+
+  class ChaosWebSocket extends Base {
+    private handler: ((ev: MessageEvent) => void) | null = null;
+
+    constructor(url: string | URL, protocols?: string | string[]) {
+      super(url, protocols);
+
+      if (scenario.dropAfter !== undefined) {
+        const timer = setTimeout(() => this.close(4000, 'chaos: dropped'), scenario.dropAfter);
+        this.addEventListener('close', () => clearTimeout(timer));
+      }
+
+      super.onmessage = (ev: MessageEvent) => {
+        if (!this.handler) return;
+        if (scenario.truncateRate && typeof ev.data === 'string' && random() < scenario.truncateRate) {
+          this.handler(new MessageEvent('message', { data: ev.data.slice(0, Math.floor(ev.data.length / 2)) }));
+          return;
+        }
+        this.handler(ev);
+      };
+    }
+
+    get onmessage(): ((ev: MessageEvent) => void) | null {
+      return this.handler;
+    }
+
+    set onmessage(fn: ((ev: MessageEvent) => void) | null) {
+      this.handler = fn;
+    }
+  }
+
+  return ChaosWebSocket as unknown as typeof WebSocket;
+}
@@ -0,0 +1,63 @@
+/**
+ * kimdb Wire Codec
+ *
+ * WebSocket 메시지 인코딩 추상화
+ * - 기본: JSON 텍스트 프레임 (프로토콜 v1)
+ * - 바이너리 인코딩이나 새 프로토콜 버전은 Codec 구현으로 교체
+ * - JSON 구현(JsonLibrary)만 바꾸려면 jsonCodec (REST 본문도 같은 구현 사용 - 클라이언트 json 옵션)
+ * - 접속 URL의 ?protocol=이름/버전으로 협상, 서버 쪽 규칙은 src/shared/protocol.js
+ */
+
+export interface WireMessage {
+  type: string;
+  [key: string]: unknown;
+}
+
+export type Frame = string | ArrayBuffer;
+
+export interface Codec {
+  /** 핸드셰이크에 사용하는 프로토콜 이름 */
+  readonly name: string;
+  /** 프로토콜 버전 */
+  readonly version: number;
+  /** true면 ArrayBuffer 프레임 사용 */
+  readonly binary: boolean;
+  encode(msg: unknown): Frame;
+  decode(frame: Frame): WireMessage;
+}
+
+/** JSON.parse/stringify와 같은 모양의 구현 (더 빠른 라이브러리로 교체용) */
+export interface JsonLibrary {
+  parse(text: string): unknown;
+  stringify(value: unknown): string;
+}
+
+/** 주어진 JSON 구현을 쓰는 프로토콜 v1 코덱 */
+export function jsonCodec(json: JsonLibrary): Codec {
+  return {
+    name: 'json',
+    version: 1,
+    binary: false,
+    encode: (msg) => json.stringify(msg),
+    decode: (frame) => json.parse(typeof frame === 'string' ? frame : new TextDecoder().decode(frame)) as WireMessage,
+  };
+}
+
+export const JsonCodec: Codec = jsonCodec(JSON);
+
+/** 프레임 바이트 수 (문자열은 UTF-8 기준) */
+export function frameSize(frame: Frame): number {
+  if (typeof frame !== 'string') return frame.byteLength;
+
+  let bytes = 0;
+  for (let i = 0; i < frame.length; i++) {
+    const code = frame.charCodeAt(i);
+    if (code < 0x80) bytes += 1;
+    else if (code < 0x800) bytes += 2;
+    else if (code >= 0xd800 && code <= 0xdbff) {
+      bytes += 4;
+      i++;
+    } else bytes += 3;
+  }
+  return bytes;
+}
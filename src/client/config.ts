@@ -0,0 +1,85 @@
+/**
+ * kimdb Client Configuration
+ *
+ * 환경변수 / JSON 파일에서 KimDBClientOptions 생성 (Node 전용)
+ * - KIMDB_URL (필수), KIMDB_API_KEY (또는 KIMDB_TOKEN)
+ * - KIMDB_AUTO_RECONNECT, KIMDB_RECONNECT_INTERVAL, KIMDB_MAX_RECONNECT_ATTEMPTS
+ * - KIMDB_BATCH_SIZE, KIMDB_BATCH_TIMEOUT, KIMDB_CONNECTION_MAX_LIFETIME, KIMDB_FIELD_NAMING
+ * - 파일과 환경변수가 모두 있으면 환경변수 우선
+ * - 잘못된 값은 모아서 ClientConfigError 하나로 보고
+ */
+
+import { readFile } from 'fs/promises';
+import type { KimDBClientOptions } from './index.js';
+import { ClientConfigError, validateClientOptions } from './options.js';
+
+export { ClientConfigError, validateClientOptions };
+
+type Env = Record<string, string | undefined>;
+
+const NUMBER_VARS = {
+  KIMDB_RECONNECT_INTERVAL: 'reconnectInterval',
+  KIMDB_MAX_RECONNECT_ATTEMPTS: 'maxReconnectAttempts',
+  KIMDB_BATCH_SIZE: 'batchSize',
+  KIMDB_BATCH_TIMEOUT: 'batchTimeout',
+  KIMDB_CONNECTION_MAX_LIFETIME: 'connectionMaxLifetime',
+} as const;
+
+function parseEnv(env: Env, issues: string[]): Partial<KimDBClientOptions> {
+  const options: Partial<KimDBClientOptions> = {};
+
+  if (env.KIMDB_URL) options.url = env.KIMDB_URL;
+  const apiKey = env.KIMDB_API_KEY || env.KIMDB_TOKEN;
+  if (apiKey) options.apiKey = apiKey;
+
+  if (env.KIMDB_AUTO_RECONNECT !== undefined) {
+    const v = env.KIMDB_AUTO_RECONNECT.toLowerCase();
+    if (v === 'true' || v === '1') options.autoReconnect = true;
+    else if (v === 'false' || v === '0') options.autoReconnect = false;
+    else issues.push(`KIMDB_AUTO_RECONNECT must be true/false, got ${JSON.stringify(env.KIMDB_AUTO_RECONNECT)}`);
+  }
+
+  for (const [name, key] of Object.entries(NUMBER_VARS)) {
+    const raw = env[name];
+    if (raw === undefined || raw === '') continue;
+    const n = Number(raw);
+    if (!Number.isFinite(n)) {
+      issues.push(`${name} must be a number, got ${JSON.stringify(raw)}`);
+    } else {
+      options[key] = n;
+    }
+  }
+
+  if (env.KIMDB_FIELD_NAMING !== undefined) {
+    options.fieldNaming = env.KIMDB_FIELD_NAMING as KimDBClientOptions['fieldNaming'];
+  }
+
+  return options;
+}
+
+/** 환경변수에서 클라이언트 옵션 생성 */
+export function clientConfigFromEnv(env: Env = process.env): KimDBClientOptions {
+  return finish({}, env);
+}
+
+/** JSON 파일(KimDBClientOptions 형태) + 환경변수에서 클라이언트 옵션 생성 */
+export async function loadClientConfig(path: string, env: Env = process.env): Promise<KimDBClientOptions> {
+  let file: unknown;
+  try {
+    file = JSON.parse(await readFile(path, 'utf8'));
+  } catch (e) {
+    throw new ClientConfigError([`Cannot read ${path}: ${(e as Error).message}`]);
+  }
+  if (!file || typeof file !== 'object' || Array.isArray(file)) {
+    throw new ClientConfigError([`${path} must contain a JSON object`]);
+  }
+  return finish(file as Partial<KimDBClientOptions>, env);
+}
+
+function finish(base: Partial<KimDBClientOptions>, env: Env): KimDBClientOptions {
+  const issues: string[] = [];
+  const options = { ...base, ...parseEnv(env, issues) };
+  issues.push(...validateClientOptions(options));
+  if (issues.length > 0) throw new ClientConfigError(issues);
+  return options as KimDBClientOptions;
+}
@@ -0,0 +1,226 @@
+/**
+ * kimdb Durable Consumer
+ *
+ * 이름 붙은 변경 스트림 구독 - 처리 위치를 저장해 재시작 후 이어받음
+ * - 위치 = 문서별 마지막 처리 _version (서버에 전역 시퀀스가 없으므로)
+ * - 핸들러가 성공한 뒤에만 위치 저장, 이벤트는 도착 순서대로 하나씩 처리
+ * - 핸들러가 실패하면 처리를 멈추고 onError 호출 (재시작 시 그 이벤트부터 다시 전달)
+ * - PositionStore로 저장소 교체 (파일, localStorage, KV 컬렉션 등)
+ * - dedup: 처리한 이벤트 키를 TTL 동안 기억해, 핸들러 성공 후 위치 저장 전에 죽어도
+ *   재시작 시 같은 이벤트를 다시 처리하지 않음
+ * - partition: 같은 그룹의 인스턴스 N개가 docId 해시로 문서를 나눠 처리
+ *   (인스턴스마다 다른 이름/위치 저장 필요, count를 바꾸면 옮겨간 문서는 새 담당자가 다시 처리)
+ */
+
+import type { KimDBClient, TailEvent } from './index.js';
+import type { KVStore } from './kv.js';
+
+export type Positions = Record<string, number>;
+
+export interface PositionStore {
+  load(consumer: string): Promise<Positions | null>;
+  save(consumer: string, positions: Positions): Promise<void>;
+}
+
+/** 프로세스 메모리 저장소 (테스트용, 재시작하면 사라짐) */
+export class MemoryPositionStore implements PositionStore {
+  private data = new Map<string, Positions>();
+
+  async load(consumer: string): Promise<Positions | null> {
+    const positions = this.data.get(consumer);
+    return positions ? { ...positions } : null;
+  }
+
+  async save(consumer: string, positions: Positions): Promise<void> {
+    this.data.set(consumer, { ...positions });
+  }
+}
+
+/** 처리 완료 이벤트 키 저장소 */
+export interface DedupStore {
+  has(key: string): Promise<boolean>;
+  add(key: string, ttlMs: number): Promise<void>;
+}
+
+export class MemoryDedupStore implements DedupStore {
+  private keys = new Map<string, number>();
+
+  async has(key: string): Promise<boolean> {
+    const expiresAt = this.keys.get(key);
+    if (expiresAt === undefined) return false;
+    if (expiresAt <= Date.now()) {
+      this.keys.delete(key);
+      return false;
+    }
+    return true;
+  }
+
+  async add(key: string, ttlMs: number): Promise<void> {
+    this.keys.set(key, Date.now() + ttlMs);
+  }
+}
+
+/** KV 컬렉션 기반 저장소 - 여러 프로세스/재시작 사이에 공유 */
+export class KVDedupStore implements DedupStore {
+  private kv: KVStore;
+
+  constructor(kv: KVStore) {
+    this.kv = kv;
+  }
+
+  async has(key: string): Promise<boolean> {
+    return (await this.kv.get(key)) !== null;
+  }
+
+  async add(key: string, ttlMs: number): Promise<void> {
+    await this.kv.set(key, true, ttlMs);
+  }
+}
+
+/** docId가 속하는 파티션 (FNV-1a 해시, 0 ~ count-1) */
+export function partitionOf(docId: string, count: number): number {
+  let hash = 0x811c9dc5;
+  for (let i = 0; i < docId.length; i++) {
+    hash ^= docId.charCodeAt(i);
+    hash = Math.imul(hash, 0x01000193);
+  }
+  return (hash >>> 0) % count;
+}
+
+/** 이벤트 식별 키 (삭제는 삭제 직전 처리 버전 기준) */
+export function eventKey(collection: string, event: TailEvent, previousVersion = 0): string {
+  return event.event === 'delete'
+    ? `${collection}__${event.id}__delete__${previousVersion}`
+    : `${collection}__${event.id}__${event._version}`;
+}
+
+export interface DurableConsumerOptions {
+  store: PositionStore;
+  onError?: (error: Error, event: TailEvent) => void;
+  /** 중복 처리 방지 저장소 (없으면 위치 저장만으로 at-least-once) */
+  dedup?: DedupStore;
+  /** 처리 키 보관 시간 (ms, 기본 24시간) */
+  dedupTtl?: number;
+  /** 컨슈머 그룹 내 담당 파티션 (index: 0 ~ count-1) */
+  partition?: { index: number; count: number };
+}
+
+export class DurableConsumer {
+  private client: Pick<KimDBClient, 'backfillAndTail'>;
+  readonly name: string;
+  readonly collection: string;
+  private options: DurableConsumerOptions;
+  private positions: Positions = {};
+  private queue: Promise<void> = Promise.resolve();
+  private unsubscribe: (() => void) | null = null;
+  private failed = false;
+  private onFailure: ((error: Error) => void) | null = null;
+
+  constructor(
+    client: Pick<KimDBClient, 'backfillAndTail'>,
+    name: string,
+    collection: string,
+    options: DurableConsumerOptions,
+  ) {
+    this.client = client;
+    this.name = name;
+    this.collection = collection;
+    this.options = options;
+
+    const { partition } = options;
+    if (partition && !(Number.isInteger(partition.index) && partition.index >= 0 && partition.index < partition.count)) {
+      throw new Error(`Invalid partition ${partition.index}/${partition.count}`);
+    }
+  }
+
+  /** 이 인스턴스가 처리할 문서인지 */
+  owns(docId: string): boolean {
+    const { partition } = this.options;
+    return !partition || partitionOf(docId, partition.count) === partition.index;
+  }
+
+  /** 저장된 위치부터 처리 시작 (백필이 끝나면 resolve, 이후 실시간 이벤트 계속 처리) */
+  async start(handler: (event: TailEvent) => void | Promise<void>): Promise<void> {
+    if (this.unsubscribe) throw new Error(`Consumer already started: ${this.name}`);
+
+    this.positions = (await this.options.store.load(this.name)) ?? {};
+    this.failed = false;
+
+    this.unsubscribe = await this.client.backfillAndTail(
+      this.collection,
+      (event) => {
+        if (!this.owns(event.id)) return;
+        this.queue = this.queue.then(() => this.process(event, handler));
+      },
+      { initialVersions: this.positions },
+    );
+  }
+
+  private async process(event: TailEvent, handler: (event: TailEvent) => void | Promise<void>): Promise<void> {
+    if (this.failed) return;
+
+    const { dedup } = this.options;
+    const key = eventKey(this.collection, event, this.positions[event.id]);
+
+    try {
+      if (!dedup || !(await dedup.has(key))) {
+        await handler(event);
+        await dedup?.add(key, this.options.dedupTtl ?? 24 * 60 * 60 * 1000);
+      }
+    } catch (e) {
+      this.failed = true;
+      this.stopStream();
+      this.options.onError?.(e as Error, event);
+      this.onFailure?.(e as Error);
+      return;
+    }
+
+    if (event.event === 'delete') {
+      delete this.positions[event.id];
+    } else {
+      this.positions[event.id] = event._version;
+    }
+    await this.options.store.save(this.name, this.positions);
+  }
+
+  private stopStream(): void {
+    this.unsubscribe?.();
+    this.unsubscribe = null;
+  }
+
+  /** 구독 해제 후 처리 중인 이벤트가 끝날 때까지 대기 */
+  async stop(): Promise<void> {
+    this.stopStream();
+    await this.queue;
+  }
+
+  /**
+   * signal이 중단될 때까지 처리 (Group.go와 함께 사용)
+   *
+   * 핸들러가 실패하면 그 오류로 reject, 어느 경우든 stop() 후 반환.
+   */
+  async run(handler: (event: TailEvent) => void | Promise<void>, signal: AbortSignal): Promise<void> {
+    const failed = new Promise<never>((_, reject) => {
+      this.onFailure = reject;
+    });
+    // 백필 중 실패해도 unhandled rejection이 되지 않도록
+    failed.catch(() => {});
+    const aborted = new Promise<void>((resolve) => {
+      if (signal.aborted) resolve();
+      else signal.addEventListener('abort', () => resolve(), { once: true });
+    });
+
+    try {
+      await this.start(handler);
+      await Promise.race([aborted, failed]);
+    } finally {
+      this.onFailure = null;
+      await this.stop();
+    }
+  }
+
+  /** 처리 완료로 저장된 문서별 버전 */
+  getPositions(): Positions {
+    return { ...this.positions };
+  }
+}
@@ -0,0 +1,58 @@
+/**
+ * kimdb Collection Copy
+ *
+ * 컬렉션 문서를 다른 컬렉션으로 복사 (스테이징 데이터 생성, 컬렉션 단위 마이그레이션)
+ * - filter: 복사할 문서만 고름, transform: 저장 전 변환 (null 반환 시 건너뜀)
+ * - 같은 ID로 저장 (PUT 병합), overwrite: false면 대상에 이미 있는 ID는 건너뜀
+ * - 원본과 (overwrite: false면) 대상 모두 마지막 페이지까지 읽음
+ */
+
+import type { KimDBRestAPI } from './api.js';
+import { mapLimit } from './fanout.js';
+import { listAll } from './page.js';
+
+type Doc = { id: string; _version: number; [key: string]: unknown };
+
+export interface CopyOptions {
+  filter?: (doc: Doc) => boolean;
+  /** 저장할 데이터 반환 (id/_version 제외), null이면 건너뜀 */
+  transform?: (doc: Doc) => Record<string, unknown> | null;
+  /** 대상에 같은 ID가 있어도 덮어쓸지 (기본 true) */
+  overwrite?: boolean;
+  /** 동시에 보낼 저장 요청 수 (기본 4) */
+  concurrency?: number;
+  /** 실제 저장 없이 복사될 ID만 반환 */
+  dryRun?: boolean;
+}
+
+export interface CopyResult {
+  copied: string[];
+  skipped: number;
+}
+
+export async function copyCollection(
+  client: KimDBRestAPI,
+  source: string,
+  target: string,
+  options: CopyOptions = {},
+): Promise<CopyResult> {
+  if (source === target) throw new Error(`Cannot copy collection onto itself: ${source}`);
+
+  const data = await listAll<Doc>(client, source);
+  const existing = options.overwrite === false
+    ? new Set((await listAll(client, target)).map(d => d.id))
+    : new Set<string>();
+
+  const writes: Array<{ id: string; data: Record<string, unknown> }> = [];
+  for (const doc of data) {
+    if (existing.has(doc.id) || (options.filter && !options.filter(doc))) continue;
+    const { id: _id, _version: _v, ...fields } = doc;
+    const out = options.transform ? options.transform(doc) : fields;
+    if (out) writes.push({ id: doc.id, data: out });
+  }
+
+  if (!options.dryRun) {
+    await mapLimit(writes, options.concurrency ?? 4, w => client.save(target, w.id, w.data));
+  }
+  return { copied: writes.map(w => w.id), skipped: data.length - writes.length };
+}
@@ -0,0 +1,116 @@
+/**
+ * kimdb Debug Transport
+ *
+ * 요청/응답, WebSocket 프레임 전체 로그 (프로토콜 불일치 진단용, 기본 비활성)
+ * - X-API-Key / Authorization 헤더와 URL의 api_key는 항상 가림
+ * - redact 규칙은 본문과 본문의 data(문서, 문서 배열)에 적용
+ * - 긴 본문은 maxBody 글자에서 자름
+ *
+ *   const client = new KimDBClient({
+ *     url,
+ *     fetch: debugFetch({ redact: [{ path: 'password' }] }),
+ *     WebSocketImpl: debugWebSocket(),
+ *   });
+ */
+
+import { redact, type RedactionRule } from './redact.js';
+
+export interface DebugOptions {
+  /** 로그 출력 (기본: console.debug) */
+  log?: (line: string) => void;
+  redact?: RedactionRule[];
+  /** 본문 최대 출력 길이 (기본 2000) */
+  maxBody?: number;
+}
+
+const SECRET_HEADERS = new Set(['x-api-key', 'authorization', 'cookie']);
+const MASK = '***';
+
+function maskUrl(url: string): string {
+  return url.replace(/([?&]api_key=)[^&]*/g, `$1${MASK}`);
+}
+
+function maskHeaders(headers: RequestInit['headers']): Record<string, string> {
+  const out: Record<string, string> = {};
+  new Headers(headers).forEach((value, key) => {
+    out[key] = SECRET_HEADERS.has(key) ? MASK : value;
+  });
+  return out;
+}
+
+/** JSON이면 파싱해 가림 규칙 적용 후 다시 문자열로 */
+function maskBody(body: string, options: DebugOptions): string {
+  const rules = options.redact ?? [];
+  let text = body;
+  if (rules.length > 0) {
+    try {
+      const parsed = JSON.parse(body);
+      let masked = redact(parsed, rules);
+      if (masked && typeof masked === 'object' && 'data' in masked) {
+        const data = (masked as { data: unknown }).data;
+        masked = {
+          ...masked,
+          data: Array.isArray(data) ? data.map((d) => redact(d, rules)) : redact(data, rules),
+        };
+      }
+      text = JSON.stringify(masked);
+    } catch {
+      // JSON이 아니면 그대로
+    }
+  }
+  const max = options.maxBody ?? 2000;
+  return text.length > max ? `${text.slice(0, max)}... (${text.length} chars)` : text;
+}
+
+export function debugFetch(options: DebugOptions = {}, base: typeof fetch = (i, o) => fetch(i, o)): typeof fetch {
+  const log = options.log ?? ((line: string) => console.debug(line));
+
+  return (async (input: Parameters<typeof fetch>[0], init?: RequestInit) => {
+    const method = init?.method ?? 'GET';
+    const url = maskUrl(String(input instanceof Request ? input.url : input));
+    const headers = JSON.stringify(maskHeaders(init?.headers));
+    const body = typeof init?.body === 'string' ? ` ${maskBody(init.body, options)}` : '';
+    log(`[kimdb-debug] → ${method} ${url} ${headers}${body}`);
+
+    const start = performance.now();
+    let res: Response;
+    try {
+      res = await base(input, init);
+    } catch (e) {
+      log(`[kimdb-debug] ✗ ${method} ${url} ${(e as Error).message}`);
+      throw e;
+    }
+
+    const text = await res.clone().text();
+    log(`[kimdb-debug] ← ${res.status} ${method} ${url} (${Math.round(performance.now() - start)}ms) ${maskBody(text, options)}`);
+    return res;
+  }) as typeof fetch;
+}
+
+export function debugWebSocket(options: DebugOptions = {}, Base: typeof WebSocket = WebSocket): typeof WebSocket {
+  const log = options.log ?? ((line: string) => console.debug(line));
+
+  class DebugWebSocket extends Base {
+    constructor(url: string | URL, protocols?: string | string[]) {
+      super(url, protocols);
+      const shown = maskUrl(String(url));
+      log(`[kimdb-debug] ws connect ${shown}`);
+
+      this.addEventListener('message', (ev: MessageEvent) => {
+        const frame = typeof ev.data === 'string' ? maskBody(ev.data, options) : `<binary ${(ev.data as ArrayBuffer).byteLength ?? '?'} bytes>`;
+        log(`[kimdb-debug] ws ← ${frame}`);
+      });
+      this.addEventListener('close', (ev: { code: number; reason: string }) => {
+        log(`[kimdb-debug] ws closed ${shown} (${ev.code}${ev.reason ? ` ${ev.reason}` : ''})`);
+      });
+    }
+
+    send(data: string | ArrayBufferLike | Blob | ArrayBufferView): void {
+      const frame = typeof data === 'string' ? maskBody(data, options) : '<binary>';
+      log(`[kimdb-debug] ws → ${frame}`);
+      super.send(data);
+    }
+  }
+
+  return DebugWebSocket as unknown as typeof WebSocket;
+}
@@ -0,0 +1,186 @@
+/**
+ * kimdb Document Diff
+ *
+ * 두 문서 사이의 변경 집합 (RFC 6902 JSON Patch)
+ * - diff(old, new): add / remove / replace 연산 생성
+ * - applyPatch(doc, patch): 원본은 건드리지 않고 새 문서 반환
+ *   (__proto__/constructor/prototype 경로와 상속된 속성을 거치는 경로는 거부)
+ * - fieldPatch(old, new): 바뀐 최상위 필드만 담은 병합용 객체 (서버 PUT/PATCH는 병합)
+ * - 배열은 인덱스 단위로 비교 (이동 감지 없음)
+ */
+
+import { FORBIDDEN_PATH_KEYS } from '../shared/update-ops.js';
+
+export type PatchOperation =
+  | { op: 'add'; path: string; value: unknown }
+  | { op: 'remove'; path: string }
+  | { op: 'replace'; path: string; value: unknown }
+  | { op: 'test'; path: string; value: unknown };
+
+/** 적용할 수 없는 패치 (경로 없음, test 실패 등) */
+export class PatchError extends Error {
+  readonly operation: PatchOperation;
+
+  constructor(operation: PatchOperation, reason: string) {
+    super(`Cannot apply ${operation.op} at ${JSON.stringify(operation.path)}: ${reason}`);
+    this.name = 'PatchError';
+    this.operation = operation;
+  }
+}
+
+// ===== JSON Pointer (RFC 6901) =====
+function escapeToken(token: string): string {
+  return token.replace(/~/g, '~0').replace(/\//g, '~1');
+}
+
+function unescapeToken(token: string): string {
+  return token.replace(/~1/g, '/').replace(/~0/g, '~');
+}
+
+function parsePointer(path: string): string[] {
+  if (path === '') return [];
+  if (!path.startsWith('/')) throw new Error(`Invalid JSON pointer: ${JSON.stringify(path)}`);
+  return path.slice(1).split('/').map(unescapeToken);
+}
+
+function isObject(value: unknown): value is Record<string, unknown> {
+  return typeof value === 'object' && value !== null && !Array.isArray(value);
+}
+
+function deepEqual(a: unknown, b: unknown): boolean {
+  if (a === b) return true;
+  if (Array.isArray(a) && Array.isArray(b)) {
+    return a.length === b.length && a.every((v, i) => deepEqual(v, b[i]));
+  }
+  if (isObject(a) && isObject(b)) {
+    const keys = Object.keys(a);
+    return keys.length === Object.keys(b).length &&
+      keys.every(k => Object.prototype.hasOwnProperty.call(b, k) && deepEqual(a[k], b[k]));
+  }
+  return false;
+}
+
+// ===== Diff =====
+/** old → new 로 가는 패치 생성 */
+export function diff(oldDoc: unknown, newDoc: unknown): PatchOperation[] {
+  const ops: PatchOperation[] = [];
+  diffValue(oldDoc, newDoc, '', ops);
+  return ops;
+}
+
+function diffValue(a: unknown, b: unknown, path: string, ops: PatchOperation[]): void {
+  if (deepEqual(a, b)) return;
+
+  if (isObject(a) && isObject(b)) {
+    for (const key of Object.keys(a)) {
+      const child = `${path}/${escapeToken(key)}`;
+      if (!Object.prototype.hasOwnProperty.call(b, key)) {
+        ops.push({ op: 'remove', path: child });
+      } else {
+        diffValue(a[key], b[key], child, ops);
+      }
+    }
+    for (const key of Object.keys(b)) {
+      if (!Object.prototype.hasOwnProperty.call(a, key)) {
+        ops.push({ op: 'add', path: `${path}/${escapeToken(key)}`, value: clone(b[key]) });
+      }
+    }
+    return;
+  }
+
+  if (Array.isArray(a) && Array.isArray(b)) {
+    const common = Math.min(a.length, b.length);
+    for (let i = 0; i < common; i++) {
+      diffValue(a[i], b[i], `${path}/${i}`, ops);
+    }
+    // 뒤에서부터 지워야 앞 인덱스가 유지됨
+    for (let i = a.length - 1; i >= common; i--) {
+      ops.push({ op: 'remove', path: `${path}/${i}` });
+    }
+    for (let i = common; i < b.length; i++) {
+      ops.push({ op: 'add', path: `${path}/${i}`, value: clone(b[i]) });
+    }
+    return;
+  }
+
+  ops.push({ op: 'replace', path, value: clone(b) });
+}
+
+/** 바뀐 최상위 필드 → 새 값 (삭제된 필드는 null) */
+export function fieldPatch(oldDoc: Record<string, unknown>, newDoc: Record<string, unknown>): Record<string, unknown> {
+  const patch: Record<string, unknown> = {};
+  for (const op of diff(oldDoc, newDoc)) {
+    const [field] = parsePointer(op.path);
+    patch[field] = field in newDoc ? clone(newDoc[field]) : null;
+  }
+  return patch;
+}
+
+function clone<T>(value: T): T {
+  return value === undefined ? value : JSON.parse(JSON.stringify(value));
+}
+
+// ===== Apply =====
+function isIndex(token: string): boolean {
+  return /^(0|[1-9][0-9]*)$/.test(token);
+}
+
+/** 패치 적용 결과 반환 (입력 문서는 변경하지 않음) */
+export function applyPatch<T = unknown>(doc: unknown, patch: PatchOperation[]): T {
+  let root = clone(doc);
+
+  for (const operation of patch) {
+    const tokens = parsePointer(operation.path);
+
+    if (tokens.length === 0) {
+      if (operation.op === 'remove') {
+        root = undefined;
+      } else if (operation.op === 'test') {
+        if (!deepEqual(root, operation.value)) throw new PatchError(operation, 'test failed');
+      } else {
+        root = clone(operation.value);
+      }
+      continue;
+    }
+
+    if (tokens.some(token => FORBIDDEN_PATH_KEYS.has(token))) throw new PatchError(operation, 'forbidden path');
+
+    let parent: unknown = root;
+    for (const token of tokens.slice(0, -1)) {
+      const container = Array.isArray(parent) ? isIndex(token) : isObject(parent);
+      if (!container || !Object.prototype.hasOwnProperty.call(parent, token)) throw new PatchError(operation, 'path not found');
+      parent = (parent as Record<string, unknown>)[token];
+    }
+    const last = tokens[tokens.length - 1];
+
+    if (Array.isArray(parent)) {
+      const index = last === '-' ? parent.length : Number(last);
+      const inRange = Number.isInteger(index) && index >= 0 &&
+        (operation.op === 'add' ? index <= parent.length : index < parent.length);
+      if (!inRange) throw new PatchError(operation, 'index out of range');
+
+      switch (operation.op) {
+        case 'add': parent.splice(index, 0, clone(operation.value)); break;
+        case 'remove': parent.splice(index, 1); break;
+        case 'replace': parent[index] = clone(operation.value); break;
+        case 'test':
+          if (!deepEqual(parent[index], operation.value)) throw new PatchError(operation, 'test failed');
+      }
+    } else if (isObject(parent)) {
+      const exists = Object.prototype.hasOwnProperty.call(parent, last);
+      if (operation.op !== 'add' && !exists) throw new PatchError(operation, 'path not found');
+
+      switch (operation.op) {
+        case 'add':
+        case 'replace': parent[last] = clone(operation.value); break;
+        case 'remove': delete parent[last]; break;
+        case 'test':
+          if (!deepEqual(parent[last], operation.value)) throw new PatchError(operation, 'test failed');
+      }
+    } else {
+      throw new PatchError(operation, 'parent is not a container');
+    }
+  }
+
+  return root as T;
+}
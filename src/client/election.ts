@@ -0,0 +1,149 @@
+/**
+ * kimdb Leader Election
+ *
+ * 리스 문서 하나로 여러 인스턴스 중 리더 하나를 뽑음 (별도 코디네이션 시스템 없이)
+ * - 리스 문서 { holder, token, expiresAt } - 모든 쓰기는 expectedVersion 조건부라 두 후보가 동시에 잡지 못함
+ * - 만료된(또는 내 이름의) 리스만 가져올 수 있고, 가져올 때마다 token(펜싱 토큰)이 1 증가
+ * - 리더는 ttl의 1/3마다 갱신, 버전 충돌이나 ttl 안에 갱신하지 못하면 onResigned
+ * - 펜싱: 리더가 하는 외부 쓰기에 fencingToken을 붙이고, 받는 쪽은 더 작은 토큰을 거부
+ * - 만료는 각 인스턴스 시계로 판단 (인스턴스 간 시계 차이가 ttl보다 충분히 작아야 함)
+ */
+
+import type { KimDBRestAPI } from './api.js';
+import { KimDBHttpError, VersionConflictError } from './errors.js';
+
+export type ElectionClient = Pick<KimDBRestAPI, 'getDoc' | 'save'>;
+
+export interface ElectionOptions {
+  /** 리스 문서를 둘 컬렉션 (기본 'kimdb_leases') */
+  collection?: string;
+  /** 리스 유지 시간 (ms, 기본 15000) */
+  ttlMs?: number;
+  /** 리더가 아닐 때 리스를 다시 확인하는 간격 (ms, 기본 ttlMs / 3) */
+  retryMs?: number;
+}
+
+interface Lease {
+  holder: string;
+  token: number;
+  /** epoch ms, 0이면 반납됨 */
+  expiresAt: number;
+}
+
+export class LeaderElection {
+  readonly name: string;
+  readonly candidate: string;
+  private client: ElectionClient;
+  private collection: string;
+  private ttlMs: number;
+  private retryMs: number;
+  private leaseVersion = 0;
+  private token: number | null = null;
+  private leaderUntil = 0;
+  private timer: ReturnType<typeof setTimeout> | null = null;
+  private running = false;
+
+  /** 리더가 됨 (token: 펜싱 토큰) */
+  onElected?: (token: number) => void;
+  /** 리더에서 물러남 (stop, 다른 후보가 리스를 가져감, 갱신 실패) */
+  onResigned?: () => void;
+  /** 충돌이 아닌 요청 실패 (네트워크 등) - 다음 주기에 다시 시도 */
+  onError?: (error: Error) => void;
+
+  constructor(client: ElectionClient, name: string, candidate: string, options: ElectionOptions = {}) {
+    this.client = client;
+    this.name = name;
+    this.candidate = candidate;
+    this.collection = options.collection ?? 'kimdb_leases';
+    this.ttlMs = options.ttlMs ?? 15000;
+    this.retryMs = options.retryMs ?? Math.floor(this.ttlMs / 3);
+  }
+
+  /** 지금 리더인지 (마지막 갱신 후 ttl 이내) */
+  get isLeader(): boolean {
+    return this.token !== null && Date.now() < this.leaderUntil;
+  }
+
+  /** 리더일 때의 펜싱 토큰 */
+  get fencingToken(): number | null {
+    return this.isLeader ? this.token : null;
+  }
+
+  /** 첫 시도를 마친 뒤 resolve, 이후 주기적으로 갱신/재시도 */
+  async start(): Promise<void> {
+    if (this.running) return;
+    this.running = true;
+    await this.tick();
+  }
+
+  /** 주기 작업을 멈추고, 리더였으면 리스를 반납 (다른 후보가 ttl을 기다리지 않고 가져감) */
+  async stop(): Promise<void> {
+    this.running = false;
+    if (this.timer) clearTimeout(this.timer);
+    this.timer = null;
+    if (this.token === null) return;
+
+    const lease: Lease = { holder: this.candidate, token: this.token, expiresAt: 0 };
+    try {
+      await this.client.save(this.collection, this.name, lease, { expectedVersion: this.leaseVersion });
+    } catch (e) {
+      // 이미 다른 후보가 가져갔으면 반납할 것이 없음
+      if (!(e instanceof VersionConflictError)) this.onError?.(e as Error);
+    }
+    this.resign();
+  }
+
+  private async tick(): Promise<void> {
+    try {
+      if (this.token !== null) await this.renew();
+      else await this.tryAcquire();
+    } catch (e) {
+      if (e instanceof VersionConflictError) {
+        if (this.token !== null) this.resign();
+      } else {
+        this.onError?.(e as Error);
+        if (this.token !== null && Date.now() >= this.leaderUntil) this.resign();
+      }
+    }
+    if (!this.running) return;
+    this.timer = setTimeout(() => {
+      this.tick().catch(() => {});
+    }, this.token !== null ? Math.floor(this.ttlMs / 3) : this.retryMs);
+  }
+
+  private async tryAcquire(): Promise<void> {
+    let current: { data: unknown; _version: number } | null = null;
+    try {
+      current = await this.client.getDoc(this.collection, this.name);
+    } catch (e) {
+      if (!(e instanceof KimDBHttpError) || e.status !== 404) throw e;
+    }
+
+    const lease = current?.data as Lease | undefined;
+    const now = Date.now();
+    if (lease && lease.holder !== this.candidate && lease.expiresAt > now) return;
+
+    const next: Lease = { holder: this.candidate, token: (lease?.token ?? 0) + 1, expiresAt: now + this.ttlMs };
+    const res = await this.client.save(this.collection, this.name, next, { expectedVersion: current?._version ?? 0 });
+    this.leaseVersion = res._version;
+    this.token = next.token;
+    this.leaderUntil = now + this.ttlMs;
+    this.onElected?.(next.token);
+  }
+
+  private async renew(): Promise<void> {
+    const now = Date.now();
+    const lease: Lease = { holder: this.candidate, token: this.token!, expiresAt: now + this.ttlMs };
+    const res = await this.client.save(this.collection, this.name, lease, { expectedVersion: this.leaseVersion });
+    this.leaseVersion = res._version;
+    this.leaderUntil = now + this.ttlMs;
+  }
+
+  private resign(): void {
+    this.token = null;
+    this.leaderUntil = 0;
+    this.onResigned?.();
+  }
+}
+
+export default LeaderElection;
@@ -0,0 +1,79 @@
+/**
+ * kimdb Client Errors
+ *
+ * REST 응답 오류
+ * - message 형식은 기존과 동일: `HTTP <status>: <body>`
+ * - 400/422 응답 본문에 errors[]가 있으면 ValidationError (필드별 issues), 없으면 KimDBHttpError
+ * - 409 응답은 VersionConflictError (expectedVersion 조건 실패, 현재 서버 버전 포함)
+ */
+
+export interface ValidationIssue {
+  path: string;
+  rule: string;
+  message: string;
+}
+
+export class KimDBHttpError extends Error {
+  readonly status: number;
+  /** 응답 본문 원문 */
+  readonly body: string;
+  /** JSON 응답의 error 필드 (없으면 본문) */
+  readonly serverMessage: string;
+
+  constructor(status: number, body: string) {
+    super(`HTTP ${status}: ${body}`);
+    this.name = 'KimDBHttpError';
+    this.status = status;
+    this.body = body;
+
+    const parsed = parseBody(body);
+    this.serverMessage = typeof parsed?.error === 'string' ? parsed.error : body;
+  }
+}
+
+export class ValidationError extends KimDBHttpError {
+  readonly issues: ValidationIssue[];
+
+  constructor(status: number, body: string) {
+    super(status, body);
+    this.name = 'ValidationError';
+
+    const parsed = parseBody(body);
+    const raw = Array.isArray(parsed?.errors) ? parsed.errors : [];
+    this.issues = raw
+      .filter((e): e is Record<string, unknown> => !!e && typeof e === 'object')
+      .map((e) => ({
+        path: String(e.path ?? e.field ?? ''),
+        rule: String(e.rule ?? e.code ?? ''),
+        message: String(e.message ?? ''),
+      }));
+  }
+}
+
+export class VersionConflictError extends KimDBHttpError {
+  /** 요청 시점의 서버 문서 버전 (0 = 문서 없음, 서버가 보내지 않았으면 null) */
+  readonly currentVersion: number | null;
+
+  constructor(status: number, body: string) {
+    super(status, body);
+    this.name = 'VersionConflictError';
+    const current = parseBody(body)?._version;
+    this.currentVersion = typeof current === 'number' ? current : null;
+  }
+}
+
+function parseBody(body: string): Record<string, unknown> | null {
+  try {
+    const parsed = JSON.parse(body);
+    return parsed && typeof parsed === 'object' ? parsed : null;
+  } catch {
+    return null;
+  }
+}
+
+/** 상태 코드에 맞는 오류 생성 */
+export function httpError(status: number, body: string): KimDBHttpError {
+  if ((status === 400 || status === 422) && Array.isArray(parseBody(body)?.errors)) return new ValidationError(status, body);
+  if (status === 409) return new VersionConflictError(status, body);
+  return new KimDBHttpError(status, body);
+}
@@ -0,0 +1,198 @@
+/**
+ * kimdb Fake Client
+ *
+ * 테스트용 인메모리 KimDBRestAPI 구현 (네트워크 없음)
+ * - 응답 형식과 PUT 병합/404/expectedVersion 409 동작은 서버와 동일
+ * - 목록도 서버처럼 삽입 순서, list는 첫 1000개만 (나머지는 listPage)
+ * - SQL은 SELECT 일부만: WHERE a = ? [AND b = ?], ORDER BY (여러 키), LIMIT
+ */
+
+import type { KimDBRestAPI } from './api.js';
+import type { WriteOptions } from './index.js';
+import type { SQLResponse } from '../shared/types.js';
+import { KimDBHttpError, VersionConflictError } from './errors.js';
+import { validateCollectionName, validateDocId } from './paths.js';
+import { validateStatement, type SQLOptions } from './sql.js';
+import { parseOrderBy, compareBy } from './sort.js';
+import { CollectionPage, type PageOptions } from './page.js';
+import { collator } from '../shared/collation.js';
+import { sampleSizeError } from '../shared/sample.js';
+
+type Doc = { id: string; _version: number; [key: string]: unknown };
+
+interface StoredDoc {
+  data: Record<string, unknown>;
+  _version: number;
+}
+
+function notFound(): KimDBHttpError {
+  return new KimDBHttpError(404, JSON.stringify({ error: 'Not found' }));
+}
+
+function badRequest(message: string): KimDBHttpError {
+  return new KimDBHttpError(400, JSON.stringify({ error: message }));
+}
+
+function checkVersion(existing: StoredDoc | undefined, options: WriteOptions): void {
+  if (options.expectedVersion === undefined) return;
+  const current = existing?._version ?? 0;
+  if (options.expectedVersion !== current) {
+    throw new VersionConflictError(409, JSON.stringify({ error: 'Version conflict', expected: options.expectedVersion, _version: current }));
+  }
+}
+
+export class FakeKimDBClient implements KimDBRestAPI {
+  private store = new Map<string, Map<string, StoredDoc>>();
+  private nextId = 1;
+
+  private col(name: string): Map<string, StoredDoc> {
+    validateCollectionName(name);
+    if (!this.store.has(name)) this.store.set(name, new Map());
+    return this.store.get(name)!;
+  }
+
+  private doc(collection: string, id: string): StoredDoc {
+    validateDocId(id);
+    const doc = this.col(collection).get(id);
+    if (!doc) throw notFound();
+    return doc;
+  }
+
+  private write(collection: string, id: string, data: unknown, options: WriteOptions = {}): { success: boolean; id: string; _version: number } {
+    if (!data || typeof data !== 'object') throw badRequest('data is required');
+    const docs = this.col(collection);
+    const existing = docs.get(id);
+    checkVersion(existing, options);
+    const stored: StoredDoc = existing
+      ? { data: { ...existing.data, ...(data as Record<string, unknown>) }, _version: existing._version + 1 }
+      : { data: { ...(data as Record<string, unknown>) }, _version: 1 };
+    docs.set(id, stored);
+    return { success: true, id, _version: stored._version };
+  }
+
+  async collections(): Promise<string[]> {
+    return [...this.store.keys()].sort();
+  }
+
+  private docs(collection: string): Doc[] {
+    return [...this.col(collection)].map(([id, d]) => ({ id, ...structuredClone(d.data), _version: d._version }));
+  }
+
+  async list(collection: string): ReturnType<KimDBRestAPI['list']> {
+    const page = await this.listPage(collection, { limit: 1000 });
+    return {
+      success: true,
+      collection,
+      count: page.data.length,
+      total: page.total ?? undefined,
+      skip: page.skip,
+      limit: page.limit,
+      hasMore: page.hasMore,
+      nextSkip: page.nextSkip,
+      data: page.data,
+    };
+  }
+
+  async listPage<T extends Doc>(collection: string, options: PageOptions = {}): Promise<CollectionPage<T>> {
+    const requested = { limit: options.limit ?? 100, skip: options.skip ?? 0 };
+    if (!Number.isInteger(requested.limit) || requested.limit < 1 || requested.limit > 1000) {
+      throw badRequest('limit must be between 1 and 1000');
+    }
+    if (!Number.isInteger(requested.skip) || requested.skip < 0) throw badRequest('skip must be a non-negative integer');
+
+    const all = this.docs(collection);
+    const data = all.slice(requested.skip, requested.skip + requested.limit) as T[];
+    const hasMore = requested.skip + data.length < all.length;
+    return new CollectionPage(collection, data, {
+      total: all.length,
+      skip: requested.skip,
+      limit: requested.limit,
+      hasMore,
+      nextSkip: hasMore ? requested.skip + data.length : null,
+    }, requested);
+  }
+
+  async sample(collection: string, n: number): ReturnType<KimDBRestAPI['sample']> {
+    const problem = sampleSizeError(n);
+    if (problem) throw new Error(problem);
+    const docs = this.docs(collection);
+    for (let i = docs.length - 1; i > 0; i--) {
+      const j = Math.floor(Math.random() * (i + 1));
+      [docs[i], docs[j]] = [docs[j], docs[i]];
+    }
+    return docs.slice(0, n);
+  }
+
+  async listRaw(collection: string): Promise<string> {
+    return JSON.stringify(await this.list(collection));
+  }
+
+  async getDoc(collection: string, id: string): ReturnType<KimDBRestAPI['getDoc']> {
+    const doc = this.doc(collection, id);
+    return { id, data: structuredClone(doc.data), _version: doc._version };
+  }
+
+  async getDocRaw(collection: string, id: string): Promise<string> {
+    return JSON.stringify({ success: true, ...(await this.getDoc(collection, id)) });
+  }
+
+  async create(collection: string, data: unknown): ReturnType<KimDBRestAPI['create']> {
+    const id = (this.nextId++).toString(16).padStart(16, '0');
+    return this.write(collection, id, data);
+  }
+
+  async save(collection: string, id: string, data: unknown, options?: WriteOptions): ReturnType<KimDBRestAPI['save']> {
+    validateDocId(id);
+    return this.write(collection, id, data, options);
+  }
+
+  async update(collection: string, id: string, data: unknown, options?: WriteOptions): ReturnType<KimDBRestAPI['update']> {
+    this.doc(collection, id);
+    return this.write(collection, id, data, options);
+  }
+
+  async remove(collection: string, id: string, options: WriteOptions = {}): ReturnType<KimDBRestAPI['remove']> {
+    checkVersion(this.doc(collection, id), options);
+    this.col(collection).delete(id);
+    return { success: true };
+  }
+
+  async sqlRaw(collection: string, sql: string, params: unknown[] = [], options: SQLOptions = {}): Promise<string> {
+    return JSON.stringify(await this.sql(collection, sql, params, options));
+  }
+
+  async sql(collection: string, sql: string, params: unknown[] = [], options: SQLOptions = {}): Promise<SQLResponse> {
+    const parsed = validateStatement(sql, params);
+    const by = options.collation ? collator(options.collation) : undefined;
+    const equals = (a: unknown, b: unknown): boolean =>
+      by && typeof a === 'string' && typeof b === 'string' ? by.compare(a, b) === 0 : a === b;
+    if (parsed.type !== 'SELECT') {
+      throw badRequest(`FakeKimDBClient supports SELECT only: ${sql}`);
+    }
+
+    let rows = (await this.list(collection)).data as Array<Record<string, unknown>>;
+
+    const where = sql.match(/\swhere\s+(.+?)(?:\s+order\s+by|\s+limit|$)/i);
+    if (where) {
+      let paramIndex = 0;
+      const conditions = where[1].split(/\s+and\s+/i).map((cond) => {
+        const m = cond.trim().match(/^([a-z_][a-z0-9_]*)\s*=\s*\?$/i);
+        if (!m) throw badRequest(`FakeKimDBClient supports "field = ?" conditions only: ${cond}`);
+        return { field: m[1], value: params[paramIndex++] };
+      });
+      rows = rows.filter((row) => conditions.every((c) => equals(row[c.field], c.value)));
+    }
+
+    const order = parseOrderBy(sql);
+    if (order) rows = [...rows].sort(compareBy(order, options.collation));
+
+    const limit = sql.match(/\slimit\s+(\d+)/i);
+    if (limit) rows = rows.slice(0, parseInt(limit[1], 10));
+
+    return { success: true, rows, rowcount: rows.length };
+  }
+}
+
+export function createFakeClient(): FakeKimDBClient {
+  return new FakeKimDBClient();
+}
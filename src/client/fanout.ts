@@ -0,0 +1,141 @@
+/**
+ * kimdb Query Fan-out
+ *
+ * 여러 쿼리를 동시에 실행하고 입력 순서대로 결과 반환
+ * - concurrency: 동시에 보낼 요청 수 상한 (모든 쿼리가 한 리미터를 공유)
+ * - 하나라도 실패하면 새 요청을 시작하지 않고 첫 에러로 실패
+ * - signal: 중단 시 남은 쿼리를 시작하지 않고 signal.reason으로 실패
+ * - searchAllCollections: 여러 컬렉션에 같은 조건 검색, 결과에 출처 컬렉션 표시
+ *   컬렉션마다 마지막 페이지까지 읽음 (limit을 채우면 거기서 멈춤)
+ */
+
+import type { KimDBRestAPI } from './api.js';
+import type { CollectionPage } from './page.js';
+import type { SQLResponse } from '../shared/types.js';
+import type { WhereFilter } from './sql.js';
+
+export interface QuerySpec {
+  collection: string;
+  sql: string;
+  params?: unknown[];
+}
+
+export interface FanoutOptions {
+  /** 동시에 보낼 요청 수 (기본: 4) */
+  concurrency?: number;
+  signal?: AbortSignal;
+}
+
+export interface SearchQuery {
+  /** 필드 값이 모두 같은 문서만 (a = ? AND b = ?) */
+  filter?: WhereFilter;
+  /** 대소문자 무시 부분 문자열 검색 */
+  text?: string;
+  /** text를 찾을 필드 (기본: 문자열 필드 전체) */
+  fields?: string[];
+  /** 컬렉션마다 최대 결과 수 */
+  limit?: number;
+}
+
+export interface SearchHit {
+  collection: string;
+  id: string;
+  _version: number;
+  doc: Record<string, unknown>;
+}
+
+/** items를 최대 limit개씩 동시에 처리, 결과는 items 순서 */
+export async function mapLimit<T, R>(
+  items: T[],
+  limit: number,
+  fn: (item: T, index: number) => Promise<R>,
+  signal?: AbortSignal,
+): Promise<R[]> {
+  if (!Number.isInteger(limit) || limit < 1) {
+    throw new RangeError(`concurrency must be a positive integer, got ${limit}`);
+  }
+  signal?.throwIfAborted();
+
+  const results = new Array<R>(items.length);
+  let next = 0;
+  let failed = false;
+
+  const worker = async (): Promise<void> => {
+    while (!failed && next < items.length) {
+      if (signal?.aborted) {
+        failed = true;
+        throw signal.reason;
+      }
+      const index = next++;
+      try {
+        results[index] = await fn(items[index], index);
+      } catch (e) {
+        failed = true;
+        throw e;
+      }
+    }
+  };
+
+  const workers = Array.from({ length: Math.min(limit, items.length) }, worker);
+  await Promise.all(workers);
+  return results;
+}
+
+export async function queryAll(
+  client: KimDBRestAPI,
+  queries: QuerySpec[],
+  options: FanoutOptions = {},
+): Promise<SQLResponse[]> {
+  return mapLimit(
+    queries,
+    options.concurrency ?? 4,
+    q => client.sql(q.collection, q.sql, q.params ?? []),
+    options.signal,
+  );
+}
+
+function matches(doc: Record<string, unknown>, query: SearchQuery): boolean {
+  for (const [field, value] of Object.entries(query.filter ?? {})) {
+    if (doc[field] !== value) return false;
+  }
+  if (!query.text) return true;
+
+  const needle = query.text.toLowerCase();
+  const fields = query.fields ?? Object.keys(doc).filter(k => k !== 'id' && k !== '_version');
+  return fields.some(f => typeof doc[f] === 'string' && (doc[f] as string).toLowerCase().includes(needle));
+}
+
+/**
+ * 여러 컬렉션을 동시에 읽어 query에 맞는 문서를 모음
+ *
+ * 결과는 collections 순서, 컬렉션 안에서는 서버 목록 순서.
+ * 조건은 클라이언트에서 평가한다 (서버마다 SQL WHERE 지원 범위가 다르므로).
+ */
+export async function searchAllCollections(
+  client: KimDBRestAPI,
+  collections: string[],
+  query: SearchQuery,
+  options: FanoutOptions = {},
+): Promise<SearchHit[]> {
+  const perCollection = await mapLimit(
+    collections,
+    options.concurrency ?? 4,
+    async (collection) => {
+      const hits: SearchHit[] = [];
+      const full = () => query.limit !== undefined && hits.length >= query.limit;
+      let page: CollectionPage | null = await client.listPage(collection, { limit: 1000 });
+      while (page && !full()) {
+        for (const { id, _version, ...doc } of page.data) {
+          if (full()) break;
+          if (matches(doc, query)) hits.push({ collection, id, _version, doc });
+        }
+        if (full()) break;
+        options.signal?.throwIfAborted();
+        page = await page.nextPage(client);
+      }
+      return hits;
+    },
+    options.signal,
+  );
+  return perCollection.flat();
+}
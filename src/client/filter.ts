@@ -0,0 +1,135 @@
+/**
+ * kimdb Typed Filter Expressions
+ *
+ * 문서 타입의 필드로 만드는 WHERE 조건 (필드 이름 변경 시 컴파일 오류로 드러남)
+ *
+ *   const User = fieldsOf<User>();
+ *   client.find('users', User.age.gt(18).and(User.name.like('kim%')));
+ *
+ * - 서버 WHERE는 괄호 없이 OR로 묶인 AND 그룹만 지원하므로 내부도 그 형태로 유지
+ *   (a OR b) AND c 는 (a AND c) OR (b AND c)로 펼쳐짐
+ * - 값은 모두 ? 파라미터로 전달
+ * - matches()는 서버 WHERE와 같은 shared/sql.js 비교 규칙 ('1' = 1처럼 느슨한 비교)
+ */
+
+import { SQLValidationError } from './sql.js';
+import { matchesCondition } from '../shared/sql.js';
+import type { SortKey } from './sort.js';
+
+export type FilterOp = '=' | '!=' | '>' | '>=' | '<' | '<=' | 'LIKE';
+
+export interface FilterCondition {
+  field: string;
+  op: FilterOp;
+  value: unknown;
+}
+
+const IDENTIFIER = /^[a-z_][a-z0-9_]*$/i;
+
+export class FilterExpr {
+  /** OR로 묶인 AND 그룹 */
+  readonly groups: FilterCondition[][];
+
+  constructor(groups: FilterCondition[][]) {
+    this.groups = groups;
+  }
+
+  and(other: FilterExpr): FilterExpr {
+    const groups: FilterCondition[][] = [];
+    for (const a of this.groups) {
+      for (const b of other.groups) groups.push([...a, ...b]);
+    }
+    return new FilterExpr(groups);
+  }
+
+  or(other: FilterExpr): FilterExpr {
+    return new FilterExpr([...this.groups, ...other.groups]);
+  }
+
+  /** 클라이언트에서 문서 데이터에 적용 (sync 이벤트 거르기용, 서버 WHERE와 같은 규칙) */
+  matches(data: unknown): boolean {
+    if (!data || typeof data !== 'object') return false;
+    const doc = data as Record<string, unknown>;
+    return this.groups.some(g => g.every(c => matchesCondition(doc, c)));
+  }
+
+  /** WHERE 뒤에 붙일 조건과 파라미터 */
+  toSQL(): { clause: string; params: unknown[] } {
+    if (this.groups.length === 0) throw new SQLValidationError('WHERE', 'Filter matches nothing (empty in())');
+    return {
+      clause: this.groups.map(g => g.map(c => `${c.field} ${c.op} ?`).join(' AND ')).join(' OR '),
+      params: this.groups.flatMap(g => g.map(c => c.value)),
+    };
+  }
+}
+
+export class FieldRef<V> {
+  readonly name: string;
+
+  constructor(name: string) {
+    if (!IDENTIFIER.test(name)) throw new SQLValidationError(name, `Invalid field name: ${name}`);
+    this.name = name;
+  }
+
+  private cond(op: FilterOp, value: unknown): FilterExpr {
+    return new FilterExpr([[{ field: this.name, op, value }]]);
+  }
+
+  eq(value: V): FilterExpr {
+    return this.cond('=', value);
+  }
+
+  ne(value: V): FilterExpr {
+    return this.cond('!=', value);
+  }
+
+  gt(value: V): FilterExpr {
+    return this.cond('>', value);
+  }
+
+  gte(value: V): FilterExpr {
+    return this.cond('>=', value);
+  }
+
+  lt(value: V): FilterExpr {
+    return this.cond('<', value);
+  }
+
+  lte(value: V): FilterExpr {
+    return this.cond('<=', value);
+  }
+
+  /** SQL LIKE 패턴 (%, _) - 문자열 필드만 */
+  like(this: FieldRef<string>, pattern: string): FilterExpr {
+    return this.cond('LIKE', pattern);
+  }
+
+  /** 값 중 하나와 같음 (eq를 OR로 묶음) */
+  in(values: V[]): FilterExpr {
+    return new FilterExpr(values.map(value => [{ field: this.name, op: '=', value }]));
+  }
+
+  /** 오름차순 정렬 키 */
+  asc(): SortKey {
+    return { field: this.name, direction: 'asc' };
+  }
+
+  /** 내림차순 정렬 키 */
+  desc(): SortKey {
+    return { field: this.name, direction: 'desc' };
+  }
+}
+
+export type Fields<T> = { readonly [K in keyof T & string]-?: FieldRef<NonNullable<T[K]>> };
+
+/** 문서 타입 T의 필드 참조 (접근한 이름으로 FieldRef 생성) */
+export function fieldsOf<T extends object>(): Fields<T> {
+  const cache = new Map<string, FieldRef<unknown>>();
+  return new Proxy({} as Fields<T>, {
+    get(_target, name) {
+      if (typeof name !== 'string') return undefined;
+      if (!cache.has(name)) cache.set(name, new FieldRef(name));
+      return cache.get(name);
+    },
+  });
+}
@@ -0,0 +1,63 @@
+/**
+ * kimdb Task Group
+ *
+ * 여러 구독/컨슈머를 한 단위로 실행하고 함께 종료 (Go errgroup과 같은 의미)
+ * - go(task): task는 group.signal이 중단되면 정리하고 끝나야 함
+ * - 하나가 실패하면 signal을 중단해 나머지도 종료
+ * - wait(): 모두 끝날 때까지 대기, 첫 번째 오류로 reject
+ *
+ *   const group = new Group(shutdown.signal);
+ *   group.go((signal) => client.run(signal));
+ *   group.go((signal) => orders.run(handleOrder, signal));
+ *   await group.wait();
+ */
+
+export class Group {
+  private controller = new AbortController();
+  private tasks: Promise<void>[] = [];
+  private firstError: unknown = undefined;
+  private hasError = false;
+
+  constructor(parent?: AbortSignal) {
+    if (parent) {
+      if (parent.aborted) this.controller.abort();
+      else parent.addEventListener('abort', () => this.controller.abort(), { once: true });
+    }
+  }
+
+  get signal(): AbortSignal {
+    return this.controller.signal;
+  }
+
+  /** task를 바로 시작 (동기 예외도 그룹 오류로 처리) */
+  go(task: (signal: AbortSignal) => Promise<void>): void {
+    let running: Promise<void>;
+    try {
+      running = Promise.resolve(task(this.controller.signal));
+    } catch (e) {
+      running = Promise.reject(e);
+    }
+    this.tasks.push(running.catch((e) => {
+      if (!this.hasError) {
+        this.hasError = true;
+        this.firstError = e;
+      }
+      this.controller.abort();
+    }));
+  }
+
+  /** 모든 task 종료 요청 */
+  stop(): void {
+    this.controller.abort();
+  }
+
+  async wait(): Promise<void> {
+    // 대기 중에 추가된 task도 포함
+    let count = 0;
+    while (count < this.tasks.length) {
+      count = this.tasks.length;
+      await Promise.all(this.tasks);
+    }
+    if (this.hasError) throw this.firstError;
+  }
+}
@@ -0,0 +1,29 @@
+/**
+ * kimdb Random IDs
+ *
+ * 클라이언트가 만드는 ID (outbox msgId, 큐 consumer 이름, 샤드 문서 ID)
+ * - 전역 crypto.randomUUID가 있으면 사용 (브라우저, Node 19+)
+ * - Node 18은 플래그 없이 전역 crypto가 없으므로 UUID v4 형식으로 직접 생성
+ *   (getRandomValues가 있으면 그것으로, 없으면 Math.random) - 보안 토큰 용도가 아님
+ */
+
+type WebCrypto = {
+  randomUUID?: () => string;
+  getRandomValues?: (bytes: Uint8Array) => Uint8Array;
+};
+
+export function randomId(): string {
+  const webCrypto = (globalThis as { crypto?: WebCrypto }).crypto;
+  if (typeof webCrypto?.randomUUID === 'function') return webCrypto.randomUUID();
+
+  const bytes = new Uint8Array(16);
+  if (typeof webCrypto?.getRandomValues === 'function') {
+    webCrypto.getRandomValues(bytes);
+  } else {
+    for (let i = 0; i < bytes.length; i++) bytes[i] = Math.floor(Math.random() * 256);
+  }
+  bytes[6] = (bytes[6] & 0x0f) | 0x40; // version 4
+  bytes[8] = (bytes[8] & 0x3f) | 0x80; // variant 10
+  const hex = Array.from(bytes, b => b.toString(16).padStart(2, '0')).join('');
+  return `${hex.slice(0, 8)}-${hex.slice(8, 12)}-${hex.slice(12, 16)}-${hex.slice(16, 20)}-${hex.slice(20)}`;
+}
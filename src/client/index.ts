@@ -8,9 +8,73 @@ import {
   VectorClock,
   CRDTDocument,
   OpBatcher,
-  UndoManager,
   PresenceManager,
+  UndoManager,
 } from '../crdt/index.js';
+import { KVStore } from './kv.js';
+import { CollaborativeText } from './text.js';
+import { Awareness, type AwarenessOptions } from './awareness.js';
+import { JsonCodec, jsonCodec, frameSize, type Codec, type Frame, type JsonLibrary, type WireMessage } from './codec.js';
+import { docPath, validateCollectionName, validateDocId } from './paths.js';
+import {
+  validateStatement,
+  buildUpdateWhere,
+  buildDeleteWhere,
+  buildCountWhere,
+  buildSelectWhere,
+  type WhereFilter,
+  SQLValidationError,
+  type SelectOptions,
+  type SQLOptions,
+} from './sql.js';
+import { checkCollation } from '../shared/collation.js';
+import { FilterExpr } from './filter.js';
+import { linearBackoff, exponentialBackoff, type BackoffStrategy } from './backoff.js';
+import { httpError, KimDBHttpError, VersionConflictError } from './errors.js';
+import { diff } from './diff.js';
+import { encodeFields, decodeFields, toSnakeCase, type FieldNaming } from './naming.js';
+import type { UpdateBuilder } from './update.js';
+import type { UpdateOp } from '../shared/update-ops.js';
+import { redact, type RedactionRule } from './redact.js';
+import { ClientConfigError, validateClientOptions } from './options.js';
+import { DurableConsumer, type DurableConsumerOptions } from './consumer.js';
+import { deleteCascade, type RefSpec, type CascadeOptions, type CascadeResult } from './cascade.js';
+import { bindDocument, type DocumentBinding } from './binding.js';
+import { loadTracked, type TrackedDocument } from './tracked.js';
+import { ReadCache, isUnreachable, type OfflineReadOptions, type Staleness } from './cache.js';
+import { archiveCollection, type ArchiveSink, type ArchiveOptions } from './archive.js';
+import { copyCollection, type CopyOptions, type CopyResult } from './copy.js';
+import { lintCollection, type LintRules, type LintOptions, type LintReport } from './lint.js';
+import { verifyCollection, type VerifyResult } from './verify.js';
+import { PartitionedCollection, type PartitionOptions } from './partition.js';
+import { EditingClaim } from './softlock.js';
+import { LeaderElection, type ElectionOptions } from './election.js';
+import { QueueConsumer, publish, type QueueMessage, type ConsumeOptions } from './queue.js';
+import { runTransaction, type Transaction, type TransactionOptions } from './transaction.js';
+import { CollectionPage, listAll, type PageOptions, type PageInfo } from './page.js';
+import { randomId } from './id.js';
+import { channelNameError, publishError } from '../shared/channels.js';
+import { snapshotLabelError, restoreState, type SnapshotInfo } from '../shared/snapshots.js';
+import { sampleSizeError } from '../shared/sample.js';
+import { MAX_VERSION_IDS } from '../shared/versions.js';
+import { freshUndoOps } from '../shared/undo.js';
+import { matchesCondition } from '../shared/sql.js';
+import {
+  queryAll,
+  searchAllCollections,
+  type QuerySpec,
+  type FanoutOptions,
+  type SearchQuery,
+  type SearchHit,
+} from './fanout.js';
+import type {
+  SQLResponse,
+  PresenceUser,
+  WSServerEventMap,
+  WSBatchAckMessage,
+  WSSyncMessage,
+  RetentionPolicy,
+} from '../shared/types.js';
 
 export interface KimDBClientOptions {
   url: string;
@@ -20,32 +84,250 @@ export interface KimDBClientOptions {
   maxReconnectAttempts?: number;
   batchSize?: number;
   batchTimeout?: number;
+  /** onStats 호출 주기 (ms, 0 = 사용 안 함) */
+  statsInterval?: number;
+  /** 메시지 인코딩 (기본: JSON) */
+  codec?: Codec;
+  /** JSON 구현 교체 (REST 요청/응답, codec을 지정하지 않으면 WebSocket 메시지도) - 기본: 전역 JSON */
+  json?: JsonLibrary;
+  /** 재연결 대기 정책 (기본: reconnectInterval * 시도 횟수, maxReconnectAttempts까지) */
+  backoff?: BackoffStrategy;
+  /** 연결 최대 유지 시간 (ms, 0 = 무제한) - 만료 시 재연결해 DNS를 다시 조회 */
+  connectionMaxLifetime?: number;
+  /** HTTP 구현 교체 (테스트, 장애 주입용) - 기본: 전역 fetch */
+  fetch?: typeof fetch;
+  /** WebSocket 구현 교체 - 기본: 전역 WebSocket */
+  WebSocketImpl?: typeof WebSocket;
+  /** REST 문서 필드 이름 변환 (기본: 'preserve') - 'snake_case'면 앱은 camelCase, 서버는 snake_case */
+  fieldNaming?: FieldNaming;
+  /** 읽기 결과에서 가릴 필드 (REST 응답, sync 이벤트, SQL 행) */
+  redact?: RedactionRule[];
+  /** 이 시간(ms)을 넘긴 메시지 핸들러는 onSlowHandler로 알림 (기본 50, 0 = 사용 안 함) */
+  slowHandlerThreshold?: number;
+  /** 구독 컬렉션별로 보관할 최근 sync 이벤트 수 (watch의 replay용, 기본 0) */
+  replayBufferSize?: number;
+  /** 서버 확인(crdt_ops_ok)을 기다리는 CRDT 연산 메시지 최대 보관 수 (기본 1000, 넘치면 오래된 것부터 버림) */
+  outboxLimit?: number;
+  /** 재연결 후 outbox 재전송 속도 (메시지/초, 기본 100) */
+  outboxRate?: number;
+  /** 서버에 닿지 못하면 list/getDoc을 마지막으로 읽은 결과로 응답 (기본 false, 결과에 staleness 표시) */
+  offlineReads?: boolean | OfflineReadOptions;
+  /**
+   * 구독 컬렉션에 이 시간(ms) 동안 아무 메시지가 없으면 멈춘 것으로 판단 (0 = 사용 안 함, 기본 0)
+   *
+   * 절반이 지나면 subscribe를 다시 보내 응답(subscribed)을 확인하고, 그래도 없으면
+   * onSubscriptionStalled 호출 후 재연결한다.
+   */
+  stallTimeout?: number;
+  /**
+   * REST 쓰기(create/save/update/remove, SQL)를 dry-run으로 전송 (기본 false)
+   *
+   * 서버가 검증하고 결과만 돌려준 뒤 롤백한다 (응답에 dryRun: true). 마이그레이션 리허설, 안전한 도구용.
+   * WebSocket으로 보내는 CRDT 연산에는 적용되지 않는다.
+   */
+  dryRun?: boolean;
 }
 
+/**
+ * 연결 상태
+ *
+ * idle: 생성 직후, connecting: 첫 연결 시도 (connectWithRetry 재시도 포함),
+ * connected: 서버 'connected' 수신 후, reconnecting: 끊긴 뒤 자동 재연결 대기/시도 중,
+ * closed: disconnect() 또는 재연결 포기
+ */
+export type ConnectionStatus = 'idle' | 'connecting' | 'connected' | 'reconnecting' | 'closed';
+
 export interface ConnectionState {
-  connected: boolean;
+  status: ConnectionStatus;
   clientId: string | null;
   serverId: string | null;
   reconnectAttempts: number;
 }
 
+export interface ConnectionStats {
+  framesSent: number;
+  framesReceived: number;
+  bytesSent: number;
+  bytesReceived: number;
+  /** 수신 메시지 타입별 개수 */
+  messagesByType: Record<string, number>;
+  reconnects: number;
+  lastPongAt: number | null;
+  /** 메시지 타입별 등록 핸들러 실행 시간 (ms) */
+  handlerLatency: Record<string, { calls: number; totalMs: number; maxMs: number }>;
+  /** 컬렉션 → 메시지 타입별 수신 통계 (collection 필드가 있는 메시지만) */
+  collections: Record<string, Record<string, CollectionEventStats>>;
+}
+
+export interface CollectionEventStats {
+  count: number;
+  bytes: number;
+  /** 등록 핸들러 실행 시간 합계 (ms) */
+  handlerMs: number;
+}
+
+export interface UndoState {
+  canUndo: boolean;
+  canRedo: boolean;
+}
+
+/** 로컬 편집이 서버에 반영되기까지 남은 양 ("저장 중..." / "모두 저장됨" 표시용) */
+export interface SyncLag {
+  /** 아직 보내지 않은 연산 수 (batchTimeout 대기 중) */
+  queued: number;
+  /** 보냈지만 서버 확인(crdt_ops_ok)을 기다리는 연산 수 */
+  pending: number;
+  /** 마지막 서버 확인의 문서 버전 (확인 전이거나 컬렉션 합계면 null) */
+  ackedVersion: number | null;
+  /** queued와 pending이 모두 0 */
+  saved: boolean;
+}
+
+export interface TailEvent {
+  /** 'backfill': 기존 문서, 'live': 실시간 sync 이벤트, 'resync': 재연결 후 놓친 변경 */
+  source: 'backfill' | 'live' | 'resync';
+  event: string;
+  id: string;
+  data: unknown;
+  _version: number;
+}
+
+export interface DocumentChange {
+  id: string;
+  /** 삭제된 문서는 null */
+  data: unknown;
+  _version: number;
+  deleted: boolean;
+  /** 서버 updated_at ('YYYY-MM-DD HH:MM:SS' UTC) - 다음 changes 호출의 since로 사용 */
+  updatedAt: string;
+}
+
 type MessageHandler = (msg: unknown) => void;
 
+function versionHeaders(options: WriteOptions): Record<string, string> {
+  return options.expectedVersion === undefined ? {} : { 'X-KimDB-Expected-Version': String(options.expectedVersion) };
+}
+
+/** 서버 error 응답 → Error (code 'version_conflict'는 REST 409와 같은 VersionConflictError) */
+function callError(msg: WireMessage): Error {
+  if (msg.code === 'version_conflict') {
+    return new VersionConflictError(409, JSON.stringify({ error: msg.message, expected: msg.expected, _version: msg._version }));
+  }
+  return new Error(String(msg.message ?? 'Request failed'));
+}
+
+/**
+ * 수신 메시지 미들웨어 - next(msg)를 호출해야 다음 단계로 넘어감
+ *
+ * 호출하지 않으면 메시지를 버리고, 다른 메시지를 넘기면 변환된다.
+ */
+export type InboundMiddleware = (msg: WireMessage, next: (msg: WireMessage) => void) => void;
+
+export interface LatencyReport {
+  /** GET /health 왕복 시간 (ms) */
+  httpMs: number;
+  /** WebSocket ping/pong 왕복 시간 (ms), 연결되어 있지 않으면 null */
+  wsMs: number | null;
+  serverId: string;
+}
+
+export interface ReadyOptions {
+  /** 전체 대기 한도 (ms, 기본 30000) */
+  timeoutMs?: number;
+  /** 중단 신호 */
+  signal?: AbortSignal;
+  /** 재시도 간격 (기본: 200ms부터 2배, 최대 5초) */
+  backoff?: BackoffStrategy;
+  /** 시도마다 호출 (시작 로그용) */
+  onAttempt?: (attempt: number, result: { ok: boolean; status?: string; error?: Error }) => void;
+}
+
+export interface CallOptions {
+  /** 응답 대기 시간 (ms, 기본 5000) - 재시도를 포함한 전체 시간 */
+  timeoutMs?: number;
+  /** 응답 전에 연결이 끊기면 재연결 후 다시 보낼 횟수 (기본 1) */
+  retries?: number;
+}
+
+export interface WriteOptions {
+  /**
+   * 서버 문서 버전이 이 값일 때만 쓰기 (0 = 문서가 없어야 함, save만 해당)
+   *
+   * 다르면 VersionConflictError (currentVersion에 서버 버전). 읽고-고쳐-쓰기를 안전하게 할 때 사용.
+   */
+  expectedVersion?: number;
+}
+
+interface FlushWaiter {
+  /** 아직 확인되지 않은 outbox 메시지 */
+  ids: Set<string>;
+  resolve: () => void;
+  reject: (error: Error) => void;
+}
+
+interface PendingCall {
+  msg: Record<string, unknown>;
+  resolve: (msg: WireMessage) => void;
+  reject: (error: Error) => void;
+  timer: ReturnType<typeof setTimeout>;
+  retriesLeft: number;
+  sent: boolean;
+}
+
 export class KimDBClient {
   private options: Required<KimDBClientOptions>;
   private ws: WebSocket | null = null;
   private state: ConnectionState = {
-    connected: false,
+    status: 'idle',
     clientId: null,
     serverId: null,
     reconnectAttempts: 0,
   };
-
-  private subscriptions = new Set<string>();
+  private stats: ConnectionStats = {
+    framesSent: 0,
+    framesReceived: 0,
+    bytesSent: 0,
+    bytesReceived: 0,
+    messagesByType: {},
+    reconnects: 0,
+    lastPongAt: null,
+    handlerLatency: {},
+    collections: {},
+  };
+  private hasConnected = false;
+  private lastError: Error | undefined;
+  private recycleTimer: ReturnType<typeof setTimeout> | null = null;
+  private reconnectTimer: ReturnType<typeof setTimeout> | null = null;
+  private dialing = false;
+  private recycling = false;
+  private statsTimer: ReturnType<typeof setInterval> | null = null;
+  private watchdogTimer: ReturnType<typeof setInterval> | null = null;
+  private lastActivity = new Map<string, number>();
+  // console.warn을 이미 남긴 메시지 타입 (느린 핸들러가 메시지마다 로그를 쌓지 않도록)
+  private slowWarned = new Set<string>();
+
+  /** 컬렉션 → 구독 참조 수 (0이 되면 서버에 unsubscribe) */
+  private subscriptions = new Map<string, number>();
+  // 채널 → 핸들러 (비면 서버 구독 해제)
+  private channelHandlers = new Map<string, Set<(payload: unknown, from: string) => void>>();
   private docSubscriptions = new Map<string, CRDTDocument>();
   private messageHandlers = new Map<string, MessageHandler[]>();
+  private inbound: InboundMiddleware[] = [];
+  private replayBuffers = new Map<string, WSSyncMessage[]>();
+  private resyncHooks = new Map<string, Set<() => Promise<void>>>();
+  private pendingCalls = new Map<string, PendingCall>();
+  private outbox = new Map<string, Record<string, unknown>>();
+  private queuedOps = new Map<string, number>();
+  private flushWaiters = new Set<FlushWaiter>();
+  private ackedVersions = new Map<string, number>();
+  private outboxTimer: ReturnType<typeof setTimeout> | null = null;
+  private readCache: ReadCache | null;
+  private callSeq = 0;
   private batcher: OpBatcher;
+  // 문서별 로컬 Undo 히스토리와 마지막으로 알린 상태 (onUndoStateChange는 바뀔 때만)
   private undoManagers = new Map<string, UndoManager>();
+  private undoStates = new Map<string, UndoState>();
+  private texts = new Map<string, Set<CollaborativeText>>();
   private presenceManager: PresenceManager | null = null;
 
   // Event handlers
@@ -53,18 +335,56 @@ export class KimDBClient {
   public onDisconnect?: () => void;
   public onError?: (error: Error) => void;
   public onSync?: (collection: string, event: string, data: unknown) => void;
-
+  public onUndoStateChange?: (collection: string, docId: string, state: UndoState) => void;
+  /** 문서의 SyncLag가 바뀔 때마다 (편집, 전송, 서버 확인) */
+  public onSyncLag?: (collection: string, docId: string, lag: SyncLag) => void;
+  /** 보낸 편집이 모두 서버에 확인되어 outbox가 빌 때 (대기 중인 연산도 없을 때) */
+  public onFlushed?: () => void;
+  public onStats?: (stats: ConnectionStats) => void;
+  /** 느린 핸들러 알림 (느릴 때마다) - 지정하지 않으면 메시지 타입마다 한 번만 console.warn */
+  public onSlowHandler?: (type: string, durationMs: number) => void;
+  /** 연결 상태가 바뀔 때마다 호출 */
+  public onStateChange?: (status: ConnectionStatus, previous: ConnectionStatus) => void;
+  /** stallTimeout 동안 메시지가 없던 구독 (호출 직후 재연결) */
+  public onSubscriptionStalled?: (collection: string, idleMs: number) => void;
+
+  /** 잘못된 옵션(빈/잘못된 URL, 음수 값 등)은 ClientConfigError로 바로 실패 */
   constructor(options: KimDBClientOptions) {
+    const issues = validateClientOptions(options);
+    if (issues.length > 0) throw new ClientConfigError(issues);
+
     this.options = {
-      url: options.url,
+      // 끝의 '/'는 제거 ('ws://host/ws/' → 'ws://host/ws')
+      url: options.url.replace(/\/+$/, ''),
       apiKey: options.apiKey || '',
       autoReconnect: options.autoReconnect ?? true,
       reconnectInterval: options.reconnectInterval ?? 1000,
       maxReconnectAttempts: options.maxReconnectAttempts ?? 10,
       batchSize: options.batchSize ?? 50,
       batchTimeout: options.batchTimeout ?? 100,
+      statsInterval: options.statsInterval ?? 0,
+      codec: options.codec ?? (options.json ? jsonCodec(options.json) : JsonCodec),
+      json: options.json ?? JSON,
+      connectionMaxLifetime: options.connectionMaxLifetime ?? 0,
+      fetch: options.fetch ?? ((input, init) => fetch(input, init)),
+      WebSocketImpl: options.WebSocketImpl ?? null,
+      fieldNaming: options.fieldNaming ?? 'preserve',
+      redact: options.redact ?? [],
+      slowHandlerThreshold: options.slowHandlerThreshold ?? 50,
+      replayBufferSize: options.replayBufferSize ?? 0,
+      outboxLimit: options.outboxLimit ?? 1000,
+      outboxRate: options.outboxRate ?? 100,
+      offlineReads: options.offlineReads ?? false,
+      stallTimeout: options.stallTimeout ?? 0,
+      dryRun: options.dryRun ?? false,
+      backoff: options.backoff ?? linearBackoff(
+        options.reconnectInterval ?? 1000,
+        options.maxReconnectAttempts ?? 10,
+      ),
     };
 
+    const offline = this.options.offlineReads;
+    this.readCache = offline ? new ReadCache(offline === true ? {} : offline) : null;
     this.batcher = new OpBatcher({
       batchSize: this.options.batchSize,
       batchTimeout: this.options.batchTimeout,
@@ -75,13 +395,17 @@ export class KimDBClient {
   // ===== Connection =====
 
   async connect(): Promise<void> {
+    if (this.state.status !== 'reconnecting') this.setStatus('connecting');
     return new Promise((resolve, reject) => {
-      const wsUrl = this.options.apiKey
-        ? `${this.options.url}?api_key=${this.options.apiKey}`
-        : this.options.url;
+      const { codec } = this.options;
+      const params = new URLSearchParams({ protocol: `${codec.name}/${codec.version}` });
+      if (this.options.apiKey) params.set('api_key', this.options.apiKey);
+      const wsUrl = `${this.options.url}${this.options.url.includes('?') ? '&' : '?'}${params}`;
 
       try {
-        this.ws = new WebSocket(wsUrl);
+        const Impl = this.options.WebSocketImpl ?? WebSocket;
+        this.ws = new Impl(wsUrl);
+        if (codec.binary) this.ws.binaryType = 'arraybuffer';
       } catch (e) {
         reject(e);
         return;
@@ -97,20 +421,57 @@ export class KimDBClient {
 
       this.ws.onmessage = (event) => {
         try {
-          const msg = JSON.parse(event.data as string);
+          const bytes = frameSize(event.data as Frame);
+          this.stats.framesReceived++;
+          this.stats.bytesReceived += bytes;
+
+          const msg = codec.decode(event.data as Frame);
+          this.stats.messagesByType[msg.type] = (this.stats.messagesByType[msg.type] || 0) + 1;
+          if (typeof msg.collection === 'string') {
+            const entry = this.collectionStats(msg.collection, msg.type);
+            entry.count++;
+            entry.bytes += bytes;
+          }
+          if (msg.type === 'pong') this.stats.lastPongAt = Date.now();
+
           this.handleMessage(msg);
 
+          // 서버가 프로토콜을 알려주면 버전 확인 (구버전 서버는 생략)
+          // 지원하지 않는 프로토콜이면 서버는 connected 대신 protocol을 담은 error를 보내고 닫음
+          const handshake = msg.type === 'connected' || (msg.type === 'error' && this.state.status !== 'connected');
+          if (handshake && msg.protocol !== undefined && msg.protocol !== `${codec.name}/${codec.version}`) {
+            clearTimeout(timeout);
+            const error = new Error(`Protocol mismatch: client ${codec.name}/${codec.version}, server ${msg.protocol}`);
+            this.options.autoReconnect = false;
+            this.ws?.close();
+            this.onError?.(error);
+            reject(error);
+            return;
+          }
+
           if (msg.type === 'connected') {
             clearTimeout(timeout);
-            this.state.connected = true;
-            this.state.clientId = msg.clientId;
-            this.state.serverId = msg.serverId;
+
+            if (this.hasConnected) this.stats.reconnects++;
+            this.hasConnected = true;
+            this.startStatsTimer();
+            this.startRecycleTimer();
+            this.startWatchdog();
+            this.setStatus('connected');
+            this.state.clientId = msg.clientId as string;
+            this.state.serverId = msg.serverId as string;
             this.state.reconnectAttempts = 0;
+            this.lastError = undefined;
 
             // Re-subscribe
-            for (const col of this.subscriptions) {
+            for (const col of this.subscriptions.keys()) {
               this.send({ type: 'subscribe', collection: col });
             }
+            for (const channel of this.channelHandlers.keys()) {
+              this.send({ type: 'channel_subscribe', channel });
+            }
+            this.flushPendingCalls();
+            this.flushOutbox();
 
             this.onConnect?.();
             resolve();
@@ -123,40 +484,243 @@ export class KimDBClient {
       this.ws.onerror = (event) => {
         clearTimeout(timeout);
         const error = new Error('WebSocket error');
+        this.lastError = error;
         this.onError?.(error);
         reject(error);
       };
 
       this.ws.onclose = () => {
-        this.state.connected = false;
+        this.clearRecycleTimer();
+        this.clearOutboxTimer();
+        this.clearWatchdog();
+        this.failInFlightCalls();
+
+        // 수명 만료로 닫은 경우 즉시 새 연결 (재시도 횟수에 포함하지 않음)
+        // connectWithRetry가 직접 재시도하는 중이면 중복 예약하지 않음
+        const delay = this.recycling || this.dialing || !this.options.autoReconnect
+          ? null
+          : this.options.backoff.nextDelay(this.state.reconnectAttempts + 1, this.lastError);
+        if (this.recycling || delay !== null) {
+          this.setStatus('reconnecting');
+        } else if (this.dialing) {
+          this.setStatus('connecting');
+        } else {
+          this.setStatus('closed');
+        }
         this.onDisconnect?.();
 
-        if (this.options.autoReconnect && this.state.reconnectAttempts < this.options.maxReconnectAttempts) {
-          this.state.reconnectAttempts++;
-          setTimeout(() => {
-            this.connect().catch(() => {});
-          }, this.options.reconnectInterval * this.state.reconnectAttempts);
+        if (this.recycling) {
+          this.recycling = false;
+          this.connect().catch(() => {});
+          return;
         }
+        if (delay === null) return;
+
+        this.state.reconnectAttempts++;
+        this.reconnectTimer = setTimeout(() => {
+          this.reconnectTimer = null;
+          this.connect().catch(() => {});
+        }, delay);
       };
     });
   }
 
+  /**
+   * 첫 연결을 성공할 때까지 재시도 (서버가 막 기동 중인 경우)
+   *
+   * backoff 기본값은 재연결 정책과 같다. null을 반환하거나 signal이 중단되면 마지막 오류로 reject.
+   * 연결된 뒤의 끊김은 기존 autoReconnect가 처리한다.
+   */
+  async connectWithRetry(options: {
+    backoff?: BackoffStrategy;
+    signal?: AbortSignal;
+    onAttempt?: (attempt: number, error?: Error) => void;
+  } = {}): Promise<void> {
+    const backoff = options.backoff ?? this.options.backoff;
+    this.dialing = true;
+
+    try {
+      for (let attempt = 1; ; attempt++) {
+        options.signal?.throwIfAborted();
+        try {
+          await this.connect();
+          options.onAttempt?.(attempt);
+          return;
+        } catch (e) {
+          const error = e as Error;
+          this.ws?.close();
+          options.onAttempt?.(attempt, error);
+
+          const delay = backoff.nextDelay(attempt, error);
+          if (delay === null) {
+            this.setStatus('closed');
+            throw error;
+          }
+          await new Promise<void>((resolve, reject) => {
+            const onAbort = () => {
+              clearTimeout(timer);
+              reject(options.signal!.reason);
+            };
+            const timer = setTimeout(() => {
+              options.signal?.removeEventListener('abort', onAbort);
+              resolve();
+            }, delay);
+            options.signal?.addEventListener('abort', onAbort, { once: true });
+          });
+        }
+      }
+    } finally {
+      this.dialing = false;
+    }
+  }
+
   disconnect(): void {
     this.options.autoReconnect = false;
+    this.recycling = false;
+    this.clearRecycleTimer();
+    // 예약된 재연결이 disconnect 이후 새 연결을 만들지 않도록
+    if (this.reconnectTimer) {
+      clearTimeout(this.reconnectTimer);
+      this.reconnectTimer = null;
+    }
+    if (this.statsTimer) {
+      clearInterval(this.statsTimer);
+      this.statsTimer = null;
+    }
+    this.clearOutboxTimer();
+    this.clearWatchdog();
     this.ws?.close();
     this.ws = null;
-    this.state.connected = false;
+    this.setStatus('closed');
+    for (const id of [...this.pendingCalls.keys()]) {
+      this.settleCall(id, new Error('Client disconnected'));
+    }
+  }
+
+  /**
+   * 연결 수명 전체를 하나의 Promise로 관리 (signal이 중단되면 연결을 닫고 resolve)
+   *
+   * 연결/재연결/재구독은 내부에서 처리한다. autoReconnect가 꺼져 있으면 연결 실패나
+   * 끊김 시 reject. 서비스 종료 시 타이머나 소켓이 남지 않는다.
+   */
+  async run(signal: AbortSignal): Promise<void> {
+    if (signal.aborted) return;
+
+    const aborted = new Promise<void>((resolve) => signal.addEventListener('abort', () => resolve(), { once: true }));
+    const autoReconnect = this.options.autoReconnect;
+    const onDisconnect = this.onDisconnect;
+
+    try {
+      try {
+        await Promise.race([this.connect(), aborted]);
+      } catch (e) {
+        // 자동 재연결 중이면 onclose가 다시 시도하므로 계속 대기
+        if (!autoReconnect) throw e;
+      }
+
+      if (!autoReconnect) {
+        const closed = new Promise<never>((_, reject) => {
+          this.onDisconnect = () => {
+            onDisconnect?.();
+            reject(new Error('Connection closed'));
+          };
+        });
+        await Promise.race([aborted, closed]);
+      } else {
+        await aborted;
+      }
+    } finally {
+      this.onDisconnect = onDisconnect;
+      this.disconnect();
+    }
+  }
+
+  private setStatus(status: ConnectionStatus): void {
+    const previous = this.state.status;
+    if (status === previous) return;
+    this.state.status = status;
+    this.onStateChange?.(status, previous);
   }
 
   // ===== Messaging =====
 
   private send(msg: unknown): void {
-    if (this.ws?.readyState === WebSocket.OPEN) {
-      this.ws.send(JSON.stringify(msg));
+    if (this.ws?.readyState === 1) { // OPEN
+      const frame = this.options.codec.encode(msg);
+      this.ws.send(frame);
+      this.stats.framesSent++;
+      this.stats.bytesSent += frameSize(frame);
+    }
+  }
+
+  private startRecycleTimer(): void {
+    this.clearRecycleTimer();
+    if (this.options.connectionMaxLifetime <= 0) return;
+    this.recycleTimer = setTimeout(() => this.recycle(), this.options.connectionMaxLifetime);
+  }
+
+  private clearRecycleTimer(): void {
+    if (this.recycleTimer) {
+      clearTimeout(this.recycleTimer);
+      this.recycleTimer = null;
     }
   }
 
+  /** 현재 연결을 닫고 새로 연결 (호스트 이름 재조회, 구독 복원) */
+  recycle(): void {
+    if (!this.ws || !this.isConnected) return;
+    this.recycling = true;
+    this.ws.close(1000, 'recycle');
+  }
+
+  // ===== Watchdog =====
+
+  private startWatchdog(): void {
+    this.clearWatchdog();
+    const timeout = this.options.stallTimeout;
+    if (timeout <= 0) return;
+
+    const now = Date.now();
+    for (const col of this.subscriptions.keys()) this.lastActivity.set(col, now);
+    // 확인 응답이 올 시간(timeout/4 이상)을 남기도록 자주 검사
+    this.watchdogTimer = setInterval(() => this.checkStalled(), timeout / 4);
+  }
+
+  private clearWatchdog(): void {
+    if (this.watchdogTimer) {
+      clearInterval(this.watchdogTimer);
+      this.watchdogTimer = null;
+    }
+  }
+
+  /** 절반 이상 조용한 구독은 재구독으로 확인, 전체 시간을 넘기면 알림 후 재연결 */
+  private checkStalled(): void {
+    if (!this.isConnected) return;
+    const timeout = this.options.stallTimeout;
+    const now = Date.now();
+    let stalled = false;
+
+    for (const col of this.subscriptions.keys()) {
+      const idle = now - (this.lastActivity.get(col) ?? now);
+      if (idle >= timeout) {
+        stalled = true;
+        this.onSubscriptionStalled?.(col, idle);
+      } else if (idle >= timeout / 2) {
+        this.send({ type: 'subscribe', collection: col });
+      }
+    }
+    if (stalled) this.recycle();
+  }
+
+  private startStatsTimer(): void {
+    if (this.statsTimer || this.options.statsInterval <= 0) return;
+    this.statsTimer = setInterval(() => this.onStats?.(this.getStats()), this.options.statsInterval);
+  }
+
   private sendBatch(ops: unknown[]): void {
+    // batcher가 쌓인 연산을 모두 내보내므로 대기 수는 전부 pending으로 넘어감
+    const flushed = [...this.queuedOps.keys()];
+    this.queuedOps.clear();
     if (ops.length === 0) return;
 
     // Group by docId
@@ -169,33 +733,327 @@ export class KimDBClient {
 
     for (const [docId, docOps] of byDoc) {
       const firstOp = docOps[0] as { collection: string };
-      this.send({
+      const msg = {
         type: 'crdt_ops',
+        msgId: randomId(),
         collection: firstOp.collection,
         docId,
         operations: docOps,
-      });
+      };
+      this.enqueueOutbox(msg);
+      this.send(msg);
+    }
+    for (const key of flushed) this.emitSyncLag(key);
+  }
+
+  /** 문서 편집 연산을 batcher에 추가 */
+  private queueOp(collection: string, docId: string, op: object): void {
+    const key = `${collection}:${docId}`;
+    this.queuedOps.set(key, (this.queuedOps.get(key) ?? 0) + 1);
+    this.batcher.add({ ...op, collection });
+    this.emitSyncLag(key);
+  }
+
+  private emitSyncLag(key: string): void {
+    if (!this.onSyncLag) return;
+    const i = key.indexOf(':');
+    const collection = key.slice(0, i);
+    const docId = key.slice(i + 1);
+    this.onSyncLag(collection, docId, this.syncLag(collection, docId));
+  }
+
+  /**
+   * 문서(docId 생략 시 컬렉션 전체)의 로컬 편집 반영 상태
+   *
+   *   client.onSyncLag = (c, id, lag) => setLabel(lag.saved ? '모두 저장됨' : '저장 중...');
+   */
+  syncLag(collection: string, docId?: string): SyncLag {
+    const matches = (key: string): boolean => docId === undefined ? key.startsWith(`${collection}:`) : key === `${collection}:${docId}`;
+    let queued = 0;
+    for (const [key, count] of this.queuedOps) if (matches(key)) queued += count;
+    let pending = 0;
+    for (const msg of this.outbox.values()) {
+      if (matches(`${msg.collection}:${msg.docId}`)) pending += (msg.operations as unknown[]).length;
     }
+    const ackedVersion = docId === undefined ? null : this.ackedVersions.get(`${collection}:${docId}`) ?? null;
+    return { queued, pending, ackedVersion, saved: queued + pending === 0 };
   }
 
-  private handleMessage(msg: { type: string; [key: string]: unknown }): void {
+  // ===== Outbox =====
+
+  /** 서버 확인 전까지 보관 (끊긴 사이 보낸 메시지는 재연결 후 재전송, 서버가 msgId로 중복 제거) */
+  private enqueueOutbox(msg: Record<string, unknown> & { msgId: string }): void {
+    this.outbox.set(msg.msgId, msg);
+    if (this.outbox.size <= this.options.outboxLimit) return;
+
+    const [oldest] = this.outbox.keys();
+    this.outbox.delete(oldest);
+    const error = new Error(`Outbox full (${this.options.outboxLimit}): dropped unacknowledged message ${oldest}`);
+    for (const waiter of this.flushWaiters) {
+      if (waiter.ids.has(oldest)) waiter.reject(error);
+    }
+    this.onError?.(error);
+  }
+
+  /**
+   * 지금까지의 편집이 모두 서버에 확인될 때까지 대기 (페이지 이동, 종료 전)
+   *
+   *   await client.flush({ timeoutMs: 5000 });
+   *   client.disconnect();
+   *
+   * batcher에 쌓인 연산을 바로 보내고, 그 시점의 outbox 메시지가 모두 확인되면 resolve한다.
+   * 이후의 편집은 기다리지 않는다. 끊긴 동안에는 재연결 후 재전송이 확인될 때까지 기다린다.
+   * 기다리던 메시지가 outbox에서 버려지면 reject.
+   */
+  async flush(options: { timeoutMs?: number; signal?: AbortSignal } = {}): Promise<void> {
+    options.signal?.throwIfAborted();
+    this.batcher.flush();
+    const ids = new Set(this.outbox.keys());
+    if (ids.size === 0) return;
+
+    return new Promise((resolve, reject) => {
+      let timer: ReturnType<typeof setTimeout> | undefined;
+      const onAbort = (): void => waiter.reject(options.signal!.reason as Error);
+      const done = (): void => {
+        this.flushWaiters.delete(waiter);
+        clearTimeout(timer);
+        options.signal?.removeEventListener('abort', onAbort);
+      };
+      const waiter: FlushWaiter = {
+        ids,
+        resolve: () => { done(); resolve(); },
+        reject: (error) => { done(); reject(error); },
+      };
+      if (options.timeoutMs !== undefined) {
+        timer = setTimeout(() => {
+          waiter.reject(new Error(`Flush timed out after ${options.timeoutMs}ms: ${waiter.ids.size} message(s) unacknowledged`));
+        }, options.timeoutMs);
+      }
+      options.signal?.addEventListener('abort', onAbort, { once: true });
+      this.flushWaiters.add(waiter);
+    });
+  }
+
+  /** 보관 중인 메시지를 outboxRate 속도로 재전송 (100ms마다 나눠 보냄) */
+  private flushOutbox(): void {
+    this.clearOutboxTimer();
+    const queue = [...this.outbox.values()];
+    const perTick = Math.max(1, Math.ceil(this.options.outboxRate / 10));
+
+    const tick = (): void => {
+      this.outboxTimer = null;
+      for (const msg of queue.splice(0, perTick)) {
+        // 그 사이 확인된 메시지는 건너뜀
+        if (this.outbox.has(msg.msgId as string)) this.send(msg);
+      }
+      if (queue.length > 0 && this.isConnected) {
+        this.outboxTimer = setTimeout(tick, 100);
+      }
+    };
+    if (queue.length > 0) tick();
+  }
+
+  private clearOutboxTimer(): void {
+    if (this.outboxTimer) {
+      clearTimeout(this.outboxTimer);
+      this.outboxTimer = null;
+    }
+  }
+
+  private handleMessage(msg: WireMessage): void {
+    if (typeof msg.requestId === 'string' && this.pendingCalls.has(msg.requestId)) {
+      this.settleCall(
+        msg.requestId,
+        msg.type === 'error' ? callError(msg) : undefined,
+        msg,
+      );
+    }
+
+    if (msg.type === 'crdt_ops_ok' && typeof msg.msgId === 'string') {
+      const sent = this.outbox.get(msg.msgId);
+      this.outbox.delete(msg.msgId);
+      if (sent) {
+        const key = `${sent.collection}:${sent.docId}`;
+        if (typeof msg.version === 'number') this.ackedVersions.set(key, msg.version);
+        this.emitSyncLag(key);
+        for (const waiter of this.flushWaiters) {
+          waiter.ids.delete(msg.msgId);
+          if (waiter.ids.size === 0) waiter.resolve();
+        }
+        if (this.outbox.size === 0 && this.queuedOps.size === 0) this.onFlushed?.();
+      }
+    }
+
+    if ((msg.type === 'sync' || msg.type === 'subscribed') && this.watchdogTimer) {
+      this.lastActivity.set(msg.collection as string, Date.now());
+    }
+
+    // 응답 짝 맞춤/outbox 확인은 미들웨어와 무관하게 처리하고, 핸들러 전달만 미들웨어를 거침
+    const chain = this.inbound;
+    const run = (index: number, m: WireMessage): void => {
+      if (index === chain.length) {
+        this.dispatch(m);
+        return;
+      }
+      chain[index](m, next => run(index + 1, next));
+    };
+    run(0, msg);
+  }
+
+  private dispatch(msg: WireMessage): void {
     const handlers = this.messageHandlers.get(msg.type);
     if (handlers) {
+      let spent = 0;
       for (const handler of handlers) {
+        const start = performance.now();
         handler(msg);
+        const ms = performance.now() - start;
+        spent += ms;
+        this.recordHandlerTime(msg.type, ms);
       }
+      if (typeof msg.collection === 'string') this.collectionStats(msg.collection, msg.type).handlerMs += spent;
     }
 
     // Sync events
     if (msg.type === 'sync') {
+      this.bufferForReplay(msg as WSSyncMessage);
       this.onSync?.(msg.collection as string, msg.event as string, msg);
     }
 
+    // Channel messages
+    if (msg.type === 'channel_message') {
+      for (const handler of this.channelHandlers.get(msg.channel as string) ?? []) {
+        handler(msg.payload, msg.from as string);
+      }
+    }
+
+    // 스냅샷 복원: 열어 둔 문서를 같은 객체 안에서 교체 (openDocument의 첫 crdt_state와 구분)
+    if (msg.type === 'crdt_state' && msg.restored !== undefined) {
+      const doc = this.docSubscriptions.get(`${msg.collection}:${msg.docId}`);
+      if (doc) {
+        restoreState(doc, msg.state as { version?: number });
+        // 복원 전 편집의 역연산은 복원된 문서에 맞지 않음
+        this.clearUndo(msg.collection as string, msg.docId as string);
+        for (const text of this.texts.get(`${msg.collection}:${msg.docId}`) || []) {
+          text.handleReset();
+        }
+      }
+    }
+
     // CRDT sync
     if (msg.type === 'crdt_sync') {
       const doc = this.docSubscriptions.get(`${msg.collection}:${msg.docId}`);
       if (doc) {
         doc.applyRemoteBatch(msg.operations as unknown[]);
+        for (const text of this.texts.get(`${msg.collection}:${msg.docId}`) || []) {
+          text.handleRemoteOps(msg.operations as unknown[]);
+        }
+      }
+    }
+  }
+
+  /**
+   * 수신 메시지가 핸들러(on/watch/onSync, CRDT 반영)에 닿기 전에 거칠 미들웨어 추가 (해제 함수 반환)
+   *
+   *   client.use((msg, next) => { if (!seen.has(msg.msgId)) next(msg); });
+   *
+   * 등록 순서대로 실행한다. 연결 핸드셰이크, call() 응답, outbox 확인은 미들웨어 결과와 무관하다.
+   */
+  use(middleware: InboundMiddleware): () => void {
+    this.inbound = [...this.inbound, middleware];
+    return () => {
+      this.inbound = this.inbound.filter(m => m !== middleware);
+    };
+  }
+
+  // ===== RPC =====
+
+  /**
+   * 요청/응답 메시지 (requestId로 짝 맞춤)
+   *
+   * 연결 전이면 연결될 때 보내고, 응답 전에 끊기면 retries 횟수만큼 재연결 후 다시 보낸다.
+   * 서버가 { type: 'error' }로 답하면 reject.
+   */
+  call<T extends WireMessage = WireMessage>(
+    type: string,
+    payload: Record<string, unknown> = {},
+    options: CallOptions = {},
+  ): Promise<T> {
+    const requestId = `r${++this.callSeq}`;
+    const timeoutMs = options.timeoutMs ?? 5000;
+
+    return new Promise<T>((resolve, reject) => {
+      const pending: PendingCall = {
+        msg: { ...payload, type, requestId },
+        resolve: resolve as (msg: WireMessage) => void,
+        reject,
+        timer: setTimeout(() => {
+          this.settleCall(requestId, new Error(`Request timeout: ${type} (${timeoutMs}ms)`));
+        }, timeoutMs),
+        retriesLeft: options.retries ?? 1,
+        sent: false,
+      };
+      this.pendingCalls.set(requestId, pending);
+
+      if (this.isConnected) {
+        this.send(pending.msg);
+        pending.sent = true;
+      }
+    });
+  }
+
+  private settleCall(requestId: string, error?: Error, response?: WireMessage): void {
+    const pending = this.pendingCalls.get(requestId);
+    if (!pending) return;
+    clearTimeout(pending.timer);
+    this.pendingCalls.delete(requestId);
+    if (error) {
+      pending.reject(error);
+    } else {
+      pending.resolve(response!);
+    }
+  }
+
+  /** 연결 직후: 아직 보내지 않은(재시도 대기 포함) 요청 전송 */
+  private flushPendingCalls(): void {
+    for (const pending of this.pendingCalls.values()) {
+      if (pending.sent) continue;
+      this.send(pending.msg);
+      pending.sent = true;
+    }
+  }
+
+  /** 연결 끊김: 보낸 요청은 재시도 횟수가 남았으면 대기, 아니면 실패 */
+  private failInFlightCalls(): void {
+    for (const [id, pending] of [...this.pendingCalls]) {
+      if (!pending.sent) continue;
+      if (pending.retriesLeft > 0) {
+        pending.retriesLeft--;
+        pending.sent = false;
+      } else {
+        this.settleCall(id, new Error(`Connection closed before response: ${pending.msg.type}`));
+      }
+    }
+  }
+
+  private collectionStats(collection: string, type: string): CollectionEventStats {
+    const byType = this.stats.collections[collection] ??= {};
+    return byType[type] ??= { count: 0, bytes: 0, handlerMs: 0 };
+  }
+
+  private recordHandlerTime(type: string, ms: number): void {
+    const entry = this.stats.handlerLatency[type] ??= { calls: 0, totalMs: 0, maxMs: 0 };
+    entry.calls++;
+    entry.totalMs += ms;
+    if (ms > entry.maxMs) entry.maxMs = ms;
+
+    const threshold = this.options.slowHandlerThreshold;
+    if (threshold > 0 && ms > threshold) {
+      if (this.onSlowHandler) {
+        this.onSlowHandler(type, ms);
+      } else if (!this.slowWarned.has(type)) {
+        this.slowWarned.add(type);
+        console.warn(`[kimdb-client] Slow '${type}' handler: ${ms.toFixed(1)}ms (threshold ${threshold}ms, later ones only in getStats().handlerLatency)`);
       }
     }
   }
@@ -207,6 +1065,17 @@ export class KimDBClient {
     this.messageHandlers.get(type)!.push(handler);
   }
 
+  /**
+   * 타입이 지정된 메시지 핸들러 등록 (해제 함수 반환)
+   *
+   *   client.onEvent('presence_users', (msg) => msg.users.length)
+   */
+  onEvent<K extends keyof WSServerEventMap>(type: K, handler: (msg: WSServerEventMap[K]) => void): () => void {
+    const wrapped = handler as MessageHandler;
+    this.on(type, wrapped);
+    return () => this.off(type, wrapped);
+  }
+
   off(type: string, handler: MessageHandler): void {
     const handlers = this.messageHandlers.get(type);
     if (handlers) {
@@ -217,23 +1086,87 @@ export class KimDBClient {
 
   // ===== Subscriptions =====
 
+  /**
+   * 컬렉션 구독 (참조 카운트)
+   *
+   * 같은 컬렉션을 여러 곳(watch, backfillAndTail 등)에서 구독하면 subscribe는 처음 한 번만 보내고,
+   * unsubscribe를 같은 횟수만큼 호출해 0이 될 때만 서버 구독을 해제한다.
+   */
   subscribe(collection: string): void {
-    this.subscriptions.add(collection);
-    if (this.state.connected) {
+    validateCollectionName(collection);
+    const count = this.subscriptions.get(collection) ?? 0;
+    this.subscriptions.set(collection, count + 1);
+    if (count > 0) return;
+    this.lastActivity.set(collection, Date.now());
+    if (this.isConnected) {
       this.send({ type: 'subscribe', collection });
     }
   }
 
+  /** 구독 참조 하나 해제 (마지막 참조일 때만 서버에 unsubscribe) */
   unsubscribe(collection: string): void {
+    const count = this.subscriptions.get(collection);
+    if (count === undefined) return;
+    if (count > 1) {
+      this.subscriptions.set(collection, count - 1);
+      return;
+    }
     this.subscriptions.delete(collection);
-    if (this.state.connected) {
+    this.replayBuffers.delete(collection);
+    this.lastActivity.delete(collection);
+    if (this.isConnected) {
       this.send({ type: 'unsubscribe', collection });
     }
   }
 
+  /**
+   * 컬렉션 sync 이벤트 핸들러 (해제 함수 반환, 해제하면 이 watch의 구독 참조도 놓음)
+   *
+   * replay: 등록 직후 최근 이벤트를 최대 N개 먼저 전달 (replayBufferSize 만큼만 보관됨).
+   * 늦게 초기화되는 UI 컴포넌트가 빈 상태로 시작하지 않도록 할 때 사용.
+   */
+  watch(collection: string, handler: (msg: WSSyncMessage) => void, options: { replay?: number } = {}): () => void {
+    this.subscribe(collection);
+
+    const replay = options.replay ?? 0;
+    if (replay > 0) {
+      for (const msg of (this.replayBuffers.get(collection) || []).slice(-replay)) {
+        handler(msg);
+      }
+    }
+
+    const wrapped: MessageHandler = (msg) => {
+      const m = msg as WSSyncMessage;
+      if (m.collection === collection) handler({ ...m, data: this.readDoc(m.data) });
+    };
+    this.on('sync', wrapped);
+    let stopped = false;
+    return () => {
+      if (stopped) return;
+      stopped = true;
+      this.off('sync', wrapped);
+      this.unsubscribe(collection);
+    };
+  }
+
+  private bufferForReplay(msg: WSSyncMessage): void {
+    const size = this.options.replayBufferSize;
+    if (size <= 0 || !this.subscriptions.has(msg.collection)) return;
+
+    let buffer = this.replayBuffers.get(msg.collection);
+    if (!buffer) {
+      buffer = [];
+      this.replayBuffers.set(msg.collection, buffer);
+    }
+    buffer.push({ ...msg, data: this.readDoc(msg.data) });
+    if (buffer.length > size) buffer.splice(0, buffer.length - size);
+  }
+
   // ===== CRDT Document =====
 
   async openDocument(collection: string, docId: string): Promise<CRDTDocument> {
+    validateCollectionName(collection);
+    validateDocId(docId);
     const key = `${collection}:${docId}`;
 
     if (this.docSubscriptions.has(key)) {
@@ -264,6 +1197,10 @@ export class KimDBClient {
   closeDocument(collection: string, docId: string): void {
     const key = `${collection}:${docId}`;
     this.docSubscriptions.delete(key);
+    this.texts.delete(key);
+    this.undoManagers.get(key)?.clear();
+    this.undoManagers.delete(key);
+    this.undoStates.delete(key);
     this.send({ type: 'unsubscribe_doc', collection, docId });
   }
 
@@ -275,8 +1212,10 @@ export class KimDBClient {
       throw new Error(`Document not opened: ${collection}/${docId}`);
     }
 
+    const previousValue = doc.get(path);
     const op = doc.set(path, value);
-    this.batcher.add({ ...op, collection });
+    this.queueOp(collection, docId, op);
+    this.captureUndo(collection, docId, op, previousValue);
   }
 
   get(collection: string, docId: string, path: string | string[]): unknown {
@@ -288,9 +1227,85 @@ export class KimDBClient {
     return doc.get(path);
   }
 
+  /** 협업 텍스트 편집기 (문서를 먼저 openDocument로 열어야 함) */
+  text(collection: string, docId: string, path: string): CollaborativeText {
+    const key = `${collection}:${docId}`;
+    const doc = this.docSubscriptions.get(key);
+    if (!doc) {
+      throw new Error(`Document not opened: ${collection}/${docId}`);
+    }
+
+    const text = new CollaborativeText(doc, path, (op) => {
+      this.queueOp(collection, docId, op as object);
+    });
+    if (!this.texts.has(key)) this.texts.set(key, new Set());
+    this.texts.get(key)!.add(text);
+    return text;
+  }
+
+  // ===== Snapshots =====
+
+  /**
+   * 협업 문서의 현재 상태를 label 이름으로 서버에 저장 (문서를 열지 않아도 됨)
+   *
+   * 라벨은 문서마다 고유하며, 이미 있는 라벨이면 reject.
+   */
+  async snapshot(collection: string, docId: string, label: string, options?: CallOptions): Promise<SnapshotInfo> {
+    validateCollectionName(collection);
+    validateDocId(docId);
+    const problem = snapshotLabelError(label);
+    if (problem) throw new Error(problem);
+    const res = await this.call('snapshot_create', { collection, docId, label }, options);
+    return { label: res.label as string, version: res.version as number, createdAt: res.createdAt as number };
+  }
+
+  /** 문서의 스냅샷 목록 (최신 먼저) */
+  async listSnapshots(collection: string, docId: string, options?: CallOptions): Promise<SnapshotInfo[]> {
+    validateCollectionName(collection);
+    validateDocId(docId);
+    const res = await this.call('snapshot_list', { collection, docId }, options);
+    return (res.snapshots as SnapshotInfo[]) ?? [];
+  }
+
+  /**
+   * 문서를 스냅샷 상태로 되돌림 (서버 문서를 교체하고 구독자 전원에게 새 상태를 보냄)
+   *
+   * 열어 둔 문서는 resolve 전에 같은 CRDTDocument 객체 안에서 교체된다.
+   * 아직 서버에 닿지 않은 로컬 편집은 복원된 상태 위에 적용된다. 새 문서 버전 반환.
+   */
+  async restoreSnapshot(collection: string, docId: string, label: string, options?: CallOptions): Promise<number> {
+    validateCollectionName(collection);
+    validateDocId(docId);
+    const problem = snapshotLabelError(label);
+    if (problem) throw new Error(problem);
+    const res = await this.call('snapshot_restore', { collection, docId, label }, options);
+    return res.version as number;
+  }
+
+  /**
+   * 여러 문서(다른 컬렉션 포함)를 서버에서 한 트랜잭션으로 병합 업데이트
+   *
+   * 모두 반영되거나 전부 롤백되며, 응답(batch_ack)은 한 번만 온다. 최대 500개.
+   * expectedVersion이 어긋난 op가 있으면 전부 롤백되고 VersionConflictError.
+   */
+  async updateBatch(
+    ops: Array<{ collection: string; id: string; data: Record<string, unknown>; expectedVersion?: number }>,
+    options?: CallOptions,
+  ): Promise<WSBatchAckMessage['results']> {
+    for (const op of ops) {
+      validateCollectionName(op.collection);
+      validateDocId(op.id);
+    }
+    const payload = ops.map(op => ({ ...op, data: encodeFields(op.data, this.options.fieldNaming) }));
+    const ack = await this.call<WSBatchAckMessage>('update_batch', { ops: payload }, options);
+    return ack.results;
+  }
+
   // ===== Undo/Redo =====
+  // Undo 히스토리는 문서별로 클라이언트가 가짐 (set만 기록, 원격 편집은 되돌리지 않음)
+  // undo/redo는 저장된 연산을 새 op로 로컬 문서에 적용하고 다른 편집처럼 crdt_ops로 전송
 
-  getUndoManager(collection: string, docId: string): UndoManager {
+  private undoManager(collection: string, docId: string): UndoManager {
     const key = `${collection}:${docId}`;
     if (!this.undoManagers.has(key)) {
       this.undoManagers.set(key, new UndoManager({ maxHistory: 100, captureTimeout: 500 }));
@@ -298,20 +1313,80 @@ export class KimDBClient {
     return this.undoManagers.get(key)!;
   }
 
-  undo(collection: string, docId: string): void {
-    const um = this.getUndoManager(collection, docId);
-    const ops = um.undo();
-    if (ops && ops.length > 0) {
-      this.send({ type: 'undo', collection, docId });
+  /** 로컬 Undo 히스토리에 편집 기록 (captureTimeout 안의 연속 편집은 한 단위) */
+  private captureUndo(
+    collection: string,
+    docId: string,
+    op: { path: string[] },
+    previousValue: unknown,
+  ): void {
+    // 문서에 저장된 것과 같은 형태(래핑된 값)로 기록해야 역연산을 그대로 적용할 수 있음
+    this.undoManager(collection, docId).capture(op, previousValue === undefined ? undefined : { path: op.path, value: previousValue });
+    this.emitUndoState(collection, docId);
+  }
+
+  /** 여러 편집을 하나의 Undo 단위로 묶음 (앞뒤 편집과 섞이지 않음) */
+  transact(collection: string, docId: string, fn: () => void): void {
+    const um = this.undoManager(collection, docId);
+    um.stopCapturing();
+    try {
+      fn();
+    } finally {
+      um.stopCapturing();
+      this.emitUndoState(collection, docId);
     }
   }
 
-  redo(collection: string, docId: string): void {
-    const um = this.getUndoManager(collection, docId);
-    const ops = um.redo();
-    if (ops && ops.length > 0) {
-      this.send({ type: 'redo', collection, docId });
+  /**
+   * 마지막 로컬 편집 단위 취소 (다른 클라이언트에는 일반 편집처럼 동기화)
+   *
+   * 되돌릴 편집이 없으면 false. 그사이 다른 사람이 같은 필드를 바꿨어도 내 편집 전 값으로 돌아간다.
+   */
+  undo(collection: string, docId: string): boolean {
+    return this.replayUndo('undo', collection, docId);
+  }
+
+  redo(collection: string, docId: string): boolean {
+    return this.replayUndo('redo', collection, docId);
+  }
+
+  getUndoState(collection: string, docId: string): UndoState {
+    const { undoCount, redoCount, pendingCount } = this.undoManager(collection, docId).state;
+    return { canUndo: undoCount + pendingCount > 0, canRedo: redoCount > 0 };
+  }
+
+  /** 문서의 Undo 히스토리 비우기 (저장, 스냅샷 복원 등 되돌리면 안 되는 시점) */
+  clearUndo(collection: string, docId: string): void {
+    this.undoManagers.get(`${collection}:${docId}`)?.clear();
+    this.emitUndoState(collection, docId);
+  }
+
+  private replayUndo(kind: 'undo' | 'redo', collection: string, docId: string): boolean {
+    const doc = this.docSubscriptions.get(`${collection}:${docId}`);
+    if (!doc) {
+      throw new Error(`Document not opened: ${collection}/${docId}`);
+    }
+
+    const um = this.undoManager(collection, docId);
+    const stored = kind === 'undo' ? um.undo() : um.redo();
+    if (!stored || stored.length === 0) return false;
+
+    // 스택의 연산은 반복해서 쓰므로 매번 새 opId/clock (같은 opId는 중복으로 버려짐)
+    for (const op of freshUndoOps(doc, stored, kind)) {
+      doc.applyRemote(op);
+      this.queueOp(collection, docId, op);
     }
+    this.emitUndoState(collection, docId);
+    return true;
+  }
+
+  private emitUndoState(collection: string, docId: string): void {
+    const key = `${collection}:${docId}`;
+    const state = this.getUndoState(collection, docId);
+    const previous = this.undoStates.get(key);
+    if (previous && previous.canUndo === state.canUndo && previous.canRedo === state.canRedo) return;
+    this.undoStates.set(key, state);
+    this.onUndoStateChange?.(collection, docId, state);
   }
 
   // ===== Presence =====
@@ -335,6 +1410,57 @@ export class KimDBClient {
     this.presenceManager = null;
   }
 
+  /** 현재 문서 참여자 목록 조회 (늦게 합류한 경우 초기 상태용) */
+  getPresence(collection: string, docId: string, timeoutMs = 5000): Promise<PresenceUser[]> {
+    return new Promise((resolve, reject) => {
+      const timer = setTimeout(() => {
+        this.off('presence_users', handler);
+        reject(new Error(`Presence request timeout: ${collection}/${docId}`));
+      }, timeoutMs);
+
+      const handler: MessageHandler = (msg) => {
+        const m = msg as { collection: string; docId: string; users: PresenceUser[] };
+        if (m.collection !== collection || m.docId !== docId) return;
+        clearTimeout(timer);
+        this.off('presence_users', handler);
+        resolve(m.users || []);
+      };
+
+      this.on('presence_users', handler);
+      this.send({ type: 'presence_get', collection, docId });
+    });
+  }
+
+  /** 휘발성 상태 (입력 중 표시 등), joinPresence 이후 사용 */
+  awareness(collection: string, docId: string, options?: AwarenessOptions): Awareness {
+    const awareness = new Awareness(collection, docId, (msg) => this.send(msg), options);
+    const handler: MessageHandler = (msg) => awareness.handleMessage(msg as { type: string });
+    this.on('presence_updated', handler);
+    this.on('presence_left', handler);
+    awareness.onDestroy = () => {
+      this.off('presence_updated', handler);
+      this.off('presence_left', handler);
+    };
+    return awareness;
+  }
+
+  /**
+   * 문서 편집 중 표시 (약한 잠금) - presence에 참여하고 awareness로 알림
+   *
+   *   const claim = client.claimEditing('docs', 'd1', { name: 'Kim' });
+   *   claim.onContention = (editors) => toast(`${editors[0].name}님도 편집 중`);
+   *   claim.release();
+   *
+   * 다른 클라이언트는 같은 방식으로 claim을 만들거나 awareness의 'editing' 필드로 확인한다.
+   */
+  claimEditing(collection: string, docId: string, user: { name: string; color?: string }, options?: AwarenessOptions): EditingClaim {
+    validateCollectionName(collection);
+    validateDocId(docId);
+    void this.joinPresence(collection, docId, user);
+    const awareness = this.awareness(collection, docId, options);
+    return new EditingClaim(awareness, user.name, (options?.ttl ?? 30000) / 3);
+  }
+
   updatePresence(cursor: { position: number; selection?: { start: number; end: number } | null }): void {
     if (this.presenceManager) {
       this.send({
@@ -345,6 +1471,53 @@ export class KimDBClient {
     }
   }
 
+  // ===== Channels =====
+
+  /**
+   * pub/sub 채널 구독 - 문서와 무관한 일회성 메시지 (해제 함수 반환)
+   *
+   *   const stop = client.subscribeChannel('chat:room1', (payload, from) => render(payload));
+   *
+   * 같은 채널에 핸들러를 여럿 달면 서버 구독은 한 번만, 마지막 핸들러를 떼면 해제.
+   * 재연결하면 다시 구독하지만 끊겨 있던 동안의 메시지는 받지 못함 (저장하지 않음).
+   */
+  subscribeChannel<T = unknown>(channel: string, handler: (payload: T, from: string) => void): () => void {
+    const problem = channelNameError(channel);
+    if (problem) throw new Error(problem);
+
+    let handlers = this.channelHandlers.get(channel);
+    if (!handlers) {
+      handlers = new Set();
+      this.channelHandlers.set(channel, handlers);
+      if (this.isConnected) this.send({ type: 'channel_subscribe', channel });
+    }
+    const entry = handler as (payload: unknown, from: string) => void;
+    handlers.add(entry);
+
+    let active = true;
+    return () => {
+      if (!active) return;
+      active = false;
+      handlers!.delete(entry);
+      if (handlers!.size > 0 || this.channelHandlers.get(channel) !== handlers) return;
+      this.channelHandlers.delete(channel);
+      if (this.isConnected) this.send({ type: 'channel_unsubscribe', channel });
+    };
+  }
+
+  /**
+   * 채널에 메시지 보내기 (보낸 클라이언트 자신은 받지 않음)
+   *
+   * 서버가 받으면 resolve, delivered는 그 서버에서 전달한 구독자 수 (Redis로 다른 서버에 간 수는 제외).
+   * 큐의 publish(topic)와 달리 저장하지 않는다.
+   */
+  async publishChannel(channel: string, payload: unknown, options?: CallOptions): Promise<{ delivered: number }> {
+    const problem = publishError(channel, payload);
+    if (problem) throw new Error(problem);
+    const res = await this.call('channel_publish', { channel, payload }, options);
+    return { delivered: (res.delivered as number) ?? 0 };
+  }
+
   // ===== REST API =====
 
   private get httpUrl(): string {
@@ -356,6 +1529,11 @@ export class KimDBClient {
   }
 
   private async httpFetch<T>(path: string, options: RequestInit = {}): Promise<T> {
+    const res = await this.httpRequest(path, options);
+    return this.options.json.parse(await res.text()) as T;
+  }
+
+  private async httpRequest(path: string, options: RequestInit = {}): Promise<Response> {
     const headers: Record<string, string> = {
       'Content-Type': 'application/json',
       ...(options.headers as Record<string, string> || {}),
@@ -364,65 +1542,983 @@ export class KimDBClient {
     if (this.options.apiKey) {
       headers['X-API-Key'] = this.options.apiKey;
     }
+    // 보존 정책 같은 설정 변경은 dry-run을 지원하지 않으므로 문서/SQL 쓰기에만 붙임
+    if (this.options.dryRun && options.method && options.method !== 'GET' && /^\/api\/(c|sql)\b/.test(path)) {
+      headers['X-KimDB-Dry-Run'] = '1';
+    }
 
-    const res = await fetch(`${this.httpUrl}${path}`, {
+    const res = await this.options.fetch(`${this.httpUrl}${path}`, {
       ...options,
       headers,
     });
 
     if (!res.ok) {
-      const error = await res.text();
-      throw new Error(`HTTP ${res.status}: ${error}`);
+      throw httpError(res.status, await res.text());
     }
 
-    return res.json() as Promise<T>;
+    return res;
+  }
+
+  /**
+   * REST: /health가 status 'ok'를 반환할 때까지 대기 (컨테이너 기동 순서, 통합 테스트용)
+   *
+   * timeoutMs가 지나거나 signal이 중단되거나 backoff가 null을 반환하면 마지막 오류로 reject.
+   */
+  async waitUntilReady(options: ReadyOptions = {}): Promise<{ version: string; serverId: string }> {
+    const deadline = Date.now() + (options.timeoutMs ?? 30000);
+    const backoff = options.backoff ?? exponentialBackoff({ initial: 200, max: 5000, jitter: 0 });
+    let lastError: Error = new Error('Server not ready');
+
+    for (let attempt = 1; ; attempt++) {
+      options.signal?.throwIfAborted();
+      try {
+        const health = await this.httpFetch<{ status: string; version: string; serverId: string }>('/health', {
+          signal: options.signal,
+        });
+        options.onAttempt?.(attempt, { ok: health.status === 'ok', status: health.status });
+        if (health.status === 'ok') return { version: health.version, serverId: health.serverId };
+        lastError = new Error(`Server not ready: status ${health.status}`);
+      } catch (e) {
+        options.signal?.throwIfAborted();
+        lastError = e as Error;
+        options.onAttempt?.(attempt, { ok: false, error: lastError });
+      }
+
+      const delay = backoff.nextDelay(attempt, lastError);
+      if (delay === null || Date.now() + delay > deadline) {
+        throw new Error(`Server not ready after ${attempt} attempts: ${lastError.message}`);
+      }
+      await new Promise((resolve) => setTimeout(resolve, delay));
+    }
+  }
+
+  /** HTTP/WebSocket 왕복 지연 측정 (배포 확인, SLA 모니터링용) */
+  async ping(timeoutMs = 5000): Promise<LatencyReport> {
+    const httpStart = performance.now();
+    const health = await this.httpFetch<{ serverId: string }>('/health', { signal: AbortSignal.timeout(timeoutMs) });
+    const httpMs = performance.now() - httpStart;
+
+    let wsMs: number | null = null;
+    if (this.isConnected) {
+      const wsStart = performance.now();
+      await this.call('ping', { time: Date.now() }, { timeoutMs, retries: 0 });
+      wsMs = performance.now() - wsStart;
+    }
+
+    return { httpMs, wsMs, serverId: health.serverId };
+  }
+
+  /** REST: 컬렉션 문서 목록 조회 (응답 JSON 원문, 파싱 생략) */
+  async listRaw(collection: string): Promise<string> {
+    const raw = await (await this.httpRequest(docPath(collection))).text();
+    return this.options.redact.length === 0 ? raw : this.options.json.stringify(await this.redactRaw(raw, true));
+  }
+
+  /** REST: 단일 문서 조회 (응답 JSON 원문, 파싱 생략) */
+  async getDocRaw(collection: string, id: string): Promise<string> {
+    const raw = await (await this.httpRequest(docPath(collection, id))).text();
+    return this.options.redact.length === 0 ? raw : this.options.json.stringify(await this.redactRaw(raw, false));
+  }
+
+  /** 원문 응답에도 가림 규칙 적용 (규칙이 있으면 파싱이 필요함) */
+  private async redactRaw(raw: string, isList: boolean): Promise<unknown> {
+    const res = this.options.json.parse(raw) as { data?: unknown };
+    if (isList && Array.isArray(res.data)) {
+      return { ...res, data: res.data.map(doc => redact(doc, this.options.redact)) };
+    }
+    return res.data === undefined ? res : { ...res, data: redact(res.data, this.options.redact) };
+  }
+
+  /** 서버에서 받은 문서 데이터 → 앱 데이터 (필드 이름 변환 후 가림) */
+  private readDoc<T>(data: T): T {
+    return redact(decodeFields(data, this.options.fieldNaming) as T, this.options.redact);
+  }
+
+  /**
+   * offlineReads가 켜져 있으면 성공한 읽기를 저장하고, 서버에 닿지 못하면 저장된 결과로 응답
+   *
+   * 캐시에도 없으면 원래 에러로 실패한다.
+   */
+  private async cachedRead<T extends object>(path: string, read: () => Promise<T>): Promise<T & { staleness?: Staleness }> {
+    if (!this.readCache) return read();
+    try {
+      const result = await read();
+      this.readCache.set(path, result);
+      return result;
+    } catch (e) {
+      if (!isUnreachable(e)) throw e;
+      const cached = this.readCache.get<T>(path, e as Error);
+      if (!cached) throw e;
+      return cached;
+    }
+  }
+
+  /** offlineReads 캐시 항목 제거 (id가 없으면 컬렉션 전체) - 쓰기 성공 후 자동 호출 */
+  invalidateCache(collection: string, id?: string): void {
+    if (!this.readCache) return;
+    const listPath = docPath(collection);
+    this.readCache.delete(listPath);
+    if (id === undefined) {
+      this.readCache.deletePrefix(`${listPath}/`);
+      return;
+    }
+    this.readCache.delete(docPath(collection, id));
+  }
+
+  /** REST: 서버의 컬렉션 이름 목록 */
+  async collections(): Promise<string[]> {
+    const res = await this.httpFetch<{ collections: string[] }>('/api/collections');
+    return res.collections;
   }
 
   /** REST: 컬렉션 문서 목록 조회 */
-  async list(collection: string): Promise<{ docs: Array<{ id: string; data: unknown; _version: number }> }> {
-    return this.httpFetch(`/api/c/${collection}`);
+  async list(collection: string): Promise<{
+    success: boolean;
+    collection: string;
+    count: number;
+    data: Array<{ id: string; _version: number; [key: string]: unknown }>;
+    /** offlineReads로 캐시에서 응답한 경우에만 있음 */
+    staleness?: Staleness;
+  } & PageInfo> {
+    const path = docPath(collection);
+    return this.cachedRead(path, async () => {
+      const res = await this.httpFetch<Awaited<ReturnType<KimDBClient['list']>>>(path);
+      return { ...res, data: res.data.map(doc => this.readDoc(doc)) };
+    });
+  }
+
+  /**
+   * REST: 컬렉션 문서 한 페이지 (limit 기본 100, 최대 1000)
+   *
+   * page.nextPage(client)로 이어서 읽는다. offlineReads 캐시는 쓰지 않는다.
+   */
+  async listPage<T extends { id: string; _version: number; [key: string]: unknown }>(
+    collection: string,
+    options: PageOptions = {},
+  ): Promise<CollectionPage<T>> {
+    const requested = { limit: options.limit ?? 100, skip: options.skip ?? 0 };
+    const query = new URLSearchParams({ limit: String(requested.limit), skip: String(requested.skip) });
+    const res = await this.httpFetch<{ data: T[] } & PageInfo>(`${docPath(collection)}?${query}`);
+    return new CollectionPage(collection, res.data.map(doc => this.readDoc(doc)), res, requested);
+  }
+
+  /** REST: 서버에서 무작위로 고른 문서 n개 (1~1000 정수) */
+  async sample(collection: string, n: number): Promise<Array<{ id: string; _version: number; [key: string]: unknown }>> {
+    validateCollectionName(collection);
+    const problem = sampleSizeError(n);
+    if (problem) throw new Error(problem);
+    const res = await this.httpFetch<{ data: Array<{ id: string; _version: number; [key: string]: unknown }> }>(
+      `/api/sample/${encodeURIComponent(collection)}?n=${n}`,
+    );
+    return res.data.map(doc => this.readDoc(doc));
+  }
+
+  /**
+   * REST: 가진 버전과 서버 버전 비교 (오프라인 후 다시 받을 문서만 확인)
+   *
+   * stale: 버전이 달라진 문서, missing: 삭제되었거나 없는 문서.
+   * 1000개씩 나눠 요청한다. 클라이언트가 모르는 새 문서는 알 수 없으므로 별도로 확인해야 한다.
+   */
+  async checkVersions(collection: string, versions: Record<string, number>): Promise<{
+    stale: Array<{ id: string; _version: number }>;
+    missing: string[];
+  }> {
+    validateCollectionName(collection);
+    const ids = Object.keys(versions);
+    const result = { stale: [] as Array<{ id: string; _version: number }>, missing: [] as string[] };
+
+    for (let i = 0; i < ids.length; i += MAX_VERSION_IDS) {
+      const chunk: Record<string, number> = {};
+      for (const id of ids.slice(i, i + MAX_VERSION_IDS)) chunk[id] = versions[id];
+
+      const res = await this.httpFetch<{ stale: typeof result.stale; missing: string[] }>(
+        `${docPath(collection)}/versions`,
+        { method: 'POST', body: this.options.json.stringify({ versions: chunk }) },
+      );
+      result.stale.push(...res.stale);
+      result.missing.push(...res.missing);
+    }
+    return result;
+  }
+
+  /**
+   * REST: 서버 컬렉션 체크섬 (루트/버킷 해시)
+   *
+   * buckets를 주면 그 버킷에 속한 문서별 해시를 받는다. 비교는 verifyCollection 사용.
+   */
+  async checksum(collection: string): Promise<{ count: number; root: string; buckets: Record<string, string> }>;
+  async checksum(collection: string, buckets: string[]): Promise<{ docs: Record<string, string> }>;
+  async checksum(collection: string, buckets?: string[]): Promise<unknown> {
+    validateCollectionName(collection);
+    const query = buckets ? `?${new URLSearchParams({ buckets: buckets.join(',') })}` : '';
+    return this.httpFetch(`/api/checksum/${encodeURIComponent(collection)}${query}`);
+  }
+
+  /**
+   * REST: 로컬에 가진 문서(id → data)와 서버 컬렉션이 같은지 체크섬으로 확인
+   *
+   * 다르면 어긋난 문서 ID를 돌려준다. data는 id/_version을 뺀 문서 데이터이며,
+   * redact로 가려진 필드가 있으면 일치하지 않는다.
+   */
+  async verifyCollection(collection: string, local: Record<string, unknown> | Map<string, unknown>): Promise<VerifyResult> {
+    return verifyCollection(this, collection, local, this.options.fieldNaming);
+  }
+
+  /** REST: 무작위 문서 하나 (비어 있으면 null) */
+  async random(collection: string): Promise<{ id: string; _version: number; [key: string]: unknown } | null> {
+    const [doc] = await this.sample(collection, 1);
+    return doc ?? null;
   }
 
   /** REST: 단일 문서 조회 */
-  async getDoc(collection: string, id: string): Promise<{ id: string; data: unknown; _version: number }> {
-    return this.httpFetch(`/api/c/${collection}/${id}`);
+  async getDoc(collection: string, id: string): Promise<{ id: string; data: unknown; _version: number; staleness?: Staleness }> {
+    const path = docPath(collection, id);
+    return this.cachedRead(path, async () => {
+      const res = await this.httpFetch<{ id: string; data: unknown; _version: number }>(path);
+      return { ...res, data: this.readDoc(res.data) };
+    });
   }
 
   /** REST: 문서 생성 (ID 자동 생성) */
-  async create(collection: string, data: unknown): Promise<{ success: boolean; id: string; _version: number }> {
-    return this.httpFetch(`/api/c/${collection}`, {
+  async create(collection: string, data: unknown): Promise<{ success: boolean; id: string; _version: number; dryRun?: boolean }> {
+    const res = await this.httpFetch<{ success: boolean; id: string; _version: number; dryRun?: boolean }>(docPath(collection), {
       method: 'POST',
-      body: JSON.stringify({ data }),
+      body: this.options.json.stringify({ data: encodeFields(data, this.options.fieldNaming) }),
     });
+    this.invalidateCache(collection, res.id);
+    return res;
   }
 
   /** REST: 문서 저장 (upsert) */
-  async save(collection: string, id: string, data: unknown): Promise<{ success: boolean; id: string; _version: number }> {
-    return this.httpFetch(`/api/c/${collection}/${id}`, {
+  async save(
+    collection: string,
+    id: string,
+    data: unknown,
+    options: WriteOptions = {},
+  ): Promise<{ success: boolean; id: string; _version: number; dryRun?: boolean }> {
+    const res = await this.httpFetch<{ success: boolean; id: string; _version: number; dryRun?: boolean }>(docPath(collection, id), {
       method: 'PUT',
-      body: JSON.stringify({ data }),
+      headers: versionHeaders(options),
+      body: this.options.json.stringify({ data: encodeFields(data, this.options.fieldNaming) }),
     });
+    this.invalidateCache(collection, id);
+    return res;
   }
 
   /** REST: 문서 부분 업데이트 */
-  async update(collection: string, id: string, data: unknown): Promise<{ success: boolean; id: string; _version: number }> {
-    return this.httpFetch(`/api/c/${collection}/${id}`, {
+  async update(
+    collection: string,
+    id: string,
+    data: unknown,
+    options: WriteOptions = {},
+  ): Promise<{ success: boolean; id: string; _version: number; dryRun?: boolean }> {
+    const res = await this.httpFetch<{ success: boolean; id: string; _version: number; dryRun?: boolean }>(docPath(collection, id), {
       method: 'PATCH',
-      body: JSON.stringify({ data }),
+      headers: versionHeaders(options),
+      body: this.options.json.stringify({ data: encodeFields(data, this.options.fieldNaming) }),
     });
+    this.invalidateCache(collection, id);
+    return res;
+  }
+
+  /**
+   * REST: 배열/중첩 필드 연산으로 부분 수정 (서버가 저장된 문서에 적용)
+   *
+   * 문서가 없으면 서버가 {}에서 시작해 만듦 (increment/addToSet이 첫 쓰기여도 됨, expectedVersion 0으로 조건 가능)
+   *
+   *   await client.modify('users', 'u1', updateOf<User>().addUnique('tags', 'vip').set('profile.city', 'Seoul'));
+   */
+  async modify<T>(
+    collection: string,
+    id: string,
+    update: UpdateBuilder<T> | UpdateOp[],
+    options: WriteOptions = {},
+  ): Promise<{ success: boolean; id: string; _version: number; dryRun?: boolean }> {
+    const ops = (Array.isArray(update) ? update : update.ops).map(op => this.encodeOp(op));
+    const res = await this.httpFetch<{ success: boolean; id: string; _version: number; dryRun?: boolean }>(docPath(collection, id), {
+      method: 'PATCH',
+      headers: versionHeaders(options),
+      body: this.options.json.stringify({ ops }),
+    });
+    this.invalidateCache(collection, id);
+    return res;
+  }
+
+  /** 필드 이름 → 서버 저장 이름 (fieldNaming) */
+  private encodeField(field: string): string {
+    return this.options.fieldNaming === 'snake_case' ? toSnakeCase(field) : field;
+  }
+
+  /** WHERE 필드 이름과 값 → 서버 저장 형태 (조건식은 조건마다 변환) */
+  private encodeFilter(filter: WhereFilter): WhereFilter {
+    const naming = this.options.fieldNaming;
+    if (naming === 'preserve') return filter;
+    if (filter instanceof FilterExpr) {
+      return new FilterExpr(filter.groups.map(group => group.map(c => ({
+        ...c,
+        field: this.encodeField(c.field),
+        value: encodeFields(c.value, naming),
+      }))));
+    }
+    return encodeFields(filter, naming) as Record<string, unknown>;
+  }
+
+  private encodeOp(op: UpdateOp): UpdateOp {
+    const naming = this.options.fieldNaming;
+    if (naming === 'preserve') return op;
+    const path = op.path.split('.').map(toSnakeCase).join('.');
+    if (op.op === 'set') return { ...op, path, value: encodeFields(op.value, naming) };
+    if (op.op === 'unset' || op.op === 'inc') return { ...op, path };
+    return { ...op, path, values: op.values.map(v => encodeFields(v, naming)) };
+  }
+
+  /** REST: 숫자 필드를 서버에서 원자적으로 증감 (읽고-더하고-쓰기 경쟁 없음) */
+  async increment(
+    collection: string,
+    id: string,
+    field: string,
+    delta = 1,
+    options?: WriteOptions,
+  ): Promise<{ success: boolean; id: string; _version: number; dryRun?: boolean }> {
+    return this.modify(collection, id, [{ op: 'inc', path: field, by: delta }], options);
+  }
+
+  /** REST: 배열 필드에 없는 값만 추가 (집합처럼 사용) */
+  async addToSet(
+    collection: string,
+    id: string,
+    field: string,
+    values: unknown[],
+    options?: WriteOptions,
+  ): Promise<{ success: boolean; id: string; _version: number; dryRun?: boolean }> {
+    return this.modify(collection, id, [{ op: 'addUnique', path: field, values }], options);
+  }
+
+  /** REST: 배열 필드에서 값 제거 */
+  async removeFromSet(
+    collection: string,
+    id: string,
+    field: string,
+    values: unknown[],
+    options?: WriteOptions,
+  ): Promise<{ success: boolean; id: string; _version: number; dryRun?: boolean }> {
+    return this.modify(collection, id, [{ op: 'pull', path: field, values }], options);
   }
 
   /** REST: 문서 삭제 */
-  async remove(collection: string, id: string): Promise<{ success: boolean }> {
-    return this.httpFetch(`/api/c/${collection}/${id}`, {
+  async remove(collection: string, id: string, options: WriteOptions = {}): Promise<{ success: boolean; dryRun?: boolean }> {
+    const res = await this.httpFetch<{ success: boolean; dryRun?: boolean }>(docPath(collection, id), {
       method: 'DELETE',
+      headers: versionHeaders(options),
     });
+    this.invalidateCache(collection, id);
+    return res;
+  }
+
+  /** SQL: filter에 일치하는 문서 (필드 = 값 객체 또는 fieldsOf 조건식) */
+  async find<T = Record<string, unknown>>(
+    collection: string,
+    filter: WhereFilter,
+    options?: SelectOptions,
+  ): Promise<T[]> {
+    const sort = options?.sort && (Array.isArray(options.sort) ? options.sort : [options.sort])
+      .map(key => ({ ...key, field: this.encodeField(key.field) }));
+    const { sql, params } = buildSelectWhere(collection, this.encodeFilter(filter), { ...options, sort });
+    const res = await this.sql(collection, sql, params, { collation: options?.collation });
+    return (res.rows ?? []).map(row => this.readDoc(row)) as T[];
+  }
+
+  /** SQL: filter에 일치하는 모든 문서에 patch 병합 (서버에서 한 번에 처리) */
+  async updateWhere(collection: string, filter: WhereFilter, patch: Record<string, unknown>): Promise<{ updated: number }> {
+    const { sql, params } = buildUpdateWhere(
+      collection,
+      this.encodeFilter(filter),
+      encodeFields(patch, this.options.fieldNaming) as Record<string, unknown>,
+    );
+    const res = await this.sql(collection, sql, params);
+    this.invalidateCache(collection);
+    return { updated: res.updated ?? 0 };
+  }
+
+  /** SQL: filter에 일치하는 모든 문서 삭제 */
+  async deleteWhere(collection: string, filter: WhereFilter): Promise<{ deleted: number }> {
+    const { sql, params } = buildDeleteWhere(collection, this.encodeFilter(filter));
+    const res = await this.sql(collection, sql, params);
+    this.invalidateCache(collection);
+    return { deleted: res.deleted ?? 0 };
+  }
+
+  /** REST: 문서 삭제 + 참조 문서 삭제/참조 해제 (dryRun이면 영향 범위만 반환) */
+  async deleteCascade(
+    collection: string,
+    id: string,
+    refs: RefSpec[],
+    options?: CascadeOptions,
+  ): Promise<CascadeResult> {
+    return deleteCascade(this, collection, id, refs, options);
+  }
+
+  /**
+   * REST: 컬렉션 보존 정책 설정 (null이면 해제)
+   *
+   * 서버 정리 주기마다 maxAgeMs보다 오래 수정되지 않은 문서, 최근 maxDocuments개 밖의 문서를
+   * 삭제한다. archiveTo가 있으면 삭제 전에 그 컬렉션으로 복사한다.
+   */
+  async setRetentionPolicy(collection: string, policy: RetentionPolicy | null): Promise<void> {
+    validateCollectionName(collection);
+    const path = `/api/retention/${encodeURIComponent(collection)}`;
+    if (policy === null) {
+      await this.httpFetch(path, { method: 'DELETE' });
+      return;
+    }
+    if (policy.archiveTo !== undefined) validateCollectionName(policy.archiveTo);
+    await this.httpFetch(path, { method: 'PUT', body: this.options.json.stringify({ policy }) });
+  }
+
+  /** REST: 컬렉션 보존 정책 (없으면 null) */
+  async getRetentionPolicy(collection: string): Promise<RetentionPolicy | null> {
+    validateCollectionName(collection);
+    const res = await this.httpFetch<{ policy: RetentionPolicy | null }>(`/api/retention/${encodeURIComponent(collection)}`);
+    return res.policy;
+  }
+
+  /** REST: timeField가 before보다 이른 문서를 sink로 내보낸 뒤 삭제 (배치 단위, 체크포인트로 이어받기) */
+  async archiveCollection(
+    collection: string,
+    before: Date | number,
+    sink: ArchiveSink,
+    options: ArchiveOptions,
+  ): Promise<{ exported: number; deleted: number }> {
+    validateCollectionName(collection);
+    return archiveCollection(this, collection, before, sink, options);
+  }
+
+  /** REST: source 문서를 target으로 복사 (filter/transform 적용, dryRun이면 대상 ID만 반환) */
+  async copyCollection(source: string, target: string, options?: CopyOptions): Promise<CopyResult> {
+    validateCollectionName(source);
+    validateCollectionName(target);
+    return copyCollection(this, source, target, options);
+  }
+
+  /** REST: 컬렉션 문서를 규칙(필수 필드, 타입, 허용 값, 참조)으로 검사한 보고서 */
+  async lintCollection(collection: string, rules: LintRules, options?: LintOptions): Promise<LintReport> {
+    validateCollectionName(collection);
+    for (const ref of rules.refs ?? []) validateCollectionName(ref.collection);
+    return lintCollection(this, collection, rules, options);
+  }
+
+  /** KV: 컬렉션을 키-값 저장소로 사용 */
+  kv(collection: string): KVStore {
+    return new KVStore(this, collection);
+  }
+
+  /** REST: timeField 기준 기간별 컬렉션(<base>_2025_01, ...)으로 나눠 저장/조회 */
+  partitioned(base: string, options: PartitionOptions): PartitionedCollection {
+    validateCollectionName(base);
+    return new PartitionedCollection(this, base, options);
+  }
+
+  /** REST: SQL 실행 (전송 전 구문/파라미터 개수 검사) */
+  async sql(collection: string, sql: string, params: unknown[] = [], options: SQLOptions = {}): Promise<SQLResponse> {
+    validateCollectionName(collection);
+    validateStatement(sql, params);
+    const { collation } = options;
+    if (collation !== undefined) {
+      const problem = checkCollation(collation);
+      if (problem) throw new SQLValidationError(sql, problem);
+    }
+    const res = await this.httpFetch<SQLResponse>('/api/sql', {
+      method: 'POST',
+      body: this.options.json.stringify({ sql, params, collection, collation }),
+    });
+    if (res.rows && this.options.redact.length > 0) {
+      res.rows = res.rows.map(row => redact(row, this.options.redact));
+    }
+    return res;
+  }
+
+  /**
+   * REST: sql()과 같은 요청, 응답 JSON 원문 (큰 결과의 파싱을 미루거나 그대로 전달할 때)
+   *
+   * 필드 이름 변환은 하지 않는다. 가림 규칙이 있으면 적용을 위해 파싱한다.
+   */
+  async sqlRaw(collection: string, sql: string, params: unknown[] = [], options: SQLOptions = {}): Promise<string> {
+    validateCollectionName(collection);
+    validateStatement(sql, params);
+    const { collation } = options;
+    if (collation !== undefined) {
+      const problem = checkCollation(collation);
+      if (problem) throw new SQLValidationError(sql, problem);
+    }
+    const raw = await (await this.httpRequest('/api/sql', {
+      method: 'POST',
+      body: this.options.json.stringify({ sql, params, collection, collation }),
+    })).text();
+    if (this.options.redact.length === 0) return raw;
+
+    const res = this.options.json.parse(raw) as SQLResponse;
+    if (res.rows) res.rows = res.rows.map(row => redact(row, this.options.redact));
+    return this.options.json.stringify(res);
+  }
+
+  /**
+   * REST: since 이후 바뀐 문서를 (updatedAt, id) 순으로 페이지 단위로 읽음 (삭제 포함)
+   *
+   *   for await (const change of client.changes('orders', cursor)) {
+   *     await backup.write(change);
+   *     cursor = change.updatedAt;
+   *   }
+   *
+   * since는 포함 조건이다. 서버 시각 해상도가 1초라, 이어받으면 마지막 초의 변경이 다시
+   * 올 수 있다 (놓치지 않는 대신 중복 가능 - id/_version으로 거르면 됨). 생략하면 처음부터.
+   */
+  async *changes(
+    collection: string,
+    since?: string | Date,
+    options: { pageSize?: number } = {},
+  ): AsyncGenerator<DocumentChange> {
+    validateCollectionName(collection);
+    let cursor = {
+      since: since instanceof Date ? since.toISOString().replace('T', ' ').slice(0, 19) : since ?? '',
+      after: '',
+    };
+
+    for (;;) {
+      const query = new URLSearchParams({ ...cursor, limit: String(options.pageSize ?? 500) });
+      const res = await this.httpFetch<{ changes: DocumentChange[]; next: typeof cursor | null }>(
+        `/api/changes/${encodeURIComponent(collection)}?${query}`,
+      );
+      for (const change of res.changes) {
+        yield { ...change, data: change.deleted ? null : this.readDoc(change.data) };
+      }
+      if (!res.next) return;
+      cursor = res.next;
+    }
+  }
+
+  /** REST: 여러 SQL 쿼리를 동시에 실행 (concurrency 상한 공유, 결과는 입력 순서) */
+  async queryAll(queries: QuerySpec[], options?: FanoutOptions): Promise<SQLResponse[]> {
+    return queryAll(this, queries, options);
+  }
+
+  /** REST: 여러 컬렉션에서 같은 조건으로 검색 (결과마다 출처 컬렉션 포함) */
+  async searchAllCollections(collections: string[], query: SearchQuery, options?: FanoutOptions): Promise<SearchHit[]> {
+    return searchAllCollections(this, collections, query, options);
+  }
+
+  /**
+   * 컬렉션의 로컬 상태를 버리고 서버에서 다시 읽음 (손상이나 오래된 불일치를 발견했을 때)
+   *
+   * offlineReads 캐시와 replay 버퍼를 비우고 subscribe를 다시 보낸 뒤, 이 컬렉션의
+   * backfillAndTail(및 이를 쓰는 subscribeCount) 구독마다 재연결 때와 같은
+   * 재동기화(source: 'resync')를 실행하고, watchComputed는 문서를 다시 읽는다.
+   * 열려 있는 CRDT 문서는 다시 열어야 한다.
+   */
+  async resync(collection: string): Promise<void> {
+    validateCollectionName(collection);
+    this.invalidateCache(collection);
+    this.replayBuffers.delete(collection);
+    if (this.subscriptions.has(collection) && this.isConnected) {
+      this.send({ type: 'subscribe', collection });
+    }
+    await Promise.all([...(this.resyncHooks.get(collection) ?? [])].map(hook => hook()));
+  }
+
+  // ===== Backfill + Tail =====
+
+  /**
+   * 기존 문서를 모두 읽은 뒤 실시간 sync 이벤트로 전환
+   *
+   * 기존 문서는 1000개씩 페이지로 마지막 페이지까지 읽는다 (list()의 첫 페이지 제한 없음).
+   * 백필 중 도착한 이벤트는 버퍼링했다가, 이미 전달한 버전 이하는 건너뛰고 재생한다.
+   * 재연결 시 끊긴 동안 놓친 변경을 REST로 다시 읽어 전달(source: 'resync')한 뒤
+   * onResynced를 호출한다. 반환된 함수를 호출하면 구독이 해제된다.
+   *
+   * initialVersions: 이미 처리한 문서 버전 (재시작 시 이어받기). 백필에서 이 버전 이하는
+   * 건너뛰고, 목록에 없는 문서는 삭제로 전달한다.
+   */
+  async backfillAndTail(
+    collection: string,
+    handler: (event: TailEvent) => void,
+    options: { onResynced?: (changed: number) => void; initialVersions?: Record<string, number> } = {},
+  ): Promise<() => void> {
+    const seen = new Map<string, number>(Object.entries(options.initialVersions ?? {}));
+    const resuming = seen.size > 0;
+    let buffer: TailEvent[] | null = [];
+    let stopped = false;
+
+    const deliver = (event: TailEvent): void => {
+      if (event.source === 'live' && (seen.get(event.id) ?? 0) >= event._version && event._version > 0) {
+        return;
+      }
+      if (event.event === 'delete') {
+        seen.delete(event.id);
+      } else {
+        seen.set(event.id, event._version);
+      }
+      handler(event);
+    };
+
+    const onSyncMessage: MessageHandler = (msg) => {
+      const m = msg as { collection?: string; event?: string; id?: string; docId?: string; data?: unknown; _version?: number };
+      if (m.collection !== collection) return;
+
+      const event: TailEvent = {
+        source: 'live',
+        event: m.event || 'update',
+        id: String(m.id ?? m.docId ?? ''),
+        data: this.readDoc(m.data),
+        _version: m._version ?? 0,
+      };
+      if (buffer) {
+        buffer.push(event);
+      } else {
+        deliver(event);
+      }
+    };
+
+    /** 목록을 읽어 새 문서/변경/삭제를 전달한 뒤 버퍼 재생, 변경 건수 반환 */
+    const fetchAndDrain = async (source: 'backfill' | 'resync'): Promise<number> => {
+      let changed = 0;
+      const docs = await listAll(this, collection);
+      const present = new Set<string>();
+
+      for (const { id, _version, ...data } of docs) {
+        present.add(id);
+        if ((source === 'resync' || resuming) && (seen.get(id) ?? 0) >= _version) continue;
+        deliver({ source, event: source === 'backfill' ? 'backfill' : 'update', id, data, _version });
+        changed++;
+      }
+
+      if (source === 'resync' || resuming) {
+        for (const id of [...seen.keys()]) {
+          if (present.has(id)) continue;
+          deliver({ source, event: 'delete', id, data: null, _version: 0 });
+          changed++;
+        }
+      }
+
+      const pending = buffer || [];
+      buffer = null;
+      for (const event of pending) {
+        deliver(event);
+      }
+      return changed;
+    };
+
+    const resync = async (): Promise<void> => {
+      if (stopped || buffer) return;
+      buffer = [];
+      try {
+        // onResynced가 없어도 재동기화는 실행 (?.() 인자 안에 두면 통째로 건너뜀)
+        const changed = await fetchAndDrain('resync');
+        options.onResynced?.(changed);
+      } catch (e) {
+        // 재동기화 실패 시 버퍼된 이벤트라도 전달
+        const pending = buffer || [];
+        buffer = null;
+        for (const event of pending) deliver(event);
+        this.onError?.(e as Error);
+        throw e;
+      }
+    };
+    const onReconnect: MessageHandler = () => {
+      resync().catch(() => {});
+    };
+
+    this.on('sync', onSyncMessage);
+    this.subscribe(collection);
+
+    const stop = (): void => {
+      stopped = true;
+      this.off('sync', onSyncMessage);
+      this.off('connected', onReconnect);
+      this.resyncHooks.get(collection)?.delete(resync);
+      this.unsubscribe(collection);
+    };
+
+    try {
+      await fetchAndDrain('backfill');
+    } catch (e) {
+      stop();
+      throw e;
+    }
+
+    this.on('connected', onReconnect);
+    if (!this.resyncHooks.has(collection)) this.resyncHooks.set(collection, new Set());
+    this.resyncHooks.get(collection)!.add(resync);
+    return stop;
+  }
+
+  /**
+   * 문서에서 계산한 값이 바뀔 때만 handler 호출
+   *
+   *   client.watchComputed('boards', 'b1',
+   *     (doc) => doc?.items.filter(i => i.status === 'open').length ?? 0,
+   *     (count) => render(count))
+   *
+   * 최초 값은 문서를 getDoc으로 읽은 뒤 한 번 전달된다 (컬렉션 전체를 읽지 않음).
+   * 이후에는 이 문서의 sync 이벤트만 반영하고, 재연결/resync 때는 문서를 다시 읽는다.
+   * 없거나 삭제된 문서는 compute(null)로 계산한다.
+   */
+  async watchComputed<T>(
+    collection: string,
+    docId: string,
+    compute: (data: any) => T,
+    handler: (value: T, previous: T | undefined) => void,
+  ): Promise<() => void> {
+    validateCollectionName(collection);
+    validateDocId(docId);
+    let hasValue = false;
+    let current: T | undefined;
+    let version = 0;
+    let buffer: Array<{ data: unknown; _version: number }> | null = [];
+    let stopped = false;
+
+    const emit = (next: T): void => {
+      if (hasValue && diff(current, next).length === 0) return;
+      const previous = current;
+      current = next;
+      hasValue = true;
+      handler(next, previous);
+    };
+
+    // 이미 반영한 버전 이하의 이벤트는 건너뜀 (삭제는 _version 0)
+    const apply = (data: unknown, _version: number): void => {
+      if (_version > 0 && _version <= version) return;
+      version = _version;
+      emit(compute(data));
+    };
+
+    const onSyncMessage: MessageHandler = (msg) => {
+      const m = msg as { collection?: string; event?: string; id?: string; docId?: string; data?: unknown; _version?: number };
+      if (m.collection !== collection || String(m.id ?? m.docId ?? '') !== docId) return;
+      const event = { data: m.event === 'delete' ? null : this.readDoc(m.data), _version: m._version ?? 0 };
+      if (buffer) {
+        buffer.push(event);
+      } else {
+        apply(event.data, event._version);
+      }
+    };
+
+    /** 문서를 읽어 값을 계산한 뒤 그동안 버퍼된 이벤트 재생 */
+    const fetchAndDrain = async (): Promise<void> => {
+      try {
+        let doc: { data: unknown; _version: number } | null = null;
+        try {
+          doc = await this.getDoc(collection, docId);
+        } catch (e) {
+          if (!(e instanceof KimDBHttpError) || e.status !== 404) throw e;
+        }
+        // 다시 읽은 값이 기준 (끊긴 동안 삭제 후 재생성되면 버전이 작아질 수 있음)
+        version = 0;
+        apply(doc ? doc.data : null, doc?._version ?? 0);
+      } finally {
+        const pending = buffer || [];
+        buffer = null;
+        for (const event of pending) apply(event.data, event._version);
+      }
+    };
+
+    const refresh = async (): Promise<void> => {
+      if (stopped || buffer) return;
+      buffer = [];
+      try {
+        await fetchAndDrain();
+      } catch (e) {
+        this.onError?.(e as Error);
+        throw e;
+      }
+    };
+    const onReconnect: MessageHandler = () => {
+      refresh().catch(() => {});
+    };
+
+    this.on('sync', onSyncMessage);
+    this.subscribe(collection);
+
+    const stop = (): void => {
+      stopped = true;
+      this.off('sync', onSyncMessage);
+      this.off('connected', onReconnect);
+      this.resyncHooks.get(collection)?.delete(refresh);
+      this.unsubscribe(collection);
+    };
+
+    try {
+      await fetchAndDrain();
+    } catch (e) {
+      stop();
+      throw e;
+    }
+
+    this.on('connected', onReconnect);
+    if (!this.resyncHooks.has(collection)) this.resyncHooks.set(collection, new Set());
+    this.resyncHooks.get(collection)!.add(refresh);
+    return stop;
+  }
+
+  /**
+   * filter에 일치하는 문서 수를 실시간으로 유지, 수가 바뀔 때만 handler 호출
+   *
+   * sync 이벤트로 일치 문서 ID 집합을 갱신하고, reconcileMs마다 COUNT 쿼리로 확인한다.
+   * 서버 값과 다르면 목록을 마지막 페이지까지 다시 읽어 집합을 맞춘다 (부분 업데이트 이벤트로 어긋난 경우).
+   */
+  async subscribeCount(
+    collection: string,
+    filter: WhereFilter,
+    handler: (count: number, previous: number | undefined) => void,
+    options: { reconcileMs?: number; onError?: (error: Error) => void } = {},
+  ): Promise<() => void> {
+    const { sql, params } = buildCountWhere(collection, this.encodeFilter(filter));
+    const matching = new Set<string>();
+    let current: number | undefined;
+
+    const matches = (data: unknown): boolean => {
+      if (filter instanceof FilterExpr) return filter.matches(data);
+      if (!data || typeof data !== 'object') return false;
+      const doc = data as Record<string, unknown>;
+      return Object.entries(filter).every(([field, value]) => matchesCondition(doc, { field, op: '=', value }));
+    };
+
+    const emit = (): void => {
+      if (matching.size === current) return;
+      const previous = current;
+      current = matching.size;
+      handler(matching.size, previous);
+    };
+
+    const reconcile = async (): Promise<void> => {
+      const res = await this.sql(collection, sql, params);
+      const cnt = (res.rows?.[0] as { cnt?: unknown } | undefined)?.cnt;
+      // COUNT(*)를 지원하지 않는 서버는 문서 목록을 돌려주므로 직접 다시 계산
+      if (typeof cnt === 'number' && cnt === matching.size) return;
+
+      const docs = await listAll(this, collection);
+      matching.clear();
+      for (const doc of docs) {
+        if (matches(doc)) matching.add(doc.id);
+      }
+      emit();
+    };
+
+    const stop = await this.backfillAndTail(collection, (event) => {
+      if (event.event !== 'delete' && matches(event.data)) matching.add(event.id);
+      else matching.delete(event.id);
+      if (event.source !== 'backfill') emit();
+    });
+    emit();
+
+    const reconcileMs = options.reconcileMs ?? 30000;
+    const timer = reconcileMs > 0
+      ? setInterval(() => {
+        reconcile().catch(e => options.onError?.(e as Error));
+      }, reconcileMs)
+      : null;
+
+    return () => {
+      if (timer) clearInterval(timer);
+      stop();
+    };
+  }
+
+  /** 문서 하나를 로컬 객체로 유지 (value 수정 후 commit으로 저장) */
+  async bindDocument<T extends Record<string, unknown>>(collection: string, docId: string, initial?: T): Promise<DocumentBinding<T>> {
+    validateDocId(docId);
+    return bindDocument(this, collection, docId, initial);
+  }
+
+  /**
+   * REST: 리스 문서 기반 리더 선출
+   *
+   *   const election = client.leaderElection('billing-cron', hostname());
+   *   election.onElected = (token) => startJobs(token);
+   *   election.onResigned = () => stopJobs();
+   *   await election.start();
+   */
+  leaderElection(name: string, candidate: string, options?: ElectionOptions): LeaderElection {
+    validateDocId(name);
+    if (options?.collection !== undefined) validateCollectionName(options.collection);
+    return new LeaderElection(this, name, candidate, options);
+  }
+
+  /** REST: 토픽 컬렉션에 메시지 발행 (메시지 ID 반환) */
+  async publish<T>(topic: string, payload: T): Promise<string> {
+    validateCollectionName(topic);
+    return publish(this, topic, payload);
+  }
+
+  /**
+   * REST: 토픽의 메시지를 그룹 단위로 소비 (at-least-once)
+   *
+   *   const consumer = client.consume<Job>('jobs', 'workers', async (m) => run(m.payload));
+   *   await consumer.start();
+   */
+  consume<T = unknown>(
+    topic: string,
+    group: string,
+    handler: (message: QueueMessage<T>) => void | Promise<void>,
+    options?: ConsumeOptions<T>,
+  ): QueueConsumer<T> {
+    validateCollectionName(topic);
+    validateDocId(group);
+    return new QueueConsumer(this, topic, group, handler, options);
+  }
+
+  /**
+   * 읽은 버전을 조건으로 여러 문서를 한 번에 쓰기, 그사이 바뀌었으면 fn을 다시 실행
+   *
+   *   await client.runTransaction(async (tx) => {
+   *     const from = await tx.get<Account>('accounts', 'a');
+   *     const to = await tx.get<Account>('accounts', 'b');
+   *     tx.set('accounts', 'a', { balance: from!.balance - 100 });
+   *     tx.set('accounts', 'b', { balance: to!.balance + 100 });
+   *   });
+   */
+  runTransaction<T>(fn: (tx: Transaction) => Promise<T>, options?: TransactionOptions): Promise<T> {
+    return runTransaction(this, fn, options);
+  }
+
+  /** REST: 문서를 불러와 수정 추적 (save는 바뀐 필드만 PATCH) */
+  async track<T extends Record<string, unknown>>(collection: string, docId: string): Promise<TrackedDocument<T>> {
+    validateCollectionName(collection);
+    validateDocId(docId);
+    return loadTracked<T>(this, collection, docId);
+  }
+
+  /** 이름 붙은 변경 스트림 소비자 (처리 위치 저장 후 이어받기) */
+  durableConsumer(name: string, collection: string, options: DurableConsumerOptions): DurableConsumer {
+    validateCollectionName(collection);
+    return new DurableConsumer(this, name, collection, options);
   }
 
   // ===== State =====
 
+  /** 연결 통계 (프레임/바이트 수, 메시지 타입별 개수, 재연결 횟수, 핸들러 실행 시간, 컬렉션별 수신량) */
+  getStats(): ConnectionStats {
+    const handlerLatency: ConnectionStats['handlerLatency'] = {};
+    for (const [type, entry] of Object.entries(this.stats.handlerLatency)) {
+      handlerLatency[type] = { ...entry };
+    }
+    const collections: ConnectionStats['collections'] = {};
+    for (const [collection, byType] of Object.entries(this.stats.collections)) {
+      collections[collection] = {};
+      for (const [type, entry] of Object.entries(byType)) collections[collection][type] = { ...entry };
+    }
+    return { ...this.stats, messagesByType: { ...this.stats.messagesByType }, handlerLatency, collections };
+  }
+
+  /** 서버 확인을 기다리는 CRDT 연산 메시지 수 */
+  get outboxSize(): number {
+    return this.outbox.size;
+  }
+
   get isConnected(): boolean {
-    return this.state.connected;
+    return this.state.status === 'connected';
+  }
+
+  get connectionStatus(): ConnectionStatus {
+    return this.state.status;
   }
 
   get clientId(): string | null {
@@ -0,0 +1,71 @@
+/**
+ * kimdb KV Store
+ *
+ * 컬렉션 위에 얹은 키-값 파사드 (세션/캐시 용도)
+ * - 키 = 문서 ID
+ * - TTL은 expiresAt 필드로 저장, 조회 시 만료 확인
+ */
+
+import type { KimDBRestAPI } from './api.js';
+import { KimDBHttpError } from './errors.js';
+
+function isNotFound(e: unknown): boolean {
+  return e instanceof KimDBHttpError && e.status === 404;
+}
+
+interface KVEntry {
+  value: unknown;
+  expiresAt: number | null;
+}
+
+export class KVStore {
+  private client: KimDBRestAPI;
+  readonly collection: string;
+
+  constructor(client: KimDBRestAPI, collection: string) {
+    this.client = client;
+    this.collection = collection;
+  }
+
+  /** 값 저장 (ttl: ms, 생략 시 만료 없음) */
+  async set(key: string, value: unknown, ttl?: number): Promise<void> {
+    const entry: KVEntry = {
+      value,
+      expiresAt: ttl && ttl > 0 ? Date.now() + ttl : null,
+    };
+    await this.client.save(this.collection, key, entry);
+  }
+
+  /** 값 조회 (없거나 만료되면 null) */
+  async get<T = unknown>(key: string): Promise<T | null> {
+    let entry: KVEntry;
+    try {
+      const res = await this.client.getDoc(this.collection, key);
+      entry = res.data as KVEntry;
+    } catch (e) {
+      if (isNotFound(e)) return null;
+      throw e;
+    }
+
+    if (!entry || typeof entry !== 'object') return null;
+
+    if (entry.expiresAt !== null && entry.expiresAt !== undefined && entry.expiresAt <= Date.now()) {
+      await this.delete(key);
+      return null;
+    }
+
+    return entry.value as T;
+  }
+
+  /** 값 삭제 (없는 키는 무시) */
+  async delete(key: string): Promise<void> {
+    try {
+      await this.client.remove(this.collection, key);
+    } catch (e) {
+      if (isNotFound(e)) return;
+      throw e;
+    }
+  }
+}
+
+export default KVStore;
@@ -0,0 +1,143 @@
+/**
+ * kimdb Data Quality Lint
+ *
+ * 컬렉션 문서를 규칙에 맞춰 검사하고 보고서 생성 (CI에서 데이터 품질 확인용)
+ * - required: 있어야 하는 필드 (null도 없는 것으로 봄)
+ * - types: 필드 타입, enums: 허용 값 목록
+ * - refs: 필드 값(배열이면 각 원소)이 다른 컬렉션의 문서 ID여야 함
+ * - 타입 규칙이 없는 필드도 문서마다 타입이 섞여 있으면 다수 타입과 다른 문서를 'mixed_type'으로 보고
+ * - sample: n개만 무작위로 검사 (큰 컬렉션), 생략하면 마지막 페이지까지 전체
+ * - refs의 대상 ID 집합은 sample과 관계없이 대상 컬렉션 전체 (일부만 보면 없는 참조로 잘못 보고)
+ * - 규칙은 JSON으로 표현 가능 (kimdb lint의 규칙 파일)
+ */
+
+import type { KimDBRestAPI } from './api.js';
+import { listAll } from './page.js';
+
+type Doc = { id: string; _version: number; [key: string]: unknown };
+
+export type FieldType = 'string' | 'number' | 'boolean' | 'object' | 'array' | 'null';
+
+export interface LintRules {
+  required?: string[];
+  /** 필드 → 허용 타입 (여러 개면 배열) */
+  types?: Record<string, FieldType | FieldType[]>;
+  /** 필드 → 허용 값 */
+  enums?: Record<string, unknown[]>;
+  refs?: Array<{ field: string; collection: string }>;
+}
+
+export interface LintIssue {
+  id: string;
+  field: string;
+  rule: 'required' | 'type' | 'enum' | 'ref' | 'mixed_type';
+  message: string;
+}
+
+export interface LintReport {
+  collection: string;
+  /** 검사한 문서 수 */
+  checked: number;
+  sampled: boolean;
+  issues: LintIssue[];
+  /** 규칙별 위반 수 */
+  counts: Record<LintIssue['rule'], number>;
+}
+
+export interface LintOptions {
+  /** 무작위로 고른 n개만 검사 */
+  sample?: number;
+  /** 보고서에 담을 최대 위반 수 (기본 1000, counts는 전체 집계) */
+  maxIssues?: number;
+}
+
+function typeOf(value: unknown): FieldType {
+  if (value === null) return 'null';
+  if (Array.isArray(value)) return 'array';
+  return typeof value === 'object' ? 'object' : typeof value as FieldType;
+}
+
+export async function lintCollection(
+  client: KimDBRestAPI,
+  collection: string,
+  rules: LintRules,
+  options: LintOptions = {},
+): Promise<LintReport> {
+  const docs: Doc[] = options.sample !== undefined
+    ? await client.sample(collection, options.sample)
+    : await listAll<Doc>(client, collection);
+
+  const maxIssues = options.maxIssues ?? 1000;
+  const report: LintReport = {
+    collection,
+    checked: docs.length,
+    sampled: options.sample !== undefined,
+    issues: [],
+    counts: { required: 0, type: 0, enum: 0, ref: 0, mixed_type: 0 },
+  };
+  const add = (issue: LintIssue): void => {
+    report.counts[issue.rule]++;
+    if (report.issues.length < maxIssues) report.issues.push(issue);
+  };
+
+  for (const doc of docs) {
+    for (const field of rules.required ?? []) {
+      if (doc[field] === undefined || doc[field] === null) {
+        add({ id: doc.id, field, rule: 'required', message: `${field} is required` });
+      }
+    }
+
+    for (const [field, allowed] of Object.entries(rules.types ?? {})) {
+      if (doc[field] === undefined) continue;
+      const actual = typeOf(doc[field]);
+      const types = Array.isArray(allowed) ? allowed : [allowed];
+      if (!types.includes(actual)) {
+        add({ id: doc.id, field, rule: 'type', message: `${field} should be ${types.join(' | ')}, got ${actual}` });
+      }
+    }
+
+    for (const [field, values] of Object.entries(rules.enums ?? {})) {
+      if (doc[field] === undefined || doc[field] === null) continue;
+      if (!values.includes(doc[field])) {
+        add({ id: doc.id, field, rule: 'enum', message: `${field} ${JSON.stringify(doc[field])} is not one of ${JSON.stringify(values)}` });
+      }
+    }
+  }
+
+  // 타입 규칙이 없는 필드의 타입 일관성
+  const seen = new Map<string, Map<FieldType, string[]>>();
+  for (const doc of docs) {
+    for (const [field, value] of Object.entries(doc)) {
+      if (field === 'id' || field === '_version' || value === null || rules.types?.[field]) continue;
+      const byType = seen.get(field) ?? new Map<FieldType, string[]>();
+      seen.set(field, byType);
+      const ids = byType.get(typeOf(value)) ?? [];
+      byType.set(typeOf(value), ids);
+      ids.push(doc.id);
+    }
+  }
+  for (const [field, byType] of seen) {
+    if (byType.size < 2) continue;
+    const [majority] = [...byType].sort((a, b) => b[1].length - a[1].length)[0];
+    for (const [type, ids] of byType) {
+      if (type === majority) continue;
+      for (const id of ids) {
+        add({ id, field, rule: 'mixed_type', message: `${field} is ${type}, most documents have ${majority}` });
+      }
+    }
+  }
+
+  for (const ref of rules.refs ?? []) {
+    const targets = new Set((await listAll(client, ref.collection)).map(d => d.id));
+    for (const doc of docs) {
+      const value = doc[ref.field];
+      if (value === undefined || value === null) continue;
+      for (const target of Array.isArray(value) ? value : [value]) {
+        if (typeof target === 'string' && targets.has(target)) continue;
+        add({ id: doc.id, field: ref.field, rule: 'ref', message: `${ref.field} ${JSON.stringify(target)} not found in ${ref.collection}` });
+      }
+    }
+  }
+
+  return report;
+}
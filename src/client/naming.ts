@@ -0,0 +1,48 @@
+/**
+ * kimdb Field Naming
+ *
+ * 앱 필드 이름과 서버 저장 이름 사이의 변환
+ * - 'preserve': 변환 없음 (기본)
+ * - 'snake_case': 보낼 때 camelCase → snake_case, 받을 때 반대로
+ * - '_'로 시작하는 예약 필드(_version 등)와 'id'는 그대로 둔다
+ */
+
+export type FieldNaming = 'preserve' | 'snake_case';
+
+export function toSnakeCase(key: string): string {
+  if (key.startsWith('_')) return key;
+  return key
+    .replace(/([a-z0-9])([A-Z])/g, '$1_$2')
+    .replace(/([A-Z]+)([A-Z][a-z])/g, '$1_$2')
+    .toLowerCase();
+}
+
+export function toCamelCase(key: string): string {
+  if (key.startsWith('_')) return key;
+  return key.replace(/_([a-z0-9])/g, (_, c: string) => c.toUpperCase());
+}
+
+/** 중첩 객체/배열의 키를 재귀적으로 변환 (값은 그대로) */
+export function mapKeys(value: unknown, fn: (key: string) => string): unknown {
+  if (Array.isArray(value)) {
+    return value.map(v => mapKeys(v, fn));
+  }
+  if (typeof value === 'object' && value !== null) {
+    const out: Record<string, unknown> = {};
+    for (const [key, v] of Object.entries(value)) {
+      out[fn(key)] = mapKeys(v, fn);
+    }
+    return out;
+  }
+  return value;
+}
+
+/** 서버로 보낼 문서 데이터 변환 */
+export function encodeFields(data: unknown, naming: FieldNaming): unknown {
+  return naming === 'snake_case' ? mapKeys(data, toSnakeCase) : data;
+}
+
+/** 서버에서 받은 문서 데이터 변환 */
+export function decodeFields(data: unknown, naming: FieldNaming): unknown {
+  return naming === 'snake_case' ? mapKeys(data, toCamelCase) : data;
+}
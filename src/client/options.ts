@@ -0,0 +1,69 @@
+/**
+ * kimdb Client Option Checks
+ *
+ * KimDBClient 생성 시, 설정 파일 로드 시 공통으로 쓰는 값 검사
+ * - url: ws:// 또는 wss:// 필수
+ * - 숫자 옵션: 음수/NaN 거부, batchSize는 1 이상 정수
+ */
+
+import type { KimDBClientOptions } from './index.js';
+
+export class ClientConfigError extends Error {
+  readonly issues: string[];
+
+  constructor(issues: string[]) {
+    super(`Invalid kimdb client config:\n  - ${issues.join('\n  - ')}`);
+    this.name = 'ClientConfigError';
+    this.issues = issues;
+  }
+}
+
+/** 값 범위 검사, 문제 목록 반환 (비어 있으면 정상) */
+export function validateClientOptions(options: Partial<KimDBClientOptions>): string[] {
+  const issues: string[] = [];
+
+  if (!options.url) {
+    issues.push('url is required');
+  } else {
+    try {
+      const { protocol } = new URL(options.url);
+      if (protocol !== 'ws:' && protocol !== 'wss:') {
+        issues.push(`url must use ws:// or wss://, got ${protocol}//`);
+      }
+    } catch {
+      issues.push(`url is not a valid URL: ${JSON.stringify(options.url)}`);
+    }
+  }
+
+  const nonNegative = [
+    'reconnectInterval',
+    'maxReconnectAttempts',
+    'batchTimeout',
+    'connectionMaxLifetime',
+    'statsInterval',
+    'slowHandlerThreshold',
+    'replayBufferSize',
+    'outboxLimit',
+    'stallTimeout',
+  ] as const;
+  for (const key of nonNegative) {
+    const v = options[key];
+    if (v !== undefined && (typeof v !== 'number' || !Number.isFinite(v) || v < 0)) {
+      issues.push(`${key} must be a non-negative number, got ${JSON.stringify(v)}`);
+    }
+  }
+  if (options.batchSize !== undefined && !(Number.isInteger(options.batchSize) && options.batchSize >= 1)) {
+    issues.push(`batchSize must be a positive integer, got ${JSON.stringify(options.batchSize)}`);
+  }
+  if (options.outboxRate !== undefined && !(typeof options.outboxRate === 'number' && options.outboxRate > 0)) {
+    issues.push(`outboxRate must be a positive number, got ${JSON.stringify(options.outboxRate)}`);
+  }
+  if (options.fieldNaming !== undefined && options.fieldNaming !== 'preserve' && options.fieldNaming !== 'snake_case') {
+    issues.push(`fieldNaming must be 'preserve' or 'snake_case', got ${JSON.stringify(options.fieldNaming)}`);
+  }
+  if (options.json !== undefined && (typeof options.json?.parse !== 'function' || typeof options.json?.stringify !== 'function')) {
+    issues.push('json must have parse and stringify functions');
+  }
+
+  return issues;
+}
@@ -0,0 +1,81 @@
+/**
+ * kimdb Collection Pages
+ *
+ * GET /api/c/:collection을 limit/skip으로 나눠 읽는 페이지 응답
+ * - total, hasMore, nextSkip은 서버가 계산 (문서 삽입 순서 기준 offset)
+ * - page.nextPage(client)로 다음 페이지, 마지막이면 null
+ * - 페이지 사이에 문서가 추가/삭제되면 건너뛰거나 겹칠 수 있음 (빠짐 없이 따라가려면 changes)
+ * - 페이지 정보가 없는 이전 서버는 한 페이지로 끝난 것으로 봄
+ * - listAll: 마지막 페이지까지 읽은 전체 문서 (list()는 서버 첫 페이지 1000개까지만)
+ */
+
+import type { KimDBClient } from './index.js';
+
+type Doc = { id: string; _version: number; [key: string]: unknown };
+
+export type PageClient = Pick<KimDBClient, 'listPage'>;
+
+export interface PageOptions {
+  /** 페이지 크기 (1~1000, 기본 100) */
+  limit?: number;
+  /** 건너뛸 문서 수 (기본 0) */
+  skip?: number;
+}
+
+/** 서버 목록 응답 중 페이지 필드 */
+export interface PageInfo {
+  total?: number;
+  skip?: number;
+  limit?: number;
+  hasMore?: boolean;
+  nextSkip?: number | null;
+}
+
+export class CollectionPage<T extends Doc = Doc> {
+  readonly collection: string;
+  readonly data: T[];
+  /** 컬렉션 전체 문서 수 (이전 서버면 null) */
+  readonly total: number | null;
+  readonly skip: number;
+  readonly limit: number;
+  readonly hasMore: boolean;
+  /** 다음 페이지의 skip (마지막 페이지면 null) */
+  readonly nextSkip: number | null;
+
+  constructor(collection: string, data: T[], info: PageInfo, requested: Required<PageOptions>) {
+    this.collection = collection;
+    this.data = data;
+    this.total = info.total ?? null;
+    this.skip = info.skip ?? requested.skip;
+    this.limit = info.limit ?? requested.limit;
+    this.hasMore = info.hasMore ?? false;
+    this.nextSkip = this.hasMore ? info.nextSkip ?? this.skip + data.length : null;
+  }
+
+  /** 같은 크기로 다음 페이지 조회 (마지막 페이지면 null) */
+  async nextPage(client: PageClient): Promise<CollectionPage<T> | null> {
+    if (this.nextSkip === null) return null;
+    return client.listPage<T>(this.collection, { limit: this.limit, skip: this.nextSkip });
+  }
+}
+
+/**
+ * 컬렉션 전체 문서 (pageSize씩 마지막 페이지까지)
+ *
+ * offset 기준이라 읽는 도중 앞쪽 문서가 삭제되면 뒤 문서를 건너뛸 수 있다.
+ * 그 사이 변경까지 놓치지 않으려면 실시간 이벤트와 함께 쓰는 backfillAndTail 사용.
+ */
+export async function listAll<T extends Doc = Doc>(
+  client: PageClient,
+  collection: string,
+  options: { pageSize?: number; signal?: AbortSignal } = {},
+): Promise<T[]> {
+  const docs: T[] = [];
+  let page: CollectionPage<T> | null = await client.listPage<T>(collection, { limit: options.pageSize ?? 1000 });
+  while (page) {
+    docs.push(...page.data);
+    options.signal?.throwIfAborted();
+    page = await page.nextPage(client);
+  }
+  return docs;
+}
@@ -0,0 +1,175 @@
+/**
+ * kimdb Partitioned Collection
+ *
+ * 시각 필드 기준으로 기간별 컬렉션(events_2025_01, ...)에 나눠 저장 (대량 이벤트 데이터)
+ * - 파티션 이름: <base>_YYYY (year), <base>_YYYY_MM (month), <base>_YYYY_MM_DD (day), UTC 기준
+ * - 쓰기: 문서의 timeField 값(epoch ms 또는 ISO 문자열)으로 파티션 결정, 값이 없으면 오류
+ * - 조회: 기간과 겹치는 파티션 중 서버에 있는 것만 동시에 읽어 시각 순으로 병합
+ *   (파티션마다 마지막 페이지까지)
+ * - 정리: dropBefore로 기간 전체가 기준보다 이른 파티션의 문서 삭제
+ */
+
+import type { KimDBRestAPI } from './api.js';
+import type { FilterExpr } from './filter.js';
+import type { SearchHit } from './fanout.js';
+import { mapLimit } from './fanout.js';
+import { docTime } from './archive.js';
+import { listAll } from './page.js';
+
+export type PartitionPeriod = 'year' | 'month' | 'day';
+
+export interface PartitionOptions {
+  /** 문서 시각 필드 (epoch ms 또는 ISO 문자열) */
+  timeField: string;
+  /** 파티션 단위 (기본 'month') */
+  period?: PartitionPeriod;
+  /** 동시에 읽을 파티션 수 (기본 4) */
+  concurrency?: number;
+}
+
+export interface PartitionQuery {
+  /** 추가 조건 (클라이언트에서 적용) */
+  filter?: FilterExpr;
+  signal?: AbortSignal;
+}
+
+type Time = Date | number | string;
+
+function toTime(value: Time): number {
+  const t = value instanceof Date ? value.getTime() : docTime(value);
+  if (t === null || Number.isNaN(t)) throw new RangeError(`Invalid time: ${String(value)}`);
+  return t;
+}
+
+const pad = (n: number): string => String(n).padStart(2, '0');
+
+export class PartitionedCollection {
+  private client: KimDBRestAPI;
+  readonly base: string;
+  readonly timeField: string;
+  readonly period: PartitionPeriod;
+  private concurrency: number;
+
+  constructor(client: KimDBRestAPI, base: string, options: PartitionOptions) {
+    this.client = client;
+    this.base = base;
+    this.timeField = options.timeField;
+    this.period = options.period ?? 'month';
+    this.concurrency = options.concurrency ?? 4;
+  }
+
+  private start(t: number): Date {
+    const d = new Date(t);
+    const y = d.getUTCFullYear();
+    if (this.period === 'year') return new Date(Date.UTC(y, 0));
+    if (this.period === 'month') return new Date(Date.UTC(y, d.getUTCMonth()));
+    return new Date(Date.UTC(y, d.getUTCMonth(), d.getUTCDate()));
+  }
+
+  private next(start: Date): Date {
+    const y = start.getUTCFullYear();
+    if (this.period === 'year') return new Date(Date.UTC(y + 1, 0));
+    if (this.period === 'month') return new Date(Date.UTC(y, start.getUTCMonth() + 1));
+    return new Date(Date.UTC(y, start.getUTCMonth(), start.getUTCDate() + 1));
+  }
+
+  private name(start: Date): string {
+    let name = `${this.base}_${start.getUTCFullYear()}`;
+    if (this.period !== 'year') name += `_${pad(start.getUTCMonth() + 1)}`;
+    if (this.period === 'day') name += `_${pad(start.getUTCDate())}`;
+    return name;
+  }
+
+  /** 이 시각이 속한 파티션 이름 */
+  partitionOf(time: Time): string {
+    return this.name(this.start(toTime(time)));
+  }
+
+  /** [from, to)와 겹치는 파티션 이름 (서버에 있는지와 무관) */
+  partitionsBetween(from: Time, to: Time): string[] {
+    const end = toTime(to);
+    const names: string[] = [];
+    for (let s = this.start(toTime(from)); s.getTime() < end; s = this.next(s)) names.push(this.name(s));
+    return names;
+  }
+
+  /** 서버에 있는 파티션 (이름 순 = 시간 순) */
+  async partitions(): Promise<string[]> {
+    const digits = { year: '\\d{4}', month: '\\d{4}_\\d{2}', day: '\\d{4}_\\d{2}_\\d{2}' }[this.period];
+    const pattern = new RegExp(`^${this.base}_${digits}$`);
+    return (await this.client.collections()).filter(c => pattern.test(c)).sort();
+  }
+
+  private route(data: unknown): string {
+    const t = docTime((data as Record<string, unknown> | null)?.[this.timeField]);
+    if (t === null) throw new RangeError(`${this.timeField} is missing or not a time`);
+    return this.partitionOf(t);
+  }
+
+  /** 문서 저장 (upsert) - timeField로 파티션 결정 */
+  async save(id: string, data: unknown): Promise<{ collection: string; id: string; _version: number }> {
+    const collection = this.route(data);
+    const res = await this.client.save(collection, id, data);
+    return { collection, id: res.id, _version: res._version };
+  }
+
+  /** 문서 생성 (ID 자동 생성) */
+  async create(data: unknown): Promise<{ collection: string; id: string; _version: number }> {
+    const collection = this.route(data);
+    const res = await this.client.create(collection, data);
+    return { collection, id: res.id, _version: res._version };
+  }
+
+  /** 문서 조회 - time은 문서의 timeField 값 (파티션 찾기용) */
+  async getDoc(id: string, time: Time): ReturnType<KimDBRestAPI['getDoc']> {
+    return this.client.getDoc(this.partitionOf(time), id);
+  }
+
+  async remove(id: string, time: Time): Promise<void> {
+    await this.client.remove(this.partitionOf(time), id);
+  }
+
+  /** [from, to) 문서를 시각 순으로 (필요한 파티션만 읽음) */
+  async query(from: Time, to: Time, options: PartitionQuery = {}): Promise<SearchHit[]> {
+    const start = toTime(from);
+    const end = toTime(to);
+    const wanted = new Set(this.partitionsBetween(start, end));
+    const targets = (await this.partitions()).filter(c => wanted.has(c));
+
+    const lists = await mapLimit(targets, this.concurrency, c => listAll(this.client, c, { signal: options.signal }), options.signal);
+    const hits: Array<SearchHit & { t: number }> = [];
+    lists.forEach((docs, i) => {
+      for (const { id, _version, ...doc } of docs) {
+        const t = docTime(doc[this.timeField]);
+        if (t === null || t < start || t >= end) continue;
+        if (options.filter && !options.filter.matches(doc)) continue;
+        hits.push({ collection: targets[i], id, _version, doc, t });
+      }
+    });
+    return hits.sort((a, b) => a.t - b.t).map(({ t: _t, ...hit }) => hit);
+  }
+
+  /** 기간 전체가 before보다 이른 파티션의 문서를 모두 삭제 */
+  async dropBefore(before: Time, signal?: AbortSignal): Promise<{ partitions: string[]; deleted: number }> {
+    const cutoff = toTime(before);
+    const old: string[] = [];
+    for (const c of await this.partitions()) {
+      const [y, m = '01', d = '01'] = c.slice(this.base.length + 1).split('_');
+      if (this.next(new Date(`${y}-${m}-${d}T00:00:00Z`)).getTime() <= cutoff) old.push(c);
+    }
+
+    let deleted = 0;
+    for (const collection of old) {
+      for (;;) {
+        signal?.throwIfAborted();
+        const { data } = await this.client.list(collection);
+        if (data.length === 0) break;
+        await mapLimit(data, this.concurrency, doc => this.client.remove(collection, doc.id), signal);
+        deleted += data.length;
+      }
+    }
+    return { partitions: old, deleted };
+  }
+}
+
+export default PartitionedCollection;
@@ -0,0 +1,90 @@
+/**
+ * kimdb REST Paths
+ *
+ * 컬렉션/문서 경로 조합 (모든 세그먼트 percent-encode)
+ *
+ * 서버 컬렉션 이름은 평면 구조이므로 계층 이름은 '__'로 이어 붙인다.
+ *   collectionPath('projects', 123, 'tasks') → 'projects__123__tasks'
+ */
+
+export const NAMESPACE_SEPARATOR = '__';
+
+/** 서버가 거부하는 컬렉션 이름/문서 ID */
+export class InvalidNameError extends Error {
+  readonly kind: 'collection' | 'id';
+  readonly value: string;
+
+  constructor(kind: 'collection' | 'id', value: string, reason: string) {
+    super(`Invalid ${kind === 'collection' ? 'collection name' : 'document id'} ${JSON.stringify(value)}: ${reason}`);
+    this.name = 'InvalidNameError';
+    this.kind = kind;
+    this.value = value;
+  }
+}
+
+/** 서버 ensureCollection 규칙과 동일: 영숫자/_ 만, '_' 또는 'sqlite'로 시작 불가 */
+export function validateCollectionName(name: string): void {
+  if (typeof name !== 'string' || name === '') {
+    throw new InvalidNameError('collection', String(name), 'must not be empty');
+  }
+  if (!/^[a-zA-Z0-9_]+$/.test(name)) {
+    throw new InvalidNameError('collection', name, 'only letters, digits and _ are allowed');
+  }
+  if (name.startsWith('_') || name.startsWith('sqlite')) {
+    throw new InvalidNameError('collection', name, "must not start with '_' or 'sqlite'");
+  }
+}
+
+/** 문서 ID는 인코딩하므로 대부분 허용, 빈 값과 경로 세그먼트('.', '..')만 거부 */
+export function validateDocId(id: string): void {
+  if (typeof id !== 'string' || id === '') {
+    throw new InvalidNameError('id', String(id), 'must not be empty');
+  }
+  if (id === '.' || id === '..') {
+    throw new InvalidNameError('id', id, 'reserved path segment');
+  }
+}
+
+/**
+ * 계층 컬렉션 이름 조합
+ *
+ * 이어 붙인 이름이 한 가지로만 나뉘도록 '__'를 포함하거나 '_'로 시작/끝나는 세그먼트는 거부
+ * ('1__x' + 'tasks'와 '1' + 'x' + 'tasks'가 같은 이름이 되는 것 방지)
+ */
+export function collectionPath(...segments: Array<string | number>): string {
+  if (segments.length === 0) {
+    throw new Error('collectionPath requires at least one segment');
+  }
+
+  const name = segments
+    .map((segment) => {
+      const s = String(segment);
+      if (s === '') {
+        throw new InvalidNameError('collection', s, 'path segment must not be empty');
+      }
+      if (s.includes(NAMESPACE_SEPARATOR)) {
+        throw new InvalidNameError('collection', s, `path segment must not contain '${NAMESPACE_SEPARATOR}'`);
+      }
+      if (s.startsWith('_') || s.endsWith('_')) {
+        throw new InvalidNameError('collection', s, "path segment must not start or end with '_'");
+      }
+      return s;
+    })
+    .join(NAMESPACE_SEPARATOR);
+  validateCollectionName(name);
+  return name;
+}
+
+/** collectionPath의 역변환 */
+export function splitCollectionPath(name: string): string[] {
+  return name.split(NAMESPACE_SEPARATOR);
+}
+
+/** /api/c/:collection[/:id] (잘못된 이름은 InvalidNameError) */
+export function docPath(collection: string, id?: string): string {
+  validateCollectionName(collection);
+  if (id !== undefined) validateDocId(id);
+
+  const base = `/api/c/${encodeURIComponent(collection)}`;
+  return id === undefined ? base : `${base}/${encodeURIComponent(id)}`;
+}
@@ -0,0 +1,125 @@
+/**
+ * kimdb Environment Promotion
+ *
+ * 두 서버의 컬렉션을 비교해 source → target으로 반영 (스테이징 → 운영 승격)
+ * - 비교 대상: 문서 내용과 보존 정책 (kimdb에는 스키마/인덱스 정의가 없음)
+ * - 기본은 검토용 diff만, apply: true일 때만 target에 쓰기
+ * - target에만 있는 문서는 deleteMissing: true일 때만 삭제
+ * - PUT은 병합이므로 source에서 빠진 필드는 null로 저장, 비교 시 null 필드 = 없는 필드
+ * - 양쪽 컬렉션 모두 마지막 페이지까지 읽은 뒤 비교 (일부만 보고 removed를 판단하지 않도록)
+ */
+
+import type { KimDBClient } from './index.js';
+import type { KimDBRestAPI } from './api.js';
+import type { RetentionPolicy } from '../shared/types.js';
+import { diff, type PatchOperation } from './diff.js';
+import { mapLimit } from './fanout.js';
+import { listAll } from './page.js';
+
+export type PromoteClient = KimDBRestAPI & Pick<KimDBClient, 'getRetentionPolicy' | 'setRetentionPolicy'>;
+
+export interface CollectionDiff {
+  collection: string;
+  /** source에만 있는 문서 */
+  added: string[];
+  /** 내용이 다른 문서 (target → source 패치) */
+  changed: Array<{ id: string; patch: PatchOperation[] }>;
+  /** target에만 있는 문서 */
+  removed: string[];
+  /** 보존 정책이 다르면 양쪽 값 */
+  retention?: { source: RetentionPolicy | null; target: RetentionPolicy | null };
+}
+
+export interface PromoteOptions {
+  /** 비교할 컬렉션 (기본: source의 모든 컬렉션) */
+  collections?: string[];
+  /** target에 반영 (기본 false = diff만) */
+  apply?: boolean;
+  /** target에만 있는 문서 삭제 (기본 false) */
+  deleteMissing?: boolean;
+  /** 동시에 보낼 쓰기 요청 수 (기본 4) */
+  concurrency?: number;
+  /** 컬렉션 하나를 비교/반영할 때마다 호출 */
+  onCollection?: (diff: CollectionDiff) => void;
+}
+
+type Fields = Record<string, unknown>;
+
+function fieldsOf(doc: { id: string; _version: number; [key: string]: unknown }): Fields {
+  const { id: _id, _version: _v, ...fields } = doc;
+  return Object.fromEntries(Object.entries(fields).filter(([, v]) => v !== null));
+}
+
+function byId(docs: Array<{ id: string; _version: number; [key: string]: unknown }>): Map<string, Fields> {
+  return new Map(docs.map(doc => [doc.id, fieldsOf(doc)]));
+}
+
+async function diffCollection(source: PromoteClient, target: PromoteClient, collection: string): Promise<{
+  diff: CollectionDiff;
+  sourceDocs: Map<string, Fields>;
+  targetDocs: Map<string, Fields>;
+}> {
+  const [s, t, sp, tp] = await Promise.all([
+    listAll(source, collection),
+    listAll(target, collection),
+    source.getRetentionPolicy(collection),
+    target.getRetentionPolicy(collection),
+  ]);
+  const sourceDocs = byId(s);
+  const targetDocs = byId(t);
+
+  const result: CollectionDiff = { collection, added: [], changed: [], removed: [] };
+  for (const [id, fields] of sourceDocs) {
+    const current = targetDocs.get(id);
+    if (!current) {
+      result.added.push(id);
+      continue;
+    }
+    const patch = diff(current, fields);
+    if (patch.length > 0) result.changed.push({ id, patch });
+  }
+  for (const id of targetDocs.keys()) {
+    if (!sourceDocs.has(id)) result.removed.push(id);
+  }
+  if (diff(sp, tp).length > 0) result.retention = { source: sp, target: tp };
+
+  return { diff: result, sourceDocs, targetDocs };
+}
+
+/** source와 target 비교, apply면 target을 source에 맞춤 */
+export async function promote(
+  source: PromoteClient,
+  target: PromoteClient,
+  options: PromoteOptions = {},
+): Promise<CollectionDiff[]> {
+  const collections = options.collections ?? await source.collections();
+  const concurrency = options.concurrency ?? 4;
+  const results: CollectionDiff[] = [];
+
+  for (const collection of collections) {
+    const { diff: d, sourceDocs, targetDocs } = await diffCollection(source, target, collection);
+
+    if (options.apply) {
+      const writes = [...d.added, ...d.changed.map(c => c.id)].map((id) => {
+        const data: Fields = { ...sourceDocs.get(id)! };
+        // target에만 있는 필드는 병합으로 남으므로 null로 덮어씀
+        for (const field of Object.keys(targetDocs.get(id) ?? {})) {
+          if (!(field in data)) data[field] = null;
+        }
+        return { id, data };
+      });
+      await mapLimit(writes, concurrency, w => target.save(collection, w.id, w.data));
+
+      if (options.deleteMissing) {
+        await mapLimit(d.removed, concurrency, id => target.remove(collection, id));
+      }
+      if (d.retention) {
+        await target.setRetentionPolicy(collection, d.retention.source);
+      }
+    }
+
+    options.onCollection?.(d);
+    results.push(d);
+  }
+  return results;
+}
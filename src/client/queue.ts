@@ -0,0 +1,175 @@
+/**
+ * kimdb Queue
+ *
+ * 컬렉션 하나를 토픽으로 쓰는 가벼운 작업 큐 (at-least-once)
+ * - publish: 토픽 컬렉션에 메시지 문서 { payload, publishedAt } 추가
+ * - 소비 그룹마다 모든 메시지를 한 번씩 받고, 그룹 안에서는 소비자 하나만 처리
+ * - 수령 상태는 `${topic}_claims` 컬렉션의 `${group}:${메시지 ID}` 문서 { visibleAt, attempts, acked }
+ *   모든 쓰기는 expectedVersion 조건부라 두 소비자가 같은 메시지를 동시에 가져가지 못함
+ * - ack 전에 visibilityMs가 지나면 다른 소비자가 다시 가져감 (처리 중 죽은 소비자 대비, 중복 처리 가능)
+ * - handler가 던지면 바로 다시 보이게 하고, maxAttempts를 넘긴 메시지는 onDeadLetter 후 ack
+ * - REST 쓰기는 실시간 이벤트가 없어 pollMs 간격으로 목록을 다시 읽음 (삽입 순서대로 처리)
+ * - 메시지는 지우지 않음 (다른 그룹이 아직 읽을 수 있음) - 정리는 setRetentionPolicy로
+ */
+
+import type { KimDBRestAPI } from './api.js';
+import { VersionConflictError } from './errors.js';
+import { listAll } from './page.js';
+import { randomId } from './id.js';
+
+export type QueueClient = Pick<KimDBRestAPI, 'listPage' | 'create' | 'save'>;
+
+export interface QueueMessage<T = unknown> {
+  id: string;
+  payload: T;
+  publishedAt: number;
+  /** 이번 수령을 포함한 시도 횟수 (1부터) */
+  attempts: number;
+}
+
+export interface ConsumeOptions<T = unknown> {
+  /** 수령한 메시지를 다른 소비자에게 숨기는 시간 (ms, 기본 30000) - handler 처리 시간보다 길어야 함 */
+  visibilityMs?: number;
+  /** 새 메시지를 확인하는 간격 (ms, 기본 1000) */
+  pollMs?: number;
+  /** 이 횟수를 넘게 실패한 메시지는 handler 대신 onDeadLetter (기본 5) */
+  maxAttempts?: number;
+  /** 소비자 이름 (수령 문서에 기록, 기본 무작위) */
+  consumer?: string;
+  onDeadLetter?: (message: QueueMessage<T>) => void | Promise<void>;
+  /** handler 실패나 요청 실패 - 다음 주기에 다시 시도 */
+  onError?: (error: Error) => void;
+}
+
+type Claim = { id: string; _version: number; visibleAt: number; attempts: number; acked: boolean };
+
+type MessageDoc<T> = { id: string; _version: number; payload: T; publishedAt: number };
+
+/** 메시지 발행 (메시지 ID 반환) */
+export async function publish<T>(client: QueueClient, topic: string, payload: T): Promise<string> {
+  const res = await client.create(topic, { payload, publishedAt: Date.now() });
+  return res.id;
+}
+
+export class QueueConsumer<T = unknown> {
+  readonly topic: string;
+  readonly group: string;
+  readonly consumer: string;
+  private client: QueueClient;
+  private handler: (message: QueueMessage<T>) => void | Promise<void>;
+  private options: ConsumeOptions<T>;
+  private claims: string;
+  private timer: ReturnType<typeof setTimeout> | null = null;
+  private running = false;
+  private current: Promise<number> | null = null;
+
+  constructor(
+    client: QueueClient,
+    topic: string,
+    group: string,
+    handler: (message: QueueMessage<T>) => void | Promise<void>,
+    options: ConsumeOptions<T> = {},
+  ) {
+    this.client = client;
+    this.topic = topic;
+    this.group = group;
+    this.handler = handler;
+    this.options = options;
+    this.consumer = options.consumer ?? randomId().slice(0, 8);
+    this.claims = `${topic}_claims`;
+  }
+
+  /** 첫 확인을 마친 뒤 resolve, 이후 pollMs마다 반복 */
+  async start(): Promise<void> {
+    if (this.running) return;
+    this.running = true;
+    await this.tick();
+  }
+
+  /** 주기 작업을 멈추고 처리 중인 메시지가 끝날 때까지 대기 */
+  async stop(): Promise<void> {
+    this.running = false;
+    if (this.timer) clearTimeout(this.timer);
+    this.timer = null;
+    await this.current?.catch(() => {});
+  }
+
+  /**
+   * 지금 보이는 메시지를 순서대로 수령해 처리 (처리한 수 반환)
+   *
+   * start 없이 직접 호출해도 됨 (크론 작업 등)
+   */
+  async poll(): Promise<number> {
+    const visibilityMs = this.options.visibilityMs ?? 30000;
+    const maxAttempts = this.options.maxAttempts ?? 5;
+    const prefix = `${this.group}:`;
+
+    const claims = new Map<string, Claim>();
+    for (const claim of await listAll<Claim>(this.client, this.claims)) {
+      if (claim.id.startsWith(prefix)) claims.set(claim.id, claim);
+    }
+    const messages = await listAll<MessageDoc<T>>(this.client, this.topic);
+
+    let handled = 0;
+    for (const doc of messages) {
+      const key = prefix + doc.id;
+      const claim = claims.get(key);
+      const now = Date.now();
+      if (claim?.acked || (claim && claim.visibleAt > now)) continue;
+
+      const attempts = (claim?.attempts ?? 0) + 1;
+      let version: number;
+      try {
+        const res = await this.client.save(
+          this.claims,
+          key,
+          { consumer: this.consumer, visibleAt: now + visibilityMs, attempts, acked: false },
+          { expectedVersion: claim?._version ?? 0 },
+        );
+        version = res._version;
+      } catch (e) {
+        // 같은 그룹의 다른 소비자가 먼저 가져감
+        if (e instanceof VersionConflictError) continue;
+        throw e;
+      }
+
+      const message: QueueMessage<T> = { id: doc.id, payload: doc.payload, publishedAt: doc.publishedAt, attempts };
+      try {
+        if (attempts > maxAttempts) await this.options.onDeadLetter?.(message);
+        else await this.handler(message);
+      } catch (e) {
+        this.options.onError?.(e as Error);
+        await this.release(key, { visibleAt: 0 }, version);
+        continue;
+      }
+      await this.release(key, { acked: true }, version);
+      handled++;
+    }
+    return handled;
+  }
+
+  /** 수령한 버전 그대로일 때만 기록 (충돌이면 visibilityMs가 지나 다른 소비자가 가져간 것) */
+  private async release(key: string, data: Partial<Claim>, version: number): Promise<void> {
+    try {
+      await this.client.save(this.claims, key, data, { expectedVersion: version });
+    } catch (e) {
+      if (!(e instanceof VersionConflictError)) throw e;
+    }
+  }
+
+  private async tick(): Promise<void> {
+    this.current = this.poll();
+    try {
+      await this.current;
+    } catch (e) {
+      this.options.onError?.(e as Error);
+    }
+    this.current = null;
+    if (!this.running) return;
+    this.timer = setTimeout(() => {
+      this.tick().catch(() => {});
+    }, this.options.pollMs ?? 1000);
+  }
+}
+
+export default QueueConsumer;
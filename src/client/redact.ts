@@ -0,0 +1,54 @@
+/**
+ * kimdb Read Redaction
+ *
+ * 클라이언트 인스턴스 단위 민감 필드 가림 (서버가 돌려줘도 앱에 전달 안 함)
+ * - path: 점 구분 필드 경로, '*'는 모든 키/배열 원소
+ *     'password', 'profile.ssn', 'cards.*.number'
+ * - action 'drop': 필드 제거, 'mask': 값 대체 (기본 '***')
+ * - 입력 값은 변경하지 않고 복사본 반환
+ */
+
+export interface RedactionRule {
+  path: string;
+  action?: 'mask' | 'drop';
+  /** mask 값 (기본: '***') */
+  mask?: unknown;
+}
+
+const DEFAULT_MASK = '***';
+
+function redactPath(value: unknown, segments: string[], rule: RedactionRule): unknown {
+  if (typeof value !== 'object' || value === null) return value;
+
+  const [head, ...rest] = segments;
+  const target: Record<string, unknown> | unknown[] = Array.isArray(value) ? [...value] : { ...value };
+  const keys = head === '*'
+    ? Object.keys(target)
+    : Object.prototype.hasOwnProperty.call(target, head) ? [head] : [];
+
+  for (const key of keys) {
+    const container = target as Record<string, unknown>;
+    if (rest.length > 0) {
+      container[key] = redactPath(container[key], rest, rule);
+    } else if (rule.action === 'drop') {
+      if (Array.isArray(target)) {
+        // 배열 원소 drop은 인덱스를 유지하기 위해 null로
+        container[key] = null;
+      } else {
+        delete container[key];
+      }
+    } else {
+      container[key] = 'mask' in rule ? rule.mask : DEFAULT_MASK;
+    }
+  }
+  return target;
+}
+
+/** 모든 규칙 적용 */
+export function redact<T>(value: T, rules: RedactionRule[]): T {
+  let out: unknown = value;
+  for (const rule of rules) {
+    out = redactPath(out, rule.path.split('.'), rule);
+  }
+  return out as T;
+}
@@ -0,0 +1,175 @@
+/**
+ * kimdb Read-Your-Writes Session
+ *
+ * 같은 세션에서 쓴 문서는 이후 읽기에서 쓰기 전 상태로 보이지 않게 (로드밸런서 뒤 노드마다 반영이 늦을 수 있음)
+ * - create/save/update/modify/remove가 성공하면 응답의 _version을 토큰에 기록 (문서별 최소 버전, 삭제는 null)
+ * - getDoc/list는 받은 _version이 토큰보다 낮거나, 지운 문서가 아직 보이면 backoff만큼 기다렸다가 다시 읽음
+ * - timeoutMs 안에 따라잡지 못하면 StaleReadError (토큰에 없는 문서는 첫 응답 그대로)
+ * - dry-run 쓰기는 기록하지 않음
+ * - token은 JSON으로 옮길 수 있음: 다른 프로세스/요청에서 new ConsistentSession(client, { token })으로 이어감
+ */
+
+import type { KimDBClient } from './index.js';
+import { exponentialBackoff, type BackoffStrategy } from './backoff.js';
+import { KimDBHttpError } from './errors.js';
+
+export type ConsistentSessionClient = Pick<KimDBClient, 'getDoc' | 'list' | 'create' | 'save' | 'update' | 'modify' | 'remove'>;
+
+/** 컬렉션 → 문서 ID → 최소 _version (null = 삭제됨) */
+export type SessionToken = Record<string, Record<string, number | null>>;
+
+export interface ConsistentSessionOptions {
+  /** 이어받을 토큰 (다른 세션의 session.token) */
+  token?: SessionToken;
+  /** 쓰기가 보일 때까지 기다리는 최대 시간 (기본 5000ms) */
+  timeoutMs?: number;
+  /** 다시 읽기 전 대기 (기본 50ms부터 2배씩, 최대 1000ms) */
+  backoff?: BackoffStrategy;
+  signal?: AbortSignal;
+}
+
+/** 토큰에 기록된 쓰기가 timeoutMs 안에 보이지 않음 */
+export class StaleReadError extends Error {
+  constructor(
+    readonly collection: string,
+    readonly id: string,
+    /** 토큰의 최소 버전 (null = 삭제되어 있어야 함) */
+    readonly expected: number | null,
+    /** 마지막으로 읽은 버전 (null = 문서 없음) */
+    readonly seen: number | null,
+  ) {
+    super(`Stale read on ${collection}/${id}: expected ${expected === null ? 'deleted' : `_version >= ${expected}`}, saw ${seen === null ? 'no document' : `_version ${seen}`}`);
+    this.name = 'StaleReadError';
+  }
+}
+
+type Versioned = { _version: number; dryRun?: boolean };
+
+/** 읽은 버전이 토큰을 만족하는지 (토큰에 없으면 항상) */
+function satisfies(expected: number | null | undefined, seen: number | null): boolean {
+  if (expected === undefined) return true;
+  if (expected === null) return seen === null;
+  return seen !== null && seen >= expected;
+}
+
+export class ConsistentSession {
+  private client: ConsistentSessionClient;
+  private options: ConsistentSessionOptions;
+  private versions = new Map<string, Map<string, number | null>>();
+
+  constructor(client: ConsistentSessionClient, options: ConsistentSessionOptions = {}) {
+    this.client = client;
+    this.options = options;
+    for (const [collection, docs] of Object.entries(options.token ?? {})) {
+      for (const [id, version] of Object.entries(docs)) this.note(collection, id, version);
+    }
+  }
+
+  /** 지금까지 쓴 문서의 최소 버전 (복사본) */
+  get token(): SessionToken {
+    const token: SessionToken = {};
+    for (const [collection, docs] of this.versions) token[collection] = Object.fromEntries(docs);
+    return token;
+  }
+
+  // ===== Writes =====
+
+  async create(...args: Parameters<ConsistentSessionClient['create']>): ReturnType<ConsistentSessionClient['create']> {
+    const res = await this.client.create(...args);
+    this.record(args[0], res.id, res);
+    return res;
+  }
+
+  async save(...args: Parameters<ConsistentSessionClient['save']>): ReturnType<ConsistentSessionClient['save']> {
+    const res = await this.client.save(...args);
+    this.record(args[0], args[1], res);
+    return res;
+  }
+
+  async update(...args: Parameters<ConsistentSessionClient['update']>): ReturnType<ConsistentSessionClient['update']> {
+    const res = await this.client.update(...args);
+    this.record(args[0], args[1], res);
+    return res;
+  }
+
+  async modify(...args: Parameters<ConsistentSessionClient['modify']>): ReturnType<ConsistentSessionClient['modify']> {
+    const res = await this.client.modify(...args);
+    this.record(args[0], args[1], res);
+    return res;
+  }
+
+  async remove(...args: Parameters<ConsistentSessionClient['remove']>): ReturnType<ConsistentSessionClient['remove']> {
+    const res = await this.client.remove(...args);
+    if (!res.dryRun) this.note(args[0], args[1], null);
+    return res;
+  }
+
+  // ===== Reads =====
+
+  /** REST: 문서 읽기 - 이 세션에서 쓴 문서면 그 쓰기가 보일 때까지 다시 읽음 (지운 문서면 404) */
+  async getDoc(collection: string, id: string): ReturnType<ConsistentSessionClient['getDoc']> {
+    const expected = this.versions.get(collection)?.get(id);
+    const { doc, error } = await this.untilSeen(
+      async () => {
+        try {
+          return { doc: await this.client.getDoc(collection, id), error: null };
+        } catch (e) {
+          // 토큰에 있는 문서의 404는 아직 반영되지 않은 노드일 수 있음
+          if (expected === undefined || !(e instanceof KimDBHttpError) || e.status !== 404) throw e;
+          return { doc: null, error: e };
+        }
+      },
+      ({ doc }) => (doc ? doc._version : null),
+      collection,
+      [id],
+    );
+    if (error) throw error;
+    return doc!;
+  }
+
+  /** REST: 컬렉션 전체 - 이 세션에서 쓰거나 지운 문서가 모두 반영된 응답이 올 때까지 다시 읽음 */
+  async list(collection: string): ReturnType<ConsistentSessionClient['list']> {
+    const ids = [...(this.versions.get(collection)?.keys() ?? [])];
+    return this.untilSeen(
+      () => this.client.list(collection),
+      (res, id) => res.data.find(doc => doc.id === id)?._version ?? null,
+      collection,
+      ids,
+    );
+  }
+
+  /** ids가 모두 토큰을 만족할 때까지 read 반복 */
+  private async untilSeen<T>(
+    read: () => Promise<T>,
+    versionOf: (result: T, id: string) => number | null,
+    collection: string,
+    ids: string[],
+  ): Promise<T> {
+    const deadline = Date.now() + (this.options.timeoutMs ?? 5000);
+    const backoff = this.options.backoff ?? exponentialBackoff({ initial: 50, max: 1000, jitter: 0 });
+    const expected = this.versions.get(collection);
+
+    for (let attempt = 1; ; attempt++) {
+      this.options.signal?.throwIfAborted();
+      const result = await read();
+      const behind = ids.find(id => !satisfies(expected?.get(id), versionOf(result, id)));
+      if (behind === undefined) return result;
+
+      const error = new StaleReadError(collection, behind, expected!.get(behind) as number | null, versionOf(result, behind));
+      const delay = backoff.nextDelay(attempt, error);
+      if (delay === null || Date.now() + delay > deadline) throw error;
+      await new Promise((resolve) => setTimeout(resolve, delay));
+    }
+  }
+
+  private record(collection: string, id: string, res: Versioned): void {
+    if (!res.dryRun) this.note(collection, id, res._version);
+  }
+
+  private note(collection: string, id: string, version: number | null): void {
+    if (!this.versions.has(collection)) this.versions.set(collection, new Map());
+    this.versions.get(collection)!.set(id, version);
+  }
+}
+
+export default ConsistentSession;
@@ -0,0 +1,221 @@
+/**
+ * kimdb Client-side Sharding
+ *
+ * 여러 kimdb 서버를 하나의 REST 클라이언트처럼 사용 (단일 인스턴스 용량을 넘는 경우)
+ * - 컬렉션 고정 배치(collections)가 우선, 그 외에는 문서 ID로 샤드 결정 (strategy)
+ * - hashStrategy: ID의 FNV-1a 해시 % 샤드 수, rangeStrategy: ID 구간 경계로 나눔
+ * - 문서 단위 요청(getDoc/save/update/remove)은 한 샤드로, create는 ID를 클라이언트에서 만들어 save
+ * - sample/SELECT는 모든 샤드에 보내 모음 (scatter-gather)
+ * - listPage는 샤드를 순서대로 이어 붙인 목록 기준 (skip/total은 전체 합)
+ *   list는 그 첫 1000개와 total/hasMore/nextSkip (단일 서버 list와 같음, 전체는 listAll)
+ *   SELECT의 ORDER BY/LIMIT은 합친 뒤 다시 적용, COUNT 같은 집계는 샤드별 행 그대로
+ * - UPDATE/DELETE는 모든 샤드에 보내 건수 합산, ID로 라우팅할 수 없는 INSERT는 거부
+ * - KimDBRestAPI를 구현하므로 KVStore, lintCollection 등에 그대로 전달 가능
+ * - 샤드 수나 경계를 바꾸면 기존 문서는 옮겨지지 않음 (copyCollection으로 재배치)
+ */
+
+import type { KimDBRestAPI } from './api.js';
+import type { WriteOptions } from './index.js';
+import type { SQLResponse } from '../shared/types.js';
+import { mapLimit } from './fanout.js';
+import { validateStatement, SQLValidationError, type SQLOptions } from './sql.js';
+import { parseOrderBy, compareBy } from './sort.js';
+import { CollectionPage, type PageOptions } from './page.js';
+import { randomId } from './id.js';
+
+type Doc = { id: string; _version: number; [key: string]: unknown };
+
+/** 문서 ID → 샤드 번호 */
+export type ShardStrategy = (collection: string, id: string) => number;
+
+export interface ShardedClientOptions {
+  /** 컬렉션 → 샤드 번호 (컬렉션 전체를 한 샤드에 둠, strategy보다 우선) */
+  collections?: Record<string, number>;
+  /** 그 외 컬렉션의 문서 배치 (기본: hashStrategy(샤드 수)) */
+  strategy?: ShardStrategy;
+  /** scatter 요청 동시 실행 수 (기본: 샤드 수) */
+  concurrency?: number;
+}
+
+/** FNV-1a 32bit 해시로 균등 분산 */
+export function hashStrategy(shardCount: number): ShardStrategy {
+  return (_collection, id) => {
+    let h = 0x811c9dc5;
+    for (let i = 0; i < id.length; i++) {
+      h ^= id.charCodeAt(i);
+      h = Math.imul(h, 0x01000193);
+    }
+    return (h >>> 0) % shardCount;
+  };
+}
+
+/**
+ * ID 구간으로 분할 - bounds[i]보다 작은 ID는 샤드 i, 마지막 경계 이상은 샤드 bounds.length
+ *
+ *   rangeStrategy(['h', 'p'])  // a-g → 0, h-o → 1, p- → 2
+ */
+export function rangeStrategy(bounds: string[]): ShardStrategy {
+  return (_collection, id) => {
+    const index = bounds.findIndex(b => id < b);
+    return index === -1 ? bounds.length : index;
+  };
+}
+
+function newId(): string {
+  return randomId().replace(/-/g, '').slice(0, 16);
+}
+
+export class ShardedClient implements KimDBRestAPI {
+  readonly shards: KimDBRestAPI[];
+  private pinned: Record<string, number>;
+  private strategy: ShardStrategy;
+  private concurrency: number;
+
+  constructor(shards: KimDBRestAPI[], options: ShardedClientOptions = {}) {
+    if (shards.length === 0) throw new RangeError('ShardedClient needs at least one shard');
+    for (const [collection, index] of Object.entries(options.collections ?? {})) {
+      if (!shards[index]) throw new RangeError(`No shard ${index} for collection ${collection}`);
+    }
+    this.shards = shards;
+    this.pinned = options.collections ?? {};
+    this.strategy = options.strategy ?? hashStrategy(shards.length);
+    this.concurrency = options.concurrency ?? shards.length;
+  }
+
+  /** 문서가 저장되는 샤드 */
+  shardFor(collection: string, id: string): KimDBRestAPI {
+    const index = this.pinned[collection] ?? this.strategy(collection, id);
+    const shard = this.shards[index];
+    if (!shard) throw new RangeError(`Strategy returned shard ${index}, only ${this.shards.length} configured`);
+    return shard;
+  }
+
+  /** 컬렉션 요청을 보낼 샤드 (고정 배치면 하나, 아니면 전체) */
+  private shardsOf(collection: string): KimDBRestAPI[] {
+    const index = this.pinned[collection];
+    return index === undefined ? this.shards : [this.shards[index]];
+  }
+
+  private scatter<R>(collection: string, fn: (shard: KimDBRestAPI) => Promise<R>): Promise<R[]> {
+    return mapLimit(this.shardsOf(collection), this.concurrency, shard => fn(shard));
+  }
+
+  async collections(): Promise<string[]> {
+    const all = await mapLimit(this.shards, this.concurrency, shard => shard.collections());
+    return [...new Set(all.flat())].sort();
+  }
+
+  async list(collection: string): ReturnType<KimDBRestAPI['list']> {
+    const page = await this.listPage(collection, { limit: 1000 });
+    return {
+      success: true,
+      collection,
+      count: page.data.length,
+      total: page.total ?? undefined,
+      skip: page.skip,
+      limit: page.limit,
+      hasMore: page.hasMore,
+      nextSkip: page.nextSkip,
+      data: page.data,
+    };
+  }
+
+  /** 샤드마다 한 번씩 요청해 total을 더하고, 전체 기준 skip부터 limit개를 앞 샤드부터 채움 */
+  async listPage<T extends Doc>(collection: string, options: PageOptions = {}): Promise<CollectionPage<T>> {
+    const requested = { limit: options.limit ?? 100, skip: options.skip ?? 0 };
+    const data: T[] = [];
+    let skip = requested.skip;
+    let total = 0;
+    for (const shard of this.shardsOf(collection)) {
+      const want = requested.limit - data.length;
+      // 페이지가 찼어도 total을 알려면 한 개는 요청해야 함
+      const page = await shard.listPage<T>(collection, { limit: Math.max(want, 1), skip });
+      const shardTotal = page.total ?? page.skip + page.data.length;
+      if (want > 0) data.push(...page.data);
+      total += shardTotal;
+      skip = Math.max(0, skip - shardTotal);
+    }
+    const hasMore = requested.skip + data.length < total;
+    return new CollectionPage(collection, data, {
+      total,
+      skip: requested.skip,
+      limit: requested.limit,
+      hasMore,
+      nextSkip: hasMore ? requested.skip + data.length : null,
+    }, requested);
+  }
+
+  async listRaw(collection: string): Promise<string> {
+    return JSON.stringify(await this.list(collection));
+  }
+
+  /** 샤드마다 n개씩 받아 섞은 뒤 n개 (샤드 크기가 크게 다르면 작은 샤드 문서가 더 자주 뽑힘) */
+  async sample(collection: string, n: number): Promise<Doc[]> {
+    const docs = (await this.scatter(collection, shard => shard.sample(collection, n))).flat();
+    for (let i = docs.length - 1; i > 0; i--) {
+      const j = Math.floor(Math.random() * (i + 1));
+      [docs[i], docs[j]] = [docs[j], docs[i]];
+    }
+    return docs.slice(0, n);
+  }
+
+  async getDoc(collection: string, id: string): ReturnType<KimDBRestAPI['getDoc']> {
+    return this.shardFor(collection, id).getDoc(collection, id);
+  }
+
+  async getDocRaw(collection: string, id: string): Promise<string> {
+    return this.shardFor(collection, id).getDocRaw(collection, id);
+  }
+
+  async create(collection: string, data: unknown): ReturnType<KimDBRestAPI['create']> {
+    const index = this.pinned[collection];
+    if (index !== undefined) return this.shards[index].create(collection, data);
+    const id = newId();
+    return this.shardFor(collection, id).save(collection, id, data);
+  }
+
+  async save(collection: string, id: string, data: unknown, options?: WriteOptions): ReturnType<KimDBRestAPI['save']> {
+    return this.shardFor(collection, id).save(collection, id, data, options);
+  }
+
+  async update(collection: string, id: string, data: unknown, options?: WriteOptions): ReturnType<KimDBRestAPI['update']> {
+    return this.shardFor(collection, id).update(collection, id, data, options);
+  }
+
+  async remove(collection: string, id: string, options?: WriteOptions): ReturnType<KimDBRestAPI['remove']> {
+    return this.shardFor(collection, id).remove(collection, id, options);
+  }
+
+  /** 샤드 하나에 고정된 컬렉션만 원문 그대로, 나머지는 합친 결과를 직렬화 */
+  async sqlRaw(collection: string, sql: string, params: unknown[] = [], options: SQLOptions = {}): Promise<string> {
+    const targets = this.shardsOf(collection);
+    if (targets.length === 1) return targets[0].sqlRaw(collection, sql, params, options);
+    return JSON.stringify(await this.sql(collection, sql, params, options));
+  }
+
+  async sql(collection: string, sql: string, params: unknown[] = [], options: SQLOptions = {}): Promise<SQLResponse> {
+    const parsed = validateStatement(sql, params);
+    const targets = this.shardsOf(collection);
+    if (targets.length === 1) return targets[0].sql(collection, sql, params, options);
+    if (parsed.type === 'INSERT') {
+      throw new SQLValidationError(sql, `INSERT cannot be routed on sharded collection ${collection}, use save()`);
+    }
+
+    const parts = await this.scatter(collection, shard => shard.sql(collection, sql, params, options));
+    if (parsed.type === 'UPDATE') {
+      return { success: true, updated: parts.reduce((n, p) => n + (p.updated ?? 0), 0) };
+    }
+    if (parsed.type === 'DELETE') {
+      return { success: true, deleted: parts.reduce((n, p) => n + (p.deleted ?? 0), 0) };
+    }
+
+    let rows = parts.flatMap(p => p.rows ?? []) as Array<Record<string, unknown>>;
+    const order = parseOrderBy(sql);
+    if (order) rows = rows.sort(compareBy(order, options.collation));
+    const limit = sql.match(/\slimit\s+(\d+)/i);
+    if (limit) rows = rows.slice(0, Number(limit[1]));
+    return { success: true, rows, rowcount: rows.length };
+  }
+}
+
+export default ShardedClient;
@@ -0,0 +1,114 @@
+/**
+ * kimdb Shared Connection
+ *
+ * 같은 서버(url + apiKey)에 대한 KimDBClient를 프로세스 내에서 공유
+ * - 세션 참조 카운트가 0이 되면 연결 종료
+ * - 컬렉션 구독도 참조 카운트로 관리 (마지막 세션이 해제할 때만 unsubscribe)
+ */
+
+import { KimDBClient, type KimDBClientOptions } from './index.js';
+
+interface PoolEntry {
+  client: KimDBClient;
+  connecting: Promise<void> | null;
+  sessions: number;
+  collections: Map<string, number>;
+}
+
+const pool = new Map<string, PoolEntry>();
+
+function poolKey(options: KimDBClientOptions): string {
+  return `${options.url}|${options.apiKey || ''}`;
+}
+
+export class SharedSession {
+  private entry: PoolEntry;
+  private key: string;
+  private collections = new Set<string>();
+  private released = false;
+
+  constructor(key: string, entry: PoolEntry) {
+    this.key = key;
+    this.entry = entry;
+  }
+
+  /** 공유 중인 클라이언트 (connect/disconnect는 직접 호출하지 말 것) */
+  get client(): KimDBClient {
+    return this.entry.client;
+  }
+
+  subscribe(collection: string): void {
+    if (this.released || this.collections.has(collection)) return;
+    this.collections.add(collection);
+
+    const count = this.entry.collections.get(collection) || 0;
+    this.entry.collections.set(collection, count + 1);
+    if (count === 0) this.entry.client.subscribe(collection);
+  }
+
+  unsubscribe(collection: string): void {
+    if (!this.collections.delete(collection)) return;
+
+    const count = (this.entry.collections.get(collection) || 1) - 1;
+    if (count === 0) {
+      this.entry.collections.delete(collection);
+      this.entry.client.unsubscribe(collection);
+    } else {
+      this.entry.collections.set(collection, count);
+    }
+  }
+
+  /** 공유 클라이언트의 컬렉션 읽기 캐시 비우기 (같은 연결의 모든 세션에 적용) */
+  invalidateCache(collection: string): void {
+    this.entry.client.invalidateCache(collection);
+  }
+
+  /** 세션 해제 - 마지막 세션이면 연결 종료 */
+  release(): void {
+    if (this.released) return;
+    for (const collection of [...this.collections]) {
+      this.unsubscribe(collection);
+    }
+    this.released = true;
+
+    this.entry.sessions--;
+    if (this.entry.sessions === 0) {
+      pool.delete(this.key);
+      this.entry.client.disconnect();
+    }
+  }
+}
+
+/**
+ * 공유 연결에서 세션 획득
+ *
+ * 첫 세션이 연결을 열고, 이후 세션은 같은 WebSocket을 재사용한다.
+ */
+export async function acquireSession(options: KimDBClientOptions): Promise<SharedSession> {
+  const key = poolKey(options);
+  let entry = pool.get(key);
+
+  if (!entry) {
+    const client = new KimDBClient(options);
+    entry = { client, connecting: null, sessions: 0, collections: new Map() };
+    const created = entry;
+    created.connecting = client.connect().finally(() => {
+      created.connecting = null;
+    });
+    pool.set(key, entry);
+  }
+
+  entry.sessions++;
+  const session = new SharedSession(key, entry);
+
+  if (entry.connecting) {
+    try {
+      await entry.connecting;
+    } catch (e) {
+      session.release();
+      throw e;
+    }
+  }
+
+  return session;
+}
@@ -0,0 +1,81 @@
+/**
+ * kimdb Editing Claim (soft lock)
+ *
+ * 문서를 편집 중이라는 표시를 presence(awareness)로 알리는 약한 잠금 ("Kim님이 편집 중")
+ * - 서버가 막지 않음: 다른 클라이언트가 보고 알아서 피하는 약속
+ * - awareness 'editing' 필드로 전파, ttl의 1/3마다 다시 보내 만료되지 않게 유지
+ * - 연결이 끊기면 서버의 presence_left, 그 외에는 ttl 만료로 자동 해제
+ * - 내가 잡고 있는 동안 다른 클라이언트도 편집을 시작하면 onContention
+ */
+
+import type { Awareness } from './awareness.js';
+
+export interface Editor {
+  nodeId: string;
+  name: string;
+  /** 편집 시작 시각 (epoch ms) */
+  since: number;
+}
+
+interface EditingState {
+  name: string;
+  since: number;
+}
+
+export class EditingClaim {
+  readonly collection: string;
+  readonly docId: string;
+  private awareness: Awareness;
+  private state: EditingState;
+  private timer: ReturnType<typeof setInterval> | null;
+  private contended = false;
+
+  /** 다른 편집자 목록이 바뀔 때 */
+  onChange?: (editors: Editor[]) => void;
+  /** 편집 중에 다른 편집자가 생김 (겹치기 시작할 때 한 번) */
+  onContention?: (editors: Editor[]) => void;
+
+  constructor(awareness: Awareness, name: string, refreshMs: number) {
+    this.collection = awareness.collection;
+    this.docId = awareness.docId;
+    this.awareness = awareness;
+    this.state = { name, since: Date.now() };
+    this.awareness.onChange = () => this.handleChange();
+    this.awareness.set('editing', this.state);
+    this.timer = setInterval(() => this.awareness.set('editing', this.state), refreshMs);
+  }
+
+  /** 잡고 있는지 (release 전) */
+  get held(): boolean {
+    return this.timer !== null;
+  }
+
+  /** 나를 제외한 편집자 (먼저 시작한 순) */
+  editors(): Editor[] {
+    const editors: Editor[] = [];
+    for (const [nodeId, state] of this.awareness.getAll()) {
+      const editing = state.editing as EditingState | undefined;
+      if (editing) editors.push({ nodeId, name: editing.name, since: editing.since });
+    }
+    return editors.sort((a, b) => a.since - b.since);
+  }
+
+  /** 편집 종료 표시 후 정리 */
+  release(): void {
+    if (!this.timer) return;
+    clearInterval(this.timer);
+    this.timer = null;
+    this.awareness.delete('editing');
+    this.awareness.destroy();
+  }
+
+  private handleChange(): void {
+    const editors = this.editors();
+    this.onChange?.(editors);
+    const contended = this.held && editors.length > 0;
+    if (contended && !this.contended) this.onContention?.(editors);
+    this.contended = contended;
+  }
+}
+
+export default EditingClaim;
@@ -0,0 +1,85 @@
+/**
+ * kimdb Sort Specification
+ *
+ * 여러 필드와 방향으로 된 정렬 조건 (앞 키가 같을 때 다음 키로 비교)
+ *
+ *   client.find('users', filter, { sort: [desc('age'), asc('name')] });
+ *   client.find('users', filter, { sort: [User.age.desc(), User.name.asc()] });
+ *
+ * - SQL에는 ORDER BY age DESC, name ASC로 직렬화 (방향은 항상 명시)
+ * - 필드 이름은 식별자만, 같은 필드를 두 번 쓰면 오류
+ * - 대소문자 무시/로캘/숫자 순 비교는 find의 collation (src/shared/collation.ts)
+ * - parseOrderBy/compareBy는 서버 대신 정렬하는 곳(FakeKimDBClient, ShardedClient)용
+ */
+
+import { SQLValidationError } from './sql.js';
+import { collator, compareValues, type Collation } from '../shared/collation.js';
+
+export type SortDirection = 'asc' | 'desc';
+
+export interface SortKey {
+  field: string;
+  direction: SortDirection;
+}
+
+/** 키 하나 또는 우선순위 순 키 목록 */
+export type SortSpec = SortKey | SortKey[];
+
+const IDENTIFIER = /^[a-z_][a-z0-9_]*$/i;
+
+export function asc(field: string): SortKey {
+  return { field, direction: 'asc' };
+}
+
+export function desc(field: string): SortKey {
+  return { field, direction: 'desc' };
+}
+
+/** 정렬 키 검사 후 배열로 (label은 오류 메시지용 SQL) */
+export function sortKeys(label: string, spec: SortSpec): SortKey[] {
+  const keys = Array.isArray(spec) ? spec : [spec];
+  if (keys.length === 0) throw new SQLValidationError(label, 'Sort must have at least one key');
+
+  const seen = new Set<string>();
+  for (const key of keys) {
+    if (!IDENTIFIER.test(key.field)) throw new SQLValidationError(label, `Invalid field name: ${key.field}`);
+    if (key.direction !== 'asc' && key.direction !== 'desc') {
+      throw new SQLValidationError(label, `Invalid sort direction for ${key.field}: ${String(key.direction)}`);
+    }
+    if (seen.has(key.field)) throw new SQLValidationError(label, `Duplicate sort field: ${key.field}`);
+    seen.add(key.field);
+  }
+  return keys;
+}
+
+/** ORDER BY 뒤에 붙일 목록 */
+export function orderByClause(label: string, spec: SortSpec): string {
+  return sortKeys(label, spec).map(k => `${k.field} ${k.direction.toUpperCase()}`).join(', ');
+}
+
+const ORDER_BY = /\sorder\s+by\s+([a-z_][a-z0-9_]*(?:\s+(?:asc|desc))?(?:\s*,\s*[a-z_][a-z0-9_]*(?:\s+(?:asc|desc))?)*)/i;
+
+/** SQL 텍스트의 ORDER BY 목록 (없으면 null) */
+export function parseOrderBy(sql: string): SortKey[] | null {
+  const match = sql.match(ORDER_BY);
+  if (!match) return null;
+  return match[1].split(',').map((part) => {
+    const [field, dir] = part.trim().split(/\s+/);
+    return { field, direction: dir?.toLowerCase() === 'desc' ? 'desc' : 'asc' };
+  });
+}
+
+/** 행 비교 함수 (Array.prototype.sort용, collation은 문자열 값끼리 비교할 때) */
+export function compareBy(
+  keys: SortKey[],
+  collation?: Collation,
+): (a: Record<string, unknown>, b: Record<string, unknown>) => number {
+  const by = collation ? collator(collation) : undefined;
+  return (a, b) => {
+    for (const { field, direction } of keys) {
+      const order = compareValues(a[field], b[field], by);
+      if (order !== 0) return direction === 'desc' ? -order : order;
+    }
+    return 0;
+  };
+}
@@ -0,0 +1,189 @@
+/**
+ * kimdb Client SQL Check
+ *
+ * /api/sql 요청 전 가벼운 구문 검사
+ * - 지원 구문: SELECT / INSERT / UPDATE / DELETE (서버 parseSql과 동일)
+ * - 파라미터(?) 개수 확인 (문자열 리터럴 내부 제외)
+ * - SQL 텍스트 기준 파싱 결과 캐시
+ */
+
+import { FilterExpr } from './filter.js';
+import { orderByClause, type SortSpec } from './sort.js';
+import type { Collation } from '../shared/collation.js';
+
+export interface ParsedStatement {
+  type: 'SELECT' | 'INSERT' | 'UPDATE' | 'DELETE';
+  table: string | null;
+  placeholders: number;
+}
+
+const MAX_CACHE = 256;
+const cache = new Map<string, ParsedStatement>();
+
+export class SQLValidationError extends Error {
+  readonly sql: string;
+
+  constructor(sql: string, message: string) {
+    super(message);
+    this.name = 'SQLValidationError';
+    this.sql = sql;
+  }
+}
+
+function countPlaceholders(sql: string): number {
+  let count = 0;
+  let quote: string | null = null;
+
+  for (let i = 0; i < sql.length; i++) {
+    const ch = sql[i];
+    if (quote) {
+      if (ch === quote) {
+        // '' 또는 "" 는 이스케이프
+        if (sql[i + 1] === quote) i++;
+        else quote = null;
+      }
+    } else if (ch === "'" || ch === '"') {
+      quote = ch;
+    } else if (ch === '?') {
+      count++;
+    }
+  }
+
+  if (quote) throw new SQLValidationError(sql, 'Unterminated string literal');
+  return count;
+}
+
+/** SQL 파싱 (캐시 사용) */
+export function parseStatement(sql: string): ParsedStatement {
+  const cached = cache.get(sql);
+  if (cached) return cached;
+
+  const trimmed = sql.trim();
+  const verb = trimmed.split(/\s+/, 1)[0].toUpperCase();
+  if (verb !== 'SELECT' && verb !== 'INSERT' && verb !== 'UPDATE' && verb !== 'DELETE') {
+    throw new SQLValidationError(sql, `Unsupported SQL: ${trimmed.slice(0, 40)}`);
+  }
+
+  const tableMatch = trimmed.match(/(?:from|into|update)\s+([a-z_][a-z0-9_]*)/i);
+  if (verb !== 'SELECT' && !tableMatch) {
+    throw new SQLValidationError(sql, `Missing table name in ${verb}`);
+  }
+  if (verb === 'UPDATE' && !/\sset\s/i.test(trimmed)) {
+    throw new SQLValidationError(sql, 'UPDATE without SET');
+  }
+  if (verb === 'INSERT' && !/\svalues\s*\(/i.test(trimmed)) {
+    throw new SQLValidationError(sql, 'INSERT without VALUES');
+  }
+
+  const parsed: ParsedStatement = {
+    type: verb,
+    table: tableMatch ? tableMatch[1] : null,
+    placeholders: countPlaceholders(trimmed),
+  };
+
+  if (cache.size >= MAX_CACHE) {
+    cache.delete(cache.keys().next().value as string);
+  }
+  cache.set(sql, parsed);
+  return parsed;
+}
+
+/** 구문 + 파라미터 개수 검사 */
+export function validateStatement(sql: string, params: unknown[] = []): ParsedStatement {
+  const parsed = parseStatement(sql);
+  if (parsed.placeholders !== params.length) {
+    throw new SQLValidationError(sql, `Expected ${parsed.placeholders} parameters, got ${params.length}`);
+  }
+  return parsed;
+}
+
+// ===== Set-based Update/Delete =====
+/** 필드 = 값 조건 객체 (AND) 또는 fieldsOf로 만든 조건식 */
+export type WhereFilter = Record<string, unknown> | FilterExpr;
+
+const IDENTIFIER = /^[a-z_][a-z0-9_]*$/i;
+
+function whereClause(sql: string, filter: WhereFilter): { clause: string; params: unknown[] } {
+  if (filter instanceof FilterExpr) return filter.toSQL();
+  const fields = Object.keys(filter);
+  if (fields.length === 0) {
+    // 빈 조건 = 컬렉션 전체, 실수 방지를 위해 거부
+    throw new SQLValidationError(sql, 'Filter must have at least one field');
+  }
+  for (const field of fields) {
+    if (!IDENTIFIER.test(field)) throw new SQLValidationError(sql, `Invalid field name: ${field}`);
+  }
+  return {
+    clause: fields.map(f => `${f} = ?`).join(' AND '),
+    params: fields.map(f => filter[f]),
+  };
+}
+
+/**
+ * filter에 일치하는 문서 전체에 patch 병합하는 UPDATE
+ *
+ * 서버 parseSql은 WHERE 파라미터를 SET보다 먼저 읽으므로 params도 그 순서로 만든다.
+ */
+export function buildUpdateWhere(
+  collection: string,
+  filter: WhereFilter,
+  patch: Record<string, unknown>,
+): { sql: string; params: unknown[] } {
+  const setFields = Object.keys(patch);
+  const label = `UPDATE ${collection}`;
+  if (setFields.length === 0) throw new SQLValidationError(label, 'Patch must have at least one field');
+  for (const field of setFields) {
+    if (!IDENTIFIER.test(field)) throw new SQLValidationError(label, `Invalid field name: ${field}`);
+  }
+
+  const where = whereClause(label, filter);
+  return {
+    sql: `UPDATE ${collection} SET ${setFields.map(f => `${f} = ?`).join(', ')} WHERE ${where.clause}`,
+    params: [...where.params, ...setFields.map(f => patch[f])],
+  };
+}
+
+/** filter에 일치하는 문서 전체를 지우는 DELETE (소프트 삭제) */
+export function buildDeleteWhere(collection: string, filter: WhereFilter): { sql: string; params: unknown[] } {
+  const where = whereClause(`DELETE FROM ${collection}`, filter);
+  return {
+    sql: `DELETE FROM ${collection} WHERE ${where.clause}`,
+    params: where.params,
+  };
+}
+
+export interface SQLOptions {
+  /** 문자열 WHERE 비교와 ORDER BY 규칙 (대소문자 무시, 로캘, 숫자 순) */
+  collation?: Collation;
+}
+
+export interface SelectOptions extends SQLOptions {
+  sort?: SortSpec;
+  limit?: number;
+}
+
+/** filter에 일치하는 문서 조회 */
+export function buildSelectWhere(
+  collection: string,
+  filter: WhereFilter,
+  options: SelectOptions = {},
+): { sql: string; params: unknown[] } {
+  const label = `SELECT * FROM ${collection}`;
+  const where = whereClause(label, filter);
+  let sql = `${label} WHERE ${where.clause}`;
+  if (options.sort !== undefined) sql += ` ORDER BY ${orderByClause(label, options.sort)}`;
+  if (options.limit !== undefined) sql += ` LIMIT ${Math.max(0, Math.floor(options.limit))}`;
+  return { sql, params: where.params };
+}
+
+/** filter에 일치하는 문서 수 (빈 filter = 컬렉션 전체) */
+export function buildCountWhere(collection: string, filter: WhereFilter): { sql: string; params: unknown[] } {
+  if (!(filter instanceof FilterExpr) && Object.keys(filter).length === 0) {
+    return { sql: `SELECT COUNT(*) AS cnt FROM ${collection}`, params: [] };
+  }
+  const where = whereClause(`SELECT COUNT(*) FROM ${collection}`, filter);
+  return {
+    sql: `SELECT COUNT(*) AS cnt FROM ${collection} WHERE ${where.clause}`,
+    params: where.params,
+  };
+}
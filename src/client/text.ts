@@ -0,0 +1,125 @@
+/**
+ * kimdb Collaborative Text
+ *
+ * CRDTDocument의 RichText 경로를 감싼 편집 API
+ * - insertAt/deleteAt → rich ops (crdt_ops로 배치 전송)
+ * - 원격 op 수신 시 로컬 커서 위치 보정
+ * - 스냅샷 복원으로 문서가 교체되면 커서를 새 길이 안으로
+ */
+
+import type { CRDTDocument } from '../crdt/index.js';
+
+type OpSink = (op: unknown) => void;
+
+interface RichOp {
+  type: string;
+  id?: string;
+  path?: string | string[];
+  richType?: string;
+}
+
+export class CollaborativeText {
+  private doc: CRDTDocument;
+  private sink: OpSink;
+  readonly path: string;
+
+  /** 로컬 커서 위치 (원격 편집에 맞춰 자동 보정) */
+  cursor = 0;
+
+  onChange?: () => void;
+
+  constructor(doc: CRDTDocument, path: string, sink: OpSink) {
+    this.doc = doc;
+    this.path = path;
+    this.sink = sink;
+  }
+
+  /** index 위치에 텍스트 삽입 */
+  insertAt(index: number, text: string, format: Record<string, unknown> = {}): void {
+    const chars = [...text];
+    for (let i = 0; i < chars.length; i++) {
+      this.sink(this.doc.richInsert(this.path, index + i, chars[i], format));
+    }
+    if (index <= this.cursor) this.cursor += chars.length;
+  }
+
+  /** index부터 length 글자 삭제 */
+  deleteAt(index: number, length = 1): void {
+    let removed = 0;
+    for (let i = 0; i < length; i++) {
+      const op = this.doc.richDelete(this.path, index);
+      if (!op) break;
+      this.sink(op);
+      removed++;
+    }
+    if (index < this.cursor) this.cursor -= Math.min(removed, this.cursor - index);
+  }
+
+  /** 범위 서식 적용 */
+  format(start: number, end: number, format: Record<string, unknown>): void {
+    for (const op of this.doc.richFormat(this.path, start, end, format)) {
+      this.sink(op);
+    }
+  }
+
+  get length(): number {
+    return this.toString().length;
+  }
+
+  toString(): string {
+    return this.doc.richGetText(this.path);
+  }
+
+  toDelta(): unknown[] {
+    return this.doc.richGetDelta(this.path);
+  }
+
+  /**
+   * 원격 op 적용 후 호출 (클라이언트가 crdt_sync 처리 시 호출)
+   *
+   * 커서 앞쪽에 삽입되면 +1, 커서 앞쪽이 삭제되면 -1
+   */
+  handleRemoteOps(ops: unknown[]): void {
+    let changed = false;
+
+    for (const raw of ops) {
+      const op = raw as RichOp;
+      if (!op.richType || !op.id || this.pathKey(op.path) !== this.path) continue;
+
+      const index = this.visibleIndexOf(op.id);
+      if (index < 0) continue;
+
+      if (op.type === 'rga_insert' && index < this.cursor) {
+        this.cursor++;
+      } else if (op.type === 'rga_delete' && index < this.cursor) {
+        this.cursor--;
+      }
+      changed = true;
+    }
+
+    if (changed) this.onChange?.();
+  }
+
+  /** 문서 전체가 교체된 뒤 호출 (스냅샷 복원) - 커서를 새 텍스트 길이 안으로 */
+  handleReset(): void {
+    this.cursor = Math.min(this.cursor, this.length);
+    this.onChange?.();
+  }
+
+  private pathKey(path: string | string[] | undefined): string {
+    return Array.isArray(path) ? path.join('.') : path || '';
+  }
+
+  /** 요소 앞에 보이는 글자 수 (삭제된 요소는 삭제 전 위치) */
+  private visibleIndexOf(id: string): number {
+    const content = this.doc.richText(this.path).content;
+    let visible = 0;
+    for (const el of content.elements) {
+      if (el.id === id) return visible;
+      if (!content.tombstones.has(el.id)) visible++;
+    }
+    return -1;
+  }
+}
+
+export default CollaborativeText;
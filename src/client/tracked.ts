@@ -0,0 +1,78 @@
+/**
+ * kimdb Tracked Document
+ *
+ * 불러온 뒤 수정한 필드만 저장하는 문서 래퍼 (필드가 많은 문서의 전송량, 충돌 범위 축소)
+ * - value를 직접 수정하고 save() - 바뀐 최상위 필드만 PATCH (중첩 필드가 바뀌면 그 최상위 필드 전체)
+ * - 삭제한 필드는 null로 저장 (서버는 병합하므로 빠진 필드는 그대로 남음)
+ * - 실시간 반영이 필요하면 bindDocument
+ */
+
+import type { KimDBRestAPI } from './api.js';
+import { fieldPatch } from './diff.js';
+
+type Doc = Record<string, unknown>;
+
+export class TrackedDocument<T extends Doc = Doc> {
+  readonly collection: string;
+  readonly id: string;
+  /** 현재 값 (직접 수정 후 save) */
+  value: T;
+  /** 마지막으로 읽거나 저장한 서버 버전 */
+  version: number;
+
+  private client: KimDBRestAPI;
+  private base: T;
+
+  constructor(client: KimDBRestAPI, collection: string, id: string, data: T, version: number) {
+    this.client = client;
+    this.collection = collection;
+    this.id = id;
+    this.version = version;
+    this.base = structuredClone(data);
+    this.value = structuredClone(data);
+  }
+
+  /** 불러온 뒤(또는 마지막 save 뒤) 바뀐 최상위 필드 */
+  changedFields(): string[] {
+    return Object.keys(fieldPatch(this.base, this.value));
+  }
+
+  get dirty(): boolean {
+    return this.changedFields().length > 0;
+  }
+
+  /** 바뀐 필드만 저장, 바뀐 것이 없으면 요청하지 않고 false */
+  async save(): Promise<boolean> {
+    const patch = fieldPatch(this.base, this.value);
+    if (Object.keys(patch).length === 0) return false;
+
+    const snapshot = structuredClone(this.value);
+    const res = await this.client.update(this.collection, this.id, patch);
+    this.base = snapshot;
+    this.version = res._version;
+    return true;
+  }
+
+  /** 저장하지 않은 수정 버리기 */
+  reset(): void {
+    this.value = structuredClone(this.base);
+  }
+}
+
+/**
+ * 문서를 불러와 수정 추적 시작
+ *
+ *   const user = await loadTracked<User>(client, 'users', 'u1');
+ *   user.value.name = 'Kim';
+ *   await user.save();  // PATCH { name: 'Kim' }
+ */
+export async function loadTracked<T extends Doc = Doc>(
+  client: KimDBRestAPI,
+  collection: string,
+  id: string,
+): Promise<TrackedDocument<T>> {
+  const doc = await client.getDoc(collection, id);
+  return new TrackedDocument(client, collection, id, (doc.data ?? {}) as T, doc._version);
+}
+
+export default TrackedDocument;
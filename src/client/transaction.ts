@@ -0,0 +1,112 @@
+/**
+ * kimdb Transactions
+ *
+ * 읽은 버전을 조건으로 여러 문서를 한 번에 쓰고, 그사이 누가 바꿨으면 처음부터 다시 실행 (Firestore runTransaction 방식)
+ * - tx.get: REST로 읽고 버전 기록 (문서가 없으면 null, 버전 0)
+ * - tx.set: 병합 쓰기를 모아 두었다가 fn이 끝나면 update_batch 하나로 커밋
+ *   읽은 문서는 읽은 버전이 조건 (expectedVersion), 읽지 않은 문서는 조건 없이 병합
+ * - 서버는 배치를 한 트랜잭션으로 적용하므로 조건 하나라도 어긋나면 전부 롤백 → VersionConflictError
+ * - 충돌이면 backoff만큼 기다린 뒤 fn을 새 Transaction으로 다시 실행 (최대 maxAttempts번), 다른 오류는 그대로 던짐
+ * - fn은 여러 번 실행될 수 있으므로 바깥 상태를 바꾸지 말 것
+ * - 읽기만 한 문서는 조건에 들어가지 않음, 삭제는 지원하지 않음 (update_batch는 병합만)
+ * - 커밋은 WebSocket이라 연결되어 있어야 함
+ */
+
+import type { KimDBClient } from './index.js';
+import { exponentialBackoff, type BackoffStrategy } from './backoff.js';
+import { KimDBHttpError, VersionConflictError } from './errors.js';
+
+export type TransactionClient = Pick<KimDBClient, 'getDoc' | 'updateBatch'>;
+
+export interface TransactionOptions {
+  /** fn 최대 실행 횟수 (기본 5) */
+  maxAttempts?: number;
+  /** 충돌 후 다시 실행하기 전 대기 (기본 50ms부터 2배씩, 최대 2000ms) */
+  backoff?: BackoffStrategy;
+  signal?: AbortSignal;
+}
+
+type Write = { collection: string; id: string; data: Record<string, unknown> };
+
+export class Transaction {
+  private client: TransactionClient;
+  private versions = new Map<string, number>();
+  private writes = new Map<string, Write>();
+
+  constructor(client: TransactionClient) {
+    this.client = client;
+  }
+
+  /** 문서 읽기 (없으면 null) - 쓰기 전에만 가능 */
+  async get<T = Record<string, unknown>>(collection: string, id: string): Promise<T | null> {
+    if (this.writes.size > 0) throw new Error('Transaction reads must come before writes');
+
+    const key = `${collection}/${id}`;
+    let doc: { data: unknown; _version: number } | null = null;
+    try {
+      doc = await this.client.getDoc(collection, id);
+    } catch (e) {
+      if (!(e instanceof KimDBHttpError) || e.status !== 404) throw e;
+    }
+    // 같은 문서를 다시 읽어도 조건은 처음 읽은 버전 (그사이 바뀌었으면 커밋이 충돌)
+    if (!this.versions.has(key)) this.versions.set(key, doc?._version ?? 0);
+    return (doc?.data as T) ?? null;
+  }
+
+  /** 병합 쓰기 예약 (커밋은 fn이 끝난 뒤) */
+  set(collection: string, id: string, data: Record<string, unknown>): void {
+    const key = `${collection}/${id}`;
+    const previous = this.writes.get(key);
+    this.writes.set(key, { collection, id, data: { ...previous?.data, ...data } });
+  }
+
+  /** 예약한 쓰기를 한 배치로 (쓰기가 없으면 아무것도 보내지 않음) */
+  async commit(): Promise<void> {
+    if (this.writes.size === 0) return;
+    await this.client.updateBatch([...this.writes].map(([key, write]) => ({
+      ...write,
+      ...(this.versions.has(key) && { expectedVersion: this.versions.get(key) }),
+    })));
+  }
+}
+
+/**
+ * fn을 실행하고 tx에 모은 쓰기를 조건부로 커밋, 버전 충돌이면 다시 실행
+ *
+ * maxAttempts번 모두 충돌하면 마지막 VersionConflictError를 던짐. 반환값은 커밋된 실행의 fn 결과.
+ */
+export async function runTransaction<T>(
+  client: TransactionClient,
+  fn: (tx: Transaction) => Promise<T>,
+  options: TransactionOptions = {},
+): Promise<T> {
+  const maxAttempts = options.maxAttempts ?? 5;
+  const backoff = options.backoff ?? exponentialBackoff({ initial: 50, max: 2000 });
+
+  for (let attempt = 1; ; attempt++) {
+    options.signal?.throwIfAborted();
+    const tx = new Transaction(client);
+    const result = await fn(tx);
+    try {
+      await tx.commit();
+      return result;
+    } catch (e) {
+      if (!(e instanceof VersionConflictError) || attempt >= maxAttempts) throw e;
+      const delay = backoff.nextDelay(attempt, e);
+      if (delay === null) throw e;
+      await new Promise<void>((resolve, reject) => {
+        const onAbort = () => {
+          clearTimeout(timer);
+          reject(options.signal!.reason);
+        };
+        const timer = setTimeout(() => {
+          options.signal?.removeEventListener('abort', onAbort);
+          resolve();
+        }, delay);
+        options.signal?.addEventListener('abort', onAbort, { once: true });
+      });
+    }
+  }
+}
+
+export default runTransaction;
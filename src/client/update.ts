@@ -0,0 +1,103 @@
+/**
+ * kimdb Update Builder
+ *
+ * 배열/중첩 필드 연산을 타입에 맞춰 만드는 빌더 (서버에서 적용, 문서 전체를 다시 쓰지 않음)
+ *
+ *   const u = updateOf<User>().push('tags', 'vip').set('profile.city', 'Seoul').inc('visits');
+ *   await client.modify('users', 'u1', u);
+ *
+ * - 경로는 점으로 이은 필드 이름 (최대 5단계까지 타입 검사), 배열 원소 경로는 지원하지 않음
+ * - 연산 규칙은 src/shared/update-ops.js
+ */
+
+import { splitPath, type UpdateOp } from '../shared/update-ops.js';
+
+type Depth = [never, 0, 1, 2, 3, 4];
+
+/** T의 점 경로 ("a", "a.b", ...) - 배열 안으로는 들어가지 않음 */
+export type Path<T, D extends number = 5> = [D] extends [never]
+  ? never
+  : T extends readonly unknown[]
+    ? never
+    : T extends object
+      ? {
+          [K in keyof T & string]-?: K | (NonNullable<T[K]> extends readonly unknown[]
+            ? never
+            : NonNullable<T[K]> extends object
+              ? `${K}.${Path<NonNullable<T[K]>, Depth[D]>}`
+              : never);
+        }[keyof T & string]
+      : never;
+
+/** 경로 P의 값 타입 (인덱스 시그니처면 unknown) */
+export type PathValue<T, P extends string> = string extends keyof T
+  ? unknown
+  : P extends `${infer K}.${infer R}`
+    ? K extends keyof T
+      ? PathValue<NonNullable<T[K]>, R>
+      : never
+    : P extends keyof T
+      ? T[P]
+      : never;
+
+/** 값이 배열인 경로 */
+export type ArrayPath<T> = string extends keyof T
+  ? string
+  : { [P in Path<T>]: NonNullable<PathValue<T, P>> extends readonly unknown[] ? P : never }[Path<T>];
+
+/** 값이 숫자인 경로 */
+export type NumberPath<T> = string extends keyof T
+  ? string
+  : { [P in Path<T>]: NonNullable<PathValue<T, P>> extends number ? P : never }[Path<T>];
+
+/** 배열 경로의 원소 타입 */
+export type ElementAt<T, P extends string> = unknown extends PathValue<T, P>
+  ? unknown
+  : NonNullable<PathValue<T, P>> extends readonly (infer E)[]
+    ? E
+    : never;
+
+export class UpdateBuilder<T = Record<string, unknown>> {
+  readonly ops: UpdateOp[] = [];
+
+  private add(op: UpdateOp): this {
+    splitPath(op.path);
+    this.ops.push(op);
+    return this;
+  }
+
+  /** 경로에 값 설정 (중간 객체가 없으면 만듦) */
+  set<P extends Path<T>>(path: P, value: PathValue<T, P>): this {
+    return this.add({ op: 'set', path, value });
+  }
+
+  /** 경로의 필드 제거 */
+  unset(path: Path<T>): this {
+    return this.add({ op: 'unset', path });
+  }
+
+  /** 배열 끝에 추가 (배열이 없으면 만듦) */
+  push<P extends ArrayPath<T>>(path: P, ...values: Array<ElementAt<T, P>>): this {
+    return this.add({ op: 'push', path, values });
+  }
+
+  /** 같은 값이 없는 것만 추가 */
+  addUnique<P extends ArrayPath<T>>(path: P, ...values: Array<ElementAt<T, P>>): this {
+    return this.add({ op: 'addUnique', path, values });
+  }
+
+  /** 같은 값 모두 제거 */
+  pull<P extends ArrayPath<T>>(path: P, ...values: Array<ElementAt<T, P>>): this {
+    return this.add({ op: 'pull', path, values });
+  }
+
+  /** 숫자 필드에 by를 더함 (필드가 없으면 by, 음수면 감소) */
+  inc(path: NumberPath<T>, by = 1): this {
+    return this.add({ op: 'inc', path, by });
+  }
+}
+
+/** 문서 타입 T에 대한 빈 빌더 */
+export function updateOf<T = Record<string, unknown>>(): UpdateBuilder<T> {
+  return new UpdateBuilder<T>();
+}
@@ -0,0 +1,65 @@
+/**
+ * kimdb Consistency Verification
+ *
+ * 로컬에 동기화해 둔 문서와 서버 문서를 체크섬 트리로 비교 (오프라인 동기화 결과 확인)
+ * - 루트 해시가 같으면 요청 한 번으로 끝남
+ * - 다르면 어긋난 버킷의 문서 해시만 받아 문서 단위로 비교 (요청당 버킷 64개)
+ * - 해시 규칙은 src/shared/checksum.js
+ */
+
+import type { KimDBClient } from './index.js';
+import { checksumTree } from '../shared/checksum.js';
+import { encodeFields, type FieldNaming } from './naming.js';
+
+export type VerifyClient = Pick<KimDBClient, 'checksum'>;
+
+export interface VerifyResult {
+  consistent: boolean;
+  /** 양쪽에 있지만 내용이 다른 문서 */
+  changed: string[];
+  /** 서버에만 있는 문서 */
+  missingLocal: string[];
+  /** 로컬에만 있는 문서 (서버에서 삭제되었거나 아직 올라가지 않음) */
+  missingRemote: string[];
+}
+
+async function sha256Hex(text: string): Promise<string> {
+  const digest = await crypto.subtle.digest('SHA-256', new TextEncoder().encode(text));
+  return Array.from(new Uint8Array(digest), b => b.toString(16).padStart(2, '0')).join('');
+}
+
+/** local: id → 문서 데이터 (id/_version 제외, 앱에서 쓰는 필드 이름) */
+export async function verifyCollection(
+  client: VerifyClient,
+  collection: string,
+  local: Record<string, unknown> | Map<string, unknown>,
+  fieldNaming: FieldNaming = 'preserve',
+): Promise<VerifyResult> {
+  const entries = local instanceof Map ? [...local] : Object.entries(local);
+  const tree = await checksumTree(entries.map(([id, data]) => [id, encodeFields(data, fieldNaming)]), sha256Hex);
+  const result: VerifyResult = { consistent: true, changed: [], missingLocal: [], missingRemote: [] };
+
+  const remote = await client.checksum(collection);
+  if (remote.root === tree.root) return result;
+
+  const buckets = [...new Set([...Object.keys(remote.buckets), ...Object.keys(tree.buckets)])]
+    .filter(b => remote.buckets[b] !== tree.buckets[b])
+    .sort();
+
+  for (let i = 0; i < buckets.length; i += 64) {
+    const chunk = buckets.slice(i, i + 64);
+    const { docs: remoteDocs } = await client.checksum(collection, chunk);
+    const localDocs: Record<string, string> = Object.assign({}, ...chunk.map(b => tree.docs[b] ?? {}));
+
+    for (const [id, hash] of Object.entries(remoteDocs)) {
+      if (!(id in localDocs)) result.missingLocal.push(id);
+      else if (localDocs[id] !== hash) result.changed.push(id);
+    }
+    for (const id of Object.keys(localDocs)) {
+      if (!(id in remoteDocs)) result.missingRemote.push(id);
+    }
+  }
+
+  result.consistent = result.changed.length + result.missingLocal.length + result.missingRemote.length === 0;
+  return result;
+}
@@ -417,7 +417,9 @@ export class RGA {
 
   insert(index, value) {
     const id = this._generateId();
-    const realIndex = this._findVisibleIndex(index - 1);
+    // 맨 앞이면 left 없음, 길이를 넘으면 마지막 요소 뒤 (원격에서도 같은 자리에 오도록 left를 항상 실제 요소로)
+    let realIndex = index > 0 ? this._findVisibleIndex(index - 1) : -1;
+    if (realIndex >= this.elements.length) realIndex = this.elements.length - 1;
     const left = realIndex >= 0 ? this.elements[realIndex]?.id : null;
 
     const element = {
@@ -1120,12 +1122,13 @@ export class CRDTDocument {
 
     if (op.type === 'map_set' || op.type === 'map_delete') {
       this.root.applyRemote(op);
+    } else if (op.type === 'rich_format' || op.richType) {
+      // Rich text ops도 rga_insert/rga_delete 타입이므로 리스트보다 먼저 확인
+      this.richText(op.path).applyRemote(op);
     } else if (op.type === 'rga_insert' || op.type === 'rga_delete') {
       this.list(op.path).applyRemote(op);
     } else if (op.type === 'lwwset_add' || op.type === 'lwwset_remove') {
       this.setCollection(op.path).applyRemote(op);
-    } else if (op.type === 'rich_format' || op.richType) {
-      this.richText(op.path).applyRemote(op);
     } else if (op.type === 'cursor_update' || op.type === 'cursor_remove') {
       this.cursors.applyRemote(op);
     }
@@ -1411,6 +1414,15 @@ export class UndoManager {
     this.pendingTimer = null;
   }
 
+  // 진행 중인 묶음을 닫음 - 다음 capture는 새 undo 단위로 시작
+  stopCapturing() {
+    if (this.pendingTimer) {
+      clearTimeout(this.pendingTimer);
+      this.pendingTimer = null;
+    }
+    this._flushPending();
+  }
+
   // Undo 가능 여부
   canUndo() {
     this._flushPending();
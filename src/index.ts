@@ -12,7 +12,123 @@ export { KimDatabase } from './server/database.js';
 
 // Re-export client
 export { KimDBClient } from './client/index.js';
-export type { KimDBClientOptions, ConnectionState } from './client/index.js';
+export type {
+  KimDBClientOptions,
+  ConnectionState,
+  ConnectionStatus,
+  ConnectionStats,
+  CollectionEventStats,
+  TailEvent,
+  DocumentChange,
+  UndoState,
+  SyncLag,
+  CallOptions,
+  WriteOptions,
+  ReadyOptions,
+  LatencyReport,
+  InboundMiddleware,
+} from './client/index.js';
+export { KVStore } from './client/kv.js';
+export { PartitionedCollection } from './client/partition.js';
+export { ShardedClient, hashStrategy, rangeStrategy } from './client/shard.js';
+export type { ShardStrategy, ShardedClientOptions } from './client/shard.js';
+export type { PartitionPeriod, PartitionOptions, PartitionQuery } from './client/partition.js';
+export { CollaborativeText } from './client/text.js';
+export { Awareness } from './client/awareness.js';
+export { EditingClaim } from './client/softlock.js';
+export type { Editor } from './client/softlock.js';
+export { LeaderElection } from './client/election.js';
+export type { ElectionClient, ElectionOptions } from './client/election.js';
+export { QueueConsumer, publish } from './client/queue.js';
+export type { QueueClient, QueueMessage, ConsumeOptions } from './client/queue.js';
+export { Transaction, runTransaction } from './client/transaction.js';
+export type { TransactionClient, TransactionOptions } from './client/transaction.js';
+export { ConsistentSession, StaleReadError } from './client/session.js';
+export type { ConsistentSessionClient, ConsistentSessionOptions, SessionToken } from './client/session.js';
+export { acquireSession, SharedSession } from './client/shared.js';
+export { JsonCodec, jsonCodec } from './client/codec.js';
+export type { Codec, JsonLibrary, WireMessage, Frame } from './client/codec.js';
+export {
+  collectionPath,
+  splitCollectionPath,
+  validateCollectionName,
+  validateDocId,
+  InvalidNameError,
+  NAMESPACE_SEPARATOR,
+} from './client/paths.js';
+export {
+  parseStatement,
+  validateStatement,
+  buildUpdateWhere,
+  buildDeleteWhere,
+  buildCountWhere,
+  buildSelectWhere,
+  SQLValidationError,
+} from './client/sql.js';
+export type { ParsedStatement, WhereFilter, SelectOptions, SQLOptions } from './client/sql.js';
+export { checkCollation } from './shared/collation.js';
+export type { Collation } from './shared/collation.js';
+export type { ChannelMessage } from './shared/channels.js';
+export type { SnapshotInfo } from './shared/snapshots.js';
+export { asc, desc } from './client/sort.js';
+export type { SortDirection, SortKey, SortSpec } from './client/sort.js';
+export { fieldsOf, FieldRef, FilterExpr } from './client/filter.js';
+export type { Fields, FilterOp, FilterCondition } from './client/filter.js';
+export { linearBackoff, exponentialBackoff } from './client/backoff.js';
+export type { BackoffStrategy, ExponentialBackoffOptions } from './client/backoff.js';
+export { KimDBHttpError, ValidationError, VersionConflictError } from './client/errors.js';
+export type { ValidationIssue } from './client/errors.js';
+export type { KimDBClientAPI, KimDBRestAPI, KimDBSocketAPI } from './client/api.js';
+export { FakeKimDBClient, createFakeClient } from './client/fake.js';
+export { chaosFetch, chaosWebSocket } from './client/chaos.js';
+export type { ChaosScenario, ChaosSocketScenario } from './client/chaos.js';
+export { debugFetch, debugWebSocket } from './client/debug.js';
+export type { DebugOptions } from './client/debug.js';
+export { clientConfigFromEnv, loadClientConfig, validateClientOptions, ClientConfigError } from './client/config.js';
+export type { AwarenessState, AwarenessOptions } from './client/awareness.js';
+export { diff, applyPatch, fieldPatch, PatchError } from './client/diff.js';
+export type { PatchOperation } from './client/diff.js';
+export { toSnakeCase, toCamelCase } from './client/naming.js';
+export type { FieldNaming } from './client/naming.js';
+export { redact } from './client/redact.js';
+export type { RedactionRule } from './client/redact.js';
+export { deleteCascade } from './client/cascade.js';
+export {
+  DurableConsumer,
+  MemoryPositionStore,
+  MemoryDedupStore,
+  KVDedupStore,
+  eventKey,
+  partitionOf,
+} from './client/consumer.js';
+export type { PositionStore, Positions, DedupStore, DurableConsumerOptions } from './client/consumer.js';
+export { Group } from './client/group.js';
+export { DocumentBinding, bindDocument } from './client/binding.js';
+export { TrackedDocument, loadTracked } from './client/tracked.js';
+export { UpdateBuilder, updateOf } from './client/update.js';
+export type { Path, PathValue, ArrayPath, ElementAt, NumberPath } from './client/update.js';
+export { applyUpdateOps, UpdateOpError } from './shared/update-ops.js';
+export type { UpdateOp } from './shared/update-ops.js';
+export { ReadCache, isUnreachable } from './client/cache.js';
+export { archiveCollection, collectionSink, MemoryCheckpointStore, KVCheckpointStore } from './client/archive.js';
+export type { ArchiveSink, ArchivedDoc, ArchiveCheckpoint, CheckpointStore, ArchiveOptions } from './client/archive.js';
+export { copyCollection } from './client/copy.js';
+export type { CopyOptions, CopyResult } from './client/copy.js';
+export { lintCollection } from './client/lint.js';
+export { verifyCollection } from './client/verify.js';
+export { CollectionPage, listAll } from './client/page.js';
+export type { PageClient, PageOptions, PageInfo } from './client/page.js';
+export type { VerifyClient, VerifyResult } from './client/verify.js';
+export { canonicalJSON, checksumTree } from './shared/checksum.js';
+export type { ChecksumTree } from './shared/checksum.js';
+export type { LintRules, LintOptions, LintReport, LintIssue, FieldType } from './client/lint.js';
+export { promote } from './client/promote.js';
+export type { PromoteClient, PromoteOptions, CollectionDiff } from './client/promote.js';
+export type { Staleness, OfflineReadOptions } from './client/cache.js';
+export type { BindingClient, BindingChange } from './client/binding.js';
+export { queryAll, mapLimit, searchAllCollections } from './client/fanout.js';
+export type { QuerySpec, FanoutOptions, SearchQuery, SearchHit } from './client/fanout.js';
+export type { RefSpec, CascadeOptions, CascadeResult } from './client/cascade.js';
 
 // Re-export CRDT
 export {
@@ -41,8 +157,18 @@ export type {
   WSMessage,
   SQLRequest,
   SQLResponse,
+  RetentionPolicy,
   ServerMetrics,
   PresenceUser,
+  WSServerEventMap,
+  WSSyncMessage,
+  WSCRDTSyncMessage,
+  WSPresenceUsersMessage,
+  WSPresenceUpdatedMessage,
+  WSPresenceLeftMessage,
+  WSErrorMessage,
+  WSBatchAckMessage,
+  WSCRDTOpsOkMessage,
 } from './shared/types.js';
 
 // Default export
@@ -170,7 +170,9 @@ class RGA {
 
   insert(index, value) {
     const id = this._generateId();
-    const realIndex = this._findVisibleIndex(index - 1);
+    // 맨 앞이면 left 없음, 길이를 넘으면 마지막 요소 뒤 (원격에서도 같은 자리에 오도록 left를 항상 실제 요소로)
+    let realIndex = index > 0 ? this._findVisibleIndex(index - 1) : -1;
+    if (realIndex >= this.elements.length) realIndex = this.elements.length - 1;
     const left = realIndex >= 0 ? this.elements[realIndex]?.id : null;
     const element = { id, value, deleted: false, clock: this.clock.clone().toJSON(), left };
 
@@ -8,7 +8,23 @@ import Database from 'better-sqlite3';
 import { join } from 'path';
 import { mkdirSync, existsSync } from 'fs';
 import type { Config } from './config.js';
-import type { DocumentRow, Collection } from '../shared/types.js';
+import type { DocumentRow, Collection, RetentionPolicy } from '../shared/types.js';
+import type { SnapshotInfo } from '../shared/snapshots.js';
+import { applyUpdateOps, type UpdateOp } from '../shared/update-ops.js';
+import { VERSION_QUERY_CHUNK } from '../shared/versions.js';
+import { getRetentionPolicy, applyRetention } from '../shared/retention.js';
+
+/** expectedVersion 불일치 (updateBatch면 배치 전체 롤백) */
+export class BatchVersionConflict extends Error {
+  constructor(
+    readonly collection: string,
+    readonly id: string,
+    readonly expected: number,
+    readonly current: number,
+  ) {
+    super(`Version conflict on ${collection}/${id}: expected ${expected}, current ${current}`);
+  }
+}
 
 export class KimDatabase {
   private db: Database.Database;
@@ -58,6 +74,24 @@ export class KimDatabase {
         ts INTEGER NOT NULL
       );
       CREATE INDEX IF NOT EXISTS idx_sync_log_ts ON _sync_log(collection, ts);
+
+      CREATE TABLE IF NOT EXISTS _retention (
+        collection TEXT PRIMARY KEY,
+        max_age_ms INTEGER,
+        max_documents INTEGER,
+        archive_to TEXT,
+        updated_at TEXT DEFAULT CURRENT_TIMESTAMP
+      );
+
+      CREATE TABLE IF NOT EXISTS _snapshots (
+        collection TEXT NOT NULL,
+        doc_id TEXT NOT NULL,
+        label TEXT NOT NULL,
+        state TEXT NOT NULL,
+        version INTEGER NOT NULL,
+        created_at INTEGER NOT NULL,
+        PRIMARY KEY (collection, doc_id, label)
+      );
     `);
 
     console.log('[kimdb] Database schema initialized');
@@ -122,11 +156,62 @@ export class KimDatabase {
   /**
    * 문서 목록 조회
    */
-  getDocuments(collection: string, limit = 1000): DocumentRow[] {
+  getDocuments(collection: string, limit = 1000, skip = 0): DocumentRow[] {
     const col = this.ensureCollection(collection);
     return this.db.prepare(
-      `SELECT id, data, crdt_state, _version FROM ${col} WHERE _deleted = 0 AND id != '_index' LIMIT ?`
-    ).all(limit) as DocumentRow[];
+      `SELECT id, data, crdt_state, _version FROM ${col} WHERE _deleted = 0 AND id != '_index' ORDER BY rowid LIMIT ? OFFSET ?`
+    ).all(limit, skip) as DocumentRow[];
+  }
+
+  /**
+   * 문서 수 (페이지 total용)
+   */
+  countDocuments(collection: string): number {
+    const col = this.ensureCollection(collection);
+    return (this.db.prepare(
+      `SELECT COUNT(*) AS n FROM ${col} WHERE _deleted = 0 AND id != '_index'`
+    ).get() as { n: number }).n;
+  }
+
+  /**
+   * 문서별 현재 버전 (삭제/없는 문서는 빠짐)
+   */
+  getVersions(collection: string, ids: string[]): Map<string, number> {
+    const col = this.ensureCollection(collection);
+    const versions = new Map<string, number>();
+    // SQLite 바인딩 변수 제한(999) 아래로 나눠 조회
+    for (let i = 0; i < ids.length; i += VERSION_QUERY_CHUNK) {
+      const chunk = ids.slice(i, i + VERSION_QUERY_CHUNK);
+      const rows = this.db.prepare(
+        `SELECT id, _version FROM ${col} WHERE _deleted = 0 AND id IN (${chunk.map(() => '?').join(',')})`
+      ).all(...chunk) as Array<{ id: string; _version: number }>;
+      for (const row of rows) versions.set(row.id, row._version);
+    }
+    return versions;
+  }
+
+  /**
+   * since(updated_at, 포함) 이후 바뀐 문서 - 삭제된 문서 포함, (updated_at, id) 순
+   *
+   * after: 같은 updated_at 안에서 이 ID 다음부터 (페이지 이어받기)
+   */
+  getChanges(collection: string, since: string, after: string | null, limit: number): DocumentRow[] {
+    const col = this.ensureCollection(collection);
+    return this.db.prepare(`
+      SELECT id, data, _version, _deleted, updated_at FROM ${col}
+      WHERE id != '_index' AND (updated_at > ? OR (updated_at = ? AND id > ?))
+      ORDER BY updated_at, id LIMIT ?
+    `).all(since, since, after ?? '', limit) as DocumentRow[];
+  }
+
+  /**
+   * 무작위 문서 n개 (SQLite RANDOM)
+   */
+  sampleDocuments(collection: string, n: number): DocumentRow[] {
+    const col = this.ensureCollection(collection);
+    return this.db.prepare(
+      `SELECT id, data, crdt_state, _version FROM ${col} WHERE _deleted = 0 AND id != '_index' ORDER BY RANDOM() LIMIT ?`
+    ).all(n) as DocumentRow[];
   }
 
   /**
@@ -145,6 +230,94 @@ export class KimDatabase {
     `).run(id, data, crdtState || null);
   }
 
+  /**
+   * 여러 문서 병합 업데이트 (upsert) - 하나의 트랜잭션, 하나라도 실패하면 전체 롤백
+   *
+   * expectedVersion이 있는 op는 현재 버전이 같을 때만 (0 = 문서가 없어야 함), 아니면 BatchVersionConflict
+   */
+  updateBatch(ops: Array<{ collection: string; id: string; data: Record<string, unknown>; expectedVersion?: number }>): Array<{
+    collection: string;
+    id: string;
+    data: Record<string, unknown>;
+    _version: number;
+  }> {
+    const apply = this.db.transaction(() => ops.map((op) => {
+      const col = this.ensureCollection(op.collection);
+      const existing = this.db.prepare(
+        `SELECT data, _version FROM ${col} WHERE id = ? AND _deleted = 0`
+      ).get(op.id) as { data: string; _version: number } | undefined;
+      const current = existing ? existing._version : 0;
+      if (op.expectedVersion !== undefined && op.expectedVersion !== current) {
+        throw new BatchVersionConflict(op.collection, op.id, op.expectedVersion, current);
+      }
+
+      if (existing) {
+        const merged = { ...JSON.parse(existing.data), ...op.data };
+        this.db.prepare(
+          `UPDATE ${col} SET data = ?, _version = _version + 1, updated_at = CURRENT_TIMESTAMP WHERE id = ?`
+        ).run(JSON.stringify(merged), op.id);
+        return { collection: col, id: op.id, data: merged, _version: existing._version + 1 };
+      }
+
+      // soft delete된 행이 남아 있을 수 있으므로 upsert
+      this.db.prepare(`
+        INSERT INTO ${col} (id, data, _version, _deleted, created_at, updated_at)
+        VALUES (?, ?, 1, 0, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+        ON CONFLICT(id) DO UPDATE SET data = excluded.data, _version = 1, _deleted = 0, updated_at = CURRENT_TIMESTAMP
+      `).run(op.id, JSON.stringify(op.data));
+      return { collection: col, id: op.id, data: { ...op.data }, _version: 1 };
+    }));
+    return apply();
+  }
+
+  /**
+   * 문서 데이터 교체 (upsert, crdt_state는 그대로) - 새 _version 반환
+   *
+   * expectedVersion이 있으면 현재 버전이 같을 때만 (0 = 문서가 없어야 함), 아니면 BatchVersionConflict
+   * soft delete된 행이 남아 있으면 되살리고 버전은 1부터 다시
+   */
+  replaceDocument(collection: string, id: string, data: Record<string, unknown>, expectedVersion?: number): number {
+    const col = this.ensureCollection(collection);
+    const existing = this.db.prepare(
+      `SELECT _version FROM ${col} WHERE id = ? AND _deleted = 0`
+    ).get(id) as { _version: number } | undefined;
+    const current = existing ? existing._version : 0;
+    if (expectedVersion !== undefined && expectedVersion !== current) {
+      throw new BatchVersionConflict(collection, id, expectedVersion, current);
+    }
+
+    if (existing) {
+      this.db.prepare(
+        `UPDATE ${col} SET data = ?, _version = _version + 1, updated_at = CURRENT_TIMESTAMP WHERE id = ?`
+      ).run(JSON.stringify(data), id);
+      return current + 1;
+    }
+    this.db.prepare(`
+      INSERT INTO ${col} (id, data, _version, _deleted, created_at, updated_at)
+      VALUES (?, ?, 1, 0, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+      ON CONFLICT(id) DO UPDATE SET data = excluded.data, _version = 1, _deleted = 0, updated_at = CURRENT_TIMESTAMP
+    `).run(id, JSON.stringify(data));
+    return 1;
+  }
+
+  /**
+   * 부분 업데이트 - 저장된 데이터에 data 병합 후 ops 적용, 새 _version 반환
+   *
+   * 문서가 없으면 {}에서 시작해 생성 (increment/addToSet이 첫 쓰기여도 되도록)
+   * 잘못된 ops는 UpdateOpError, 버전 조건은 replaceDocument와 같음
+   */
+  patchDocument(
+    collection: string,
+    id: string,
+    data: Record<string, unknown> | undefined,
+    ops: UpdateOp[] | undefined,
+    expectedVersion?: number,
+  ): number {
+    const existing = this.getDocument(collection, id);
+    const merged = { ...(existing ? JSON.parse(existing.data) : {}), ...data };
+    return this.replaceDocument(collection, id, ops ? applyUpdateOps(merged, ops) : merged, expectedVersion);
+  }
+
   /**
    * 문서 삭제 (soft delete)
    */
@@ -156,6 +329,82 @@ export class KimDatabase {
     return result.changes > 0;
   }
 
+  /**
+   * 보존 정책 조회 (없으면 null)
+   */
+  getRetentionPolicy(collection: string): RetentionPolicy | null {
+    return getRetentionPolicy(this.db, collection);
+  }
+
+  /**
+   * 보존 정책 저장 (null이면 삭제)
+   */
+  setRetentionPolicy(collection: string, policy: RetentionPolicy | null): void {
+    const col = this.ensureCollection(collection);
+    if (!policy) {
+      this.db.prepare(`DELETE FROM _retention WHERE collection = ?`).run(col);
+      return;
+    }
+    if (policy.archiveTo) this.ensureCollection(policy.archiveTo);
+    this.db.prepare(`
+      INSERT INTO _retention (collection, max_age_ms, max_documents, archive_to, updated_at)
+      VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP)
+      ON CONFLICT(collection) DO UPDATE SET
+        max_age_ms = excluded.max_age_ms,
+        max_documents = excluded.max_documents,
+        archive_to = excluded.archive_to,
+        updated_at = CURRENT_TIMESTAMP
+    `).run(col, policy.maxAgeMs ?? null, policy.maxDocuments ?? null, policy.archiveTo ?? null);
+  }
+
+  /**
+   * 정책이 있는 컬렉션 목록
+   */
+  getRetentionCollections(): string[] {
+    return (this.db.prepare(`SELECT collection FROM _retention`).all() as Array<{ collection: string }>)
+      .map(r => r.collection);
+  }
+
+  /**
+   * 보존 정책 적용 - 만료/초과 문서를 archiveTo에 복사한 뒤 soft delete, 삭제한 ID 반환
+   */
+  applyRetention(collection: string, now = Date.now()): string[] {
+    return applyRetention(this.db, collection, (name) => this.ensureCollection(name), now);
+  }
+
+  /**
+   * 문서 스냅샷 저장 (state: CRDT toJSON 결과) - 같은 라벨이 이미 있으면 null
+   */
+  createSnapshot(collection: string, docId: string, label: string, state: { version?: number }, now = Date.now()): SnapshotInfo | null {
+    const version = state.version || 0;
+    const result = this.db.prepare(`
+      INSERT INTO _snapshots (collection, doc_id, label, state, version, created_at)
+      VALUES (?, ?, ?, ?, ?, ?)
+      ON CONFLICT(collection, doc_id, label) DO NOTHING
+    `).run(collection, docId, label, JSON.stringify(state), version, now);
+    return result.changes > 0 ? { label, version, createdAt: now } : null;
+  }
+
+  /**
+   * 문서 스냅샷 목록 (최신 먼저)
+   */
+  listSnapshots(collection: string, docId: string): SnapshotInfo[] {
+    return this.db.prepare(`
+      SELECT label, version, created_at AS createdAt FROM _snapshots
+      WHERE collection = ? AND doc_id = ? ORDER BY created_at DESC, rowid DESC
+    `).all(collection, docId) as SnapshotInfo[];
+  }
+
+  /**
+   * 스냅샷 상태 조회 (없으면 null)
+   */
+  getSnapshotState(collection: string, docId: string, label: string): Record<string, unknown> | null {
+    const row = this.db.prepare(
+      `SELECT state FROM _snapshots WHERE collection = ? AND doc_id = ? AND label = ?`
+    ).get(collection, docId, label) as { state: string } | undefined;
+    return row ? JSON.parse(row.state) : null;
+  }
+
   /**
    * 동기화 로그 추가
    */
@@ -198,6 +447,23 @@ export class KimDatabase {
     return this.db.prepare(sql).run(...params);
   }
 
+  /**
+   * dry-run: fn을 트랜잭션 안에서 실행한 뒤 롤백하고 결과만 반환 (검증용, 저장하지 않음)
+   */
+  rehearse<T>(fn: () => T): T {
+    let result!: T;
+    const rollback = new Error('dry run');
+    try {
+      this.db.transaction(() => {
+        result = fn();
+        throw rollback;
+      })();
+    } catch (e) {
+      if (e !== rollback) throw e;
+    }
+    return result;
+  }
+
   /**
    * WAL 체크포인트
    */
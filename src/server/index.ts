@@ -8,19 +8,31 @@
 // eslint-disable-next-line @typescript-eslint/no-var-requires
 const nexusLog = require('../../../kimnexus-log.js')('kimdb', '253');
 
-import Fastify from 'fastify';
+import Fastify, { type FastifyRequest, type FastifyReply } from 'fastify';
 import cors from '@fastify/cors';
 import websocket from '@fastify/websocket';
 import crypto from 'crypto';
 import { loadConfig, logConfig, type Config } from './config.js';
-import { KimDatabase } from './database.js';
+import { KimDatabase, BatchVersionConflict } from './database.js';
+import type { RetentionPolicy, SQLRequest } from '../shared/types.js';
+import { checksumTree } from '../shared/checksum.js';
+import { checkCollation, collator } from '../shared/collation.js';
+import { ChannelRooms, channelNameError, publishError, channelMessage } from '../shared/channels.js';
+import { snapshotLabelError, restoreState } from '../shared/snapshots.js';
+import { sampleSizeError } from '../shared/sample.js';
+import { versionsRequestError, compareVersions } from '../shared/versions.js';
+import { retentionPolicyError } from '../shared/retention.js';
+import { negotiateProtocol, SERVER_PROTOCOLS } from '../shared/protocol.js';
 import {
   VectorClock,
   CRDTDocument,
   UndoManager,
-  PresenceManager,
   LWWMap,
 } from '../crdt/index.js';
+import { PresenceRooms, type PresenceBroadcast } from './presence.js';
+import { freshUndoOps } from '../shared/undo.js';
+import { executeSQL } from './sql.js';
+import { UpdateOpError, type UpdateOp } from '../shared/update-ops.js';
 
 const VERSION = '7.0.0';
 
@@ -107,6 +119,21 @@ class LRUCache<T> {
   }
 }
 
+// ===== Expected Version =====
+/** X-KimDB-Expected-Version 헤더 (없으면 undefined, 음이 아닌 정수가 아니면 NaN) */
+function expectedVersionHeader(headers: Record<string, string | string[] | undefined>): number | undefined {
+  const header = headers['x-kimdb-expected-version'];
+  if (header === undefined) return undefined;
+  const expected = Number(header);
+  return Number.isInteger(expected) && expected >= 0 ? expected : NaN;
+}
+
+// ===== Validation =====
+/** 400 응답 본문 - errors[]가 있으면 클라이언트가 ValidationError로 받음 */
+function validationBody(path: string, rule: string, message: string): Record<string, unknown> {
+  return { error: message, errors: [{ path, rule, message }] };
+}
+
 // ===== Server Class =====
 export class KimDBServer {
   private config: Config;
@@ -123,9 +150,11 @@ export class KimDBServer {
   private subscriptions = new Map<string, Set<string>>();
   private docSubscriptions = new Map<string, Set<string>>();
   private crdtDocs: LRUCache<CRDTDocument>;
-  private presenceManagers = new Map<string, { pm: PresenceManager; lastAccess: number }>();
-  private clientPresence = new Map<string, { collection: string; docId: string; nodeId: string }>();
+  private presence: PresenceRooms;
+  private channels = new ChannelRooms();
   private clientUndoManagers = new Map<string, { um: UndoManager; lastAccess: number }>();
+  // msgId -> 보낸 crdt_ops_ok (클라이언트 outbox 재전송 중복 제거)
+  private processedOps = new LRUCache<unknown>(10000);
 
   // Metrics
   private metrics = {
@@ -151,6 +180,7 @@ export class KimDBServer {
     this.config = config ? { ...loadConfig(), ...config } : loadConfig();
     this.db = new KimDatabase(this.config);
     this.crdtDocs = new LRUCache(this.config.cache.maxDocs);
+    this.presence = new PresenceRooms(this.config.serverId, this.config.cache.presenceTTL);
     this.fastify = Fastify({ logger: false, trustProxy: true, bodyLimit: 10 * 1024 * 1024 });
   }
 
@@ -194,26 +224,6 @@ export class KimDBServer {
     }
   }
 
-  private getPresenceManager(collection: string, docId: string): PresenceManager {
-    const key = `${collection}:${docId}`;
-    let entry = this.presenceManagers.get(key);
-
-    if (!entry) {
-      entry = {
-        pm: new PresenceManager(`server_${this.config.serverId}`, {
-          heartbeatInterval: 10000,
-          timeout: this.config.cache.presenceTTL,
-        }),
-        lastAccess: Date.now(),
-      };
-      this.presenceManagers.set(key, entry);
-    } else {
-      entry.lastAccess = Date.now();
-    }
-
-    return entry.pm;
-  }
-
   private getClientUndoManager(clientId: string, collection: string, docId: string): UndoManager {
     const key = `${clientId}:${collection}:${docId}`;
     let entry = this.clientUndoManagers.get(key);
@@ -271,6 +281,12 @@ export class KimDBServer {
     return count;
   }
 
+  private broadcastPresence(event: PresenceBroadcast | null | undefined, excludeClientId: string): void {
+    if (!event) return;
+    if (event.message.type === 'presence_left') this.metrics.presence.leaves++;
+    this.localBroadcastToDoc(event.collection, event.docId, event.message, excludeClientId);
+  }
+
   private broadcastOp(collection: string, docId: string, operations: unknown[], excludeClientId: string | null): void {
     const msg = {
       type: 'crdt_sync',
@@ -301,24 +317,8 @@ export class KimDBServer {
     }
 
     // Presence cleanup
-    const presence = this.clientPresence.get(clientId);
-    if (presence) {
-      const pm = this.presenceManagers.get(`${presence.collection}:${presence.docId}`);
-      if (pm) {
-        pm.pm.users.delete(presence.nodeId);
-      }
-
-      this.localBroadcastToDoc(presence.collection, presence.docId, {
-        type: 'presence_left',
-        collection: presence.collection,
-        docId: presence.docId,
-        nodeId: presence.nodeId,
-        timestamp: Date.now(),
-      }, clientId);
-
-      this.clientPresence.delete(clientId);
-      this.metrics.presence.leaves++;
-    }
+    this.broadcastPresence(this.presence.leave(clientId), clientId);
+    this.channels.leaveAll(clientId);
 
     this.clients.delete(clientId);
     this.metrics.websocket.connections--;
@@ -334,13 +334,7 @@ export class KimDBServer {
     });
 
     // Cleanup presence managers
-    for (const [key, entry] of this.presenceManagers) {
-      if (now - entry.lastAccess > this.config.cache.presenceTTL * 2) {
-        this.presenceManagers.delete(key);
-      } else {
-        entry.pm.cleanup();
-      }
-    }
+    this.presence.cleanup(now);
 
     // Cleanup undo managers
     for (const [key, entry] of this.clientUndoManagers) {
@@ -348,6 +342,19 @@ export class KimDBServer {
         this.clientUndoManagers.delete(key);
       }
     }
+
+    // Retention policies
+    for (const collection of this.db.getRetentionCollections()) {
+      try {
+        const removed = this.db.applyRetention(collection, now);
+        for (const id of removed) {
+          this.localBroadcast(collection, 'delete', { collection, id }, null);
+        }
+        if (removed.length > 0) console.log(`[kimdb] Retention: ${collection} removed=${removed.length}`);
+      } catch (e) {
+        console.error(`[kimdb] Retention failed for ${collection}:`, (e as Error).message);
+      }
+    }
   }
 
   async start(): Promise<void> {
@@ -414,7 +421,7 @@ export class KimDBServer {
       ...this.metrics,
       memory: {
         cachedDocs: this.crdtDocs.size,
-        presenceManagers: this.presenceManagers.size,
+        presenceManagers: this.presence.size,
         undoManagers: this.clientUndoManagers.size,
         heapUsed: `${Math.round(process.memoryUsage().heapUsed / 1024 / 1024)}MB`,
       },
@@ -426,13 +433,31 @@ export class KimDBServer {
       return { success: true, collections: collections.map((c) => c.name) };
     });
 
-    // Collection documents
-    this.fastify.get('/api/c/:collection', async (req) => {
-      const rows = this.db.getDocuments((req.params as { collection: string }).collection);
+    // Collection documents (limit: 1~1000, skip: 0~)
+    this.fastify.get('/api/c/:collection', async (req, reply) => {
+      const { collection } = req.params as { collection: string };
+      const query = req.query as { limit?: string; skip?: string };
+      const limit = parseInt(query.limit ?? '1000', 10);
+      const skip = parseInt(query.skip ?? '0', 10);
+      if (!Number.isInteger(limit) || limit < 1 || limit > 1000) {
+        return reply.code(400).send({ error: 'limit must be between 1 and 1000' });
+      }
+      if (!Number.isInteger(skip) || skip < 0) {
+        return reply.code(400).send({ error: 'skip must be a non-negative integer' });
+      }
+
+      const rows = this.db.getDocuments(collection, limit, skip);
+      const total = this.db.countDocuments(collection);
+      const hasMore = skip + rows.length < total;
       return {
         success: true,
-        collection: (req.params as { collection: string }).collection,
+        collection,
         count: rows.length,
+        total,
+        skip,
+        limit,
+        hasMore,
+        nextSkip: hasMore ? skip + rows.length : null,
         data: rows.map((r) => ({ id: r.id, ...JSON.parse(r.data), _version: r._version })),
       };
     });
@@ -447,16 +472,162 @@ export class KimDBServer {
       return { success: true, id: row.id, data: JSON.parse(row.data), _version: row._version };
     });
 
+    // Upsert (병합) - 없으면 생성, soft delete된 문서면 버전 1로 되살림
+    this.fastify.put('/api/c/:collection/:id', async (req, reply) => {
+      const { collection, id } = req.params as { collection: string; id: string };
+      const { data } = (req.body || {}) as { data?: Record<string, unknown> };
+      if (!data) return reply.code(400).send(validationBody('data', 'required', 'data is required'));
+
+      const existing = this.db.getDocument(collection, id);
+      const merged = existing ? { ...JSON.parse(existing.data), ...data } : data;
+      return this.restWrite(req, reply, (expected) => ({
+        success: true,
+        id,
+        _version: this.db.replaceDocument(collection, id, merged, expected),
+      }));
+    });
+
+    // 부분 업데이트 (data 병합 후 ops 적용) - ops가 있으면 없는 문서도 {}에서 시작해 생성
+    this.fastify.patch('/api/c/:collection/:id', async (req, reply) => {
+      const { collection, id } = req.params as { collection: string; id: string };
+      const { data, ops } = (req.body || {}) as { data?: Record<string, unknown>; ops?: UpdateOp[] };
+      if (!data && !ops) return reply.code(400).send(validationBody('data', 'required', 'data or ops is required'));
+
+      if (!ops && !this.db.getDocument(collection, id)) return reply.code(404).send({ error: 'Not found' });
+      return this.restWrite(req, reply, (expected) => ({
+        success: true,
+        id,
+        _version: this.db.patchDocument(collection, id, data, ops, expected),
+      }));
+    });
+
+    // 삭제 (soft delete)
+    this.fastify.delete('/api/c/:collection/:id', async (req, reply) => {
+      const { collection, id } = req.params as { collection: string; id: string };
+      const existing = this.db.getDocument(collection, id);
+      if (!existing) return reply.code(404).send({ error: 'Not found' });
+      return this.restWrite(req, reply, (expected) => {
+        if (expected !== undefined && expected !== existing._version) {
+          throw new BatchVersionConflict(collection, id, expected, existing._version);
+        }
+        this.db.deleteDocument(collection, id);
+        return { success: true, deleted: true };
+      });
+    });
+
+    // Version check - 클라이언트가 가진 버전과 비교해 다시 받아야 할 문서만 알려줌
+    this.fastify.post('/api/c/:collection/versions', async (req, reply) => {
+      const { collection } = req.params as { collection: string };
+      const { versions } = (req.body || {}) as { versions?: Record<string, number> };
+      const problem = versionsRequestError(versions);
+      if (problem) return reply.code(400).send({ error: problem });
+
+      const current = this.db.getVersions(collection, Object.keys(versions));
+      return { success: true, collection, ...compareVersions(versions, current) };
+    });
+
+    // Random sample
+    this.fastify.get('/api/sample/:collection', async (req, reply) => {
+      const { collection } = req.params as { collection: string };
+      const n = Number((req.query as { n?: string }).n ?? 1);
+      const problem = sampleSizeError(n);
+      if (problem) return reply.code(400).send(validationBody('n', 'range', problem));
+      const rows = this.db.sampleDocuments(collection, n);
+      return {
+        success: true,
+        collection,
+        count: rows.length,
+        data: rows.map((r) => ({ id: r.id, ...JSON.parse(r.data), _version: r._version })),
+      };
+    });
+
+    // Checksum - 클라이언트 로컬 상태와 비교 (루트/버킷 해시, buckets를 주면 그 버킷의 문서 해시)
+    this.fastify.get('/api/checksum/:collection', async (req) => {
+      const { collection } = req.params as { collection: string };
+      const { buckets } = req.query as { buckets?: string };
+      // LIMIT -1 = 전체 문서
+      const rows = this.db.getDocuments(collection, -1);
+      const tree = await checksumTree(
+        rows.map((r) => [r.id, JSON.parse(r.data)] as [string, unknown]),
+        (text) => crypto.createHash('sha256').update(text).digest('hex'),
+      );
+      if (!buckets) {
+        return { success: true, collection, count: rows.length, root: tree.root, buckets: tree.buckets };
+      }
+      const docs: Record<string, string> = {};
+      for (const bucket of buckets.split(',')) Object.assign(docs, tree.docs[bucket]);
+      return { success: true, collection, docs };
+    });
+
+    // Changes since (updated_at 기준, 삭제 포함) - 증분 백업/환경 간 동기화용
+    this.fastify.get('/api/changes/:collection', async (req, reply) => {
+      const { collection } = req.params as { collection: string };
+      const query = req.query as { since?: string; after?: string; limit?: string };
+      const limit = parseInt(query.limit ?? '500', 10);
+      if (!Number.isInteger(limit) || limit < 1 || limit > 1000) {
+        return reply.code(400).send({ error: 'limit must be between 1 and 1000' });
+      }
+
+      const rows = this.db.getChanges(collection, query.since ?? '', query.after || null, limit);
+      const last = rows[rows.length - 1];
+      return {
+        success: true,
+        collection,
+        changes: rows.map((r) => ({
+          id: r.id,
+          data: r._deleted ? null : JSON.parse(r.data),
+          _version: r._version,
+          deleted: r._deleted === 1,
+          updatedAt: r.updated_at,
+        })),
+        next: rows.length === limit ? { since: last.updated_at, after: last.id } : null,
+      };
+    });
+
+    // Retention policy
+    this.fastify.get('/api/retention/:collection', async (req) => {
+      const { collection } = req.params as { collection: string };
+      return { success: true, collection, policy: this.db.getRetentionPolicy(collection) };
+    });
+
+    this.fastify.put('/api/retention/:collection', async (req, reply) => {
+      const { collection } = req.params as { collection: string };
+      const { policy } = (req.body || {}) as { policy?: RetentionPolicy };
+      const error = retentionPolicyError(collection, policy);
+      if (error) return reply.code(400).send({ error });
+
+      try {
+        this.db.setRetentionPolicy(collection, policy!);
+      } catch (e) {
+        return reply.code(400).send({ error: (e as Error).message });
+      }
+      return { success: true, collection, policy: this.db.getRetentionPolicy(collection) };
+    });
+
+    this.fastify.delete('/api/retention/:collection', async (req) => {
+      const { collection } = req.params as { collection: string };
+      this.db.setRetentionPolicy(collection, null);
+      return { success: true, collection };
+    });
+
     // SQL API
     this.fastify.post('/api/sql', async (req, reply) => {
-      const body = req.body as { sql: string; params?: unknown[]; collection: string };
-      const { sql, params: sqlParams = [], collection } = body;
+      const body = req.body as SQLRequest;
+      const { sql, params: sqlParams = [], collection, collation } = body;
 
       if (!sql) return reply.code(400).send({ error: 'sql is required' });
       if (!collection) return reply.code(400).send({ error: 'collection is required' });
+      const collationError = collation === undefined ? null : checkCollation(collation);
+      if (collationError) return reply.code(400).send({ error: collationError });
+      const by = collation ? collator(collation) : null;
 
       try {
-        const result = this.executeSQL(sql, sqlParams, collection);
+        // X-KimDB-Dry-Run: 실행 결과만 반환하고 롤백
+        if (req.headers['x-kimdb-dry-run'] === '1') {
+          const result = this.db.rehearse(() => executeSQL(this.db, sql, sqlParams, collection, by));
+          return { success: true, dryRun: true, ...result };
+        }
+        const result = executeSQL(this.db, sql, sqlParams, collection, by);
         return { success: true, ...result };
       } catch (e) {
         return reply.code(500).send({ error: (e as Error).message });
@@ -464,9 +635,47 @@ export class KimDBServer {
     });
   }
 
+  /**
+   * REST 문서 쓰기 공통 처리
+   * - X-KimDB-Expected-Version: n 이면 write가 현재 버전이 n일 때만 쓰고 (0 = 문서가 없어야 함), 아니면 409
+   * - X-KimDB-Dry-Run: 1 이면 결과만 반환하고 롤백
+   * - 확인과 쓰기 사이에 await가 없으므로 다른 요청이 끼어들 수 없음
+   */
+  private restWrite(
+    req: FastifyRequest,
+    reply: FastifyReply,
+    write: (expectedVersion: number | undefined) => Record<string, unknown>,
+  ): Record<string, unknown> | FastifyReply {
+    const expected = expectedVersionHeader(req.headers);
+    if (Number.isNaN(expected)) {
+      return reply.code(400).send({ error: 'X-KimDB-Expected-Version must be a non-negative integer' });
+    }
+    try {
+      if (req.headers['x-kimdb-dry-run'] === '1') {
+        return { ...this.db.rehearse(() => write(expected)), dryRun: true };
+      }
+      return write(expected);
+    } catch (e) {
+      if (e instanceof BatchVersionConflict) {
+        this.metrics.sync.conflicts++;
+        return reply.code(409).send({ error: 'Version conflict', expected: e.expected, _version: e.current });
+      }
+      if (e instanceof UpdateOpError) return reply.code(400).send({ error: e.message });
+      throw e;
+    }
+  }
+
   private registerWebSocket(): void {
     this.fastify.register(async (fastify) => {
-      fastify.get('/ws', { websocket: true }, (socket) => {
+      fastify.get('/ws', { websocket: true }, (socket, req) => {
+        const requested = (req.query as { protocol?: string }).protocol;
+        const protocol = negotiateProtocol(requested);
+        if (!protocol) {
+          socket.send(JSON.stringify({ type: 'error', message: `Unsupported protocol: ${requested}`, protocol: SERVER_PROTOCOLS[0] }));
+          socket.close(1002, 'Unsupported protocol');
+          return;
+        }
+
         const clientId = this.generateClientId();
         this.clients.set(clientId, {
           socket: socket as unknown as WebSocket,
@@ -484,6 +693,7 @@ export class KimDBServer {
           type: 'connected',
           clientId,
           serverId: this.config.serverId,
+          protocol,
         }));
 
         socket.on('message', (raw: Buffer) => {
@@ -503,9 +713,10 @@ export class KimDBServer {
   }
 
   private handleWebSocketMessage(clientId: string, socket: WebSocket, msg: { type: string; [key: string]: unknown }): void {
+    // 요청에 requestId가 있으면 응답에 그대로 붙여 클라이언트가 짝을 맞출 수 있게 함
     const send = (data: unknown) => {
       if (socket.readyState === 1) {
-        socket.send(JSON.stringify(data));
+        socket.send(JSON.stringify(msg.requestId !== undefined ? { ...(data as object), requestId: msg.requestId } : data));
         this.metrics.websocket.messages.sent++;
       }
     };
@@ -567,12 +778,222 @@ export class KimDBServer {
       }
 
       case 'crdt_ops': {
+        // 재전송된 메시지는 다시 적용하지 않고 처음 보낸 확인만 다시 보냄
+        const msgId = typeof msg.msgId === 'string' ? msg.msgId : null;
+        const previous = msgId ? this.processedOps.get(msgId) : null;
+        if (previous) {
+          send(previous);
+          break;
+        }
+
         const doc = this.getCRDTDoc(msg.collection as string, msg.docId as string);
         const applied = doc.applyRemoteBatch(msg.operations as unknown[]);
         this.saveCRDTToDB(msg.collection as string, msg.docId as string, doc);
         this.broadcastOp(msg.collection as string, msg.docId as string, msg.operations as unknown[], clientId);
         this.metrics.sync.operations += applied;
-        send({ type: 'crdt_ops_ok', docId: msg.docId, applied, version: doc.version });
+        const ack = { type: 'crdt_ops_ok', docId: msg.docId, applied, version: doc.version, ...(msgId && { msgId }) };
+        if (msgId) this.processedOps.set(msgId, ack);
+        send(ack);
+        break;
+      }
+
+      // op.expectedVersion이 있으면 현재 버전이 같을 때만 (src/api-server.js와 같은 규칙)
+      case 'update_batch': {
+        const ops = msg.ops as Array<{ collection: string; id: string; data: Record<string, unknown>; expectedVersion?: number }>;
+        const valid = Array.isArray(ops) && ops.length > 0 && ops.length <= 500 && ops.every((op) =>
+          op && typeof op.collection === 'string' && typeof op.id === 'string' && op.id !== '' &&
+          typeof op.data === 'object' && op.data !== null && !Array.isArray(op.data) &&
+          (op.expectedVersion === undefined || (Number.isInteger(op.expectedVersion) && op.expectedVersion >= 0)));
+        if (!valid) {
+          send({ type: 'error', message: 'ops must be 1-500 items of { collection, id, data, expectedVersion? }' });
+          break;
+        }
+
+        try {
+          const results = this.db.updateBatch(ops);
+          send({
+            type: 'batch_ack',
+            results: results.map(({ collection, id, _version }) => ({ collection, id, _version })),
+          });
+          // 커밋된 뒤에만 다른 구독자에게 알림
+          for (const r of results) {
+            this.localBroadcast(r.collection, 'update', { collection: r.collection, id: r.id, data: r.data, _version: r._version }, clientId);
+          }
+        } catch (e) {
+          if (e instanceof BatchVersionConflict) {
+            this.metrics.sync.conflicts++;
+            send({
+              type: 'error',
+              message: `Batch rolled back: ${e.message}`,
+              code: 'version_conflict',
+              collection: e.collection,
+              id: e.id,
+              expected: e.expected,
+              _version: e.current,
+            });
+          } else {
+            send({ type: 'error', message: `Batch rolled back: ${(e as Error).message}` });
+          }
+        }
+        break;
+      }
+
+      // ===== Undo/Redo (클라이언트별 스택, 역연산은 서버가 적용해 방송) =====
+      case 'undo_capture': {
+        const um = this.getClientUndoManager(clientId, msg.collection as string, msg.docId as string);
+        // boundary: 이전 편집과 묶지 않고 새 Undo 단위로 시작 (클라이언트 transact)
+        if (msg.boundary) um.stopCapturing();
+        um.capture(msg.op, msg.previousValue);
+        this.metrics.undo.captures++;
+        send({ type: 'undo_capture_ok', state: um.state });
+        break;
+      }
+
+      case 'undo':
+      case 'redo': {
+        const kind = msg.type as 'undo' | 'redo';
+        const um = this.getClientUndoManager(clientId, msg.collection as string, msg.docId as string);
+        const stored = kind === 'undo' ? um.undo() : um.redo();
+        if (!stored || stored.length === 0) {
+          send({ type: `${kind}_empty`, state: um.state });
+          break;
+        }
+
+        const doc = this.getCRDTDoc(msg.collection as string, msg.docId as string);
+        const ops = freshUndoOps(doc, stored, kind);
+        for (const op of ops) doc.applyRemote(op);
+        this.saveCRDTToDB(msg.collection as string, msg.docId as string, doc);
+        this.broadcastOp(msg.collection as string, msg.docId as string, ops, clientId);
+
+        if (kind === 'undo') this.metrics.undo.undos++;
+        else this.metrics.undo.redos++;
+        send({ type: `${kind}_ok`, docId: msg.docId, operations: ops, state: um.state, docVersion: doc.version });
+        break;
+      }
+
+      case 'undo_state': {
+        const um = this.getClientUndoManager(clientId, msg.collection as string, msg.docId as string);
+        send({ type: 'undo_state', docId: msg.docId, canUndo: um.canUndo(), canRedo: um.canRedo(), state: um.state });
+        break;
+      }
+
+      case 'undo_clear': {
+        this.getClientUndoManager(clientId, msg.collection as string, msg.docId as string).clear();
+        send({ type: 'undo_clear_ok' });
+        break;
+      }
+
+      // ===== Presence (awareness 상태도 presence_update의 user로 전달) =====
+      case 'presence_join': {
+        const result = this.presence.join(clientId, msg.collection as string, msg.docId as string, msg.user as Record<string, unknown>);
+        this.broadcastPresence(result.left, clientId);
+        this.broadcastPresence(result.broadcast, clientId);
+        this.metrics.presence.joins++;
+        send(result.reply);
+        break;
+      }
+
+      case 'presence_update': {
+        const result = this.presence.update(clientId, msg.user as Record<string, unknown>, msg.cursor);
+        if (result.broadcast) this.metrics.presence.updates++;
+        this.broadcastPresence(result.broadcast, clientId);
+        send(result.reply);
+        break;
+      }
+
+      case 'presence_cursor': {
+        // 커서는 고빈도라 응답 없음
+        this.broadcastPresence(this.presence.cursor(clientId, msg.position, msg.selection), clientId);
+        break;
+      }
+
+      case 'presence_leave': {
+        this.broadcastPresence(this.presence.leave(clientId), clientId);
+        send({ type: 'presence_leave_ok' });
+        break;
+      }
+
+      case 'presence_get': {
+        send(this.presence.users(msg.collection as string, msg.docId as string));
+        break;
+      }
+
+      // ===== Channels (저장 없는 pub/sub) =====
+      case 'channel_subscribe':
+      case 'channel_unsubscribe': {
+        const problem = channelNameError(msg.channel);
+        if (problem) {
+          send({ type: 'error', message: problem });
+          break;
+        }
+        const channel = msg.channel as string;
+        if (msg.type === 'channel_subscribe') this.channels.join(clientId, channel);
+        else this.channels.leave(clientId, channel);
+        send({ type: `${msg.type}d`, channel });
+        break;
+      }
+
+      case 'channel_publish': {
+        const problem = publishError(msg.channel, msg.payload);
+        if (problem) {
+          send({ type: 'error', message: problem });
+          break;
+        }
+        const channel = msg.channel as string;
+        const frame = JSON.stringify(channelMessage(channel, msg.payload, clientId));
+        let delivered = 0;
+        for (const id of this.channels.recipients(channel, clientId)) {
+          const client = this.clients.get(id);
+          if (client && client.socket.readyState === 1) {
+            client.socket.send(frame);
+            delivered++;
+          }
+        }
+        this.metrics.websocket.broadcasts++;
+        send({ type: 'channel_published', channel, delivered });
+        break;
+      }
+
+      // ===== Snapshots (협업 문서의 이름 붙인 체크포인트) =====
+      case 'snapshot_create': {
+        const problem = snapshotLabelError(msg.label);
+        if (problem) {
+          send({ type: 'error', message: problem });
+          break;
+        }
+        const collection = msg.collection as string;
+        const docId = msg.docId as string;
+        const doc = this.getCRDTDoc(collection, docId);
+        const info = this.db.createSnapshot(collection, docId, msg.label as string, doc.toJSON());
+        if (!info) {
+          send({ type: 'error', message: `Snapshot already exists: ${msg.label}` });
+          break;
+        }
+        send({ type: 'snapshot_created', collection, docId, ...info });
+        break;
+      }
+
+      case 'snapshot_list': {
+        const snapshots = this.db.listSnapshots(msg.collection as string, msg.docId as string);
+        send({ type: 'snapshots', collection: msg.collection, docId: msg.docId, snapshots });
+        break;
+      }
+
+      case 'snapshot_restore': {
+        const collection = msg.collection as string;
+        const docId = msg.docId as string;
+        const state = typeof msg.label === 'string' ? this.db.getSnapshotState(collection, docId, msg.label) : null;
+        if (!state) {
+          send({ type: 'error', message: `Snapshot not found: ${msg.label}` });
+          break;
+        }
+        const doc = restoreState(this.getCRDTDoc(collection, docId), state);
+        this.saveCRDTToDB(collection, docId, doc);
+        // 요청한 클라이언트도 구독 중이면 응답보다 먼저 새 상태를 받음
+        this.localBroadcastToDoc(collection, docId, {
+          type: 'crdt_state', collection, docId, state: doc.toJSON(), data: doc.toObject(), restored: msg.label,
+        }, null);
+        send({ type: 'snapshot_restored', collection, docId, label: msg.label, version: doc.version });
         break;
       }
 
@@ -586,38 +1007,6 @@ export class KimDBServer {
     }
   }
 
-  private executeSQL(sql: string, params: unknown[], collection: string): {
-    rows?: unknown[];
-    rowcount?: number;
-    row?: unknown;
-    lastrowid?: number;
-    updated?: number;
-    deleted?: number;
-  } {
-    // SQL 파싱 및 실행 (기존 api-server.js 로직 사용)
-    const sqlLower = sql.toLowerCase().trim();
-
-    if (sqlLower.startsWith('select')) {
-      const rows = this.db.getDocuments(collection);
-      const docs = rows.map((r) => {
-        let data = JSON.parse(r.data);
-        if (data.data && typeof data.data === 'object') data = data.data;
-        return { ...data, id: parseInt(r.id) || r.id };
-      });
-      return { rows: docs, rowcount: docs.length };
-    }
-
-    if (sqlLower.startsWith('insert')) {
-      // INSERT 처리
-      const id = crypto.randomBytes(8).toString('hex');
-      const data = JSON.stringify({ id });
-      this.db.saveDocument(collection, id, data);
-      return { row: { id }, lastrowid: parseInt(id) || 0 };
-    }
-
-    throw new Error(`Unsupported SQL: ${sql}`);
-  }
-
   async stop(): Promise<void> {
     console.log('[kimdb] Shutting down...');
 
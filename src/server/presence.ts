@@ -0,0 +1,174 @@
+/**
+ * kimdb Presence Rooms
+ *
+ * 문서별 참여자 목록 (WebSocket presence_* 메시지 처리)
+ * - 클라이언트는 한 번에 한 문서에만 참여 (다른 문서에 join하면 이전 문서에서 나감)
+ * - presence_update의 user 필드(awareness 포함)를 병합해 같은 문서의 다른 클라이언트에게 presence_updated로 전달
+ * - 저장하지 않음, presenceTTL 동안 갱신이 없는 참여자는 cleanup에서 제거
+ * - 전송은 서버 몫: 각 메서드는 요청자 응답(reply)과 문서 방송(broadcast)을 돌려줌
+ *
+ * 메시지 형식은 src/api-server.js의 presence_* 처리와 같음
+ */
+
+import { PresenceManager } from '../crdt/index.js';
+
+type Message = Record<string, unknown>;
+
+export interface PresenceBroadcast {
+  collection: string;
+  docId: string;
+  message: Message;
+}
+
+export interface PresenceResult {
+  reply: Message;
+  broadcast?: PresenceBroadcast;
+}
+
+interface Member {
+  collection: string;
+  docId: string;
+  nodeId: string;
+}
+
+export class PresenceRooms {
+  private serverId: string;
+  private ttl: number;
+  private rooms = new Map<string, { pm: PresenceManager; lastAccess: number }>();
+  private members = new Map<string, Member>();
+
+  constructor(serverId: string, ttl: number) {
+    this.serverId = serverId;
+    this.ttl = ttl;
+  }
+
+  /** 참여자 목록을 가진 문서 수 (metrics) */
+  get size(): number {
+    return this.rooms.size;
+  }
+
+  private room(collection: string, docId: string): PresenceManager {
+    const key = `${collection}:${docId}`;
+    let entry = this.rooms.get(key);
+    if (!entry) {
+      entry = {
+        pm: new PresenceManager(`server_${this.serverId}`, { heartbeatInterval: 10000, timeout: this.ttl }),
+        lastAccess: Date.now(),
+      };
+      this.rooms.set(key, entry);
+    } else {
+      entry.lastAccess = Date.now();
+    }
+    return entry.pm;
+  }
+
+  join(clientId: string, collection: string, docId: string, user: Message = {}): PresenceResult & { left: PresenceBroadcast | null } {
+    const previous = this.members.get(clientId);
+    const left = previous && (previous.collection !== collection || previous.docId !== docId) ? this.leave(clientId) : null;
+
+    const pm = this.room(collection, docId);
+    const nodeId = `client_${clientId}`;
+    this.members.set(clientId, { collection, docId, nodeId });
+    pm.users.set(nodeId, { ...user, nodeId, lastSeen: Date.now() });
+
+    return {
+      left,
+      reply: { type: 'presence_join_ok', nodeId, users: [...pm.users.values()] },
+      broadcast: {
+        collection,
+        docId,
+        message: { type: 'presence_joined', collection, docId, user: { nodeId, ...user }, timestamp: Date.now() },
+      },
+    };
+  }
+
+  /** 참여 중인 문서의 내 상태 병합 (join 전이면 오류 응답만) */
+  update(clientId: string, user: Message = {}, cursor?: unknown): PresenceResult {
+    const member = this.members.get(clientId);
+    if (!member) return { reply: { type: 'error', message: 'Not joined' } };
+
+    const pm = this.room(member.collection, member.docId);
+    const info = pm.users.get(member.nodeId);
+    if (info) Object.assign(info, user, cursor !== undefined && { cursor }, { lastSeen: Date.now() });
+
+    return {
+      reply: { type: 'presence_update_ok' },
+      broadcast: {
+        collection: member.collection,
+        docId: member.docId,
+        message: {
+          type: 'presence_updated',
+          collection: member.collection,
+          docId: member.docId,
+          nodeId: member.nodeId,
+          user: info,
+          timestamp: Date.now(),
+        },
+      },
+    };
+  }
+
+  /** 커서 이동 (응답 없음, join 전이면 null) */
+  cursor(clientId: string, position: unknown, selection: unknown): PresenceBroadcast | null {
+    const member = this.members.get(clientId);
+    if (!member) return null;
+
+    const cursor = { position, selection };
+    const info = this.room(member.collection, member.docId).users.get(member.nodeId);
+    if (info) Object.assign(info, { cursor, lastSeen: Date.now() });
+
+    return {
+      collection: member.collection,
+      docId: member.docId,
+      message: {
+        type: 'presence_cursor_moved',
+        collection: member.collection,
+        docId: member.docId,
+        nodeId: member.nodeId,
+        cursor,
+        timestamp: Date.now(),
+      },
+    };
+  }
+
+  /** 나가기 (presence_leave, 연결 종료) - 참여 중이 아니면 null */
+  leave(clientId: string): PresenceBroadcast | null {
+    const member = this.members.get(clientId);
+    if (!member) return null;
+
+    this.rooms.get(`${member.collection}:${member.docId}`)?.pm.users.delete(member.nodeId);
+    this.members.delete(clientId);
+    return {
+      collection: member.collection,
+      docId: member.docId,
+      message: {
+        type: 'presence_left',
+        collection: member.collection,
+        docId: member.docId,
+        nodeId: member.nodeId,
+        timestamp: Date.now(),
+      },
+    };
+  }
+
+  /** presence_get 응답 (만료된 참여자 제외) - 참여자 없는 문서는 조회만으로 목록을 만들지 않음 */
+  users(collection: string, docId: string): Message {
+    const pm = this.rooms.has(`${collection}:${docId}`) ? this.room(collection, docId) : null;
+    pm?.cleanup();
+    const users = pm ? [...pm.users.values()] : [];
+    return { type: 'presence_users', collection, docId, users, count: users.length };
+  }
+
+  /** 만료된 참여자 제거, 오래 쓰지 않은 문서 목록 삭제 */
+  cleanup(now = Date.now()): void {
+    for (const [key, entry] of this.rooms) {
+      if (now - entry.lastAccess > this.ttl * 2) {
+        this.rooms.delete(key);
+      } else {
+        entry.pm.cleanup();
+      }
+    }
+  }
+}
+
+export default PresenceRooms;
@@ -0,0 +1,95 @@
+/**
+ * kimdb Server SQL
+ *
+ * /api/sql 실행 (파싱과 WHERE/ORDER BY는 shared/sql.js, api-server.js와 같은 결과)
+ * - SELECT: 삭제되지 않은 문서 전체를 { ...data, id }로 읽어 selectRows (COUNT(*) [AS alias] 포함)
+ * - INSERT: _index 문서의 next_id로 숫자 ID 발급, 값은 문서 데이터로 저장
+ * - UPDATE: WHERE에 맞는 문서에 SET 값 병합 (_version + 1)
+ * - DELETE: WHERE에 맞는 문서 soft delete (_index의 ids에서도 제거)
+ * - UPDATE/DELETE는 한 트랜잭션 (중간에 실패하면 전체 롤백)
+ */
+
+import type { KimDatabase } from './database.js';
+import { parseSql, matchesWhere, selectRows } from '../shared/sql.js';
+
+export interface SQLResult {
+  rows?: unknown[];
+  rowcount?: number;
+  row?: unknown;
+  lastrowid?: number;
+  updated?: number;
+  deleted?: number;
+}
+
+type Doc = { id: string; data: Record<string, unknown>; view: Record<string, unknown> };
+
+/** 삭제되지 않은 문서 전체 (view: WHERE/SELECT가 보는 { ...data, id }) */
+function readAll(db: KimDatabase, collection: string): Doc[] {
+  const rows = db.getDocuments(collection, Math.max(db.countDocuments(collection), 1));
+  return rows.map((r) => {
+    const data = JSON.parse(r.data) as Record<string, unknown>;
+    // aiosqlite_compat에서 {data: {...}} 형태로 저장된 경우 처리
+    const fields = data.data && typeof data.data === 'object' ? data.data as Record<string, unknown> : data;
+    return { id: r.id, data, view: { ...fields, id: parseInt(r.id) || r.id } };
+  });
+}
+
+export function executeSQL(
+  db: KimDatabase,
+  sql: string,
+  params: unknown[],
+  collection: string,
+  collator: Intl.Collator | null = null,
+): SQLResult {
+  const parsed = parseSql(sql, params);
+  parsed.collator = collator;
+
+  switch (parsed.type) {
+    case 'SELECT': {
+      const rows = selectRows(readAll(db, collection).map((d) => d.view), parsed);
+      return { rows, rowcount: rows.length };
+    }
+
+    case 'INSERT': {
+      const indexRow = db.getDocument(collection, '_index');
+      const index = indexRow ? JSON.parse(indexRow.data) as { ids: string[]; next_id: number } : { ids: [], next_id: 1 };
+      const id = index.next_id++;
+      index.ids.push(String(id));
+      const row = { ...parsed.values, id };
+      db.raw.transaction(() => {
+        db.saveDocument(collection, '_index', JSON.stringify(index));
+        db.saveDocument(collection, String(id), JSON.stringify(row));
+      })();
+      return { row, lastrowid: id };
+    }
+
+    case 'UPDATE':
+      return db.raw.transaction(() => {
+        let updated = 0;
+        for (const doc of readAll(db, collection)) {
+          if (!matchesWhere(doc.view, parsed)) continue;
+          db.saveDocument(collection, doc.id, JSON.stringify({ ...doc.data, ...parsed.values }));
+          updated++;
+        }
+        return { updated };
+      })();
+
+    case 'DELETE':
+      return db.raw.transaction(() => {
+        const removed = new Set<string>();
+        for (const doc of readAll(db, collection)) {
+          if (!matchesWhere(doc.view, parsed)) continue;
+          db.deleteDocument(collection, doc.id);
+          removed.add(doc.id);
+        }
+        // INSERT가 만든 _index에서도 제거
+        const indexRow = removed.size > 0 ? db.getDocument(collection, '_index') : null;
+        if (indexRow) {
+          const index = JSON.parse(indexRow.data) as { ids: string[]; next_id: number };
+          index.ids = index.ids.filter((id) => !removed.has(id));
+          db.saveDocument(collection, '_index', JSON.stringify(index));
+        }
+        return { deleted: removed.size };
+      })();
+  }
+}
@@ -0,0 +1,33 @@
+/**
+ * kimdb Channels - channels.js 타입 선언
+ */
+
+export const MAX_CHANNEL_PAYLOAD: number;
+
+/** 잘못된 채널 이름이면 이유, 아니면 null */
+export function channelNameError(channel: unknown): string | null;
+
+/** 잘못된 publish 요청이면 이유, 아니면 null */
+export function publishError(channel: unknown, payload: unknown): string | null;
+
+export class ChannelRooms {
+  /** 구독자가 있는 채널 수 (metrics) */
+  get size(): number;
+  join(clientId: string, channel: string): void;
+  leave(clientId: string, channel: string): void;
+  /** 연결 종료: 모든 채널에서 나감 */
+  leaveAll(clientId: string): void;
+  /** 채널 구독자 (excludeClientId 제외) */
+  recipients(channel: string, excludeClientId?: string | null): string[];
+}
+
+export interface ChannelMessage {
+  type: 'channel_message';
+  channel: string;
+  payload: unknown;
+  /** 보낸 클라이언트 ID */
+  from: string;
+}
+
+/** 구독자에게 보낼 메시지 */
+export function channelMessage(channel: string, payload: unknown, from: string): ChannelMessage;
@@ -0,0 +1,83 @@
+/**
+ * kimdb Channels
+ *
+ * 문서 저장과 무관한 pub/sub 채널 (채팅, 알림 같은 일회성 실시간 메시지)
+ * - channel_subscribe / channel_unsubscribe { channel } → channel_subscribed / channel_unsubscribed
+ * - channel_publish { channel, payload } → 같은 채널의 다른 구독자에게 channel_message { channel, payload, from }
+ *   보낸 클라이언트에게는 channel_published { channel, delivered } (delivered: 이 서버에서 전달한 수)
+ * - 저장하지 않음: 연결이 끊겨 있던 동안의 메시지는 받지 못함
+ * - 채널 이름은 영문/숫자/_ : . - 로 1~128자 ("chat:room1"), payload는 JSON 64KB까지
+ * - 전송은 서버 몫: ChannelRooms는 누구에게 보낼지만 알려줌
+ *
+ * 타입 선언은 channels.d.ts (api-server.js가 빌드 없이 가져다 쓰므로 JS로 둠)
+ */
+
+export const MAX_CHANNEL_PAYLOAD = 64 * 1024;
+
+const CHANNEL_NAME = /^[A-Za-z0-9_:.-]{1,128}$/;
+
+/** 잘못된 채널 이름이면 이유, 아니면 null */
+export function channelNameError(channel) {
+  if (typeof channel !== 'string' || !CHANNEL_NAME.test(channel)) {
+    return 'channel must be 1-128 characters of letters, digits, _ : . -';
+  }
+  return null;
+}
+
+/** 잘못된 publish 요청이면 이유, 아니면 null */
+export function publishError(channel, payload) {
+  const nameError = channelNameError(channel);
+  if (nameError) return nameError;
+  const size = JSON.stringify(payload ?? null).length;
+  if (size > MAX_CHANNEL_PAYLOAD) return `payload must be at most ${MAX_CHANNEL_PAYLOAD} bytes of JSON`;
+  return null;
+}
+
+export class ChannelRooms {
+  constructor() {
+    this.members = new Map(); // channel → Set<clientId>
+    this.joined = new Map(); // clientId → Set<channel>
+  }
+
+  /** 구독자가 있는 채널 수 (metrics) */
+  get size() {
+    return this.members.size;
+  }
+
+  join(clientId, channel) {
+    if (!this.members.has(channel)) this.members.set(channel, new Set());
+    this.members.get(channel).add(clientId);
+    if (!this.joined.has(clientId)) this.joined.set(clientId, new Set());
+    this.joined.get(clientId).add(channel);
+  }
+
+  leave(clientId, channel) {
+    const members = this.members.get(channel);
+    if (members) {
+      members.delete(clientId);
+      if (members.size === 0) this.members.delete(channel);
+    }
+    const channels = this.joined.get(clientId);
+    if (channels) {
+      channels.delete(channel);
+      if (channels.size === 0) this.joined.delete(clientId);
+    }
+  }
+
+  /** 연결 종료: 모든 채널에서 나감 */
+  leaveAll(clientId) {
+    for (const channel of this.joined.get(clientId) ?? []) {
+      this.leave(clientId, channel);
+    }
+  }
+
+  /** 채널 구독자 (excludeClientId 제외) */
+  recipients(channel, excludeClientId = null) {
+    return [...(this.members.get(channel) ?? [])].filter(id => id !== excludeClientId);
+  }
+}
+
+/** 구독자에게 보낼 메시지 */
+export function channelMessage(channel, payload, from) {
+  return { type: 'channel_message', channel, payload: payload ?? null, from };
+}
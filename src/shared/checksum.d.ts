@@ -0,0 +1,21 @@
+/**
+ * kimdb Collection Checksum - checksum.js 타입 선언
+ */
+
+export type Sha256Hex = (text: string) => string | Promise<string>;
+
+export const CHECKSUM_LENGTH: number;
+
+/** 키를 정렬한 JSON (undefined 값 필드는 JSON.stringify처럼 생략) */
+export function canonicalJSON(value: unknown): string;
+
+export interface ChecksumTree {
+  root: string;
+  /** 버킷 → 버킷 해시 (문서가 있는 버킷만) */
+  buckets: Record<string, string>;
+  /** 버킷 → (id → 문서 해시) */
+  docs: Record<string, Record<string, string>>;
+}
+
+/** 문서 데이터(id → data)에서 체크섬 트리 계산 */
+export function checksumTree(docs: Iterable<[string, unknown]>, sha256: Sha256Hex): Promise<ChecksumTree>;
@@ -0,0 +1,42 @@
+/**
+ * kimdb Collection Checksum
+ *
+ * 서버와 클라이언트가 같은 문서 데이터에서 같은 체크섬을 얻기 위한 규칙
+ * - 문서 해시: sha256(canonicalJSON(data)) hex 앞 16자 (id/_version 제외한 데이터)
+ * - 버킷: sha256(id) hex 앞 2자 (256개)
+ * - 버킷 해시: ID 순으로 정렬한 "id:문서해시\n"을 이어 붙인 문자열의 해시
+ * - 루트 해시: 버킷 이름 순으로 정렬한 "버킷:버킷해시\n"의 해시
+ * - 해시 함수는 주입 (서버: node crypto, 클라이언트: Web Crypto)
+ *
+ * 타입 선언은 checksum.d.ts (api-server.js가 빌드 없이 가져다 쓰므로 JS로 둠)
+ */
+
+export const CHECKSUM_LENGTH = 16;
+
+/** 키를 정렬한 JSON (undefined 값 필드는 JSON.stringify처럼 생략) */
+export function canonicalJSON(value) {
+  if (value === null || typeof value !== 'object') return JSON.stringify(value) ?? 'null';
+  if (Array.isArray(value)) return `[${value.map(v => v === undefined ? 'null' : canonicalJSON(v)).join(',')}]`;
+  const entries = Object.keys(value)
+    .sort()
+    .filter(key => value[key] !== undefined)
+    .map(key => `${JSON.stringify(key)}:${canonicalJSON(value[key])}`);
+  return `{${entries.join(',')}}`;
+}
+
+/** 문서 데이터(id → data)에서 체크섬 트리 계산 */
+export async function checksumTree(docs, sha256) {
+  const tree = { root: '', buckets: {}, docs: {} };
+  for (const [id, data] of docs) {
+    const bucket = (await sha256(id)).slice(0, 2);
+    (tree.docs[bucket] ??= {})[id] = (await sha256(canonicalJSON(data))).slice(0, CHECKSUM_LENGTH);
+  }
+
+  for (const bucket of Object.keys(tree.docs).sort()) {
+    const lines = Object.keys(tree.docs[bucket]).sort().map(id => `${id}:${tree.docs[bucket][id]}\n`);
+    tree.buckets[bucket] = (await sha256(lines.join(''))).slice(0, CHECKSUM_LENGTH);
+  }
+  const rootLines = Object.keys(tree.buckets).sort().map(b => `${b}:${tree.buckets[b]}\n`);
+  tree.root = (await sha256(rootLines.join(''))).slice(0, CHECKSUM_LENGTH);
+  return tree;
+}
@@ -0,0 +1,17 @@
+/**
+ * kimdb Collation - collation.js 타입 선언
+ */
+
+export interface Collation {
+  locale?: string;
+  caseInsensitive?: boolean;
+  numeric?: boolean;
+}
+
+/** 잘못된 collation이면 이유, 아니면 null */
+export function checkCollation(value: unknown): string | null;
+
+export function collator(collation: Collation): Intl.Collator;
+
+/** 음수: a가 앞, 양수: b가 앞, 0: 같음 (비교할 수 없는 값도 0) */
+export function compareValues(a: unknown, b: unknown, by?: Intl.Collator | null): number;
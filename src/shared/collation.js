@@ -0,0 +1,41 @@
+/**
+ * kimdb Collation
+ *
+ * 문자열 비교 규칙 (사용자에게 보이는 정렬 목록용) - SQL 요청 본문의 collation으로 전달
+ * - locale: BCP 47 태그 (생략하면 런타임 기본 로캘)
+ * - caseInsensitive: 대소문자 무시 (WHERE 비교와 ORDER BY 모두)
+ * - numeric: 문자열 안의 숫자를 수로 비교 ("item2" < "item10")
+ * - 양쪽이 모두 문자열일 때만 적용, 그 외 값은 기존 비교
+ *
+ * 타입 선언은 collation.d.ts (api-server.js가 빌드 없이 가져다 쓰므로 JS로 둠)
+ */
+
+/** 잘못된 collation이면 이유, 아니면 null */
+export function checkCollation(value) {
+  if (!value || typeof value !== 'object' || Array.isArray(value)) return 'collation must be an object';
+  const { locale, caseInsensitive, numeric } = value;
+  if (caseInsensitive !== undefined && typeof caseInsensitive !== 'boolean') return 'collation.caseInsensitive must be a boolean';
+  if (numeric !== undefined && typeof numeric !== 'boolean') return 'collation.numeric must be a boolean';
+  if (locale !== undefined) {
+    if (typeof locale !== 'string') return 'collation.locale must be a string';
+    try {
+      Intl.getCanonicalLocales(locale);
+    } catch {
+      return `Invalid collation locale: ${locale}`;
+    }
+  }
+  return null;
+}
+
+export function collator(collation) {
+  return new Intl.Collator(collation.locale, {
+    sensitivity: collation.caseInsensitive ? 'accent' : 'variant',
+    numeric: collation.numeric ?? false,
+  });
+}
+
+/** 음수: a가 앞, 양수: b가 앞, 0: 같음 (비교할 수 없는 값도 0) */
+export function compareValues(a, b, by) {
+  if (by && typeof a === 'string' && typeof b === 'string') return by.compare(a, b);
+  return a < b ? -1 : a > b ? 1 : 0;
+}
@@ -0,0 +1,9 @@
+/**
+ * kimdb Wire Protocol Negotiation - protocol.js 타입 선언
+ */
+
+/** 서버가 말할 수 있는 프로토콜 (앞이 기본) */
+export const SERVER_PROTOCOLS: string[];
+
+/** 요청된 protocol 쿼리 → 사용할 프로토콜 (지원하지 않으면 null) */
+export function negotiateProtocol(requested: unknown): string | null;
@@ -0,0 +1,19 @@
+/**
+ * kimdb Wire Protocol Negotiation
+ *
+ * 클라이언트는 WebSocket URL의 ?protocol=<codec 이름>/<버전>으로 원하는 프로토콜을 알림
+ * - 서버는 지원하면 'connected' 메시지의 protocol에 그대로 돌려줌
+ * - 지원하지 않으면 protocol(서버가 쓰는 것)을 담은 error를 보내고 1002로 닫음
+ * - protocol 없이 접속한 이전 클라이언트는 json/1
+ *
+ * 타입 선언은 protocol.d.ts (api-server.js가 빌드 없이 가져다 쓰므로 JS로 둠)
+ */
+
+/** 서버가 말할 수 있는 프로토콜 (앞이 기본) */
+export const SERVER_PROTOCOLS = ['json/1'];
+
+/** 요청된 protocol 쿼리 → 사용할 프로토콜 (지원하지 않으면 null) */
+export function negotiateProtocol(requested) {
+  if (requested === undefined || requested === '') return SERVER_PROTOCOLS[0];
+  return typeof requested === 'string' && SERVER_PROTOCOLS.includes(requested) ? requested : null;
+}
@@ -0,0 +1,20 @@
+/**
+ * kimdb Retention - retention.js 타입 선언
+ */
+
+import type BetterSqlite3 from 'better-sqlite3';
+import type { RetentionPolicy } from './types.js';
+
+/** 보존 정책 요청 검사 (문제 없으면 null) */
+export function retentionPolicyError(collection: string, policy: RetentionPolicy | undefined): string | null;
+
+/** 저장된 정책 (없으면 null) */
+export function getRetentionPolicy(db: BetterSqlite3.Database, collection: string): RetentionPolicy | null;
+
+/** 만료/초과 문서를 archiveTo에 복사한 뒤 soft delete, 삭제한 ID 반환 */
+export function applyRetention(
+  db: BetterSqlite3.Database,
+  collection: string,
+  ensureCollection: (name: string) => string,
+  now?: number,
+): string[];
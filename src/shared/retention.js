@@ -0,0 +1,77 @@
+/**
+ * kimdb Retention
+ *
+ * 컬렉션 보존 정책 (두 서버가 같이 씀 - 정책은 각 서버 DB의 _retention 테이블)
+ * - maxAgeMs: 마지막 수정 후 이 시간이 지난 문서 삭제 (updated_at 기준)
+ * - maxDocuments: 최근 수정 순으로 이 개수만 유지
+ * - archiveTo: 삭제 전 이 컬렉션에 복사 (같은 ID면 덮어쓰고 버전 증가)
+ * - 삭제는 soft delete, 서버 정리 주기마다 적용
+ * - db는 better-sqlite3 연결, ensureCollection은 서버의 컬렉션 이름 검사/생성 (테이블 이름 반환)
+ *
+ * 타입 선언은 retention.d.ts (api-server.js가 빌드 없이 가져다 쓰므로 JS로 둠)
+ */
+
+/** 보존 정책 요청 검사 (문제 없으면 null) */
+export function retentionPolicyError(collection, policy) {
+  if (!policy || typeof policy !== 'object') return 'policy is required';
+  const { maxAgeMs, maxDocuments, archiveTo } = policy;
+  if (maxAgeMs === undefined && maxDocuments === undefined) return 'policy needs maxAgeMs or maxDocuments';
+  if (maxAgeMs !== undefined && !(Number.isInteger(maxAgeMs) && maxAgeMs > 0)) return 'maxAgeMs must be a positive integer';
+  if (maxDocuments !== undefined && !(Number.isInteger(maxDocuments) && maxDocuments >= 0)) {
+    return 'maxDocuments must be a non-negative integer';
+  }
+  if (archiveTo !== undefined && (typeof archiveTo !== 'string' || archiveTo === collection)) {
+    return 'archiveTo must be another collection name';
+  }
+  return null;
+}
+
+/** 저장된 정책 (없으면 null) */
+export function getRetentionPolicy(db, collection) {
+  const row = db.prepare(`SELECT max_age_ms, max_documents, archive_to FROM _retention WHERE collection = ?`).get(collection);
+  if (!row) return null;
+  return {
+    ...(row.max_age_ms !== null && { maxAgeMs: row.max_age_ms }),
+    ...(row.max_documents !== null && { maxDocuments: row.max_documents }),
+    ...(row.archive_to !== null && { archiveTo: row.archive_to }),
+  };
+}
+
+/** 만료/초과 문서를 archiveTo에 복사한 뒤 soft delete, 삭제한 ID 반환 */
+export function applyRetention(db, collection, ensureCollection, now = Date.now()) {
+  const policy = getRetentionPolicy(db, collection);
+  if (!policy) return [];
+  const col = ensureCollection(collection);
+
+  const apply = db.transaction(() => {
+    const ids = new Set();
+    if (policy.maxAgeMs !== undefined) {
+      // updated_at은 CURRENT_TIMESTAMP 형식 (UTC 'YYYY-MM-DD HH:MM:SS')
+      const cutoff = new Date(now - policy.maxAgeMs).toISOString().replace('T', ' ').slice(0, 19);
+      const rows = db.prepare(`SELECT id FROM ${col} WHERE _deleted = 0 AND id != '_index' AND updated_at < ?`).all(cutoff);
+      for (const r of rows) ids.add(r.id);
+    }
+    if (policy.maxDocuments !== undefined) {
+      const rows = db.prepare(
+        `SELECT id FROM ${col} WHERE _deleted = 0 AND id != '_index' ORDER BY updated_at DESC, id DESC LIMIT -1 OFFSET ?`
+      ).all(policy.maxDocuments);
+      for (const r of rows) ids.add(r.id);
+    }
+    if (ids.size === 0) return [];
+
+    const archive = policy.archiveTo ? ensureCollection(policy.archiveTo) : null;
+    for (const id of ids) {
+      if (archive) {
+        const row = db.prepare(`SELECT data FROM ${col} WHERE id = ?`).get(id);
+        db.prepare(`
+          INSERT INTO ${archive} (id, data, _version, _deleted, created_at, updated_at)
+          VALUES (?, ?, 1, 0, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+          ON CONFLICT(id) DO UPDATE SET data = excluded.data, _version = _version + 1, _deleted = 0, updated_at = CURRENT_TIMESTAMP
+        `).run(id, row.data);
+      }
+      db.prepare(`UPDATE ${col} SET _deleted = 1, updated_at = CURRENT_TIMESTAMP WHERE id = ?`).run(id);
+    }
+    return [...ids];
+  });
+  return apply();
+}
@@ -0,0 +1,8 @@
+/**
+ * kimdb Random Sample - sample.js 타입 선언
+ */
+
+export const MAX_SAMPLE_SIZE: number;
+
+/** 잘못된 n이면 이유, 아니면 null */
+export function sampleSizeError(n: unknown): string | null;
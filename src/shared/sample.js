@@ -0,0 +1,19 @@
+/**
+ * kimdb Random Sample
+ *
+ * GET /api/sample/:collection?n= 의 n 규칙 (두 서버와 클라이언트가 같이 씀)
+ * - 1~1000 정수만 (음수, 0, 소수, 숫자가 아닌 값, 1000 초과는 400)
+ * - 서버는 쿼리 문자열을 Number()로 바꿔 검사하므로 "2.5"를 2로 자르지 않음
+ *
+ * 타입 선언은 sample.d.ts (api-server.js가 빌드 없이 가져다 쓰므로 JS로 둠)
+ */
+
+export const MAX_SAMPLE_SIZE = 1000;
+
+/** 잘못된 n이면 이유, 아니면 null */
+export function sampleSizeError(n) {
+  if (!Number.isInteger(n) || n < 1 || n > MAX_SAMPLE_SIZE) {
+    return `n must be an integer between 1 and ${MAX_SAMPLE_SIZE}`;
+  }
+  return null;
+}
@@ -0,0 +1,19 @@
+/**
+ * kimdb Document Snapshots - snapshots.js 타입 선언
+ */
+
+export const MAX_SNAPSHOT_LABEL: number;
+
+/** 잘못된 라벨이면 이유, 아니면 null */
+export function snapshotLabelError(label: unknown): string | null;
+
+export interface SnapshotInfo {
+  label: string;
+  /** 스냅샷을 만들 때의 문서 버전 */
+  version: number;
+  /** ms timestamp */
+  createdAt: number;
+}
+
+/** 문서를 스냅샷 상태(toJSON 결과)로 제자리 교체 (버전과 벡터 클록은 앞으로만) */
+export function restoreState<D extends { version: number }>(doc: D, state: { version?: number }): D;
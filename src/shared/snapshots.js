@@ -0,0 +1,37 @@
+/**
+ * kimdb Document Snapshots
+ *
+ * 협업 문서(CRDT)의 이름 붙인 체크포인트 (두 서버가 같이 씀 - 저장은 각 서버의 _snapshots 테이블)
+ * - snapshot_create { collection, docId, label } → snapshot_created { collection, docId, label, version, createdAt }
+ * - snapshot_list { collection, docId } → snapshots { collection, docId, snapshots: [{ label, version, createdAt }] } (최신 먼저)
+ * - snapshot_restore { collection, docId, label } → 문서 구독자 전원에게 crdt_state { ..., restored: label },
+ *   요청한 클라이언트에게 snapshot_restored { collection, docId, label, version }
+ * - 라벨은 문서마다 고유 (같은 라벨로 다시 만들면 오류), 1~100자
+ * - 복원은 문서를 스냅샷 상태로 제자리 교체: 버전과 벡터 클록은 뒤로 가지 않음 (복원 뒤 편집이 복원 전 편집보다 나중)
+ *
+ * 타입 선언은 snapshots.d.ts (api-server.js가 빌드 없이 가져다 쓰므로 JS로 둠)
+ */
+
+export const MAX_SNAPSHOT_LABEL = 100;
+
+/** 잘못된 라벨이면 이유, 아니면 null */
+export function snapshotLabelError(label) {
+  if (typeof label !== 'string' || label.length === 0 || label.length > MAX_SNAPSHOT_LABEL) {
+    return `label must be a string of 1-${MAX_SNAPSHOT_LABEL} characters`;
+  }
+  return null;
+}
+
+/**
+ * 문서를 스냅샷 상태(toJSON 결과)로 교체
+ *
+ * 객체는 그대로 두고 내용만 바꾸므로 doc을 들고 있는 쪽(캐시, 편집기)은 다시 열 필요 없음.
+ * 이미 적용한 op ID는 남겨서 복원 전 op가 재전송돼도 다시 적용하지 않음.
+ */
+export function restoreState(doc, state) {
+  const clock = doc.clock.clone();
+  doc.loadFromSnapshot({ state, version: Math.max(doc.version, state.version || 0) + 1 });
+  doc.clock.merge(clock);
+  doc.clock.tick();
+  return doc;
+}
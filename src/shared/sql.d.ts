@@ -0,0 +1,43 @@
+/**
+ * kimdb SQL Subset - sql.js 타입 선언
+ */
+
+export type SqlType = 'SELECT' | 'INSERT' | 'UPDATE' | 'DELETE';
+
+export interface SqlCondition {
+  field: string;
+  op: '=' | '!=' | '<>' | '>' | '<' | '>=' | '<=' | 'LIKE' | 'GLOB';
+  value: unknown;
+}
+
+export interface ParsedSql {
+  type: SqlType;
+  table: string | null;
+  /** SELECT 컬럼 원문 ('*', 'a, b', 'COUNT(*) AS cnt') */
+  columns: string;
+  /** OR가 없을 때의 AND 조건 */
+  where: SqlCondition[];
+  /** OR로 나눈 AND 조건 묶음 */
+  orGroups: SqlCondition[][];
+  orderBy: string | null;
+  orderDir: 'ASC' | 'DESC';
+  orderKeys: Array<{ field: string; dir: 'ASC' | 'DESC' }> | null;
+  limit: number | null;
+  offset: number | null;
+  /** INSERT 컬럼 값, UPDATE SET 값 */
+  values: Record<string, unknown>;
+  paramIndex: number;
+  /** 문자열 비교/정렬 규칙 (요청의 collation) */
+  collator: Intl.Collator | null;
+}
+
+/** 지원하지 않는 문장이면 Unsupported SQL 오류 */
+export function parseSql(sql: string, params?: unknown[]): ParsedSql;
+
+export function matchesCondition(doc: Record<string, unknown>, condition: SqlCondition, collator?: Intl.Collator | null): boolean;
+
+/** WHERE가 없으면 true */
+export function matchesWhere(doc: Record<string, unknown>, parsed: ParsedSql): boolean;
+
+/** SELECT 결과 행 (WHERE → COUNT 또는 ORDER BY → OFFSET → LIMIT → 컬럼 선택) */
+export function selectRows(docs: Array<Record<string, unknown>>, parsed: ParsedSql): Array<Record<string, unknown>>;
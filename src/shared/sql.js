@@ -0,0 +1,260 @@
+/**
+ * kimdb SQL Subset
+ *
+ * /api/sql 요청의 파싱과 문서 필터링 (두 서버가 같이 씀 - 저장은 각 서버가)
+ * - SELECT * | col, ... | COUNT(*) [AS alias] FROM table WHERE ... ORDER BY a [ASC|DESC], b ... LIMIT n OFFSET n
+ * - INSERT INTO table (col1, col2) VALUES (?, ?)
+ * - UPDATE table SET col1 = ?, col2 = ? WHERE ...
+ * - DELETE FROM table WHERE ...
+ * - WHERE: =, !=, <>, >, <, >=, <=, LIKE, GLOB를 AND/OR로 (괄호 없음, AND가 먼저)
+ * - parsed.collator가 있으면 문자열끼리 비교와 정렬은 Collator로 (LIKE/GLOB 제외)
+ *
+ * 타입 선언은 sql.d.ts (api-server.js가 빌드 없이 가져다 쓰므로 JS로 둠)
+ */
+
+import { compareValues } from './collation.js';
+
+/** 리터럴 또는 ? 바인딩 값 */
+function literal(text, params, result) {
+  if (text === '?') return params[result.paramIndex++];
+  if (text.match(/^['"].*['"]$/)) return text.slice(1, -1);
+  if (!isNaN(text)) return Number(text);
+  return text;
+}
+
+export function parseSql(sql, params = []) {
+  const sqlLower = sql.toLowerCase().trim();
+  const result = { type: null, table: null, columns: '*', where: [], orGroups: [], orderBy: null, orderDir: 'ASC', orderKeys: null, limit: null, offset: null, values: {}, paramIndex: 0, collator: null };
+
+  // 쿼리 타입 판별
+  if (sqlLower.startsWith('select')) {
+    result.type = 'SELECT';
+  } else if (sqlLower.startsWith('insert')) {
+    result.type = 'INSERT';
+  } else if (sqlLower.startsWith('update')) {
+    result.type = 'UPDATE';
+  } else if (sqlLower.startsWith('delete')) {
+    result.type = 'DELETE';
+  } else {
+    throw new Error(`Unsupported SQL: ${sql}`);
+  }
+
+  // 테이블명 추출
+  const tableMatch = sqlLower.match(/(?:from|into|update)\s+([a-z_][a-z0-9_]*)/i);
+  if (tableMatch) {
+    result.table = tableMatch[1];
+  }
+
+  // SELECT 컬럼 추출
+  if (result.type === 'SELECT') {
+    const colMatch = sql.match(/select\s+(.+?)\s+from/i);
+    if (colMatch) {
+      result.columns = colMatch[1].trim();
+    }
+  }
+
+  // WHERE 절 파싱
+  const whereMatch = sql.match(/where\s+(.+?)(?:\s+order\s+by|\s+limit|\s+offset|$)/i);
+  if (whereMatch) {
+    const wherePart = whereMatch[1].trim();
+
+    // OR로 분리 후 각각 AND 조건 처리
+    for (const orGroup of wherePart.split(/\s+or\s+/i)) {
+      const andConditions = [];
+
+      for (const cond of orGroup.split(/\s+and\s+/i)) {
+        // GLOB 패턴: field GLOB 'pattern'
+        const globParts = cond.match(/([a-z_][a-z0-9_]*)\s+glob\s+['"]?([^'"]+)['"]?/i);
+        if (globParts) {
+          andConditions.push({ field: globParts[1].trim(), op: 'GLOB', value: globParts[2].trim() });
+          continue;
+        }
+
+        // 범위/비교 연산자 (>=, <= 먼저 체크!)
+        const parts = cond.match(/([a-z_][a-z0-9_]*)\s*(>=|<=|!=|<>|>|<|=|like)\s*(.+)/i);
+        if (parts) {
+          andConditions.push({ field: parts[1].trim(), op: parts[2].trim().toUpperCase(), value: literal(parts[3].trim(), params, result) });
+        }
+      }
+
+      if (andConditions.length > 0) {
+        result.orGroups.push(andConditions);
+      }
+    }
+
+    // 단일 AND 조건만 있을 경우 기존 호환성 유지
+    if (result.orGroups.length === 1) {
+      result.where = result.orGroups[0];
+    }
+  }
+
+  // ORDER BY a DESC, b ASC - 앞 키가 같을 때 다음 키로 비교 (orderBy/orderDir는 첫 키)
+  const orderMatch = sql.match(/order\s+by\s+([a-z_][a-z0-9_]*(?:\s+(?:asc|desc))?(?:\s*,\s*[a-z_][a-z0-9_]*(?:\s+(?:asc|desc))?)*)/i);
+  if (orderMatch) {
+    result.orderKeys = orderMatch[1].split(',').map(part => {
+      const [field, dir] = part.trim().split(/\s+/);
+      return { field, dir: (dir || 'ASC').toUpperCase() };
+    });
+    result.orderBy = result.orderKeys[0].field;
+    result.orderDir = result.orderKeys[0].dir;
+  }
+
+  // LIMIT
+  const limitMatch = sql.match(/limit\s+(\?|\d+)/i);
+  if (limitMatch) {
+    result.limit = limitMatch[1] === '?' ? params[result.paramIndex++] : parseInt(limitMatch[1]);
+  }
+
+  // OFFSET
+  const offsetMatch = sql.match(/offset\s+(\?|\d+)/i);
+  if (offsetMatch) {
+    result.offset = offsetMatch[1] === '?' ? params[result.paramIndex++] : parseInt(offsetMatch[1]);
+  }
+
+  // INSERT VALUES
+  if (result.type === 'INSERT') {
+    const colsMatch = sql.match(/\(([^)]+)\)\s*values/i);
+    const valsMatch = sql.match(/values\s*\(([^)]+)\)/i);
+    if (colsMatch && valsMatch) {
+      const cols = colsMatch[1].split(',').map(c => c.trim());
+      const vals = valsMatch[1].split(',').map(v => v.trim());
+      for (let i = 0; i < cols.length; i++) {
+        result.values[cols[i]] = literal(vals[i], params, result);
+      }
+    }
+  }
+
+  // UPDATE SET - ? 바인딩은 WHERE 다음 순서 (클라이언트 buildUpdateWhere가 이 순서에 맞춰 params를 만듦)
+  if (result.type === 'UPDATE') {
+    const setMatch = sql.match(/set\s+(.+?)(?:\s+where|$)/i);
+    if (setMatch) {
+      for (const part of setMatch[1].split(',')) {
+        const [col, val] = part.split('=').map(s => s.trim());
+        result.values[col] = literal(val, params, result);
+      }
+    }
+  }
+
+  return result;
+}
+
+export function matchesCondition(doc, { field, op, value }, collator = null) {
+  let docVal = doc[field];
+
+  // is_active 기본값
+  if (docVal === undefined && field === 'is_active') {
+    docVal = 1;
+  }
+
+  // collation이 있으면 문자열끼리 비교는 Collator로 (LIKE/GLOB 제외)
+  if (collator && typeof docVal === 'string' && typeof value === 'string' && op !== 'LIKE' && op !== 'GLOB') {
+    const order = collator.compare(docVal, value);
+    switch (op) {
+      case '=': return order === 0;
+      case '!=':
+      case '<>': return order !== 0;
+      case '>': return order > 0;
+      case '<': return order < 0;
+      case '>=': return order >= 0;
+      case '<=': return order <= 0;
+    }
+  }
+
+  switch (op) {
+    case '=':
+      return docVal == value;
+    case '!=':
+    case '<>':
+      return docVal != value;
+    case '>':
+      return docVal > value;
+    case '<':
+      return docVal < value;
+    case '>=':
+      return docVal >= value;
+    case '<=':
+      return docVal <= value;
+    case 'LIKE': {
+      const likePattern = value.replace(/%/g, '.*').replace(/_/g, '.');
+      return new RegExp(`^${likePattern}$`, 'i').test(docVal || '');
+    }
+    case 'GLOB': {
+      // GLOB: * → .*, ? → ., [abc] → [abc]
+      const globPattern = value
+        .replace(/\*/g, '.*')
+        .replace(/\?/g, '.')
+        .replace(/\[!/g, '[^');
+      return new RegExp(`^${globPattern}$`).test(docVal || '');
+    }
+    default:
+      return true;
+  }
+}
+
+/** WHERE가 없으면 true */
+export function matchesWhere(doc, parsed) {
+  // OR 조건 그룹이 있으면 하나라도 만족하면 true
+  if (parsed.orGroups.length > 1) {
+    return parsed.orGroups.some(andGroup =>
+      andGroup.every(cond => matchesCondition(doc, cond, parsed.collator))
+    );
+  }
+
+  // AND 조건만 있는 경우
+  return parsed.where.every(cond => matchesCondition(doc, cond, parsed.collator));
+}
+
+/**
+ * SELECT 결과 행 (WHERE → COUNT 또는 ORDER BY → OFFSET → LIMIT → 컬럼 선택)
+ *
+ * docs: 삭제되지 않은 문서 전체 ({ ...data, id })
+ */
+export function selectRows(docs, parsed) {
+  const isCount = parsed.columns.toLowerCase().includes('count(*)');
+  let rows = docs.filter(doc => matchesWhere(doc, parsed));
+
+  // COUNT(*) [AS alias] - 호환성을 위해 alias와 COUNT(*) 둘 다 제공
+  if (isCount) {
+    const aliasMatch = parsed.columns.match(/count\s*\(\s*\*\s*\)\s+(?:as\s+)?([a-z_][a-z0-9_]*)/i);
+    const result = aliasMatch ? { [aliasMatch[1]]: rows.length } : {};
+    result['COUNT(*)'] = rows.length;
+    return [result];
+  }
+
+  // ORDER BY
+  if (parsed.orderKeys) {
+    rows.sort((a, b) => {
+      for (const { field, dir } of parsed.orderKeys) {
+        const order = compareValues(a[field], b[field], parsed.collator);
+        if (order !== 0) return dir === 'ASC' ? order : -order;
+      }
+      return 0;
+    });
+  }
+
+  // OFFSET
+  if (parsed.offset) {
+    rows = rows.slice(parsed.offset);
+  }
+
+  // LIMIT
+  if (parsed.limit) {
+    rows = rows.slice(0, parsed.limit);
+  }
+
+  // 특정 컬럼만 선택
+  if (parsed.columns !== '*') {
+    const cols = parsed.columns.split(',').map(c => c.trim());
+    rows = rows.map(doc => {
+      const result = {};
+      for (const col of cols) {
+        if (Object.prototype.hasOwnProperty.call(doc, col)) {
+          result[col] = doc[col];
+        }
+      }
+      return result;
+    });
+  }
+
+  return rows;
+}
@@ -2,6 +2,9 @@
  * kimdb Shared Types
  */
 
+import type { Collation } from './collation.js';
+import type { ChannelMessage } from './channels.js';
+
 // ===== Configuration =====
 export interface KimDBConfig {
   port: number;
@@ -129,11 +132,122 @@ export interface WSCRDTOpsMessage extends WSMessage {
   operations: CRDTOperation[];
 }
 
+// ----- Server → Client -----
+export interface WSSyncMessage extends WSMessage {
+  type: 'sync';
+  event: string;
+  collection: string;
+  id?: string;
+  docId?: string;
+  data?: unknown;
+  _version?: number;
+}
+
+export interface WSCRDTStateMessage extends WSMessage {
+  type: 'crdt_state';
+  collection: string;
+  docId: string;
+  state: unknown;
+  data: Record<string, unknown>;
+  /** 스냅샷 복원으로 교체된 상태면 그 라벨 */
+  restored?: string;
+}
+
+export interface WSCRDTSyncMessage extends WSMessage {
+  type: 'crdt_sync';
+  collection: string;
+  docId: string;
+  operations: CRDTOperation[];
+  serverTime: number;
+}
+
+export interface WSSubscribedMessage extends WSMessage {
+  type: 'subscribed' | 'unsubscribed';
+  collection: string;
+}
+
+export interface WSSubscribedDocMessage extends WSMessage {
+  type: 'subscribed_doc';
+  collection: string;
+  docId: string;
+}
+
+export interface WSPresenceUsersMessage extends WSMessage {
+  type: 'presence_users';
+  collection: string;
+  docId: string;
+  users: PresenceUser[];
+  count: number;
+}
+
+export interface WSPresenceUpdatedMessage extends WSMessage {
+  type: 'presence_updated';
+  collection: string;
+  docId: string;
+  nodeId: string;
+  user: Partial<PresenceUser> & { awareness?: Record<string, unknown> };
+  timestamp: number;
+}
+
+export interface WSPresenceLeftMessage extends WSMessage {
+  type: 'presence_left';
+  collection: string;
+  docId: string;
+  nodeId: string;
+  timestamp: number;
+}
+
+export interface WSBatchAckMessage extends WSMessage {
+  type: 'batch_ack';
+  results: Array<{ collection: string; id: string; _version: number }>;
+}
+
+export interface WSCRDTOpsOkMessage extends WSMessage {
+  type: 'crdt_ops_ok';
+  docId: string;
+  applied: number;
+  version: number;
+  /** 요청의 msgId (outbox 확인용) */
+  msgId?: string;
+}
+
+export interface WSPongMessage extends WSMessage {
+  type: 'pong';
+  time: number;
+}
+
+export interface WSErrorMessage extends WSMessage {
+  type: 'error';
+  message: string;
+}
+
+/** 서버가 보내는 메시지 타입 → 페이로드 */
+export interface WSServerEventMap {
+  connected: WSConnectedMessage;
+  sync: WSSyncMessage;
+  crdt_state: WSCRDTStateMessage;
+  crdt_sync: WSCRDTSyncMessage;
+  subscribed: WSSubscribedMessage;
+  unsubscribed: WSSubscribedMessage;
+  subscribed_doc: WSSubscribedDocMessage;
+  presence_users: WSPresenceUsersMessage;
+  presence_updated: WSPresenceUpdatedMessage;
+  presence_left: WSPresenceLeftMessage;
+  batch_ack: WSBatchAckMessage;
+  crdt_ops_ok: WSCRDTOpsOkMessage;
+  pong: WSPongMessage;
+  error: WSErrorMessage;
+  channel_message: ChannelMessage;
+  server_shutdown: WSMessage & { type: 'server_shutdown' };
+}
+
 // ===== SQL Types =====
 export interface SQLRequest {
   sql: string;
   params?: unknown[];
   collection: string;
+  /** 문자열 WHERE 비교와 ORDER BY 규칙 */
+  collation?: Collation;
 }
 
 export interface SQLResponse {
@@ -143,6 +257,8 @@ export interface SQLResponse {
   lastrowid?: number;
   updated?: number;
   deleted?: number;
+  /** X-KimDB-Dry-Run 요청: 결과만 계산하고 저장하지 않음 */
+  dryRun?: boolean;
   error?: string;
 }
 
@@ -253,6 +369,16 @@ export interface DocumentRow {
   updated_at: string;
 }
 
+/** 컬렉션 보존 정책 - 조건을 넘는 문서는 서버 정리 주기마다 삭제 (archiveTo가 있으면 먼저 복사) */
+export interface RetentionPolicy {
+  /** 마지막 수정 후 이 시간(ms)이 지난 문서 삭제 */
+  maxAgeMs?: number;
+  /** 최근 수정 순으로 이 개수만 유지 */
+  maxDocuments?: number;
+  /** 삭제 전 복사할 컬렉션 */
+  archiveTo?: string;
+}
+
 export interface Collection {
   name: string;
   created_at: string;
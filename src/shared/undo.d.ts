@@ -0,0 +1,10 @@
+/**
+ * kimdb Undo Ops - undo.js 타입 선언
+ */
+
+import type { CRDTDocument } from '../crdt/index.js';
+
+export type StoredOp = { type: string; key?: string; [field: string]: unknown };
+
+/** 새 opId/clock (map 연산은 현재보다 큰 timestamp)을 붙인 복사본 */
+export function freshUndoOps(doc: CRDTDocument, ops: StoredOp[], kind: 'undo' | 'redo'): StoredOp[];
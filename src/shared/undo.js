@@ -0,0 +1,27 @@
+/**
+ * kimdb Undo Ops
+ *
+ * Undo 스택의 연산을 문서에 다시 적용할 때 쓰는 복사본 (클라이언트 로컬 Undo와 두 서버의 undo/redo 메시지가 같이 씀)
+ * - 스택의 연산은 undo/redo를 반복할 때마다 다시 쓰므로 매번 새 opId/clock (같은 opId는 중복으로 무시됨)
+ * - map 연산의 timestamp는 현재 필드보다 크게 해서 LWW에서 항상 이김 (다른 노드 시계가 빨라도)
+ *   한 묶음에 같은 필드가 여러 번 있으면 뒤의 연산이 더 큰 timestamp (묶음 순서대로 적용됨)
+ *
+ * 타입 선언은 undo.d.ts (api-server.js가 빌드 없이 가져다 쓰므로 JS로 둠)
+ */
+
+export function freshUndoOps(doc, ops, kind) {
+  const latest = new Map(); // key → 이 묶음에서 마지막으로 붙인 timestamp
+  return ops.map(op => {
+    const fresh = {
+      ...op,
+      clock: doc.clock.tick().toJSON(),
+      opId: `${kind}_${Date.now()}_${Math.random().toString(36).slice(2, 8)}`,
+    };
+    if (op.type === 'map_set' || op.type === 'map_delete') {
+      const current = latest.get(op.key) ?? doc.root.fields.get(op.key)?.timestamp ?? 0;
+      fresh.timestamp = Math.max(Date.now() * 1000, current + 1);
+      latest.set(op.key, fresh.timestamp);
+    }
+    return fresh;
+  });
+}
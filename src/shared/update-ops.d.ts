@@ -0,0 +1,24 @@
+/**
+ * kimdb Update Operators - update-ops.js 타입 선언
+ */
+
+export type UpdateOp =
+  | { op: 'set'; path: string; value: unknown }
+  | { op: 'unset'; path: string }
+  | { op: 'push'; path: string; values: unknown[] }
+  | { op: 'pull'; path: string; values: unknown[] }
+  | { op: 'addUnique'; path: string; values: unknown[] }
+  | { op: 'inc'; path: string; by: number };
+
+export class UpdateOpError extends Error {
+  constructor(message: string);
+}
+
+/** 프로토타입 오염 방지 - 경로에 쓸 수 없는 키 */
+export const FORBIDDEN_PATH_KEYS: ReadonlySet<string>;
+
+/** 점 경로를 키 배열로 (빈 키, 금지된 키는 UpdateOpError) */
+export function splitPath(path: string): string[];
+
+/** ops를 적용한 새 문서 (입력은 변경하지 않음) */
+export function applyUpdateOps(doc: Record<string, unknown>, ops: UpdateOp[]): Record<string, unknown>;
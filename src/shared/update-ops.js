@@ -0,0 +1,105 @@
+/**
+ * kimdb Update Operators
+ *
+ * 배열/중첩 필드 부분 수정 연산 (PATCH { ops }) - 서버가 저장된 문서에 순서대로 적용
+ * - set / unset: 점 경로("a.b.c") 값 설정/제거, 중간 객체는 없으면 만듦
+ * - push: 배열 끝에 추가, addUnique: 같은 값이 없을 때만 추가 (없는 배열은 만듦)
+ * - pull: 같은 값 모두 제거 (값 비교는 키 순서 무시)
+ * - inc: 숫자 필드에 by를 더함 (없으면 by로 만듦) - 서버가 한 요청 안에서 적용하므로 원자적
+ * - 경로 중간이 객체가 아니거나 배열 연산 대상이 배열이 아니면 UpdateOpError
+ *
+ * 타입 선언은 update-ops.d.ts (api-server.js가 빌드 없이 가져다 쓰므로 JS로 둠)
+ */
+
+import { canonicalJSON } from './checksum.js';
+
+export class UpdateOpError extends Error {
+  constructor(message) {
+    super(message);
+    this.name = 'UpdateOpError';
+  }
+}
+
+/** 프로토타입 오염 방지 - 경로에 쓸 수 없는 키 */
+export const FORBIDDEN_PATH_KEYS = new Set(['__proto__', 'constructor', 'prototype']);
+
+function isObject(value) {
+  return typeof value === 'object' && value !== null && !Array.isArray(value);
+}
+
+export function splitPath(path) {
+  const keys = typeof path === 'string' ? path.split('.') : [];
+  if (keys.length === 0 || keys.some(k => k === '' || FORBIDDEN_PATH_KEYS.has(k))) {
+    throw new UpdateOpError(`Invalid path: ${JSON.stringify(path)}`);
+  }
+  return keys;
+}
+
+/** ops를 적용한 새 문서 (입력은 변경하지 않음) */
+export function applyUpdateOps(doc, ops) {
+  if (!Array.isArray(ops)) throw new UpdateOpError('ops must be an array');
+  const root = structuredClone(doc);
+
+  for (const op of ops) {
+    const keys = splitPath(op?.path);
+    const last = keys[keys.length - 1];
+    let parent = root;
+    for (const key of keys.slice(0, -1)) {
+      if (parent[key] === undefined) {
+        // 없는 경로에서 지우는 연산은 할 일 없음
+        if (op.op === 'unset' || op.op === 'pull') {
+          parent = null;
+          break;
+        }
+        parent[key] = {};
+      }
+      const next = parent[key];
+      if (!isObject(next)) throw new UpdateOpError(`${op.path}: ${key} is not an object`);
+      parent = next;
+    }
+    if (!parent) continue;
+
+    switch (op.op) {
+      case 'set':
+        parent[last] = structuredClone(op.value);
+        break;
+      case 'unset':
+        delete parent[last];
+        break;
+      case 'inc': {
+        if (typeof op.by !== 'number' || !Number.isFinite(op.by)) throw new UpdateOpError(`${op.path}: inc needs a finite number`);
+        const current = parent[last] ?? 0;
+        if (typeof current !== 'number') throw new UpdateOpError(`${op.path} is not a number`);
+        parent[last] = current + op.by;
+        break;
+      }
+      case 'push':
+      case 'addUnique':
+      case 'pull': {
+        if (!Array.isArray(op.values)) throw new UpdateOpError(`${op.path}: ${op.op} needs values`);
+        const current = parent[last];
+        if (current === undefined) {
+          if (op.op !== 'pull') parent[last] = structuredClone(op.values);
+          break;
+        }
+        if (!Array.isArray(current)) throw new UpdateOpError(`${op.path} is not an array`);
+        const seen = new Set(current.map(v => canonicalJSON(v)));
+        if (op.op === 'pull') {
+          const remove = new Set(op.values.map(v => canonicalJSON(v)));
+          parent[last] = current.filter(v => !remove.has(canonicalJSON(v)));
+        } else {
+          for (const value of op.values) {
+            const key = canonicalJSON(value);
+            if (op.op === 'addUnique' && seen.has(key)) continue;
+            seen.add(key);
+            current.push(structuredClone(value));
+          }
+        }
+        break;
+      }
+      default:
+        throw new UpdateOpError(`Unknown op: ${JSON.stringify(op?.op)}`);
+    }
+  }
+  return root;
+}
@@ -0,0 +1,20 @@
+/**
+ * kimdb Version Check - versions.js 타입 선언
+ */
+
+export const MAX_VERSION_IDS: number;
+export const VERSION_QUERY_CHUNK: number;
+
+/** 잘못된 요청이면 이유, 아니면 null */
+export function versionsRequestError(versions: unknown): string | null;
+
+export interface VersionComparison {
+  /** 서버 버전이 다른 문서 */
+  stale: Array<{ id: string; _version: number }>;
+  /** 삭제되었거나 없는 문서 */
+  missing: string[];
+  upToDate: number;
+}
+
+/** 클라이언트 버전과 서버 버전 비교 */
+export function compareVersions(versions: Record<string, number>, current: Map<string, number>): VersionComparison;
@@ -0,0 +1,33 @@
+/**
+ * kimdb Version Check
+ *
+ * POST /api/c/:collection/versions { versions: { id: _version } } 의 규칙 (두 서버가 같이 씀)
+ * - 요청당 최대 1000개 (클라이언트 checkVersions가 1000개씩 나눠 보냄)
+ * - stale: 서버 버전이 다른 문서 ({ id, _version }), missing: 삭제되었거나 없는 문서
+ * - 서버 조회는 SQLite 바인딩 변수 제한(999) 아래로 500개씩
+ *
+ * 타입 선언은 versions.d.ts (api-server.js가 빌드 없이 가져다 쓰므로 JS로 둠)
+ */
+
+export const MAX_VERSION_IDS = 1000;
+export const VERSION_QUERY_CHUNK = 500;
+
+/** 잘못된 요청이면 이유, 아니면 null */
+export function versionsRequestError(versions) {
+  if (!versions || typeof versions !== 'object' || Array.isArray(versions)) return 'versions is required';
+  if (Object.keys(versions).length > MAX_VERSION_IDS) return `At most ${MAX_VERSION_IDS} ids per request`;
+  return null;
+}
+
+/** 클라이언트 버전과 서버 버전(current: id → _version, 삭제된 문서는 없음) 비교 */
+export function compareVersions(versions, current) {
+  const ids = Object.keys(versions);
+  const stale = [];
+  const missing = [];
+  for (const id of ids) {
+    const v = current.get(id);
+    if (v === undefined) missing.push(id);
+    else if (v !== versions[id]) stale.push({ id, _version: v });
+  }
+  return { stale, missing, upToDate: ids.length - stale.length - missing.length };
+}
@@ -0,0 +1,80 @@
+/**
+ * Collection Archive Unit Tests
+ */
+
+import { describe, it, expect } from 'vitest';
+import { archiveCollection, collectionSink, MemoryCheckpointStore, type ArchiveSink } from '../src/client/archive.js';
+import { FakeKimDBClient } from '../src/client/fake.js';
+
+async function seed(fake: FakeKimDBClient): Promise<void> {
+  await fake.save('events', 'e1', { createdAt: 1000 });
+  await fake.save('events', 'e2', { createdAt: '1970-01-01T00:00:02.000Z' });
+  await fake.save('events', 'e3', { createdAt: 3000 });
+  await fake.save('events', 'e4', { createdAt: 9000 });
+  await fake.save('events', 'e5', { note: 'no timestamp' });
+}
+
+describe('archiveCollection', () => {
+  it('should export old documents in batches and delete them', async () => {
+    const fake = new FakeKimDBClient();
+    await seed(fake);
+    const batches: string[][] = [];
+    const sink: ArchiveSink = {
+      write: async (collection, docs) => {
+        batches.push(docs.map(d => d.id));
+        await collectionSink(fake, `${collection}_archive`).write(collection, docs);
+      },
+    };
+
+    const result = await archiveCollection(fake, 'events', 5000, sink, { timeField: 'createdAt', batchSize: 2 });
+
+    expect(result).toEqual({ exported: 3, deleted: 3 });
+    expect(batches).toEqual([['e1', 'e2'], ['e3']]);
+    expect((await fake.list('events')).data.map(d => d.id)).toEqual(['e4', 'e5']);
+    expect((await fake.getDoc('events_archive', 'e1')).data).toEqual({ createdAt: 1000 });
+  });
+
+  it('should finish a batch from the checkpoint without writing it again', async () => {
+    const fake = new FakeKimDBClient();
+    await seed(fake);
+    const checkpoint = new MemoryCheckpointStore();
+    await checkpoint.save('archive:events', { pending: ['e1', 'e2'], exported: 2, deleted: 0 });
+
+    const written: string[] = [];
+    const result = await archiveCollection(fake, 'events', 5000, {
+      write: async (_, docs) => { written.push(...docs.map(d => d.id)); },
+    }, { timeField: 'createdAt', checkpoint });
+
+    expect(written).toEqual(['e3']);
+    expect(result).toEqual({ exported: 3, deleted: 3 });
+    expect(await checkpoint.load('archive:events')).toEqual({ pending: [], exported: 3, deleted: 3 });
+  });
+
+  it('should keep documents when the sink fails', async () => {
+    const fake = new FakeKimDBClient();
+    await seed(fake);
+
+    const run = archiveCollection(fake, 'events', 5000, {
+      write: async () => { throw new Error('bucket unavailable'); },
+    }, { timeField: 'createdAt' });
+
+    await expect(run).rejects.toThrow('bucket unavailable');
+    expect((await fake.list('events')).count).toBe(5);
+  });
+
+  it('should archive old documents on every page', async () => {
+    const fake = new FakeKimDBClient();
+    const old = (i: number) => (i >= 990 && i < 1010) || i >= 2495;
+    for (let i = 0; i < 2500; i++) await fake.save('events', `e${i}`, { createdAt: old(i) ? i : 10_000 });
+
+    const written: string[] = [];
+    const result = await archiveCollection(fake, 'events', 5000, {
+      write: async (_, docs) => { written.push(...docs.map(d => d.id)); },
+    }, { timeField: 'createdAt' });
+
+    const expected = [...Array(2500).keys()].filter(old).map(i => `e${i}`);
+    expect(result).toEqual({ exported: 25, deleted: 25 });
+    expect(written).toEqual(expected);
+    expect((await fake.listPage('events', { limit: 1000 })).total).toBe(2475);
+  });
+});
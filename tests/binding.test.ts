@@ -0,0 +1,69 @@
+/**
+ * Document Binding Unit Tests
+ */
+
+import { describe, it, expect } from 'vitest';
+import { bindDocument, type BindingClient } from '../src/client/binding.js';
+import type { TailEvent } from '../src/client/index.js';
+
+/** backfillAndTail 핸들러를 직접 호출할 수 있는 스텁 */
+function stubClient(backfill: TailEvent[] = []) {
+  let handler: (event: TailEvent) => void = () => {};
+  const saved: Array<Record<string, unknown>> = [];
+  let version = backfill.at(-1)?._version ?? 0;
+
+  const client: BindingClient = {
+    backfillAndTail: async (_collection, h) => {
+      handler = h;
+      backfill.forEach(h);
+      return () => {};
+    },
+    save: async (_collection, id, data) => {
+      saved.push(data as Record<string, unknown>);
+      return { success: true, id, _version: ++version };
+    },
+  };
+  const push = (data: unknown, _version: number, event = 'update') =>
+    handler({ source: 'live', event, id: 'd1', data, _version });
+
+  return { client, saved, push };
+}
+
+describe('bindDocument', () => {
+  it('should load server state and commit only changed fields', async () => {
+    const { client, saved } = stubClient([
+      { source: 'backfill', event: 'update', id: 'd1', data: { id: 'd1', a: 1, b: 2, _version: 3 }, _version: 3 },
+    ]);
+    const doc = await bindDocument(client, 'configs', 'd1', { a: 0 });
+
+    expect(doc.value).toEqual({ a: 1, b: 2 });
+    expect(await doc.commit()).toBe(false);
+
+    doc.value.a = 5;
+    delete (doc.value as Record<string, unknown>).b;
+    expect(doc.dirty).toBe(true);
+    expect(await doc.commit()).toBe(true);
+    expect(saved).toEqual([{ a: 5, b: null }]);
+    expect(doc.version).toBe(4);
+    expect(doc.dirty).toBe(false);
+  });
+
+  it('should keep local edits on top of remote changes', async () => {
+    const { client, push } = stubClient();
+    const doc = await bindDocument<Record<string, unknown>>(client, 'configs', 'd1', { a: 1, b: 1 });
+    const changes: boolean[] = [];
+    doc.onChange((_, change) => changes.push(change.dropped));
+
+    doc.value.a = 2;
+    push({ b: 9 }, 1);
+    expect(doc.value).toEqual({ a: 2, b: 9 });
+
+    // 오래된 이벤트는 무시
+    push({ b: 0 }, 1);
+    expect(doc.value.b).toBe(9);
+
+    push(null, 2, 'delete');
+    expect(doc.value).toEqual({});
+    expect(changes).toEqual([false, true]);
+  });
+});
@@ -0,0 +1,36 @@
+/**
+ * Channel Relay Unit Tests
+ */
+
+import { describe, it, expect } from 'vitest';
+import { ChannelRooms, channelNameError, publishError, channelMessage, MAX_CHANNEL_PAYLOAD } from '../src/shared/channels.js';
+
+describe('ChannelRooms', () => {
+  it('should route to other members and forget clients that leave', () => {
+    const rooms = new ChannelRooms();
+    rooms.join('c1', 'chat:room1');
+    rooms.join('c2', 'chat:room1');
+    rooms.join('c2', 'chat:room2');
+    rooms.join('c3', 'chat:room1');
+
+    expect(rooms.recipients('chat:room1', 'c1')).toEqual(['c2', 'c3']);
+    expect(rooms.recipients('chat:none')).toEqual([]);
+
+    rooms.leave('c3', 'chat:room1');
+    rooms.leaveAll('c2');
+    expect(rooms.recipients('chat:room1')).toEqual(['c1']);
+    expect(rooms.size).toBe(1);
+  });
+
+  it('should validate channel names and payload size', () => {
+    expect(channelNameError('chat:room-1.a_b')).toBeNull();
+    expect(channelNameError('')).toMatch('channel must be');
+    expect(channelNameError('chat room')).toMatch('channel must be');
+    expect(channelNameError('x'.repeat(129))).toMatch('channel must be');
+    expect(channelNameError(42)).toMatch('channel must be');
+
+    expect(publishError('chat:room1', { text: 'hi' })).toBeNull();
+    expect(publishError('chat:room1', 'x'.repeat(MAX_CHANNEL_PAYLOAD))).toMatch('payload must be at most');
+    expect(channelMessage('chat:room1', undefined, 'c1')).toEqual({ type: 'channel_message', channel: 'chat:room1', payload: null, from: 'c1' });
+  });
+});
@@ -0,0 +1,24 @@
+/**
+ * Chaos Transport Unit Tests
+ */
+
+import { describe, it, expect } from 'vitest';
+import { chaosFetch } from '../src/client/chaos.js';
+
+const ok: typeof fetch = async () => new Response('{"success":true}', { status: 200 });
+
+describe('chaosFetch', () => {
+  it('should return a burst of 5xx responses once triggered', async () => {
+    const rolls = [0, 1, 1, 1];
+    const f = chaosFetch({ errorRate: 0.5, errorBurst: 2, random: () => rolls.shift() ?? 1 }, ok);
+
+    const statuses = [];
+    for (let i = 0; i < 4; i++) statuses.push((await f('http://x/health')).status);
+    expect(statuses).toEqual([503, 503, 200, 200]);
+  });
+
+  it('should inject network errors', async () => {
+    const f = chaosFetch({ dropRate: 1 }, ok);
+    await expect(f('http://x/health')).rejects.toThrow(TypeError);
+  });
+});
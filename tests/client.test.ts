@@ -0,0 +1,1423 @@
+/**
+ * KimDBClient Unit Tests
+ */
+
+import { describe, it, expect, vi, afterEach } from 'vitest';
+import { KimDBClient } from '../src/client/index.js';
+import { linearBackoff, exponentialBackoff } from '../src/client/backoff.js';
+import { CRDTDocument } from '../src/crdt/index.js';
+import { negotiateProtocol } from '../src/shared/protocol.js';
+import { restoreState } from '../src/shared/snapshots.js';
+import { JsonCodec } from '../src/client/codec.js';
+import { PresenceRooms } from '../src/server/presence.js';
+
+describe('waitUntilReady', () => {
+  it('should poll /health until the server is ok', async () => {
+    let calls = 0;
+    const client = new KimDBClient({
+      url: 'ws://localhost:40000/ws',
+      fetch: async (input) => {
+        expect(String(input)).toBe('http://localhost:40000/health');
+        if (++calls < 3) throw new TypeError('fetch failed');
+        return new Response(JSON.stringify({ status: 'ok', version: '7.6.1', serverId: 'srv_1' }));
+      },
+    });
+
+    const attempts: boolean[] = [];
+    const info = await client.waitUntilReady({ backoff: linearBackoff(1, 10), onAttempt: (_, r) => attempts.push(r.ok) });
+
+    expect(info).toEqual({ version: '7.6.1', serverId: 'srv_1' });
+    expect(attempts).toEqual([false, false, true]);
+  });
+
+  it('should give up when the backoff stops', async () => {
+    const client = new KimDBClient({
+      url: 'ws://localhost:40000/ws',
+      fetch: async () => new Response('nope', { status: 503 }),
+    });
+
+    await expect(client.waitUntilReady({ backoff: linearBackoff(1, 2) })).rejects.toThrow(/after 3 attempts: HTTP 503/);
+  });
+});
+
+describe('ping', () => {
+  it('should report HTTP latency and skip WebSocket when offline', async () => {
+    const client = new KimDBClient({
+      url: 'ws://localhost:40000/ws',
+      fetch: async () => new Response(JSON.stringify({ status: 'ok', serverId: 'srv_1' })),
+    });
+
+    const report = await client.ping();
+    expect(report.serverId).toBe('srv_1');
+    expect(report.httpMs).toBeGreaterThanOrEqual(0);
+    expect(report.wsMs).toBeNull();
+  });
+});
+
+/** 'connected' 메시지만 보내는 최소 WebSocket */
+class MockSocket {
+  static instances: MockSocket[] = [];
+  readyState = 0;
+  binaryType = 'blob';
+  onopen: (() => void) | null = null;
+  onmessage: ((event: { data: string }) => void) | null = null;
+  onerror: (() => void) | null = null;
+  onclose: (() => void) | null = null;
+
+  constructor(readonly url: string) {
+    MockSocket.instances.push(this);
+    setTimeout(() => {
+      this.readyState = 1;
+      this.onopen?.();
+      this.onmessage?.({ data: JSON.stringify({ type: 'connected', clientId: 'c1', serverId: 's1' }) });
+    }, 0);
+  }
+
+  send(): void {}
+
+  close(): void {
+    if (this.readyState === 3) return;
+    this.readyState = 3;
+    setTimeout(() => this.onclose?.(), 0);
+  }
+}
+
+describe('use', () => {
+  it('should run middleware in order before handlers and drop or rewrite messages', async () => {
+    MockSocket.instances = [];
+    const client = new KimDBClient({
+      url: 'ws://localhost:40000/ws',
+      WebSocketImpl: MockSocket as unknown as typeof WebSocket,
+    });
+    await client.connect();
+
+    const order: string[] = [];
+    const seen = new Set<unknown>();
+    client.use((msg, next) => {
+      order.push(`dedup:${msg.msgId}`);
+      if (seen.has(msg.msgId)) return;
+      seen.add(msg.msgId);
+      next(msg);
+    });
+    const stopTagging = client.use((msg, next) => next({ ...msg, tagged: true }));
+
+    const received: unknown[] = [];
+    client.on('sync', (msg) => received.push(msg));
+
+    const push = (data: object) => MockSocket.instances[0].onmessage?.({ data: JSON.stringify(data) });
+    push({ type: 'sync', collection: 'users', event: 'update', msgId: 'm1' });
+    push({ type: 'sync', collection: 'users', event: 'update', msgId: 'm1' });
+    stopTagging();
+    push({ type: 'sync', collection: 'users', event: 'update', msgId: 'm2' });
+
+    expect(order).toEqual(['dedup:m1', 'dedup:m1', 'dedup:m2']);
+    expect(received).toEqual([
+      { type: 'sync', collection: 'users', event: 'update', msgId: 'm1', tagged: true },
+      { type: 'sync', collection: 'users', event: 'update', msgId: 'm2' },
+    ]);
+    client.disconnect();
+  });
+});
+
+describe('connectionStatus', () => {
+  it('should walk through connecting, connected, reconnecting and closed', async () => {
+    MockSocket.instances = [];
+    const client = new KimDBClient({
+      url: 'ws://localhost:40000/ws',
+      WebSocketImpl: MockSocket as unknown as typeof WebSocket,
+      reconnectInterval: 1,
+    });
+    const transitions: string[] = [];
+    client.onStateChange = (status, previous) => transitions.push(`${previous}->${status}`);
+    expect(client.connectionStatus).toBe('idle');
+
+    await client.connect();
+    const reconnected = new Promise<void>(resolve => { client.onConnect = resolve; });
+    MockSocket.instances[0].close();
+    await reconnected;
+    client.disconnect();
+
+    expect(client.connectionStatus).toBe('closed');
+    expect(transitions).toEqual([
+      'idle->connecting',
+      'connecting->connected',
+      'connected->reconnecting',
+      'reconnecting->connected',
+      'connected->closed',
+    ]);
+  });
+});
+
+describe('getStats', () => {
+  it('should count received events per collection and type', async () => {
+    MockSocket.instances = [];
+    const client = new KimDBClient({
+      url: 'ws://localhost:40000/ws',
+      WebSocketImpl: MockSocket as unknown as typeof WebSocket,
+    });
+    await client.connect();
+    client.on('sync', () => {});
+
+    const push = (data: object) => MockSocket.instances[0].onmessage?.({ data: JSON.stringify(data) });
+    push({ type: 'sync', collection: 'orders', event: 'update' });
+    push({ type: 'sync', collection: 'orders', event: 'update' });
+    push({ type: 'subscribed', collection: 'users' });
+
+    const { collections } = client.getStats();
+    expect(Object.keys(collections).sort()).toEqual(['orders', 'users']);
+    expect(collections.orders.sync.count).toBe(2);
+    expect(collections.orders.sync.bytes).toBeGreaterThan(0);
+    expect(collections.orders.sync.handlerMs).toBeGreaterThanOrEqual(0);
+    expect(collections.users.subscribed).toMatchObject({ count: 1, handlerMs: 0 });
+
+    collections.orders.sync.count = 0;
+    expect(client.getStats().collections.orders.sync.count).toBe(2);
+    client.disconnect();
+  });
+});
+
+describe('slowHandlerThreshold', () => {
+  /** performance.now를 고정하고, 핸들러가 ms만큼 시간을 쓴 것처럼 보이게 함 */
+  function fakeClock() {
+    let now = 0;
+    const spy = vi.spyOn(performance, 'now').mockImplementation(() => now);
+    return { spend: (ms: number) => { now += ms; }, restore: () => spy.mockRestore() };
+  }
+
+  async function connected(options: { slowHandlerThreshold?: number } = {}) {
+    MockSocket.instances = [];
+    const client = new KimDBClient({
+      url: 'ws://localhost:40000/ws',
+      WebSocketImpl: MockSocket as unknown as typeof WebSocket,
+      ...options,
+    });
+    await client.connect();
+    const push = (data: object) => MockSocket.instances[0].onmessage?.({ data: JSON.stringify(data) });
+    return { client, push };
+  }
+
+  it('should warn once per message type and keep counting slow calls in stats', async () => {
+    const { client, push } = await connected();
+    const clock = fakeClock();
+    const warn = vi.spyOn(console, 'warn').mockImplementation(() => {});
+    try {
+      client.on('sync', () => clock.spend(80));
+      client.on('pong', () => clock.spend(10));
+      push({ type: 'sync', collection: 'orders', event: 'update' });
+      push({ type: 'sync', collection: 'orders', event: 'update' });
+      push({ type: 'pong' });
+
+      expect(warn).toHaveBeenCalledTimes(1);
+      expect(warn.mock.calls[0][0]).toContain("Slow 'sync' handler: 80.0ms (threshold 50ms");
+      expect(client.getStats().handlerLatency.sync).toEqual({ calls: 2, totalMs: 160, maxMs: 80 });
+
+      client.on('subscribed', () => clock.spend(60));
+      push({ type: 'subscribed', collection: 'users' });
+      expect(warn).toHaveBeenCalledTimes(2);
+      expect(warn.mock.calls[1][0]).toContain("Slow 'subscribed' handler");
+    } finally {
+      warn.mockRestore();
+      clock.restore();
+      client.disconnect();
+    }
+  });
+
+  it('should report every slow call to onSlowHandler instead of the console', async () => {
+    const { client, push } = await connected({ slowHandlerThreshold: 20 });
+    const clock = fakeClock();
+    const warn = vi.spyOn(console, 'warn').mockImplementation(() => {});
+    try {
+      const slow: Array<[string, number]> = [];
+      client.onSlowHandler = (type, ms) => slow.push([type, ms]);
+      client.on('sync', () => clock.spend(30));
+      push({ type: 'sync', collection: 'orders', event: 'update' });
+      push({ type: 'sync', collection: 'orders', event: 'update' });
+
+      expect(slow).toEqual([['sync', 30], ['sync', 30]]);
+      expect(warn).not.toHaveBeenCalled();
+    } finally {
+      warn.mockRestore();
+      clock.restore();
+      client.disconnect();
+    }
+  });
+
+  it('should not warn when the threshold is 0', async () => {
+    const { client, push } = await connected({ slowHandlerThreshold: 0 });
+    const clock = fakeClock();
+    const warn = vi.spyOn(console, 'warn').mockImplementation(() => {});
+    try {
+      client.on('sync', () => clock.spend(1000));
+      push({ type: 'sync', collection: 'orders', event: 'update' });
+      expect(warn).not.toHaveBeenCalled();
+    } finally {
+      warn.mockRestore();
+      clock.restore();
+      client.disconnect();
+    }
+  });
+});
+
+describe('stallTimeout', () => {
+  it('should report a silent subscription and reconnect', async () => {
+    MockSocket.instances = [];
+    const client = new KimDBClient({
+      url: 'ws://localhost:40000/ws',
+      WebSocketImpl: MockSocket as unknown as typeof WebSocket,
+      stallTimeout: 80,
+    });
+    const stalled: string[] = [];
+    client.onSubscriptionStalled = (collection) => stalled.push(collection);
+    client.subscribe('users');
+    await client.connect();
+
+    await new Promise(resolve => setTimeout(resolve, 240));
+    expect(stalled[0]).toBe('users');
+    expect(MockSocket.instances.length).toBeGreaterThan(1);
+    client.disconnect();
+  });
+
+  it('should treat a subscribed reply to the probe as a heartbeat', async () => {
+    class AckSocket extends MockSocket {
+      send(frame?: string): void {
+        const msg = JSON.parse(frame!) as { type: string; collection: string };
+        if (msg.type === 'subscribe') {
+          setTimeout(() => this.onmessage?.({ data: JSON.stringify({ type: 'subscribed', collection: msg.collection }) }), 0);
+        }
+      }
+    }
+    MockSocket.instances = [];
+    const client = new KimDBClient({
+      url: 'ws://localhost:40000/ws',
+      WebSocketImpl: AckSocket as unknown as typeof WebSocket,
+      stallTimeout: 80,
+    });
+    const stalled: string[] = [];
+    client.onSubscriptionStalled = (collection) => stalled.push(collection);
+    client.subscribe('users');
+    await client.connect();
+
+    await new Promise(resolve => setTimeout(resolve, 240));
+    expect(stalled).toEqual([]);
+    expect(MockSocket.instances).toHaveLength(1);
+    client.disconnect();
+  });
+});
+
+describe('connectionMaxLifetime', () => {
+  /** 보낸 메시지를 기록하는 소켓 */
+  class RecordingSocket extends MockSocket {
+    sent: Array<Record<string, unknown>> = [];
+    closeReason?: string;
+
+    send(frame?: string): void {
+      this.sent.push(JSON.parse(frame!));
+    }
+
+    close(code?: number, reason?: string): void {
+      this.closeReason ??= reason;
+      super.close();
+    }
+  }
+
+  afterEach(() => {
+    vi.useRealTimers();
+  });
+
+  it('should recycle the connection when it expires and restore subscriptions', async () => {
+    vi.useFakeTimers();
+    MockSocket.instances = [];
+    const client = new KimDBClient({
+      url: 'ws://localhost:40000/ws',
+      WebSocketImpl: RecordingSocket as unknown as typeof WebSocket,
+      connectionMaxLifetime: 60_000,
+      reconnectInterval: 10_000,
+    });
+    const statuses: string[] = [];
+    client.onStateChange = (status) => statuses.push(status);
+    client.subscribe('users');
+    client.subscribeChannel('chat:room1', () => {});
+    const connecting = client.connect();
+    await vi.advanceTimersByTimeAsync(0);
+    await connecting;
+
+    await vi.advanceTimersByTimeAsync(59_999);
+    expect(MockSocket.instances).toHaveLength(1);
+
+    await vi.advanceTimersByTimeAsync(1);
+    const [first] = MockSocket.instances as RecordingSocket[];
+    expect(first.closeReason).toBe('recycle');
+
+    // 재연결 대기(reconnectInterval) 없이 바로 새 연결
+    await vi.advanceTimersByTimeAsync(10);
+    expect(MockSocket.instances).toHaveLength(2);
+    const second = MockSocket.instances[1] as RecordingSocket;
+    expect(client.isConnected).toBe(true);
+    expect(second.sent).toEqual(expect.arrayContaining([
+      { type: 'subscribe', collection: 'users' },
+      { type: 'channel_subscribe', channel: 'chat:room1' },
+    ]));
+    expect(statuses).toEqual(['connecting', 'connected', 'reconnecting', 'connected']);
+    expect(client.getStats().reconnects).toBe(1);
+
+    // 새 연결도 수명이 다시 시작됨
+    await vi.advanceTimersByTimeAsync(60_000);
+    expect(second.closeReason).toBe('recycle');
+    expect(MockSocket.instances).toHaveLength(3);
+    client.disconnect();
+  });
+
+  it('should not recycle after disconnect', async () => {
+    vi.useFakeTimers();
+    MockSocket.instances = [];
+    const client = new KimDBClient({
+      url: 'ws://localhost:40000/ws',
+      WebSocketImpl: RecordingSocket as unknown as typeof WebSocket,
+      connectionMaxLifetime: 1_000,
+    });
+    const connecting = client.connect();
+    await vi.advanceTimersByTimeAsync(0);
+    await connecting;
+    client.disconnect();
+
+    await vi.advanceTimersByTimeAsync(5_000);
+    expect(MockSocket.instances).toHaveLength(1);
+  });
+});
+
+describe('run', () => {
+  it('should connect and close when the signal aborts', async () => {
+    MockSocket.instances = [];
+    const client = new KimDBClient({
+      url: 'ws://localhost:40000/ws',
+      WebSocketImpl: MockSocket as unknown as typeof WebSocket,
+    });
+    const controller = new AbortController();
+    client.onConnect = () => controller.abort();
+
+    await client.run(controller.signal);
+
+    expect(MockSocket.instances).toHaveLength(1);
+    expect(MockSocket.instances[0].readyState).toBe(3);
+    expect(client.isConnected).toBe(false);
+  });
+});
+
+describe('connectWithRetry', () => {
+  it('should keep dialing until the server accepts', async () => {
+    let attempts = 0;
+    class FlakySocket extends MockSocket {
+      constructor(url: string) {
+        if (++attempts < 3) {
+          throw new Error('ECONNREFUSED');
+        }
+        super(url);
+      }
+    }
+
+    const client = new KimDBClient({
+      url: 'ws://localhost:40000/ws',
+      WebSocketImpl: FlakySocket as unknown as typeof WebSocket,
+    });
+    const errors: string[] = [];
+    await client.connectWithRetry({
+      backoff: linearBackoff(1, 5),
+      onAttempt: (_, e) => e && errors.push(e.message),
+    });
+
+    expect(client.isConnected).toBe(true);
+    expect(errors).toEqual(['ECONNREFUSED', 'ECONNREFUSED']);
+    client.disconnect();
+  });
+});
+
+describe('backoff', () => {
+  it('should grow linearly and stop after maxAttempts', () => {
+    const backoff = linearBackoff(100, 3);
+    expect([1, 2, 3, 4].map(a => backoff.nextDelay(a))).toEqual([100, 200, 300, null]);
+  });
+
+  it('should grow exponentially up to max without jitter', () => {
+    const backoff = exponentialBackoff({ initial: 100, max: 1000, factor: 3, jitter: 0, maxAttempts: 5 });
+    expect([1, 2, 3, 4, 5, 6].map(a => backoff.nextDelay(a))).toEqual([100, 300, 900, 1000, 1000, null]);
+  });
+
+  it('should keep jittered delays within the spread around the capped base', () => {
+    const backoff = exponentialBackoff({ initial: 500, max: 2000, jitter: 0.2 });
+    const random = vi.spyOn(Math, 'random');
+    try {
+      random.mockReturnValue(0);
+      expect(backoff.nextDelay(1)).toBe(400);
+      expect(backoff.nextDelay(10)).toBe(1600);
+      random.mockReturnValue(0.999999);
+      expect(backoff.nextDelay(1)).toBe(600);
+      expect(backoff.nextDelay(10)).toBe(2400);
+    } finally {
+      random.mockRestore();
+    }
+
+    for (let attempt = 1; attempt <= 20; attempt++) {
+      const base = Math.min(2000, 500 * 2 ** (attempt - 1));
+      const delay = backoff.nextDelay(attempt)!;
+      expect(delay).toBeGreaterThanOrEqual(base * 0.8);
+      expect(delay).toBeLessThanOrEqual(base * 1.2);
+    }
+  });
+
+  it('should use the documented defaults', () => {
+    const random = vi.spyOn(Math, 'random').mockReturnValue(0.5);
+    try {
+      const backoff = exponentialBackoff();
+      expect(backoff.nextDelay(1)).toBe(500);
+      expect(backoff.nextDelay(100)).toBe(30000);
+    } finally {
+      random.mockRestore();
+    }
+  });
+});
+
+describe('outbox', () => {
+  /** crdt_get에 빈 문서로 답하고, ack가 켜져 있으면 crdt_ops에 확인을 보내는 소켓 */
+  class DocSocket extends MockSocket {
+    static ack = false;
+    sent: Array<Record<string, unknown>> = [];
+
+    send(frame?: string): void {
+      const msg = JSON.parse(frame!) as Record<string, unknown>;
+      this.sent.push(msg);
+      const reply = (data: unknown) => setTimeout(() => this.onmessage?.({ data: JSON.stringify(data) }), 0);
+      if (msg.type === 'crdt_get') {
+        reply({ type: 'crdt_state', collection: msg.collection, docId: msg.docId, state: new CRDTDocument('server', 'd1').toJSON() });
+      }
+      if (msg.type === 'crdt_ops' && DocSocket.ack) {
+        reply({ type: 'crdt_ops_ok', docId: msg.docId, applied: 1, version: 1, msgId: msg.msgId });
+      }
+    }
+  }
+
+  it('should retransmit unacknowledged ops after reconnecting', async () => {
+    MockSocket.instances = [];
+    DocSocket.ack = false;
+    const client = new KimDBClient({
+      url: 'ws://localhost:40000/ws',
+      WebSocketImpl: DocSocket as unknown as typeof WebSocket,
+      backoff: linearBackoff(1, 5),
+      batchTimeout: 1,
+    });
+    await client.connect();
+    await client.openDocument('docs', 'd1');
+
+    client.set('docs', 'd1', 'title', 'hello');
+    await new Promise(resolve => setTimeout(resolve, 20));
+    const [first] = (MockSocket.instances[0] as DocSocket).sent.filter(m => m.type === 'crdt_ops');
+    expect(client.outboxSize).toBe(1);
+
+    // 확인 전에 끊김 → 재연결 후 같은 msgId로 재전송, 확인되면 비움
+    DocSocket.ack = true;
+    const reconnected = new Promise<void>(resolve => { client.onConnect = resolve; });
+    MockSocket.instances[0].close();
+    await reconnected;
+    await new Promise(resolve => setTimeout(resolve, 20));
+
+    const resent = (MockSocket.instances[1] as DocSocket).sent.filter(m => m.type === 'crdt_ops');
+    expect(resent.map(m => m.msgId)).toEqual([first.msgId]);
+    expect(client.outboxSize).toBe(0);
+    client.disconnect();
+  });
+
+  it('should report sync lag from queued through acknowledged', async () => {
+    MockSocket.instances = [];
+    DocSocket.ack = false;
+    const client = new KimDBClient({
+      url: 'ws://localhost:40000/ws',
+      WebSocketImpl: DocSocket as unknown as typeof WebSocket,
+      batchTimeout: 5,
+    });
+    const labels: string[] = [];
+    client.onSyncLag = (_c, _id, lag) => labels.push(lag.saved ? 'saved' : `q${lag.queued}p${lag.pending}`);
+    await client.connect();
+    await client.openDocument('docs', 'd1');
+
+    client.set('docs', 'd1', 'title', 'a');
+    client.set('docs', 'd1', 'title', 'ab');
+    expect(client.syncLag('docs', 'd1')).toEqual({ queued: 2, pending: 0, ackedVersion: null, saved: false });
+
+    await new Promise(resolve => setTimeout(resolve, 20));
+    expect(client.syncLag('docs')).toMatchObject({ queued: 0, pending: 1, saved: false });
+
+    DocSocket.ack = true;
+    client.set('docs', 'd1', 'body', 'x');
+    await new Promise(resolve => setTimeout(resolve, 20));
+    expect(client.syncLag('docs', 'd1')).toMatchObject({ pending: 1, ackedVersion: 1 });
+    expect(labels).toEqual(['q1p0', 'q2p0', 'q0p1', 'q1p1', 'q0p2', 'q0p1']);
+    client.disconnect();
+  });
+
+  it('should flush queued edits and resolve once they are acknowledged', async () => {
+    MockSocket.instances = [];
+    DocSocket.ack = false;
+    const client = new KimDBClient({
+      url: 'ws://localhost:40000/ws',
+      WebSocketImpl: DocSocket as unknown as typeof WebSocket,
+      backoff: linearBackoff(1, 5),
+      batchTimeout: 1000,
+    });
+    let flushed = 0;
+    client.onFlushed = () => { flushed++; };
+    await client.connect();
+    await client.openDocument('docs', 'd1');
+
+    client.set('docs', 'd1', 'title', 'hello');
+    await expect(client.flush({ timeoutMs: 30 })).rejects.toThrow('1 message(s) unacknowledged');
+
+    // 재연결 후 재전송이 확인되면 resolve
+    DocSocket.ack = true;
+    const done = client.flush();
+    MockSocket.instances[0].close();
+    await done;
+    expect(client.outboxSize).toBe(0);
+    expect(flushed).toBe(1);
+    await client.flush();
+    client.disconnect();
+  });
+});
+
+describe('watch', () => {
+  it('should release its own subscription reference when disposed', async () => {
+    class SentSocket extends MockSocket {
+      sent: Array<{ type: string }> = [];
+      send(frame?: string): void {
+        this.sent.push(JSON.parse(frame!));
+      }
+    }
+    MockSocket.instances = [];
+    const client = new KimDBClient({ url: 'ws://localhost:40000/ws', WebSocketImpl: SentSocket as unknown as typeof WebSocket });
+    await client.connect();
+    const socket = MockSocket.instances[0] as SentSocket;
+    const types = () => socket.sent.map(m => m.type);
+
+    const got: string[] = [];
+    const stopA = client.watch('users', (m) => got.push(`a:${m.id}`));
+    const stopB = client.watch('users', (m) => got.push(`b:${m.id}`));
+    stopA();
+    stopA();
+    socket.onmessage?.({ data: JSON.stringify({ type: 'sync', collection: 'users', event: 'update', id: 'u1', data: {} }) });
+    expect(got).toEqual(['b:u1']);
+    expect(types()).toEqual(['subscribe']);
+
+    stopB();
+    expect(types()).toEqual(['subscribe', 'unsubscribe']);
+    client.disconnect();
+  });
+});
+
+describe('channels', () => {
+  class ChannelSocket extends MockSocket {
+    sent: Array<{ type: string; channel?: string; requestId?: string; payload?: unknown }> = [];
+    send(frame?: string): void {
+      this.sent.push(JSON.parse(frame!));
+    }
+    reply(data: object): void {
+      this.onmessage?.({ data: JSON.stringify(data) });
+    }
+  }
+
+  it('should subscribe once per channel, deliver messages and unsubscribe after the last handler', async () => {
+    MockSocket.instances = [];
+    const client = new KimDBClient({ url: 'ws://localhost:40000/ws', WebSocketImpl: ChannelSocket as unknown as typeof WebSocket });
+    await client.connect();
+    const socket = MockSocket.instances[0] as ChannelSocket;
+
+    const got: string[] = [];
+    const stopA = client.subscribeChannel<{ text: string }>('chat:room1', (p, from) => got.push(`a:${p.text}:${from}`));
+    const stopB = client.subscribeChannel<{ text: string }>('chat:room1', (p) => got.push(`b:${p.text}`));
+    socket.reply({ type: 'channel_message', channel: 'chat:room1', payload: { text: 'hi' }, from: 'c2' });
+    socket.reply({ type: 'channel_message', channel: 'chat:other', payload: { text: 'no' }, from: 'c2' });
+    expect(got).toEqual(['a:hi:c2', 'b:hi']);
+
+    stopA();
+    stopA();
+    stopB();
+    expect(socket.sent.map(m => [m.type, m.channel])).toEqual([
+      ['channel_subscribe', 'chat:room1'],
+      ['channel_unsubscribe', 'chat:room1'],
+    ]);
+    expect(() => client.subscribeChannel('chat room', () => {})).toThrow('channel must be');
+    client.disconnect();
+  });
+
+  it('should publish through call and resolve with the delivered count', async () => {
+    MockSocket.instances = [];
+    const client = new KimDBClient({ url: 'ws://localhost:40000/ws', WebSocketImpl: ChannelSocket as unknown as typeof WebSocket });
+    await client.connect();
+    const socket = MockSocket.instances[0] as ChannelSocket;
+
+    const published = client.publishChannel('chat:room1', { text: 'hi' });
+    const request = socket.sent[0];
+    expect(request).toMatchObject({ type: 'channel_publish', channel: 'chat:room1', payload: { text: 'hi' } });
+    socket.reply({ type: 'channel_published', channel: 'chat:room1', delivered: 2, requestId: request.requestId });
+    expect(await published).toEqual({ delivered: 2 });
+    client.disconnect();
+  });
+});
+
+describe('snapshots', () => {
+  /** 서버 문서 하나로 crdt_get과 snapshot_* 요청에 답하는 소켓 */
+  class SnapshotSocket extends MockSocket {
+    static server: CRDTDocument;
+    static saved = new Map<string, unknown>();
+    sent: Array<Record<string, unknown>> = [];
+
+    send(frame?: string): void {
+      const msg = JSON.parse(frame!) as Record<string, unknown>;
+      this.sent.push(msg);
+      const doc = SnapshotSocket.server;
+      const { collection, docId, label, requestId } = msg;
+      if (msg.type === 'crdt_get') {
+        this.reply({ type: 'crdt_state', collection, docId, state: doc.toJSON() });
+      } else if (msg.type === 'snapshot_create') {
+        SnapshotSocket.saved.set(label as string, JSON.parse(JSON.stringify(doc.toJSON())));
+        this.reply({ type: 'snapshot_created', collection, docId, label, version: doc.version, createdAt: 1000, requestId });
+      } else if (msg.type === 'snapshot_list') {
+        const snapshots = [...SnapshotSocket.saved.keys()].map(l => ({ label: l, version: 1, createdAt: 1000 }));
+        this.reply({ type: 'snapshots', collection, docId, snapshots, requestId });
+      } else if (msg.type === 'snapshot_restore') {
+        restoreState(doc, SnapshotSocket.saved.get(label as string) as { version?: number });
+        this.reply({ type: 'crdt_state', collection, docId, state: doc.toJSON(), restored: label });
+        this.reply({ type: 'snapshot_restored', collection, docId, label, version: doc.version, requestId });
+      }
+    }
+
+    reply(data: object): void {
+      setTimeout(() => this.onmessage?.({ data: JSON.stringify(data) }), 0);
+    }
+  }
+
+  it('should save a snapshot and restore it into the open document and its text editors', async () => {
+    MockSocket.instances = [];
+    SnapshotSocket.server = new CRDTDocument('server', 'd1');
+    SnapshotSocket.server.set('title', 'v1');
+    const client = new KimDBClient({ url: 'ws://localhost:40000/ws', WebSocketImpl: SnapshotSocket as unknown as typeof WebSocket });
+    await client.connect();
+    const socket = MockSocket.instances[0] as SnapshotSocket;
+    const doc = await client.openDocument('docs', 'd1');
+    const text = client.text('docs', 'd1', 'body');
+    let changes = 0;
+    text.onChange = () => changes++;
+
+    expect(await client.snapshot('docs', 'd1', 'draft')).toEqual({ label: 'draft', version: SnapshotSocket.server.version, createdAt: 1000 });
+    expect(await client.listSnapshots('docs', 'd1')).toEqual([{ label: 'draft', version: 1, createdAt: 1000 }]);
+
+    // 스냅샷 뒤 다른 클라이언트의 편집
+    const operations = [SnapshotSocket.server.set('title', 'v2'), ...[...'hello'].map((c, i) => SnapshotSocket.server.richInsert('body', i, c))];
+    socket.onmessage?.({ data: JSON.stringify({ type: 'crdt_sync', collection: 'docs', docId: 'd1', operations }) });
+    text.cursor = 5;
+    expect(doc.get('title')).toBe('v2');
+
+    const version = await client.restoreSnapshot('docs', 'd1', 'draft');
+    expect(version).toBe(SnapshotSocket.server.version);
+    expect(doc.get('title')).toBe('v1');
+    expect(text.toString()).toBe('');
+    expect(text.cursor).toBe(0);
+    expect(changes).toBeGreaterThan(0);
+    expect(await client.openDocument('docs', 'd1')).toBe(doc);
+
+    await expect(client.snapshot('docs', 'd1', '')).rejects.toThrow('label must be');
+    expect(socket.sent.filter(m => String(m.type).startsWith('snapshot_')).map(m => m.type)).toEqual(['snapshot_create', 'snapshot_list', 'snapshot_restore']);
+    client.disconnect();
+  });
+});
+
+describe('getPresence', () => {
+  /** presence_join/presence_get을 서버의 PresenceRooms로 처리하는 소켓 */
+  class PresenceSocket extends MockSocket {
+    static rooms: PresenceRooms;
+    static answer = true;
+
+    send(frame?: string): void {
+      const msg = JSON.parse(frame!) as Record<string, unknown>;
+      const { rooms } = PresenceSocket;
+      if (msg.type === 'presence_join') {
+        this.reply(rooms.join('c1', msg.collection as string, msg.docId as string, msg.user as Record<string, unknown>).reply);
+      } else if (msg.type === 'presence_get' && PresenceSocket.answer) {
+        // 다른 문서의 응답이 먼저 와도 요청한 문서의 응답만 받아야 함
+        this.reply(rooms.users('docs', 'other'));
+        this.reply(rooms.users(msg.collection as string, msg.docId as string));
+      }
+    }
+
+    reply(data: object): void {
+      setTimeout(() => this.onmessage?.({ data: JSON.stringify(data) }), 0);
+    }
+  }
+
+  async function connected() {
+    MockSocket.instances = [];
+    PresenceSocket.rooms = new PresenceRooms('s1', 30000);
+    PresenceSocket.answer = true;
+    const client = new KimDBClient({
+      url: 'ws://localhost:40000/ws',
+      WebSocketImpl: PresenceSocket as unknown as typeof WebSocket,
+    });
+    await client.connect();
+    return client;
+  }
+
+  it('should return the users already in the document', async () => {
+    const client = await connected();
+    PresenceSocket.rooms.join('c2', 'docs', 'd1', { name: 'Lee' });
+    PresenceSocket.rooms.join('c3', 'docs', 'other', { name: 'Park' });
+    client.joinPresence('docs', 'd1', { name: 'Kim' });
+
+    const users = await client.getPresence('docs', 'd1');
+    expect(users.map(u => u.nodeId)).toEqual(['client_c2', 'client_c1']);
+    expect(users[0]).toMatchObject({ name: 'Lee' });
+    expect(await client.getPresence('docs', 'empty')).toEqual([]);
+    client.disconnect();
+  });
+
+  it('should reject after the timeout and stop listening', async () => {
+    const client = await connected();
+    PresenceSocket.answer = false;
+    vi.useFakeTimers();
+    try {
+      const pending = client.getPresence('docs', 'd1', 1000);
+      const rejected = expect(pending).rejects.toThrow('Presence request timeout: docs/d1');
+      await vi.advanceTimersByTimeAsync(1000);
+      await rejected;
+
+      const handlers = (client as unknown as { messageHandlers: Map<string, unknown[]> }).messageHandlers;
+      expect(handlers.get('presence_users') ?? []).toHaveLength(0);
+    } finally {
+      vi.useRealTimers();
+      client.disconnect();
+    }
+  });
+});
+
+describe('call', () => {
+  /** 받은 요청을 쌓아 두고 테스트가 원하는 순서로 답하는 소켓 */
+  class RpcSocket extends MockSocket {
+    requests: Array<{ type: string; requestId: string; [key: string]: unknown }> = [];
+
+    send(frame?: string): void {
+      this.requests.push(JSON.parse(frame!));
+    }
+
+    reply(data: object): void {
+      this.onmessage?.({ data: JSON.stringify(data) });
+    }
+  }
+
+  async function rpcClient(): Promise<{ client: KimDBClient; socket: RpcSocket }> {
+    MockSocket.instances = [];
+    const client = new KimDBClient({ url: 'ws://localhost:40000/ws', WebSocketImpl: RpcSocket as unknown as typeof WebSocket });
+    await client.connect();
+    return { client, socket: MockSocket.instances[0] as RpcSocket };
+  }
+
+  it('should resolve each caller with its own reply when replies arrive out of order', async () => {
+    const { client, socket } = await rpcClient();
+    const calls = ['a', 'b', 'c'].map(name => client.call('echo', { name }));
+    expect(socket.requests.map(r => r.name)).toEqual(['a', 'b', 'c']);
+
+    for (const request of [...socket.requests].reverse()) {
+      socket.reply({ type: 'echo_ok', requestId: request.requestId, name: request.name });
+    }
+    // 요청 ID가 없는 메시지는 어느 호출에도 붙지 않음
+    socket.reply({ type: 'echo_ok', name: 'stray' });
+
+    const replies = await Promise.all(calls);
+    expect(replies.map(r => r.name)).toEqual(['a', 'b', 'c']);
+    client.disconnect();
+  });
+
+  it('should not hand a late reply for a timed-out call to the next caller', async () => {
+    const { client, socket } = await rpcClient();
+    const slow = client.call('echo', { name: 'slow' }, { timeoutMs: 10 });
+    await expect(slow).rejects.toThrow('Request timeout: echo (10ms)');
+
+    const next = client.call('echo', { name: 'next' });
+    const failing = client.call('echo', { name: 'failing' });
+    const [slowReq, nextReq, failingReq] = socket.requests;
+    expect(new Set([slowReq.requestId, nextReq.requestId, failingReq.requestId]).size).toBe(3);
+
+    socket.reply({ type: 'echo_ok', requestId: slowReq.requestId, name: 'slow' });
+    socket.reply({ type: 'error', requestId: failingReq.requestId, message: 'nope' });
+    socket.reply({ type: 'echo_ok', requestId: nextReq.requestId, name: 'next' });
+
+    await expect(failing).rejects.toThrow('nope');
+    expect((await next).name).toBe('next');
+    client.disconnect();
+  });
+});
+
+describe('protocol handshake', () => {
+  /** URL의 protocol을 서버와 같은 규칙으로 협상하는 소켓 */
+  class HandshakeSocket {
+    static urls: string[] = [];
+    readyState = 0;
+    binaryType = 'blob';
+    onopen: (() => void) | null = null;
+    onmessage: ((event: { data: string }) => void) | null = null;
+    onerror: (() => void) | null = null;
+    onclose: (() => void) | null = null;
+
+    constructor(url: string) {
+      HandshakeSocket.urls.push(url);
+      const requested = new URL(url).searchParams.get('protocol') ?? undefined;
+      const protocol = negotiateProtocol(requested);
+      setTimeout(() => {
+        this.readyState = 1;
+        this.onopen?.();
+        const msg = protocol
+          ? { type: 'connected', clientId: 'c1', serverId: 's1', protocol }
+          : { type: 'error', message: `Unsupported protocol: ${requested}`, protocol: 'json/1' };
+        this.onmessage?.({ data: JSON.stringify(msg) });
+        if (!protocol) this.close();
+      }, 0);
+    }
+
+    send(): void {}
+
+    close(): void {
+      if (this.readyState === 3) return;
+      this.readyState = 3;
+      setTimeout(() => this.onclose?.(), 0);
+    }
+  }
+
+  it('should negotiate only protocols the server speaks', () => {
+    expect(negotiateProtocol(undefined)).toBe('json/1');
+    expect(negotiateProtocol('json/1')).toBe('json/1');
+    expect(negotiateProtocol('json/2')).toBeNull();
+    expect(negotiateProtocol(['json/1'])).toBeNull();
+  });
+
+  it('should send its protocol and connect when the server echoes it', async () => {
+    HandshakeSocket.urls = [];
+    const client = new KimDBClient({ url: 'ws://localhost:40000/ws', WebSocketImpl: HandshakeSocket as unknown as typeof WebSocket });
+    await client.connect();
+    expect(new URL(HandshakeSocket.urls[0]).searchParams.get('protocol')).toBe('json/1');
+    expect(client.isConnected).toBe(true);
+    client.disconnect();
+  });
+
+  it('should fail without reconnecting when the server rejects the protocol', async () => {
+    const errors: string[] = [];
+    const client = new KimDBClient({
+      url: 'ws://localhost:40000/ws',
+      WebSocketImpl: HandshakeSocket as unknown as typeof WebSocket,
+      codec: { ...JsonCodec, name: 'msgpack' },
+    });
+    client.onError = e => errors.push(e.message);
+    await expect(client.connect()).rejects.toThrow('Protocol mismatch: client msgpack/1, server json/1');
+    await new Promise(resolve => setTimeout(resolve, 10));
+    expect(client.connectionStatus).toBe('closed');
+    expect(errors).toEqual(['Protocol mismatch: client msgpack/1, server json/1']);
+  });
+});
+
+describe('undo/redo', () => {
+  /** 서버 문서에 crdt_ops를 적용하는 소켓 */
+  class UndoSocket extends MockSocket {
+    static doc = new CRDTDocument('server', 'd1');
+    sent: Array<Record<string, unknown>> = [];
+
+    send(frame?: string): void {
+      const msg = JSON.parse(frame!) as Record<string, unknown> & { collection: string; docId: string };
+      this.sent.push(msg);
+      const { doc } = UndoSocket;
+      const reply = (data: object) => setTimeout(() => this.onmessage?.({ data: JSON.stringify({ ...data, requestId: msg.requestId }) }), 0);
+      switch (msg.type) {
+        case 'crdt_get':
+          reply({ type: 'crdt_state', collection: msg.collection, docId: msg.docId, state: doc.toJSON() });
+          break;
+        case 'crdt_ops':
+          doc.applyRemoteBatch(msg.operations as unknown[]);
+          reply({ type: 'crdt_ops_ok', docId: msg.docId, applied: 1, version: doc.version, msgId: msg.msgId });
+          break;
+      }
+    }
+  }
+
+  it('should undo and redo from local history and sync the result like any edit', async () => {
+    MockSocket.instances = [];
+    UndoSocket.doc = new CRDTDocument('server', 'd1');
+    const client = new KimDBClient({
+      url: 'ws://localhost:40000/ws',
+      WebSocketImpl: UndoSocket as unknown as typeof WebSocket,
+      batchTimeout: 1,
+    });
+    const states: string[] = [];
+    client.onUndoStateChange = (_c, _id, s) => states.push(`${s.canUndo ? 'U' : '-'}${s.canRedo ? 'R' : '-'}`);
+    await client.connect();
+    await client.openDocument('docs', 'd1');
+
+    // transact로 묶은 두 편집이 한 단위, 뒤의 편집은 별도 단위
+    client.transact('docs', 'd1', () => {
+      client.set('docs', 'd1', 'title', 'a');
+      client.set('docs', 'd1', 'body', 'x');
+    });
+    client.set('docs', 'd1', 'title', 'b');
+
+    const synced = async () => {
+      await new Promise(resolve => setTimeout(resolve, 20));
+      return [client.get('docs', 'd1', 'title'), UndoSocket.doc.get('title'), client.get('docs', 'd1', 'body')];
+    };
+    expect(await synced()).toEqual(['b', 'b', 'x']);
+
+    // 로컬 문서에는 바로 반영
+    expect(client.undo('docs', 'd1')).toBe(true);
+    expect(client.get('docs', 'd1', 'title')).toBe('a');
+    expect(await synced()).toEqual(['a', 'a', 'x']);
+    expect(client.undo('docs', 'd1')).toBe(true);
+    expect(await synced()).toEqual([undefined, undefined, undefined]);
+    expect(client.undo('docs', 'd1')).toBe(false);
+    expect(client.getUndoState('docs', 'd1')).toEqual({ canUndo: false, canRedo: true });
+
+    // 같은 스택 항목을 다시 적용해도 중복 op로 버려지지 않음
+    expect(client.redo('docs', 'd1')).toBe(true);
+    expect(client.redo('docs', 'd1')).toBe(true);
+    expect(await synced()).toEqual(['b', 'b', 'x']);
+    expect(client.undo('docs', 'd1')).toBe(true);
+    expect(await synced()).toEqual(['a', 'a', 'x']);
+
+    // 새 편집은 redo 히스토리를 지움
+    client.set('docs', 'd1', 'title', 'c');
+    expect(client.getUndoState('docs', 'd1')).toEqual({ canUndo: true, canRedo: false });
+    client.clearUndo('docs', 'd1');
+
+    const sentTypes = new Set((MockSocket.instances[0] as UndoSocket).sent.map(m => m.type));
+    expect(sentTypes.has('undo_capture') || sentTypes.has('undo')).toBe(false);
+    expect(states).toEqual(['U-', 'UR', '-R', 'UR', 'U-', 'UR', 'U-', '--']);
+    client.disconnect();
+  });
+
+  it('should group edits made within captureTimeout', async () => {
+    vi.useFakeTimers();
+    try {
+      MockSocket.instances = [];
+      UndoSocket.doc = new CRDTDocument('server', 'd1');
+      const client = new KimDBClient({ url: 'ws://localhost:40000/ws', WebSocketImpl: UndoSocket as unknown as typeof WebSocket });
+      const connected = client.connect();
+      await vi.advanceTimersByTimeAsync(1);
+      await connected;
+      const opened = client.openDocument('docs', 'd1');
+      await vi.advanceTimersByTimeAsync(1);
+      await opened;
+
+      client.set('docs', 'd1', 'title', 'a');
+      await vi.advanceTimersByTimeAsync(100);
+      client.set('docs', 'd1', 'title', 'ab');
+      await vi.advanceTimersByTimeAsync(600);
+      client.set('docs', 'd1', 'title', 'abc');
+
+      expect(client.undo('docs', 'd1')).toBe(true);
+      expect(client.get('docs', 'd1', 'title')).toBe('ab');
+      expect(client.undo('docs', 'd1')).toBe(true);
+      expect(client.get('docs', 'd1', 'title')).toBeUndefined();
+      client.disconnect();
+    } finally {
+      vi.useRealTimers();
+    }
+  });
+});
+
+describe('offlineReads', () => {
+  it('should serve cached reads with staleness when the server is unreachable', async () => {
+    let up = true;
+    const client = new KimDBClient({
+      url: 'ws://localhost:40000/ws',
+      offlineReads: true,
+      fetch: async (input) => {
+        if (!up) throw new TypeError('fetch failed');
+        if (String(input).endsWith('/missing')) return new Response('{"error":"Not found"}', { status: 404 });
+        return new Response(JSON.stringify({ success: true, id: 'd1', data: { a: 1 }, _version: 2 }));
+      },
+    });
+
+    const fresh = await client.getDoc('docs', 'd1');
+    expect(fresh.staleness).toBeUndefined();
+
+    up = false;
+    const cached = await client.getDoc('docs', 'd1');
+    expect(cached.data).toEqual({ a: 1 });
+    expect(cached.staleness?.error.message).toBe('fetch failed');
+    await expect(client.getDoc('docs', 'd2')).rejects.toThrow('fetch failed');
+
+    // 404는 캐시로 대신하지 않음
+    up = true;
+    await expect(client.getDoc('docs', 'missing')).rejects.toThrow(/HTTP 404/);
+
+    // 쓰기 후에는 캐시된 값을 쓰지 않음
+    await client.save('docs', 'd1', { a: 2 });
+    up = false;
+    await expect(client.getDoc('docs', 'd1')).rejects.toThrow('fetch failed');
+  });
+});
+
+describe('resync', () => {
+  it('should drop cached reads and re-run tail resync against the server', async () => {
+    let up = true;
+    let docs = [{ id: 'u1', name: 'kim', _version: 1 }, { id: 'u2', name: 'lee', _version: 1 }];
+    const client = new KimDBClient({
+      url: 'ws://localhost:40000/ws',
+      offlineReads: true,
+      fetch: async () => {
+        if (!up) throw new TypeError('fetch failed');
+        return new Response(JSON.stringify({ success: true, collection: 'users', count: docs.length, data: docs }));
+      },
+    });
+
+    const events: string[] = [];
+    const resynced: number[] = [];
+    const stop = await client.backfillAndTail('users', (e) => events.push(`${e.source}:${e.event}:${e.id}`), {
+      onResynced: (changed) => resynced.push(changed),
+    });
+
+    docs = [{ id: 'u1', name: 'kim', _version: 2 }];
+    await client.resync('users');
+    expect(events).toEqual(['backfill:backfill:u1', 'backfill:backfill:u2', 'resync:update:u1', 'resync:delete:u2']);
+    expect(resynced).toEqual([2]);
+
+    // 캐시가 비었으므로 서버에 닿지 못하면 그대로 실패
+    client.invalidateCache('users');
+    up = false;
+    await expect(client.list('users')).rejects.toThrow('fetch failed');
+    stop();
+  });
+});
+
+describe('retention policy', () => {
+  it('should PUT, GET and DELETE the policy endpoint', async () => {
+    const calls: string[] = [];
+    const client = new KimDBClient({
+      url: 'ws://localhost:40000/ws',
+      fetch: async (input, init) => {
+        calls.push(`${init?.method ?? 'GET'} ${new URL(String(input)).pathname} ${init?.body ?? ''}`.trim());
+        return new Response(JSON.stringify({ success: true, policy: { maxAgeMs: 1000 } }));
+      },
+    });
+
+    await client.setRetentionPolicy('events', { maxAgeMs: 1000 });
+    expect(await client.getRetentionPolicy('events')).toEqual({ maxAgeMs: 1000 });
+    await client.setRetentionPolicy('events', null);
+
+    expect(calls).toEqual([
+      'PUT /api/retention/events {"policy":{"maxAgeMs":1000}}',
+      'GET /api/retention/events',
+      'DELETE /api/retention/events',
+    ]);
+  });
+});
+
+describe('dryRun', () => {
+  it('should flag document and SQL writes but not reads or policy changes', async () => {
+    const calls: string[] = [];
+    const client = new KimDBClient({
+      url: 'ws://localhost:40000/ws',
+      dryRun: true,
+      fetch: async (input, init) => {
+        const flag = new Headers(init?.headers).get('X-KimDB-Dry-Run') ?? '-';
+        calls.push(`${init?.method ?? 'GET'} ${new URL(String(input)).pathname} ${flag}`);
+        return new Response(JSON.stringify({ success: true, id: 'a', _version: 2, dryRun: true, data: {} }));
+      },
+    });
+
+    expect(await client.save('users', 'a', { name: 'kim' })).toMatchObject({ _version: 2, dryRun: true });
+    await client.remove('users', 'a');
+    await client.sql('users', 'DELETE FROM users WHERE id = ?', ['a']);
+    await client.getDoc('users', 'a');
+    await client.setRetentionPolicy('users', { maxAgeMs: 1000 });
+
+    expect(calls).toEqual([
+      'PUT /api/c/users/a 1',
+      'DELETE /api/c/users/a 1',
+      'POST /api/sql 1',
+      'GET /api/c/users/a -',
+      'PUT /api/retention/users -',
+    ]);
+  });
+});
+
+describe('json', () => {
+  it('should use the injected JSON library for REST and WebSocket', async () => {
+    MockSocket.instances = [];
+    const calls: string[] = [];
+    const json = {
+      parse: (text: string) => { calls.push('parse'); return JSON.parse(text); },
+      stringify: (value: unknown) => { calls.push('stringify'); return JSON.stringify(value); },
+    };
+    const client = new KimDBClient({
+      url: 'ws://localhost:40000/ws',
+      WebSocketImpl: MockSocket as unknown as typeof WebSocket,
+      json,
+      fetch: async () => new Response(JSON.stringify({ success: true, id: 'a', _version: 1 })),
+    });
+
+    await client.connect();
+    expect(calls).toEqual(['parse']);
+    await client.save('users', 'a', { name: 'kim' });
+    expect(calls).toEqual(['parse', 'stringify', 'parse']);
+    client.disconnect();
+  });
+});
+
+describe('sqlRaw', () => {
+  it('should return the SQL response body untouched unless redaction applies', async () => {
+    const body = '{"success":true,"rows":[{"id":1, "name":"Kim","password":"x"}],"rowcount":1}';
+    const requests: string[] = [];
+    const fetch = async (input: RequestInfo | URL, init?: RequestInit) => {
+      requests.push(`${init?.method} ${new URL(String(input)).pathname} ${init?.body}`);
+      return new Response(body);
+    };
+
+    const plain = new KimDBClient({ url: 'ws://localhost:40000/ws', fetch });
+    expect(await plain.sqlRaw('users', 'SELECT * FROM users WHERE id = ?', [1])).toBe(body);
+    expect(requests).toEqual(['POST /api/sql {"sql":"SELECT * FROM users WHERE id = ?","params":[1],"collection":"users"}']);
+
+    const redacted = new KimDBClient({ url: 'ws://localhost:40000/ws', fetch, redact: [{ path: 'password', action: 'drop' }] });
+    expect(JSON.parse(await redacted.sqlRaw('users', 'SELECT * FROM users'))).toEqual({
+      success: true,
+      rows: [{ id: 1, name: 'Kim' }],
+      rowcount: 1,
+    });
+    await expect(plain.sqlRaw('users', 'DROP TABLE users')).rejects.toThrow();
+  });
+});
+
+describe('changes', () => {
+  it('should page through changes with the server cursor', async () => {
+    const queries: string[] = [];
+    const pages = [
+      { changes: [{ id: 'a', data: { n: 1 }, _version: 1, deleted: false, updatedAt: '2026-01-01 00:00:00' }], next: { since: '2026-01-01 00:00:00', after: 'a' } },
+      { changes: [{ id: 'b', data: null, _version: 2, deleted: true, updatedAt: '2026-01-01 00:00:00' }], next: null },
+    ];
+    const client = new KimDBClient({
+      url: 'ws://localhost:40000/ws',
+      fetch: async (input) => {
+        queries.push(new URL(String(input)).search);
+        return new Response(JSON.stringify({ success: true, ...pages.shift() }));
+      },
+    });
+
+    const seen: string[] = [];
+    for await (const change of client.changes('orders', new Date('2025-12-31T23:59:59.500Z'), { pageSize: 1 })) {
+      seen.push(`${change.id}:${change.deleted}`);
+    }
+
+    expect(seen).toEqual(['a:false', 'b:true']);
+    expect(queries).toEqual([
+      '?since=2025-12-31+23%3A59%3A59&after=&limit=1',
+      '?since=2026-01-01+00%3A00%3A00&after=a&limit=1',
+    ]);
+  });
+});
+
+describe('listPage', () => {
+  it('should follow nextSkip until the last page', async () => {
+    const docs = [{ id: 'a', n: 1 }, { id: 'b', n: 2 }, { id: 'c', n: 3 }].map(d => ({ ...d, _version: 1 }));
+    const queries: string[] = [];
+    const client = new KimDBClient({
+      url: 'ws://localhost:40000/ws',
+      fetch: async (input) => {
+        const url = new URL(String(input));
+        queries.push(url.search);
+        const limit = Number(url.searchParams.get('limit'));
+        const skip = Number(url.searchParams.get('skip'));
+        const data = docs.slice(skip, skip + limit);
+        const hasMore = skip + data.length < docs.length;
+        return new Response(JSON.stringify({
+          success: true, count: data.length, total: docs.length, skip, limit, hasMore,
+          nextSkip: hasMore ? skip + data.length : null, data,
+        }));
+      },
+    });
+
+    const first = await client.listPage('items', { limit: 2 });
+    expect(first.data.map(d => d.id)).toEqual(['a', 'b']);
+    expect(first).toMatchObject({ total: 3, hasMore: true, nextSkip: 2 });
+
+    const second = await first.nextPage(client);
+    expect(second?.data.map(d => d.id)).toEqual(['c']);
+    expect(second).toMatchObject({ skip: 2, hasMore: false, nextSkip: null });
+    expect(await second!.nextPage(client)).toBeNull();
+    expect(queries).toEqual(['?limit=2&skip=0', '?limit=2&skip=2']);
+  });
+
+  it('should treat a response without page fields as the last page', async () => {
+    const client = new KimDBClient({
+      url: 'ws://localhost:40000/ws',
+      fetch: async () => new Response(JSON.stringify({ success: true, count: 1, data: [{ id: 'a', _version: 1 }] })),
+    });
+
+    const page = await client.listPage('items');
+    expect(page).toMatchObject({ total: null, skip: 0, limit: 100, hasMore: false, nextSkip: null });
+    expect(await page.nextPage(client)).toBeNull();
+  });
+});
+
+/** GET /api/c/:collection?limit=&skip= 를 서버처럼 나눠 응답 (onPage: 페이지 응답 직전 호출) */
+function pagedFetch(docs: () => Array<{ id: string; _version: number; [key: string]: unknown }>, onPage?: (skip: number) => void) {
+  return async (input: RequestInfo | URL): Promise<Response> => {
+    const url = new URL(String(input));
+    const limit = Number(url.searchParams.get('limit') ?? 1000);
+    const skip = Number(url.searchParams.get('skip') ?? 0);
+    onPage?.(skip);
+    const all = docs();
+    const data = all.slice(skip, skip + limit);
+    const hasMore = skip + data.length < all.length;
+    return new Response(JSON.stringify({
+      success: true, count: data.length, total: all.length, skip, limit, hasMore,
+      nextSkip: hasMore ? skip + data.length : null, data,
+    }));
+  };
+}
+
+describe('backfillAndTail', () => {
+  it('should backfill every page and replay live events buffered meanwhile exactly once', async () => {
+    MockSocket.instances = [];
+    const docs = Array.from({ length: 2500 }, (_, i) => ({ id: `d${i}`, n: i, _version: 1 }));
+    const push = (data: object) => MockSocket.instances[0].onmessage?.({ data: JSON.stringify(data) });
+    const client = new KimDBClient({
+      url: 'ws://localhost:40000/ws',
+      WebSocketImpl: MockSocket as unknown as typeof WebSocket,
+      fetch: pagedFetch(() => docs, (skip) => {
+        // 두 번째 페이지를 읽는 동안 첫 페이지 문서 변경 + 이미 읽은 버전의 중복 이벤트
+        if (skip !== 1000) return;
+        push({ type: 'sync', collection: 'items', event: 'update', id: 'd0', data: { n: -1 }, _version: 2 });
+        push({ type: 'sync', collection: 'items', event: 'update', id: 'd2400', data: { n: 2400 }, _version: 1 });
+      }),
+    });
+    await client.connect();
+
+    const events: Array<{ source: string; id: string; _version: number }> = [];
+    const stop = await client.backfillAndTail('items', (e) => events.push({ source: e.source, id: e.id, _version: e._version }));
+
+    const backfilled = events.filter(e => e.source === 'backfill');
+    expect(backfilled).toHaveLength(2500);
+    expect(new Set(backfilled.map(e => e.id)).size).toBe(2500);
+    expect(events.filter(e => e.source === 'live')).toEqual([{ source: 'live', id: 'd0', _version: 2 }]);
+    stop();
+    client.disconnect();
+  });
+
+  it('should keep the server subscription while another listener still uses it', async () => {
+    class SentSocket extends MockSocket {
+      sent: Array<{ type: string; collection?: string }> = [];
+      send(frame?: string): void {
+        this.sent.push(JSON.parse(frame!));
+      }
+    }
+    MockSocket.instances = [];
+    const client = new KimDBClient({
+      url: 'ws://localhost:40000/ws',
+      WebSocketImpl: SentSocket as unknown as typeof WebSocket,
+      fetch: pagedFetch(() => []),
+    });
+    await client.connect();
+    const socket = MockSocket.instances[0] as SentSocket;
+    const subscriptions = () => socket.sent.filter(m => m.type === 'subscribe' || m.type === 'unsubscribe').map(m => m.type);
+
+    const first: string[] = [];
+    const second: string[] = [];
+    const stopFirst = await client.backfillAndTail('items', (e) => first.push(e.id));
+    const stopSecond = await client.backfillAndTail('items', (e) => second.push(e.id));
+    expect(subscriptions()).toEqual(['subscribe']);
+
+    stopFirst();
+    expect(subscriptions()).toEqual(['subscribe']);
+    socket.onmessage?.({ data: JSON.stringify({ type: 'sync', collection: 'items', event: 'update', id: 'd1', data: {}, _version: 1 }) });
+    expect(first).toEqual([]);
+    expect(second).toEqual(['d1']);
+
+    stopSecond();
+    expect(subscriptions()).toEqual(['subscribe', 'unsubscribe']);
+    client.disconnect();
+  });
+
+  it('should not report documents past the first page as deleted on resume or resync', async () => {
+    let docs = Array.from({ length: 2500 }, (_, i) => ({ id: `d${i}`, n: i, _version: 1 }));
+    const client = new KimDBClient({ url: 'ws://localhost:40000/ws', fetch: pagedFetch(() => docs) });
+
+    // 이어받기: 모든 문서를 이미 처리했으면 아무것도 전달하지 않음
+    const initialVersions = Object.fromEntries(docs.map(d => [d.id, 1]));
+    initialVersions.gone = 1;
+    const events: string[] = [];
+    const stop = await client.backfillAndTail('items', (e) => events.push(`${e.source}:${e.event}:${e.id}`), { initialVersions });
+    expect(events).toEqual(['backfill:delete:gone']);
+
+    // 세 번째 페이지의 변경/삭제만 전달
+    docs = docs.filter(d => d.id !== 'd2100').map(d => (d.id === 'd2400' ? { ...d, _version: 2 } : d));
+    events.length = 0;
+    await client.resync('items');
+    expect(events).toEqual(['resync:update:d2400', 'resync:delete:d2100']);
+    stop();
+  });
+});
+
+describe('watchComputed', () => {
+  it('should read only the watched document and apply its newer sync events', async () => {
+    MockSocket.instances = [];
+    const paths: string[] = [];
+    const push = (data: object) => MockSocket.instances[0].onmessage?.({ data: JSON.stringify(data) });
+    const client = new KimDBClient({
+      url: 'ws://localhost:40000/ws',
+      WebSocketImpl: MockSocket as unknown as typeof WebSocket,
+      fetch: async (input) => {
+        paths.push(new URL(String(input)).pathname);
+        return new Response(JSON.stringify({ success: true, id: 'b1', data: { items: [1, 2] }, _version: 3 }));
+      },
+    });
+    await client.connect();
+
+    const values: number[] = [];
+    const stop = await client.watchComputed('boards', 'b1', (doc) => doc?.items.length ?? 0, (n) => values.push(n));
+    expect(paths).toEqual(['/api/c/boards/b1']);
+
+    push({ type: 'sync', collection: 'boards', event: 'update', id: 'b2', data: { items: [] }, _version: 9 });
+    push({ type: 'sync', collection: 'boards', event: 'update', id: 'b1', data: { items: [] }, _version: 2 });
+    push({ type: 'sync', collection: 'boards', event: 'update', id: 'b1', data: { items: [1, 2, 3] }, _version: 4 });
+    push({ type: 'sync', collection: 'boards', event: 'delete', id: 'b1', _version: 0 });
+    expect(values).toEqual([2, 3, 0]);
+    expect(paths).toHaveLength(1);
+    stop();
+    client.disconnect();
+  });
+
+  it('should compute from null when the document does not exist', async () => {
+    const client = new KimDBClient({
+      url: 'ws://localhost:40000/ws',
+      fetch: async () => new Response('{"error":"Not found"}', { status: 404 }),
+    });
+    const values: number[] = [];
+    const stop = await client.watchComputed('boards', 'missing', (doc) => doc?.items.length ?? -1, (n) => values.push(n));
+    expect(values).toEqual([-1]);
+    stop();
+  });
+});
+
+describe('subscribeCount', () => {
+  it('should reconcile against every page of the collection', async () => {
+    const docs = Array.from({ length: 2500 }, (_, i) => ({ id: `d${i}`, status: i % 2 === 0 ? 'open' : 'done', _version: 1 }));
+    const list = pagedFetch(() => docs);
+    const client = new KimDBClient({
+      url: 'ws://localhost:40000/ws',
+      fetch: async (input) => {
+        if (new URL(String(input)).pathname !== '/api/sql') return list(input);
+        const cnt = docs.filter(d => d.status === 'open').length;
+        return new Response(JSON.stringify({ success: true, rows: [{ cnt }] }));
+      },
+    });
+
+    const counts: number[] = [];
+    const stop = await client.subscribeCount('tasks', { status: 'open' }, (n) => counts.push(n), { reconcileMs: 10 });
+    expect(counts).toEqual([1250]);
+
+    // 이벤트 없이 서버에서 바뀐 마지막 페이지 문서
+    docs[2499] = { ...docs[2499], status: 'open', _version: 2 };
+    await vi.waitFor(() => expect(counts).toEqual([1250, 1251]));
+    stop();
+  });
+});
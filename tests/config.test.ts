@@ -0,0 +1,55 @@
+/**
+ * Client Configuration Unit Tests
+ */
+
+import { describe, it, expect } from 'vitest';
+import { writeFileSync, mkdtempSync } from 'fs';
+import { join } from 'path';
+import { tmpdir } from 'os';
+import { clientConfigFromEnv, loadClientConfig, ClientConfigError } from '../src/client/config.js';
+import { KimDBClient } from '../src/client/index.js';
+
+describe('clientConfigFromEnv', () => {
+  it('should read url, token and numeric settings', () => {
+    expect(clientConfigFromEnv({
+      KIMDB_URL: 'wss://db.example.com/ws',
+      KIMDB_TOKEN: 'secret',
+      KIMDB_AUTO_RECONNECT: 'false',
+      KIMDB_MAX_RECONNECT_ATTEMPTS: '3',
+    })).toEqual({
+      url: 'wss://db.example.com/ws',
+      apiKey: 'secret',
+      autoReconnect: false,
+      maxReconnectAttempts: 3,
+    });
+  });
+
+  it('should report every invalid value at once', () => {
+    try {
+      clientConfigFromEnv({ KIMDB_URL: 'http://x', KIMDB_BATCH_SIZE: 'many', KIMDB_RECONNECT_INTERVAL: '-1' });
+      expect.unreachable();
+    } catch (e) {
+      expect(e).toBeInstanceOf(ClientConfigError);
+      expect((e as ClientConfigError).issues).toHaveLength(3);
+    }
+  });
+});
+
+describe('loadClientConfig', () => {
+  it('should let environment variables override the file', async () => {
+    const path = join(mkdtempSync(join(tmpdir(), 'kimdb-')), 'client.json');
+    writeFileSync(path, JSON.stringify({ url: 'ws://localhost:40000/ws', batchSize: 10 }));
+
+    const options = await loadClientConfig(path, { KIMDB_BATCH_SIZE: '20' });
+    expect(options).toEqual({ url: 'ws://localhost:40000/ws', batchSize: 20 });
+  });
+});
+
+describe('KimDBClient construction', () => {
+  it('should fail fast on invalid options', () => {
+    expect(() => new KimDBClient({ url: '' })).toThrow(ClientConfigError);
+    expect(() => new KimDBClient({ url: 'localhost:40000' })).toThrow(/ws:\/\/ or wss:\/\//);
+    expect(() => new KimDBClient({ url: 'ws://localhost/ws', maxReconnectAttempts: -1 })).toThrow(/maxReconnectAttempts/);
+    expect(() => new KimDBClient({ url: 'ws://localhost:40000/ws/' })).not.toThrow();
+  });
+});
@@ -0,0 +1,90 @@
+/**
+ * Durable Consumer Unit Tests
+ */
+
+import { describe, it, expect } from 'vitest';
+import { KimDBClient } from '../src/client/index.js';
+import { MemoryPositionStore, MemoryDedupStore, eventKey, partitionOf } from '../src/client/consumer.js';
+import type { TailEvent } from '../src/client/index.js';
+
+function clientWithDocs(docs: () => Array<{ id: string; _version: number; [k: string]: unknown }>): KimDBClient {
+  return new KimDBClient({
+    url: 'ws://localhost:1/ws',
+    autoReconnect: false,
+    fetch: async () => new Response(JSON.stringify({ success: true, collection: 'tasks', count: docs().length, data: docs() })),
+  });
+}
+
+describe('DurableConsumer', () => {
+  it('should resume from stored positions', async () => {
+    let docs = [{ id: 'a', _version: 1, t: 1 }, { id: 'b', _version: 1, t: 2 }];
+    const client = clientWithDocs(() => docs);
+    const store = new MemoryPositionStore();
+
+    const first: TailEvent[] = [];
+    const c1 = client.durableConsumer('worker', 'tasks', { store });
+    await c1.start((e) => { first.push(e); });
+    await c1.stop();
+    expect(first.map(e => e.id)).toEqual(['a', 'b']);
+
+    // a 변경, b 삭제, c 추가 후 재시작
+    docs = [{ id: 'a', _version: 2, t: 3 }, { id: 'c', _version: 1, t: 4 }];
+    const second: TailEvent[] = [];
+    const c2 = client.durableConsumer('worker', 'tasks', { store });
+    await c2.start((e) => { second.push(e); });
+    await c2.stop();
+
+    expect(second.map(e => [e.id, e.event])).toEqual([['a', 'backfill'], ['c', 'backfill'], ['b', 'delete']]);
+    expect(c2.getPositions()).toEqual({ a: 2, c: 1 });
+  });
+
+  it('should not commit events whose handler fails', async () => {
+    const client = clientWithDocs(() => [{ id: 'a', _version: 1 }, { id: 'b', _version: 1 }]);
+    const store = new MemoryPositionStore();
+    const errors: string[] = [];
+
+    const consumer = client.durableConsumer('worker', 'tasks', { store, onError: (_, e) => errors.push(e.id) });
+    await consumer.start((e) => {
+      if (e.id === 'b') throw new Error('boom');
+    });
+    await consumer.stop();
+
+    expect(errors).toEqual(['b']);
+    expect(await store.load('worker')).toEqual({ a: 1 });
+  });
+
+  it('should skip events already in the dedup store but still commit them', async () => {
+    const client = clientWithDocs(() => [{ id: 'a', _version: 3 }, { id: 'b', _version: 1 }]);
+    const store = new MemoryPositionStore();
+    const dedup = new MemoryDedupStore();
+    // 이전 실행에서 처리는 했지만 위치 저장 전에 종료된 경우
+    await dedup.add(eventKey('tasks', { source: 'backfill', event: 'backfill', id: 'a', data: {}, _version: 3 }), 60_000);
+
+    const handled: string[] = [];
+    const consumer = client.durableConsumer('worker', 'tasks', { store, dedup });
+    await consumer.start((e) => { handled.push(e.id); });
+    await consumer.stop();
+
+    expect(handled).toEqual(['b']);
+    expect(consumer.getPositions()).toEqual({ a: 3, b: 1 });
+    expect(await dedup.has(eventKey('tasks', { source: 'backfill', event: 'backfill', id: 'b', data: {}, _version: 1 }))).toBe(true);
+  });
+
+  it('should split documents across a consumer group without overlap', async () => {
+    const docs = Array.from({ length: 20 }, (_, i) => ({ id: `doc${i}`, _version: 1 }));
+    const client = clientWithDocs(() => docs);
+    const handled: string[][] = [[], [], []];
+
+    for (let index = 0; index < 3; index++) {
+      const consumer = client.durableConsumer(`group-${index}`, 'tasks', {
+        store: new MemoryPositionStore(),
+        partition: { index, count: 3 },
+      });
+      await consumer.start((e) => { handled[index].push(e.id); });
+      await consumer.stop();
+      expect(handled[index].every(id => partitionOf(id, 3) === index)).toBe(true);
+    }
+
+    expect(handled.flat().sort()).toEqual(docs.map(d => d.id).sort());
+  });
+});
@@ -0,0 +1,59 @@
+/**
+ * Collection Copy Unit Tests
+ */
+
+import { describe, it, expect } from 'vitest';
+import { copyCollection } from '../src/client/copy.js';
+import { FakeKimDBClient } from '../src/client/fake.js';
+
+async function seed(): Promise<FakeKimDBClient> {
+  const fake = new FakeKimDBClient();
+  await fake.save('users', 'u1', { name: 'kim', email: 'kim@example.com', active: true });
+  await fake.save('users', 'u2', { name: 'lee', email: 'lee@example.com', active: false });
+  await fake.save('users', 'u3', { name: 'park', email: 'park@example.com', active: true });
+  return fake;
+}
+
+describe('copyCollection', () => {
+  it('should copy filtered documents with a transform', async () => {
+    const fake = await seed();
+
+    const result = await copyCollection(fake, 'users', 'users_staging', {
+      filter: doc => doc.active === true,
+      transform: ({ name }) => ({ name, email: `${name}@staging.invalid` }),
+    });
+
+    expect(result).toEqual({ copied: ['u1', 'u3'], skipped: 1 });
+    expect((await fake.getDoc('users_staging', 'u3')).data).toEqual({ name: 'park', email: 'park@staging.invalid' });
+  });
+
+  it('should skip existing target ids without overwrite and write nothing on dryRun', async () => {
+    const fake = await seed();
+    await fake.save('users_staging', 'u1', { name: 'keep' });
+
+    const dry = await copyCollection(fake, 'users', 'users_staging', { overwrite: false, dryRun: true });
+    expect(dry.copied).toEqual(['u2', 'u3']);
+    expect((await fake.list('users_staging')).count).toBe(1);
+
+    await copyCollection(fake, 'users', 'users_staging', { overwrite: false });
+    expect((await fake.getDoc('users_staging', 'u1')).data).toEqual({ name: 'keep' });
+    expect((await fake.list('users_staging')).count).toBe(3);
+  });
+
+  it('should copy every page and skip existing ids found past the first page', async () => {
+    const fake = new FakeKimDBClient();
+    for (let i = 0; i < 1500; i++) await fake.save('events', `e${i}`, { n: i });
+    for (let i = 0; i < 1200; i++) await fake.save('events_copy', `x${i}`, { n: -1 });
+    await fake.save('events_copy', 'e1400', { n: -1 });
+
+    const result = await copyCollection(fake, 'events', 'events_copy', { overwrite: false });
+    expect(result.copied).toHaveLength(1499);
+    expect(result.skipped).toBe(1);
+    expect((await fake.getDoc('events_copy', 'e1499')).data).toEqual({ n: 1499 });
+    expect((await fake.getDoc('events_copy', 'e1400')).data).toEqual({ n: -1 });
+  });
+
+  it('should refuse to copy onto the same collection', async () => {
+    await expect(copyCollection(await seed(), 'users', 'users')).rejects.toThrow(/onto itself/);
+  });
+});
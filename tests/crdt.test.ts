@@ -191,9 +191,17 @@ describe('RGA (List)', () => {
     rga.insert(0, 'b');
     rga.insert(0, 'a');
 
-    // RGA inserts after left reference, so 'a' comes after 'b' at position 0
-    // This is correct RGA behavior
-    expect(rga.toArray()).toEqual(['b', 'a']);
+    expect(rga.toArray()).toEqual(['a', 'b']);
+  });
+
+  it('should place inserts at the head or past the end at the same spot on other replicas', () => {
+    const rga2 = new RGA('node2');
+    const ops = [rga.insert(0, 'b'), rga.insert(0, 'a'), rga.insert(10, 'c')];
+    ops.push(rga.delete(2), rga.insert(5, 'd'));
+    for (const op of ops) rga2.applyRemote(op);
+
+    expect(rga.toArray()).toEqual(['a', 'b', 'd']);
+    expect(rga2.toArray()).toEqual(rga.toArray());
   });
 
   it('should handle concurrent inserts', () => {
@@ -267,6 +275,18 @@ describe('CRDTDocument', () => {
     expect(doc.get('remote-key')).toBe('remote-value');
   });
 
+  it('should apply remote rich text operations', () => {
+    const doc2 = new CRDTDocument('node2', 'doc1');
+    const ops = [doc2.richInsert('body', 0, 'h'), doc2.richInsert('body', 1, 'i')];
+
+    for (const op of ops) doc.applyRemote(op);
+    expect(doc.richGetText('body')).toBe('hi');
+    expect(doc.listGet('body')).toEqual([]);
+
+    doc.applyRemote(doc2.richDelete('body', 0));
+    expect(doc.richGetText('body')).toBe('i');
+  });
+
   it('should serialize and deserialize', () => {
     doc.set('name', 'test');
     doc.set('count', 42);
@@ -351,6 +371,25 @@ describe('UndoManager', () => {
     expect(redoOps).not.toBeNull();
   });
 
+  it('should split undo groups on stopCapturing', () => {
+    const op = {
+      type: 'map_set',
+      key: 'name',
+      value: 'new',
+      opId: 'op1',
+      clock: { nodeId: 'node1', clock: { node1: 1 } },
+      nodeId: 'node1',
+    };
+
+    um.capture(op, 'old');
+    um.stopCapturing();
+    um.capture({ ...op, opId: 'op2', value: 'newer' }, 'new');
+    um.stopCapturing();
+
+    expect(um.state.undoCount).toBe(2);
+    expect(um.undo()![0].value).toBe('new');
+  });
+
   it('should clear history', async () => {
     const op = {
       type: 'map_set',
@@ -0,0 +1,105 @@
+/**
+ * Server Database Unit Tests
+ */
+
+import { describe, it, expect, beforeEach, afterEach } from 'vitest';
+import { mkdtempSync, rmSync } from 'fs';
+import { join } from 'path';
+import { tmpdir } from 'os';
+import { KimDatabase, BatchVersionConflict } from '../src/server/database.js';
+import type { Config } from '../src/server/config.js';
+
+describe('KimDatabase.updateBatch', () => {
+  let dir: string;
+  let db: KimDatabase;
+
+  beforeEach(() => {
+    dir = mkdtempSync(join(tmpdir(), 'kimdb-'));
+    db = new KimDatabase({ dataDir: dir } as Config);
+    db.saveDocument('users', 'u1', JSON.stringify({ name: 'Kim', age: 30 }));
+  });
+
+  afterEach(() => {
+    db.close();
+    rmSync(dir, { recursive: true, force: true });
+  });
+
+  it('should roll back earlier items when a later item fails', () => {
+    expect(() => db.updateBatch([
+      { collection: 'users', id: 'u1', data: { age: 31 } },
+      { collection: 'users', id: 'u2', data: { name: 'Lee' } },
+      { collection: '_bad', id: 'x', data: {} },
+    ])).toThrow('Invalid collection name: _bad');
+
+    expect(db.getDocument('users', 'u1')).toMatchObject({ data: JSON.stringify({ name: 'Kim', age: 30 }), _version: 1 });
+    expect(db.getDocument('users', 'u2')).toBeUndefined();
+  });
+
+  it('should apply every item only when all expected versions match', () => {
+    const conflict = (() => {
+      try {
+        db.updateBatch([
+          { collection: 'users', id: 'u1', data: { age: 31 }, expectedVersion: 1 },
+          { collection: 'orders', id: 'o1', data: { total: 5 }, expectedVersion: 0 },
+          { collection: 'users', id: 'u3', data: { name: 'Park' }, expectedVersion: 2 },
+        ]);
+      } catch (e) {
+        return e;
+      }
+    })();
+    expect(conflict).toBeInstanceOf(BatchVersionConflict);
+    expect(conflict).toMatchObject({ collection: 'users', id: 'u3', expected: 2, current: 0 });
+    expect(db.getDocument('users', 'u1')).toMatchObject({ _version: 1 });
+    expect(db.getDocument('orders', 'o1')).toBeUndefined();
+
+    const results = db.updateBatch([
+      { collection: 'users', id: 'u1', data: { age: 31 }, expectedVersion: 1 },
+      { collection: 'orders', id: 'o1', data: { total: 5 }, expectedVersion: 0 },
+    ]);
+    expect(results.map(r => [r.collection, r.id, r._version])).toEqual([['users', 'u1', 2], ['orders', 'o1', 1]]);
+    expect(JSON.parse(db.getDocument('users', 'u1')!.data)).toEqual({ name: 'Kim', age: 31 });
+  });
+});
+
+describe('KimDatabase.replaceDocument', () => {
+  let dir: string;
+  let db: KimDatabase;
+
+  beforeEach(() => {
+    dir = mkdtempSync(join(tmpdir(), 'kimdb-'));
+    db = new KimDatabase({ dataDir: dir } as Config);
+  });
+
+  afterEach(() => {
+    db.close();
+    rmSync(dir, { recursive: true, force: true });
+  });
+
+  it('should revive a soft-deleted id at version 1 when expecting version 0', () => {
+    db.saveDocument('users', 'u1', JSON.stringify({ name: 'Kim' }));
+    db.saveDocument('users', 'u1', JSON.stringify({ name: 'Kim', age: 30 }));
+    db.deleteDocument('users', 'u1');
+
+    expect(db.replaceDocument('users', 'u1', { name: 'Lee' }, 0)).toBe(1);
+    expect(db.getDocument('users', 'u1')).toMatchObject({ data: JSON.stringify({ name: 'Lee' }), _version: 1 });
+  });
+
+  it('should replace only when the expected version matches', () => {
+    expect(db.replaceDocument('users', 'u1', { name: 'Kim' })).toBe(1);
+    expect(() => db.replaceDocument('users', 'u1', { name: 'Lee' }, 0)).toThrow(BatchVersionConflict);
+    expect(() => db.replaceDocument('users', 'u1', { name: 'Lee' }, 2)).toThrow(expect.objectContaining({ expected: 2, current: 1 }));
+
+    expect(db.replaceDocument('users', 'u1', { name: 'Lee' }, 1)).toBe(2);
+    expect(JSON.parse(db.getDocument('users', 'u1')!.data)).toEqual({ name: 'Lee' });
+  });
+
+  it('should start ops from an empty document when the id does not exist', () => {
+    expect(db.patchDocument('counters', 'hits', undefined, [{ op: 'inc', path: 'n', by: 1 }])).toBe(1);
+    expect(db.patchDocument('counters', 'hits', undefined, [{ op: 'inc', path: 'n', by: 2 }], 1)).toBe(2);
+    expect(JSON.parse(db.getDocument('counters', 'hits')!.data)).toEqual({ n: 3 });
+
+    db.deleteDocument('counters', 'hits');
+    expect(db.patchDocument('counters', 'hits', { label: 'x' }, [{ op: 'addUnique', path: 'tags', values: ['a'] }], 0)).toBe(1);
+    expect(JSON.parse(db.getDocument('counters', 'hits')!.data)).toEqual({ label: 'x', tags: ['a'] });
+  });
+});
@@ -0,0 +1,27 @@
+/**
+ * Debug Transport Unit Tests
+ */
+
+import { describe, it, expect } from 'vitest';
+import { debugFetch } from '../src/client/debug.js';
+
+describe('debugFetch', () => {
+  it('should log requests and responses with secrets masked', async () => {
+    const lines: string[] = [];
+    const base: typeof fetch = async () =>
+      new Response(JSON.stringify({ success: true, data: [{ id: 'u1', password: 'hunter2' }] }));
+    const f = debugFetch({ log: (l) => lines.push(l), redact: [{ path: 'password' }] }, base);
+
+    const res = await f('http://x/api/c/users?api_key=abc', {
+      method: 'PUT',
+      headers: { 'X-API-Key': 'secret-key', 'Content-Type': 'application/json' },
+      body: JSON.stringify({ data: { password: 'hunter2', name: 'Kim' } }),
+    });
+
+    // 원래 응답은 그대로 전달
+    expect((await res.json()).data[0].password).toBe('hunter2');
+    expect(lines).toHaveLength(2);
+    expect(lines.join('\n')).not.toMatch(/secret-key|hunter2|api_key=abc/);
+    expect(lines[0]).toContain('"name":"Kim"');
+  });
+});
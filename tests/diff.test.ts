@@ -0,0 +1,58 @@
+/**
+ * Document Diff Unit Tests
+ */
+
+import { describe, it, expect } from 'vitest';
+import { diff, applyPatch, PatchError } from '../src/client/diff.js';
+
+describe('diff', () => {
+  it('should produce add/remove/replace operations', () => {
+    const before = { title: 'a', tags: ['x', 'y'], meta: { views: 1, old: true } };
+    const after = { title: 'b', tags: ['x'], meta: { views: 2 }, owner: 'kim' };
+
+    expect(diff(before, after)).toEqual([
+      { op: 'replace', path: '/title', value: 'b' },
+      { op: 'remove', path: '/tags/1' },
+      { op: 'replace', path: '/meta/views', value: 2 },
+      { op: 'remove', path: '/meta/old' },
+      { op: 'add', path: '/owner', value: 'kim' },
+    ]);
+  });
+
+  it('should escape keys in pointers', () => {
+    expect(diff({}, { 'a/b~c': 1 })).toEqual([{ op: 'add', path: '/a~1b~0c', value: 1 }]);
+  });
+
+  it('should round trip through applyPatch', () => {
+    const before = { items: [1, 2, 3], nested: { a: [{ b: 1 }] } };
+    const after = { items: [1, 4], nested: { a: [{ b: 2 }, { c: 3 }] }, extra: null };
+
+    expect(applyPatch(before, diff(before, after))).toEqual(after);
+    expect(before.items).toEqual([1, 2, 3]);
+  });
+});
+
+describe('applyPatch', () => {
+  it('should fail on test mismatch and missing paths', () => {
+    expect(() => applyPatch({ v: 1 }, [{ op: 'test', path: '/v', value: 2 }])).toThrow(PatchError);
+    expect(() => applyPatch({}, [{ op: 'remove', path: '/missing' }])).toThrow(PatchError);
+  });
+
+  it('should reject prototype paths and inherited intermediates', () => {
+    const polluting = [
+      { op: 'add', path: '/__proto__/polluted', value: 'yes' },
+      { op: 'add', path: '/constructor/prototype/polluted', value: 'yes' },
+      { op: 'replace', path: '/a/__proto__', value: {} },
+      { op: 'add', path: '/toString/polluted', value: 'yes' },
+    ] as const;
+    for (const operation of polluting) {
+      expect(() => applyPatch({ a: {} }, [operation])).toThrow(PatchError);
+    }
+    expect(({} as Record<string, unknown>).polluted).toBeUndefined();
+    expect(() => applyPatch({ a: [1] }, [{ op: 'add', path: '/a/length/x', value: 1 }])).toThrow(PatchError);
+  });
+
+  it('should append with "-"', () => {
+    expect(applyPatch({ a: [1] }, [{ op: 'add', path: '/a/-', value: 2 }])).toEqual({ a: [1, 2] });
+  });
+});
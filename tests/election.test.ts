@@ -0,0 +1,98 @@
+/**
+ * Leader Election Unit Tests
+ */
+
+import { describe, it, expect, vi, afterEach } from 'vitest';
+import { FakeKimDBClient } from '../src/client/fake.js';
+import { LeaderElection } from '../src/client/election.js';
+import { VersionConflictError } from '../src/client/errors.js';
+
+afterEach(() => {
+  vi.useRealTimers();
+});
+
+/** down이 켜지면 모든 요청이 네트워크 오류로 실패하는 클라이언트 */
+function flaky(fake: FakeKimDBClient) {
+  const state = { down: false };
+  const guard = <T>(fn: () => Promise<T>): Promise<T> => (state.down ? Promise.reject(new TypeError('fetch failed')) : fn());
+  return {
+    state,
+    client: {
+      getDoc: (c: string, id: string) => guard(() => fake.getDoc(c, id)),
+      save: (c: string, id: string, data: unknown, o?: { expectedVersion?: number }) => guard(() => fake.save(c, id, data, o)),
+    },
+  };
+}
+
+describe('expectedVersion', () => {
+  it('should reject writes whose expected version does not match', async () => {
+    const fake = new FakeKimDBClient();
+    await fake.save('docs', 'd1', { a: 1 }, { expectedVersion: 0 });
+    await expect(fake.save('docs', 'd1', { a: 2 }, { expectedVersion: 0 })).rejects.toBeInstanceOf(VersionConflictError);
+    await fake.update('docs', 'd1', { a: 2 }, { expectedVersion: 1 });
+
+    const conflict = await fake.remove('docs', 'd1', { expectedVersion: 1 }).catch(e => e);
+    expect(conflict).toBeInstanceOf(VersionConflictError);
+    expect(conflict.currentVersion).toBe(2);
+    await fake.remove('docs', 'd1', { expectedVersion: 2 });
+  });
+});
+
+describe('LeaderElection', () => {
+  it('should elect one candidate and hand over with a higher token on stop', async () => {
+    vi.useFakeTimers();
+    const fake = new FakeKimDBClient();
+    const a = new LeaderElection(fake, 'cron', 'a', { ttlMs: 3000 });
+    const b = new LeaderElection(fake, 'cron', 'b', { ttlMs: 3000 });
+    const log: string[] = [];
+    a.onElected = t => log.push(`a:elected:${t}`);
+    a.onResigned = () => log.push('a:resigned');
+    b.onElected = t => log.push(`b:elected:${t}`);
+
+    await Promise.all([a.start(), b.start()]);
+    expect([a.isLeader, b.isLeader].filter(Boolean)).toHaveLength(1);
+    const [first, second] = a.isLeader ? [a, b] : [b, a];
+
+    // 리더는 계속 갱신하므로 ttl이 여러 번 지나도 유지
+    await vi.advanceTimersByTimeAsync(10000);
+    expect(first.isLeader).toBe(true);
+    expect(second.isLeader).toBe(false);
+
+    await first.stop();
+    await vi.advanceTimersByTimeAsync(1000);
+    expect(second.isLeader).toBe(true);
+    expect(second.fencingToken).toBe(2);
+    await second.stop();
+    expect(log.filter(l => l.includes('elected'))).toHaveLength(2);
+  });
+
+  it('should resign when it cannot renew and let another candidate take over after expiry', async () => {
+    vi.useFakeTimers();
+    const fake = new FakeKimDBClient();
+    const { client, state } = flaky(fake);
+    const a = new LeaderElection(client, 'cron', 'a', { ttlMs: 3000 });
+    const b = new LeaderElection(fake, 'cron', 'b', { ttlMs: 3000 });
+    const resigned: string[] = [];
+    a.onResigned = () => resigned.push('a');
+    a.onError = () => {};
+
+    await a.start();
+    await b.start();
+    expect(a.fencingToken).toBe(1);
+    expect(b.isLeader).toBe(false);
+
+    state.down = true;
+    await vi.advanceTimersByTimeAsync(4000);
+    expect(a.isLeader).toBe(false);
+    expect(resigned).toEqual(['a']);
+    expect(b.fencingToken).toBe(2);
+
+    // 돌아와도 b의 리스가 살아 있으므로 따름
+    state.down = false;
+    await vi.advanceTimersByTimeAsync(3000);
+    expect(a.isLeader).toBe(false);
+    expect(b.isLeader).toBe(true);
+    await a.stop();
+    await b.stop();
+  });
+});
@@ -0,0 +1,66 @@
+/**
+ * HTTP Error Unit Tests
+ */
+
+import { describe, it, expect } from 'vitest';
+import { httpError, KimDBHttpError, ValidationError, VersionConflictError } from '../src/client/errors.js';
+import { KimDBClient } from '../src/client/index.js';
+
+describe('httpError', () => {
+  it('should build a ValidationError with issues when the body has errors[]', () => {
+    const body = JSON.stringify({
+      error: 'data is required',
+      errors: [
+        { path: 'data', rule: 'required', message: 'data is required' },
+        { field: 'age', code: 'min', message: 'too small' },
+        'ignored',
+      ],
+    });
+    const error = httpError(400, body);
+
+    expect(error).toBeInstanceOf(ValidationError);
+    expect(error.message).toBe(`HTTP 400: ${body}`);
+    expect(error.serverMessage).toBe('data is required');
+    expect((error as ValidationError).issues).toEqual([
+      { path: 'data', rule: 'required', message: 'data is required' },
+      { path: 'age', rule: 'min', message: 'too small' },
+    ]);
+    expect(httpError(422, JSON.stringify({ errors: [] }))).toBeInstanceOf(ValidationError);
+  });
+
+  it('should keep plain 400s without errors[] as KimDBHttpError', () => {
+    for (const body of [JSON.stringify({ error: 'limit must be between 1 and 1000' }), 'Bad Request', JSON.stringify({ errors: 'x' })]) {
+      const error = httpError(400, body);
+      expect(error).toBeInstanceOf(KimDBHttpError);
+      expect(error).not.toBeInstanceOf(ValidationError);
+      expect(error.status).toBe(400);
+    }
+    expect(httpError(400, JSON.stringify({ error: 'nope' })).serverMessage).toBe('nope');
+    expect(httpError(400, 'Bad Request').serverMessage).toBe('Bad Request');
+  });
+
+  it('should not treat errors[] on other statuses as validation', () => {
+    expect(httpError(500, JSON.stringify({ errors: [{ path: 'a' }] }))).not.toBeInstanceOf(ValidationError);
+  });
+
+  it('should read the current version from 409 responses', () => {
+    const error = httpError(409, JSON.stringify({ error: 'Version conflict', expected: 1, _version: 3 }));
+    expect(error).toBeInstanceOf(VersionConflictError);
+    expect((error as VersionConflictError).currentVersion).toBe(3);
+    expect((httpError(409, 'conflict') as VersionConflictError).currentVersion).toBeNull();
+  });
+
+  it('should surface server validation responses from REST writes', async () => {
+    const client = new KimDBClient({
+      url: 'ws://localhost:40000/ws',
+      fetch: async () => new Response(
+        JSON.stringify({ error: 'data is required', errors: [{ path: 'data', rule: 'required', message: 'data is required' }] }),
+        { status: 400 },
+      ),
+    });
+
+    const error = await client.save('docs', 'd1', null).catch(e => e);
+    expect(error).toBeInstanceOf(ValidationError);
+    expect(error.issues).toEqual([{ path: 'data', rule: 'required', message: 'data is required' }]);
+  });
+});
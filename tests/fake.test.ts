@@ -0,0 +1,174 @@
+/**
+ * Fake Client Unit Tests
+ */
+
+import { describe, it, expect, beforeEach, afterEach, vi } from 'vitest';
+import { FakeKimDBClient } from '../src/client/fake.js';
+import { KimDBHttpError } from '../src/client/errors.js';
+import { KVStore } from '../src/client/kv.js';
+import { deleteCascade } from '../src/client/cascade.js';
+
+describe('FakeKimDBClient', () => {
+  let client: FakeKimDBClient;
+
+  beforeEach(() => {
+    client = new FakeKimDBClient();
+  });
+
+  it('should merge saves and bump versions like the server', async () => {
+    await client.save('users', 'u1', { name: 'Kim', age: 30 });
+    const res = await client.save('users', 'u1', { age: 31 });
+
+    expect(res._version).toBe(2);
+    expect((await client.getDoc('users', 'u1')).data).toEqual({ name: 'Kim', age: 31 });
+  });
+
+  it('should return 404 errors for missing documents', async () => {
+    await expect(client.getDoc('users', 'nope')).rejects.toBeInstanceOf(KimDBHttpError);
+    await expect(client.update('users', 'nope', { a: 1 })).rejects.toMatchObject({ status: 404 });
+  });
+
+  it('should run simple SELECT queries', async () => {
+    await client.save('users', 'a', { team: 'x', age: 40 });
+    await client.save('users', 'b', { team: 'x', age: 20 });
+    await client.save('users', 'c', { team: 'y', age: 30 });
+
+    const res = await client.sql('users', 'SELECT * FROM users WHERE team = ? ORDER BY age LIMIT 5', ['x']);
+    expect(res.rows!.map((r) => (r as { id: string }).id)).toEqual(['b', 'a']);
+  });
+
+  it('should cap list at 1000 and page the rest like the server', async () => {
+    for (let i = 0; i < 1005; i++) await client.save('events', `e${i}`, { i });
+
+    const first = await client.list('events');
+    expect(first).toMatchObject({ count: 1000, total: 1005, hasMore: true, nextSkip: 1000 });
+    const last = await client.listPage('events', { limit: 1000, skip: first.nextSkip! });
+    expect(last.data.map(d => d.id)).toEqual(['e1000', 'e1001', 'e1002', 'e1003', 'e1004']);
+    expect(await last.nextPage(client)).toBeNull();
+    await expect(client.listPage('events', { limit: 0 })).rejects.toMatchObject({ status: 400 });
+  });
+});
+
+describe('KVStore', () => {
+  it('should expire values after ttl', async () => {
+    const kv = new KVStore(new FakeKimDBClient(), 'sessions');
+
+    await kv.set('s1', { user: 'kim' });
+    await kv.set('s2', 'short', 1);
+    await new Promise((r) => setTimeout(r, 5));
+
+    expect(await kv.get<{ user: string }>('s1')).toEqual({ user: 'kim' });
+    expect(await kv.get('s2')).toBeNull();
+    expect(await kv.get('missing')).toBeNull();
+  });
+
+  describe('expiresAt', () => {
+    let client: FakeKimDBClient;
+    let kv: KVStore;
+
+    beforeEach(() => {
+      vi.useFakeTimers();
+      vi.setSystemTime(10_000);
+      client = new FakeKimDBClient();
+      kv = new KVStore(client, 'sessions');
+    });
+
+    afterEach(() => {
+      vi.useRealTimers();
+    });
+
+    it('should store now + ttl, and null without a ttl', async () => {
+      await kv.set('a', 1, 500);
+      await kv.set('b', 2);
+      await kv.set('c', 3, 0);
+
+      expect((await client.getDoc('sessions', 'a')).data).toEqual({ value: 1, expiresAt: 10_500 });
+      expect((await client.getDoc('sessions', 'b')).data).toEqual({ value: 2, expiresAt: null });
+      expect((await client.getDoc('sessions', 'c')).data).toEqual({ value: 3, expiresAt: null });
+    });
+
+    it('should return values whose expiresAt is in the future', async () => {
+      await client.save('sessions', 's1', { value: 'live', expiresAt: 10_001 });
+
+      expect(await kv.get('s1')).toBe('live');
+      expect((await client.getDoc('sessions', 's1'))._version).toBe(1);
+    });
+
+    it('should treat expiresAt equal to or before now as expired and delete the document', async () => {
+      await client.save('sessions', 'past', { value: 'old', expiresAt: 9_000 });
+      await client.save('sessions', 'now', { value: 'edge', expiresAt: 10_000 });
+
+      expect(await kv.get('past')).toBeNull();
+      expect(await kv.get('now')).toBeNull();
+      await expect(client.getDoc('sessions', 'past')).rejects.toMatchObject({ status: 404 });
+      await expect(client.getDoc('sessions', 'now')).rejects.toMatchObject({ status: 404 });
+    });
+
+    it('should never expire entries without expiresAt', async () => {
+      await client.save('sessions', 'legacy', { value: 'kept' });
+
+      vi.setSystemTime(Number.MAX_SAFE_INTEGER);
+      expect(await kv.get('legacy')).toBe('kept');
+    });
+
+    it('should expire once the clock passes the ttl', async () => {
+      await kv.set('s1', 'short', 100);
+
+      vi.advanceTimersByTime(99);
+      expect(await kv.get('s1')).toBe('short');
+      vi.advanceTimersByTime(1);
+      expect(await kv.get('s1')).toBeNull();
+      await expect(client.getDoc('sessions', 's1')).rejects.toMatchObject({ status: 404 });
+    });
+
+    it('should ignore a concurrent delete of an expired entry', async () => {
+      await client.save('sessions', 's1', { value: 'old', expiresAt: 1 });
+      const remove = vi.spyOn(client, 'remove').mockRejectedValueOnce(new KimDBHttpError(404, 'Document not found'));
+
+      expect(await kv.get('s1')).toBeNull();
+      expect(remove).toHaveBeenCalledWith('sessions', 's1');
+    });
+  });
+});
+
+describe('deleteCascade', () => {
+  let client: FakeKimDBClient;
+
+  beforeEach(async () => {
+    client = new FakeKimDBClient();
+    await client.save('users', 'u1', { name: 'Kim' });
+    await client.save('posts', 'p1', { author: 'u1' });
+    await client.save('posts', 'p2', { author: 'u2' });
+    await client.save('teams', 't1', { members: ['u1', 'u2'], lead: 'u1' });
+  });
+
+  const refs = [
+    { collection: 'posts', field: 'author', action: 'delete' as const },
+    { collection: 'teams', field: 'members', action: 'nullify' as const },
+    { collection: 'teams', field: 'lead', action: 'nullify' as const },
+  ];
+
+  it('should report affected documents without changes on dry run', async () => {
+    const result = await deleteCascade(client, 'users', 'u1', refs, { dryRun: true });
+
+    expect(result.deleted).toEqual([{ collection: 'posts', id: 'p1' }, { collection: 'users', id: 'u1' }]);
+    expect(result.nullified).toHaveLength(2);
+    expect((await client.list('posts')).count).toBe(2);
+  });
+
+  it('should delete and nullify references', async () => {
+    await deleteCascade(client, 'users', 'u1', refs);
+
+    expect((await client.list('posts')).data.map(d => d.id)).toEqual(['p2']);
+    expect((await client.getDoc('teams', 't1')).data).toEqual({ members: ['u2'], lead: null });
+    await expect(client.getDoc('users', 'u1')).rejects.toMatchObject({ status: 404 });
+  });
+
+  it('should find references past the first page', async () => {
+    for (let i = 0; i < 1500; i++) await client.save('comments', `c${i}`, { author: i % 500 === 499 ? 'u1' : 'u2' });
+
+    const result = await deleteCascade(client, 'users', 'u1', [{ collection: 'comments', field: 'author', action: 'delete' }]);
+    expect(result.deleted.map(d => d.id)).toEqual(['c499', 'c999', 'c1499', 'u1']);
+    expect((await client.listPage('comments', { limit: 1000 })).total).toBe(1497);
+  });
+});
@@ -0,0 +1,102 @@
+/**
+ * Query Fan-out Unit Tests
+ */
+
+import { describe, it, expect } from 'vitest';
+import { mapLimit, queryAll, searchAllCollections } from '../src/client/fanout.js';
+import { FakeKimDBClient } from '../src/client/fake.js';
+
+const delay = (ms: number) => new Promise(resolve => setTimeout(resolve, ms));
+
+describe('mapLimit', () => {
+  it('should keep input order and respect the concurrency limit', async () => {
+    let running = 0;
+    let peak = 0;
+
+    const results = await mapLimit([30, 10, 20, 5, 15], 2, async (ms, i) => {
+      running++;
+      peak = Math.max(peak, running);
+      await delay(ms);
+      running--;
+      return i;
+    });
+
+    expect(results).toEqual([0, 1, 2, 3, 4]);
+    expect(peak).toBe(2);
+  });
+
+  it('should stop starting new work after a failure', async () => {
+    const started: number[] = [];
+    const run = mapLimit([0, 1, 2, 3], 1, async (n) => {
+      started.push(n);
+      if (n === 1) throw new Error('boom');
+      return n;
+    });
+
+    await expect(run).rejects.toThrow('boom');
+    expect(started).toEqual([0, 1]);
+  });
+
+  it('should reject invalid concurrency', async () => {
+    await expect(mapLimit([1], 0, async n => n)).rejects.toThrow(RangeError);
+  });
+});
+
+describe('queryAll', () => {
+  it('should return one SQL result per query in order', async () => {
+    const fake = new FakeKimDBClient();
+    await fake.save('users', 'u1', { role: 'admin' });
+    await fake.save('orders', 'o1', { status: 'open' });
+    await fake.save('orders', 'o2', { status: 'done' });
+
+    const [users, orders] = await queryAll(fake, [
+      { collection: 'users', sql: 'SELECT * FROM users' },
+      { collection: 'orders', sql: 'SELECT * FROM orders WHERE status = ?', params: ['open'] },
+    ]);
+
+    expect(users.rows).toHaveLength(1);
+    expect(orders.rows).toEqual([expect.objectContaining({ id: 'o1' })]);
+  });
+});
+
+describe('searchAllCollections', () => {
+  it('should merge matches from every collection with provenance', async () => {
+    const fake = new FakeKimDBClient();
+    await fake.save('users', 'u1', { name: 'Kim Minji', team: 'a' });
+    await fake.save('users', 'u2', { name: 'Lee', team: 'a' });
+    await fake.save('posts', 'p1', { title: 'Hello from kim', team: 'b' });
+    await fake.save('posts', 'p2', { title: 'KIM again', team: 'a' });
+
+    const hits = await searchAllCollections(fake, ['users', 'posts'], { text: 'kim' });
+    expect(hits.map(h => `${h.collection}/${h.id}`)).toEqual(['users/u1', 'posts/p1', 'posts/p2']);
+    expect(hits[0]).toEqual({ collection: 'users', id: 'u1', _version: 1, doc: { name: 'Kim Minji', team: 'a' } });
+
+    const filtered = await searchAllCollections(fake, ['users', 'posts'], { text: 'kim', filter: { team: 'a' }, limit: 1 });
+    expect(filtered.map(h => h.id)).toEqual(['u1', 'p2']);
+  });
+
+  it('should search only the given fields', async () => {
+    const fake = new FakeKimDBClient();
+    await fake.save('posts', 'p1', { title: 'x', body: 'kim' });
+
+    expect(await searchAllCollections(fake, ['posts'], { text: 'kim', fields: ['title'] })).toEqual([]);
+  });
+
+  it('should search past the first page and stop reading once limit is met', async () => {
+    const fake = new FakeKimDBClient();
+    for (let i = 0; i < 2500; i++) await fake.save('logs', `l${i}`, { level: i % 1000 === 999 ? 'error' : 'info' });
+
+    const all = await searchAllCollections(fake, ['logs'], { filter: { level: 'error' } });
+    expect(all.map(h => h.id)).toEqual(['l999', 'l1999']);
+
+    const pages: number[] = [];
+    const listPage = fake.listPage.bind(fake);
+    fake.listPage = (collection, options) => {
+      pages.push(options?.skip ?? 0);
+      return listPage(collection, options);
+    };
+    const first = await searchAllCollections(fake, ['logs'], { filter: { level: 'error' }, limit: 1 });
+    expect(first.map(h => h.id)).toEqual(['l999']);
+    expect(pages).toEqual([0]);
+  });
+});
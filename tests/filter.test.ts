@@ -0,0 +1,78 @@
+/**
+ * Typed Filter Expression Unit Tests
+ */
+
+import { describe, it, expect } from 'vitest';
+import { fieldsOf } from '../src/client/filter.js';
+import { buildSelectWhere, buildUpdateWhere, buildCountWhere, SQLValidationError } from '../src/client/sql.js';
+import { parseSql, matchesWhere } from '../src/shared/sql.js';
+
+interface User {
+  name: string;
+  age: number;
+  role?: 'admin' | 'member';
+}
+
+const User = fieldsOf<User>();
+
+describe('fieldsOf', () => {
+  it('should compile and/or into OR-of-AND SQL with parameters', () => {
+    const expr = User.age.gt(18).and(User.name.like('kim%'));
+    expect(expr.toSQL()).toEqual({ clause: 'age > ? AND name LIKE ?', params: [18, 'kim%'] });
+
+    const either = User.role.eq('admin').or(User.age.gte(65)).and(User.name.ne('root'));
+    expect(either.toSQL()).toEqual({
+      clause: 'role = ? AND name != ? OR age >= ? AND name != ?',
+      params: ['admin', 'root', 65, 'root'],
+    });
+  });
+
+  it('should expand in() to OR conditions and reject an empty list', () => {
+    expect(User.role.in(['admin', 'member']).toSQL().clause).toBe('role = ? OR role = ?');
+    expect(() => User.role.in([]).toSQL()).toThrow(SQLValidationError);
+  });
+
+  it('should match documents on the client like the server WHERE', () => {
+    const expr = User.age.lt(30).and(User.name.like('K_m%'));
+    expect(expr.matches({ name: 'kimchi', age: 20 })).toBe(true);
+    expect(expr.matches({ name: 'kimchi', age: 40 })).toBe(false);
+    expect(expr.matches({ name: 'lee', age: 20 })).toBe(false);
+    expect(expr.matches(null)).toBe(false);
+  });
+
+  it('should compare loosely like the server WHERE', () => {
+    const Doc = fieldsOf<{ code: string | number; score: number }>();
+    const docs = [{ code: '1' }, { code: 1 }, { code: 2 }, { score: '10' }, { score: 9 }];
+    const cases = [Doc.code.eq(1), Doc.code.eq('1'), Doc.code.ne(1), Doc.score.gt(9), Doc.code.in([1, 2])];
+
+    for (const expr of cases) {
+      const { clause, params } = expr.toSQL();
+      const parsed = parseSql(`SELECT * FROM docs WHERE ${clause}`, params);
+      for (const doc of docs) {
+        expect(expr.matches(doc), `${clause} ${JSON.stringify(params)} on ${JSON.stringify(doc)}`).toBe(matchesWhere(doc, parsed));
+      }
+    }
+
+    expect(Doc.code.eq(1).matches({ code: '1' })).toBe(true);
+    expect(Doc.code.eq('1').matches({ code: 1 })).toBe(true);
+    expect(Doc.code.ne(1).matches({ code: '1' })).toBe(false);
+  });
+
+  it('should plug into the set-based SQL builders', () => {
+    const adults = User.age.gte(18);
+    expect(buildSelectWhere('users', adults, { sort: User.age.desc(), limit: 10 })).toEqual({
+      sql: 'SELECT * FROM users WHERE age >= ? ORDER BY age DESC LIMIT 10',
+      params: [18],
+    });
+    expect(buildUpdateWhere('users', adults, { role: 'member' })).toEqual({
+      sql: 'UPDATE users SET role = ? WHERE age >= ?',
+      params: [18, 'member'],
+    });
+    expect(buildCountWhere('users', adults).sql).toBe('SELECT COUNT(*) AS cnt FROM users WHERE age >= ?');
+  });
+
+  it('should reject field names that are not identifiers', () => {
+    const fields = fieldsOf<{ 'bad-name': string }>();
+    expect(() => fields['bad-name']).toThrow(SQLValidationError);
+  });
+});
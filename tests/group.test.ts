@@ -0,0 +1,37 @@
+/**
+ * Task Group Unit Tests
+ */
+
+import { describe, it, expect } from 'vitest';
+import { Group } from '../src/client/group.js';
+
+const untilAborted = (signal: AbortSignal) =>
+  new Promise<void>((resolve) => signal.addEventListener('abort', () => resolve(), { once: true }));
+
+describe('Group', () => {
+  it('should stop all tasks when one fails', async () => {
+    const group = new Group();
+    const stopped: string[] = [];
+
+    group.go(async (signal) => {
+      await untilAborted(signal);
+      stopped.push('watcher');
+    });
+    group.go(async () => {
+      throw new Error('consumer failed');
+    });
+
+    await expect(group.wait()).rejects.toThrow('consumer failed');
+    expect(stopped).toEqual(['watcher']);
+  });
+
+  it('should finish cleanly when the parent signal aborts', async () => {
+    const parent = new AbortController();
+    const group = new Group(parent.signal);
+    group.go(untilAborted);
+    group.go(untilAborted);
+
+    parent.abort();
+    await expect(group.wait()).resolves.toBeUndefined();
+  });
+});
@@ -0,0 +1,30 @@
+/**
+ * Random ID Unit Tests
+ */
+
+import { describe, it, expect, afterEach, vi } from 'vitest';
+import { randomId } from '../src/client/id.js';
+
+const UUID_V4 = /^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$/;
+
+describe('randomId', () => {
+  afterEach(() => {
+    vi.unstubAllGlobals();
+  });
+
+  it('should use the global randomUUID when present', () => {
+    expect(randomId()).toMatch(UUID_V4);
+  });
+
+  it('should build a v4 UUID from getRandomValues without randomUUID (Node 18)', () => {
+    vi.stubGlobal('crypto', { getRandomValues: (bytes: Uint8Array) => bytes.fill(0xff) });
+    expect(randomId()).toBe('ffffffff-ffff-4fff-bfff-ffffffffffff');
+  });
+
+  it('should fall back to Math.random without any global crypto', () => {
+    vi.stubGlobal('crypto', undefined);
+    const ids = new Set(Array.from({ length: 100 }, () => randomId()));
+    expect(ids.size).toBe(100);
+    for (const id of ids) expect(id).toMatch(UUID_V4);
+  });
+});
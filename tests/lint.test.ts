@@ -0,0 +1,72 @@
+/**
+ * Data Quality Lint Unit Tests
+ */
+
+import { describe, it, expect } from 'vitest';
+import { lintCollection } from '../src/client/lint.js';
+import { FakeKimDBClient } from '../src/client/fake.js';
+
+async function seed(): Promise<FakeKimDBClient> {
+  const fake = new FakeKimDBClient();
+  await fake.save('teams', 't1', { name: 'core' });
+  await fake.save('users', 'u1', { name: 'kim', age: 30, role: 'admin', team: 't1' });
+  await fake.save('users', 'u2', { name: 'lee', age: '41', role: 'owner', team: 't9' });
+  await fake.save('users', 'u3', { age: 25, role: 'member', team: 't1' });
+  await fake.save('users', 'u4', { name: 'park', age: 52, role: 'member', tags: ['a'] });
+  await fake.save('users', 'u5', { name: 'choi', age: 19, role: 'member', tags: 'b' });
+  return fake;
+}
+
+describe('lintCollection', () => {
+  it('should report required, type, enum and ref violations', async () => {
+    const report = await lintCollection(await seed(), 'users', {
+      required: ['name'],
+      types: { age: 'number' },
+      enums: { role: ['admin', 'member'] },
+      refs: [{ field: 'team', collection: 'teams' }],
+    });
+
+    expect(report.checked).toBe(5);
+    expect(report.sampled).toBe(false);
+    expect(report.issues.filter(i => i.rule !== 'mixed_type').map(i => `${i.rule}:${i.id}:${i.field}`).sort()).toEqual([
+      'enum:u2:role',
+      'ref:u2:team',
+      'required:u3:name',
+      'type:u2:age',
+    ]);
+  });
+
+  it('should flag minority types on fields without a type rule', async () => {
+    const fake = await seed();
+    await fake.save('users', 'u6', { tags: ['c'] });
+
+    const report = await lintCollection(fake, 'users', {});
+    expect(report.issues.map(i => `${i.rule}:${i.id}:${i.field}`).sort()).toEqual([
+      'mixed_type:u2:age',
+      'mixed_type:u5:tags',
+    ]);
+
+    const typed = await lintCollection(fake, 'users', { types: { age: ['number', 'string'] } });
+    expect(typed.issues.map(i => i.id)).toEqual(['u5']);
+  });
+
+  it('should check only a sample and cap reported issues', async () => {
+    const fake = await seed();
+    const sampled = await lintCollection(fake, 'users', {}, { sample: 2 });
+    expect(sampled).toMatchObject({ checked: 2, sampled: true });
+
+    const capped = await lintCollection(fake, 'users', { required: ['missing'] }, { maxIssues: 2 });
+    expect(capped.issues).toHaveLength(2);
+    expect(capped.counts.required).toBe(5);
+  });
+
+  it('should check every page and resolve refs against the whole target collection', async () => {
+    const fake = new FakeKimDBClient();
+    for (let i = 0; i < 1500; i++) await fake.save('teams', `t${i}`, { name: `team ${i}` });
+    for (let i = 0; i < 1200; i++) await fake.save('users', `u${i}`, { team: i === 1100 ? 'nope' : `t${1499 - i}` });
+
+    const report = await lintCollection(fake, 'users', { refs: [{ field: 'team', collection: 'teams' }] });
+    expect(report.checked).toBe(1200);
+    expect(report.issues).toEqual([{ id: 'u1100', field: 'team', rule: 'ref', message: 'team "nope" not found in teams' }]);
+  });
+});
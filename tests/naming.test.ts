@@ -0,0 +1,63 @@
+/**
+ * Field Naming Unit Tests
+ */
+
+import { describe, it, expect } from 'vitest';
+import { toSnakeCase, toCamelCase, encodeFields, decodeFields } from '../src/client/naming.js';
+import { KimDBClient } from '../src/client/index.js';
+import { fieldsOf } from '../src/client/filter.js';
+import { desc } from '../src/client/sort.js';
+
+describe('field naming', () => {
+  it('should convert between camelCase and snake_case', () => {
+    expect(toSnakeCase('createdAt')).toBe('created_at');
+    expect(toSnakeCase('userID')).toBe('user_id');
+    expect(toSnakeCase('HTTPServer')).toBe('http_server');
+    expect(toCamelCase('created_at')).toBe('createdAt');
+  });
+
+  it('should keep reserved fields', () => {
+    expect(toSnakeCase('_version')).toBe('_version');
+    expect(toCamelCase('_version')).toBe('_version');
+  });
+
+  it('should map nested keys only when enabled', () => {
+    const doc = { ownerName: 'kim', items: [{ dueDate: 1 }] };
+    const encoded = encodeFields(doc, 'snake_case');
+    expect(encoded).toEqual({ owner_name: 'kim', items: [{ due_date: 1 }] });
+    expect(decodeFields(encoded, 'snake_case')).toEqual(doc);
+    expect(encodeFields(doc, 'preserve')).toBe(doc);
+  });
+});
+
+describe('field naming in SQL helpers', () => {
+  interface Task {
+    ownerName: string;
+    dueDate: number;
+  }
+
+  it('should encode filter, sort and patch names and decode found rows', async () => {
+    const bodies: Array<{ sql: string; params: unknown[] }> = [];
+    const client = new KimDBClient({
+      url: 'ws://localhost:40000/ws',
+      fieldNaming: 'snake_case',
+      fetch: async (_input, init) => {
+        bodies.push(JSON.parse(String(init?.body)));
+        return new Response(JSON.stringify({ success: true, rows: [{ id: 1, owner_name: 'kim', due_date: 3 }], updated: 1, deleted: 1 }));
+      },
+    });
+    const Task = fieldsOf<Task>();
+
+    expect(await client.find('tasks', { ownerName: 'kim' }, { sort: desc('dueDate') })).toEqual([{ id: 1, ownerName: 'kim', dueDate: 3 }]);
+    await client.find('tasks', Task.dueDate.gt(1).or(Task.ownerName.eq('lee')));
+    await client.updateWhere('tasks', { ownerName: 'kim' }, { dueDate: 5 });
+    await client.deleteWhere('tasks', Task.dueDate.lt(0));
+
+    expect(bodies.map(b => [b.sql, b.params])).toEqual([
+      ['SELECT * FROM tasks WHERE owner_name = ? ORDER BY due_date DESC', ['kim']],
+      ['SELECT * FROM tasks WHERE due_date > ? OR owner_name = ?', [1, 'lee']],
+      ['UPDATE tasks SET due_date = ? WHERE owner_name = ?', ['kim', 5]],
+      ['DELETE FROM tasks WHERE due_date < ?', [0]],
+    ]);
+  });
+});
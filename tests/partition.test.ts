@@ -0,0 +1,70 @@
+/**
+ * Partitioned Collection Unit Tests
+ */
+
+import { describe, it, expect } from 'vitest';
+import { PartitionedCollection } from '../src/client/partition.js';
+import { fieldsOf } from '../src/client/filter.js';
+import { FakeKimDBClient } from '../src/client/fake.js';
+
+interface Event {
+  at: string;
+  kind: string;
+}
+
+async function seed(): Promise<{ fake: FakeKimDBClient; events: PartitionedCollection }> {
+  const fake = new FakeKimDBClient();
+  const events = new PartitionedCollection(fake, 'events', { timeField: 'at' });
+  await events.save('e1', { at: '2025-01-31T23:59:00Z', kind: 'click' });
+  await events.save('e2', { at: '2025-01-05T00:00:00Z', kind: 'view' });
+  await events.save('e3', { at: Date.UTC(2025, 1, 10), kind: 'click' });
+  await events.save('e4', { at: '2025-03-01T00:00:00Z', kind: 'view' });
+  return { fake, events };
+}
+
+describe('PartitionedCollection', () => {
+  it('should name partitions by UTC period', () => {
+    const fake = new FakeKimDBClient();
+    const byDay = new PartitionedCollection(fake, 'logs', { timeField: 'at', period: 'day' });
+    expect(byDay.partitionOf('2025-12-31T23:00:00Z')).toBe('logs_2025_12_31');
+    expect(byDay.partitionsBetween('2025-12-30T12:00:00Z', '2026-01-01T00:00:01Z'))
+      .toEqual(['logs_2025_12_30', 'logs_2025_12_31', 'logs_2026_01_01']);
+    expect(new PartitionedCollection(fake, 'logs', { timeField: 'at', period: 'year' }).partitionOf(0)).toBe('logs_1970');
+  });
+
+  it('should route writes by the time field', async () => {
+    const { fake, events } = await seed();
+    expect(await events.partitions()).toEqual(['events_2025_01', 'events_2025_02', 'events_2025_03']);
+    expect((await fake.list('events_2025_01')).data.map(d => d.id).sort()).toEqual(['e1', 'e2']);
+    expect((await events.getDoc('e3', Date.UTC(2025, 1, 10))).data).toMatchObject({ kind: 'click' });
+    await expect(events.save('bad', { kind: 'view' })).rejects.toThrow(RangeError);
+  });
+
+  it('should query only overlapping partitions and merge in time order', async () => {
+    const { events } = await seed();
+    const hits = await events.query('2025-01-10T00:00:00Z', '2025-03-01T00:00:00Z');
+    expect(hits.map(h => `${h.collection}/${h.id}`)).toEqual(['events_2025_01/e1', 'events_2025_02/e3']);
+
+    const Event = fieldsOf<Event>();
+    const views = await events.query('2025-01-01T00:00:00Z', '2026-01-01T00:00:00Z', { filter: Event.kind.eq('view') });
+    expect(views.map(h => h.id)).toEqual(['e2', 'e4']);
+  });
+
+  it('should query every page of a partition', async () => {
+    const fake = new FakeKimDBClient();
+    const events = new PartitionedCollection(fake, 'events', { timeField: 'at' });
+    for (let i = 0; i < 1500; i++) await events.save(`e${i}`, { at: Date.UTC(2025, 0, 1) + (1499 - i) * 1000, kind: 'click' });
+
+    const hits = await events.query('2025-01-01T00:00:00Z', '2025-02-01T00:00:00Z');
+    expect(hits).toHaveLength(1500);
+    expect(hits[0].id).toBe('e1499');
+  });
+
+  it('should drop partitions that end before the cutoff', async () => {
+    const { fake, events } = await seed();
+    const result = await events.dropBefore('2025-03-01T00:00:00Z');
+    expect(result).toEqual({ partitions: ['events_2025_01', 'events_2025_02'], deleted: 3 });
+    expect((await fake.list('events_2025_01')).count).toBe(0);
+    expect((await fake.list('events_2025_03')).count).toBe(1);
+  });
+});
@@ -0,0 +1,57 @@
+/**
+ * Client Path Unit Tests
+ */
+
+import { describe, it, expect } from 'vitest';
+import {
+  collectionPath,
+  splitCollectionPath,
+  docPath,
+  InvalidNameError,
+} from '../src/client/paths.js';
+
+describe('collectionPath', () => {
+  it('should join segments with the namespace separator', () => {
+    expect(collectionPath('projects', 123, 'tasks')).toBe('projects__123__tasks');
+    expect(splitCollectionPath('projects__123__tasks')).toEqual(['projects', '123', 'tasks']);
+  });
+
+  it('should reject segments that break the round trip', () => {
+    expect(() => collectionPath('a__b')).toThrow(InvalidNameError);
+    expect(() => collectionPath('_private')).toThrow(InvalidNameError);
+    expect(() => collectionPath('')).toThrow(InvalidNameError);
+  });
+
+  it('should not map two different paths to the same name', () => {
+    expect(collectionPath('projects', 1, 'x', 'tasks')).toBe('projects__1__x__tasks');
+    expect(() => collectionPath('projects', '1__x', 'tasks')).toThrow("must not contain '__'");
+    // 'a_' + 'b' = 'a___b' 는 다시 나누면 ['a', '_b']
+    expect(() => collectionPath('a_', 'b')).toThrow("must not start or end with '_'");
+    expect(() => collectionPath('a', '_b')).toThrow("must not start or end with '_'");
+  });
+
+  it('should split back into the original segments', () => {
+    for (const segments of [['a'], ['projects', '123', 'tasks'], ['snake_case', 'x_1', 'y']]) {
+      expect(splitCollectionPath(collectionPath(...segments))).toEqual(segments);
+    }
+  });
+});
+
+describe('docPath', () => {
+  it('should percent-encode document ids', () => {
+    expect(docPath('users')).toBe('/api/c/users');
+    expect(docPath('users', 'a b/c')).toBe('/api/c/users/a%20b%2Fc');
+  });
+
+  it('should reject collection names the server rejects', () => {
+    expect(() => docPath('my tasks')).toThrow(InvalidNameError);
+    expect(() => docPath('projects/1')).toThrow(InvalidNameError);
+    expect(() => docPath('_collections')).toThrow(InvalidNameError);
+    expect(() => docPath('sqlite_master')).toThrow(InvalidNameError);
+  });
+
+  it('should reject empty and dot ids', () => {
+    expect(() => docPath('users', '')).toThrow(InvalidNameError);
+    expect(() => docPath('users', '..')).toThrow(InvalidNameError);
+  });
+});
@@ -0,0 +1,100 @@
+/**
+ * Presence Rooms / Awareness Unit Tests
+ */
+
+import { describe, it, expect, afterEach, vi } from 'vitest';
+import { PresenceRooms } from '../src/server/presence.js';
+import { Awareness } from '../src/client/awareness.js';
+
+describe('PresenceRooms', () => {
+  it('should carry awareness state from one client to the others in the document', () => {
+    const rooms = new PresenceRooms('s1', 30000);
+    rooms.join('a', 'docs', 'd1', { name: 'Kim' });
+    const joined = rooms.join('b', 'docs', 'd1', { name: 'Lee' });
+    expect(joined.reply).toMatchObject({ type: 'presence_join_ok', nodeId: 'client_b', users: [{ name: 'Kim' }, { name: 'Lee' }] });
+    expect(joined.broadcast?.message).toMatchObject({ type: 'presence_joined', user: { nodeId: 'client_b', name: 'Lee' } });
+
+    // a의 awareness가 보낸 presence_update를 서버가 처리해 b에게 방송
+    const b = new Awareness('docs', 'd1', () => {});
+    const changes: string[] = [];
+    b.onChange = (nodeId, state) => changes.push(`${nodeId}:${JSON.stringify(state)}`);
+    const a = new Awareness('docs', 'd1', (msg) => {
+      const { user } = msg as { user: Record<string, unknown> };
+      const result = rooms.update('a', user);
+      expect(result.reply).toEqual({ type: 'presence_update_ok' });
+      b.handleMessage(result.broadcast!.message as { type: string });
+    });
+
+    a.set('typing', true);
+    expect(b.get('client_a', 'typing')).toBe(true);
+    expect(rooms.users('docs', 'd1')).toMatchObject({ count: 2, users: [{ name: 'Kim', awareness: { typing: true } }, { name: 'Lee' }] });
+
+    b.handleMessage(rooms.leave('a')!.message as { type: string });
+    expect(b.getAll().size).toBe(0);
+    expect(changes).toEqual(['client_a:{"typing":true}', 'client_a:null']);
+    expect(rooms.users('docs', 'd1')).toMatchObject({ count: 1 });
+  });
+
+  it('should reject updates before join and leave the old document on rejoin', () => {
+    const rooms = new PresenceRooms('s1', 30000);
+    expect(rooms.update('a', { x: 1 })).toEqual({ reply: { type: 'error', message: 'Not joined' } });
+    expect(rooms.cursor('a', 1, null)).toBeNull();
+    expect(rooms.leave('a')).toBeNull();
+
+    rooms.join('a', 'docs', 'd1');
+    const moved = rooms.join('a', 'docs', 'd2');
+    expect(moved.left).toMatchObject({ docId: 'd1', message: { type: 'presence_left', nodeId: 'client_a' } });
+    expect(rooms.users('docs', 'd1')).toMatchObject({ count: 0 });
+    expect(rooms.cursor('a', 5, [1, 3])?.message).toMatchObject({ type: 'presence_cursor_moved', docId: 'd2', cursor: { position: 5 } });
+  });
+
+  describe('users (presence_get)', () => {
+    afterEach(() => {
+      vi.useRealTimers();
+    });
+
+    it('should list only the members of the requested document', () => {
+      const rooms = new PresenceRooms('s1', 30000);
+      rooms.join('a', 'docs', 'd1', { name: 'Kim' });
+      rooms.join('b', 'docs', 'd1', { name: 'Lee' });
+      rooms.join('c', 'docs', 'd2', { name: 'Park' });
+      rooms.join('d', 'notes', 'd1', { name: 'Choi' });
+
+      const reply = rooms.users('docs', 'd1');
+      expect(reply).toMatchObject({ type: 'presence_users', collection: 'docs', docId: 'd1', count: 2 });
+      expect((reply.users as Array<{ nodeId: string }>).map(u => u.nodeId)).toEqual(['client_a', 'client_b']);
+    });
+
+    it('should leave out members whose last update is older than the ttl', () => {
+      vi.useFakeTimers();
+      vi.setSystemTime(0);
+      const rooms = new PresenceRooms('s1', 1000);
+      rooms.join('a', 'docs', 'd1', { name: 'Kim' });
+      vi.setSystemTime(800);
+      rooms.join('b', 'docs', 'd1', { name: 'Lee' });
+
+      vi.setSystemTime(1500);
+      expect(rooms.users('docs', 'd1')).toMatchObject({ count: 1, users: [{ nodeId: 'client_b' }] });
+
+      rooms.update('b', { typing: true });
+      vi.setSystemTime(2400);
+      expect(rooms.users('docs', 'd1')).toMatchObject({ count: 1, users: [{ nodeId: 'client_b', typing: true }] });
+    });
+
+    it('should answer an empty list without creating a room', () => {
+      const rooms = new PresenceRooms('s1', 30000);
+      expect(rooms.users('docs', 'nobody')).toEqual({ type: 'presence_users', collection: 'docs', docId: 'nobody', users: [], count: 0 });
+      expect(rooms.size).toBe(0);
+    });
+  });
+
+  it('should drop members that stop updating after the ttl', () => {
+    const rooms = new PresenceRooms('s1', 1000);
+    rooms.join('a', 'docs', 'd1');
+    const start = Date.now();
+    rooms.cleanup(start);
+    expect(rooms.size).toBe(1);
+    rooms.cleanup(start + 2001);
+    expect(rooms.size).toBe(0);
+  });
+});
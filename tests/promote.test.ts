@@ -0,0 +1,81 @@
+/**
+ * Environment Promotion Unit Tests
+ */
+
+import { describe, it, expect } from 'vitest';
+import { promote } from '../src/client/promote.js';
+import { FakeKimDBClient } from '../src/client/fake.js';
+import type { RetentionPolicy } from '../src/shared/types.js';
+
+class FakeWithRetention extends FakeKimDBClient {
+  private policies = new Map<string, RetentionPolicy>();
+
+  async getRetentionPolicy(collection: string): Promise<RetentionPolicy | null> {
+    return this.policies.get(collection) ?? null;
+  }
+
+  async setRetentionPolicy(collection: string, policy: RetentionPolicy | null): Promise<void> {
+    if (policy) this.policies.set(collection, policy);
+    else this.policies.delete(collection);
+  }
+}
+
+async function environments(): Promise<{ staging: FakeWithRetention; prod: FakeWithRetention }> {
+  const staging = new FakeWithRetention();
+  const prod = new FakeWithRetention();
+  await staging.save('configs', 'flags', { darkMode: true, beta: false });
+  await staging.save('configs', 'limits', { rate: 100 });
+  await prod.save('configs', 'flags', { darkMode: false, legacy: true });
+  await prod.save('configs', 'old', { unused: 1 });
+  await staging.setRetentionPolicy('configs', { maxDocuments: 50 });
+  return { staging, prod };
+}
+
+describe('promote', () => {
+  it('should report differences without writing by default', async () => {
+    const { staging, prod } = await environments();
+
+    const [d] = await promote(staging, prod, { collections: ['configs'] });
+
+    expect(d.added).toEqual(['limits']);
+    expect(d.changed.map(c => c.id)).toEqual(['flags']);
+    expect(d.removed).toEqual(['old']);
+    expect(d.retention).toEqual({ source: { maxDocuments: 50 }, target: null });
+    expect((await prod.getDoc('configs', 'flags')).data).toEqual({ darkMode: false, legacy: true });
+  });
+
+  it('should make the target match the source on apply', async () => {
+    const { staging, prod } = await environments();
+
+    await promote(staging, prod, { collections: ['configs'], apply: true, deleteMissing: true });
+
+    const [again] = await promote(staging, prod, { collections: ['configs'] });
+    expect(again).toEqual({ collection: 'configs', added: [], changed: [], removed: [] });
+    expect(await prod.getRetentionPolicy('configs')).toEqual({ maxDocuments: 50 });
+    expect((await prod.list('configs')).count).toBe(2);
+  });
+
+  it('should keep target-only documents unless deleteMissing is set', async () => {
+    const { staging, prod } = await environments();
+
+    await promote(staging, prod, { apply: true });
+
+    expect((await prod.getDoc('configs', 'old')).data).toEqual({ unused: 1 });
+  });
+
+  it('should compare every page so deleteMissing only removes target-only documents', async () => {
+    const staging = new FakeWithRetention();
+    const prod = new FakeWithRetention();
+    // 양쪽 삽입 순서가 달라 한쪽 첫 페이지의 문서가 다른 쪽에서는 뒤 페이지에 있음
+    for (let i = 0; i < 1500; i++) await staging.save('items', `i${i}`, { n: i });
+    for (let i = 1499; i >= 0; i--) await prod.save('items', `i${i}`, { n: i === 1200 ? -1 : i });
+    await prod.save('items', 'stale', { n: 0 });
+
+    const [d] = await promote(staging, prod, { collections: ['items'], apply: true, deleteMissing: true });
+    expect(d.added).toEqual([]);
+    expect(d.changed.map(c => c.id)).toEqual(['i1200']);
+    expect(d.removed).toEqual(['stale']);
+    expect((await prod.listPage('items', { limit: 1000 })).total).toBe(1500);
+    expect((await prod.getDoc('items', 'i1200')).data).toEqual({ n: 1200 });
+  });
+});
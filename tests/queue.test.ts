@@ -0,0 +1,97 @@
+/**
+ * Queue Unit Tests
+ */
+
+import { describe, it, expect, vi, afterEach } from 'vitest';
+import { FakeKimDBClient } from '../src/client/fake.js';
+import { QueueConsumer, publish, type QueueMessage } from '../src/client/queue.js';
+
+afterEach(() => {
+  vi.useRealTimers();
+});
+
+describe('QueueConsumer', () => {
+  it('should deliver each message once per group, to one consumer in the group', async () => {
+    const fake = new FakeKimDBClient();
+    for (let i = 0; i < 20; i++) await publish(fake, 'jobs', { n: i });
+
+    const seen: Array<[string, number]> = [];
+    const handler = (name: string) => (m: QueueMessage<{ n: number }>) => {
+      seen.push([name, m.payload.n]);
+    };
+    const a = new QueueConsumer(fake, 'jobs', 'workers', handler('a'));
+    const b = new QueueConsumer(fake, 'jobs', 'workers', handler('b'));
+    const audit = new QueueConsumer(fake, 'jobs', 'audit', handler('audit'));
+
+    const [fromA, fromB] = await Promise.all([a.poll(), b.poll()]);
+    expect(fromA + fromB).toBe(20);
+    const workers = seen.filter(([name]) => name !== 'audit').map(([, n]) => n).sort((x, y) => x - y);
+    expect(workers).toEqual([...Array(20).keys()]);
+
+    expect(await audit.poll()).toBe(20);
+    expect(await a.poll()).toBe(0);
+  });
+
+  it('should retry failed messages and dead-letter them after maxAttempts', async () => {
+    const fake = new FakeKimDBClient();
+    await publish(fake, 'jobs', 'bad');
+    const attempts: number[] = [];
+    const dead: QueueMessage[] = [];
+    const errors: Error[] = [];
+    const consumer = new QueueConsumer(fake, 'jobs', 'workers', (m) => {
+      attempts.push(m.attempts);
+      throw new Error('boom');
+    }, { maxAttempts: 2, onDeadLetter: m => { dead.push(m); }, onError: e => errors.push(e) });
+
+    expect(await consumer.poll()).toBe(0);
+    expect(await consumer.poll()).toBe(0);
+    expect(await consumer.poll()).toBe(1);
+    expect(attempts).toEqual([1, 2]);
+    expect(errors).toHaveLength(2);
+    expect(dead).toMatchObject([{ payload: 'bad', attempts: 3 }]);
+    expect(await consumer.poll()).toBe(0);
+  });
+
+  it('should redeliver after the visibility timeout when a consumer stalls', async () => {
+    vi.useFakeTimers();
+    const fake = new FakeKimDBClient();
+    await publish(fake, 'jobs', 'slow');
+
+    let finish!: () => void;
+    const stalled = new QueueConsumer(fake, 'jobs', 'workers', () => new Promise<void>((r) => { finish = r; }), {
+      visibilityMs: 1000,
+    });
+    const stalledPoll = stalled.poll();
+    await vi.advanceTimersByTimeAsync(0);
+
+    const got: number[] = [];
+    const backup = new QueueConsumer(fake, 'jobs', 'workers', (m) => { got.push(m.attempts); });
+    expect(await backup.poll()).toBe(0);
+
+    vi.setSystemTime(Date.now() + 1001);
+    expect(await backup.poll()).toBe(1);
+    expect(got).toEqual([2]);
+
+    // 늦게 끝난 소비자의 ack는 버전 충돌로 무시되고 다시 배달되지 않음
+    finish();
+    await stalledPoll;
+    expect(await backup.poll()).toBe(0);
+  });
+
+  it('should keep polling after start until stopped', async () => {
+    vi.useFakeTimers();
+    const fake = new FakeKimDBClient();
+    const got: string[] = [];
+    const consumer = new QueueConsumer<string>(fake, 'jobs', 'workers', (m) => { got.push(m.payload); }, { pollMs: 100 });
+
+    await consumer.start();
+    await publish(fake, 'jobs', 'later');
+    await vi.advanceTimersByTimeAsync(100);
+    expect(got).toEqual(['later']);
+
+    await consumer.stop();
+    await publish(fake, 'jobs', 'after-stop');
+    await vi.advanceTimersByTimeAsync(500);
+    expect(got).toEqual(['later']);
+  });
+});
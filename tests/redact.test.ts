@@ -0,0 +1,36 @@
+/**
+ * Read Redaction Unit Tests
+ */
+
+import { describe, it, expect } from 'vitest';
+import { redact } from '../src/client/redact.js';
+
+describe('redact', () => {
+  const doc = {
+    name: 'Kim',
+    password: 'secret',
+    profile: { ssn: '123-45', city: 'Seoul' },
+    cards: [{ number: '4111', brand: 'visa' }, { number: '5500', brand: 'mc' }],
+  };
+
+  it('should drop and mask fields without mutating input', () => {
+    const out = redact(doc, [
+      { path: 'password', action: 'drop' },
+      { path: 'profile.ssn' },
+      { path: 'cards.*.number', mask: null },
+    ]);
+
+    expect(out).toEqual({
+      name: 'Kim',
+      profile: { ssn: '***', city: 'Seoul' },
+      cards: [{ number: null, brand: 'visa' }, { number: null, brand: 'mc' }],
+    });
+    expect(doc.password).toBe('secret');
+    expect(doc.cards[0].number).toBe('4111');
+  });
+
+  it('should ignore missing paths and scalars', () => {
+    expect(redact({ a: 1 }, [{ path: 'b.c' }])).toEqual({ a: 1 });
+    expect(redact(null, [{ path: 'a' }])).toBeNull();
+  });
+});
@@ -0,0 +1,74 @@
+/**
+ * Retention Policy Unit Tests
+ */
+
+import { describe, it, expect, beforeEach, afterEach } from 'vitest';
+import { mkdtempSync, rmSync } from 'fs';
+import { join } from 'path';
+import { tmpdir } from 'os';
+import { retentionPolicyError } from '../src/shared/retention.js';
+import { KimDatabase } from '../src/server/database.js';
+import type { Config } from '../src/server/config.js';
+
+describe('retentionPolicyError', () => {
+  it('should accept an age or count limit with an optional archive', () => {
+    expect(retentionPolicyError('logs', { maxAgeMs: 1000 })).toBeNull();
+    expect(retentionPolicyError('logs', { maxDocuments: 0, archiveTo: 'old_logs' })).toBeNull();
+  });
+
+  it('should explain what is wrong with a policy', () => {
+    expect(retentionPolicyError('logs', undefined)).toBe('policy is required');
+    expect(retentionPolicyError('logs', {})).toBe('policy needs maxAgeMs or maxDocuments');
+    expect(retentionPolicyError('logs', { maxAgeMs: 0 })).toBe('maxAgeMs must be a positive integer');
+    expect(retentionPolicyError('logs', { maxDocuments: 1.5 })).toBe('maxDocuments must be a non-negative integer');
+    expect(retentionPolicyError('logs', { maxDocuments: 1, archiveTo: 'logs' })).toBe('archiveTo must be another collection name');
+  });
+});
+
+describe('KimDatabase retention', () => {
+  let dir: string;
+  let db: KimDatabase;
+
+  beforeEach(() => {
+    dir = mkdtempSync(join(tmpdir(), 'kimdb-'));
+    db = new KimDatabase({ dataDir: dir } as Config);
+    for (const id of ['a', 'b', 'c']) db.saveDocument('logs', id, JSON.stringify({ id }));
+  });
+
+  afterEach(() => {
+    db.close();
+    rmSync(dir, { recursive: true, force: true });
+  });
+
+  it('should store policies and read back only the fields that were set', () => {
+    expect(db.getRetentionPolicy('logs')).toBeNull();
+    db.setRetentionPolicy('logs', { maxDocuments: 2 });
+    expect(db.getRetentionPolicy('logs')).toEqual({ maxDocuments: 2 });
+    expect(db.getRetentionCollections()).toEqual(['logs']);
+
+    db.setRetentionPolicy('logs', null);
+    expect(db.getRetentionPolicy('logs')).toBeNull();
+  });
+
+  it('should keep the newest maxDocuments and archive the rest', () => {
+    db.setRetentionPolicy('logs', { maxDocuments: 1, archiveTo: 'old_logs' });
+
+    expect(db.applyRetention('logs').sort()).toEqual(['a', 'b']);
+    expect(db.getDocuments('logs', -1).map(r => r.id)).toEqual(['c']);
+    expect(db.getDocuments('old_logs', -1).map(r => r.id).sort()).toEqual(['a', 'b']);
+    expect(db.applyRetention('logs')).toEqual([]);
+  });
+
+  it('should delete documents older than maxAgeMs', () => {
+    db.setRetentionPolicy('logs', { maxAgeMs: 60 * 60 * 1000 });
+
+    expect(db.applyRetention('logs')).toEqual([]);
+    expect(db.applyRetention('logs', Date.now() + 2 * 60 * 60 * 1000).sort()).toEqual(['a', 'b', 'c']);
+    expect(db.getDocuments('logs', -1)).toEqual([]);
+  });
+
+  it('should do nothing without a policy', () => {
+    expect(db.applyRetention('logs')).toEqual([]);
+    expect(db.getDocuments('logs', -1)).toHaveLength(3);
+  });
+});
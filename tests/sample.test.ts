@@ -0,0 +1,103 @@
+/**
+ * Random Sample Unit Tests
+ */
+
+import { describe, it, expect, beforeEach, afterEach } from 'vitest';
+import { mkdtempSync, rmSync } from 'fs';
+import { join } from 'path';
+import { tmpdir } from 'os';
+import { sampleSizeError, MAX_SAMPLE_SIZE } from '../src/shared/sample.js';
+import { KimDBClient } from '../src/client/index.js';
+import { FakeKimDBClient } from '../src/client/fake.js';
+import { KimDatabase } from '../src/server/database.js';
+import type { Config } from '../src/server/config.js';
+
+describe('sampleSizeError', () => {
+  it('should accept integers from 1 to 1000', () => {
+    expect(MAX_SAMPLE_SIZE).toBe(1000);
+    for (const n of [1, 2, 1000]) expect(sampleSizeError(n)).toBeNull();
+  });
+
+  it('should reject negative, zero, fractional, huge and non-numeric sizes', () => {
+    for (const n of [-1, 0, 2.5, 1001, 1e9, Infinity, NaN, '5', null, undefined]) {
+      expect(sampleSizeError(n)).toBe('n must be an integer between 1 and 1000');
+    }
+  });
+
+  it('should reject query strings the way the servers parse them', () => {
+    // 서버는 Number(query.n ?? 1)로 바꿔 검사
+    const check = (query: string | undefined) => sampleSizeError(Number(query ?? 1));
+    expect(check(undefined)).toBeNull();
+    expect(check('25')).toBeNull();
+    for (const query of ['', '-3', '0', '2.5', '1001', '99999999999', 'abc', '10abc']) {
+      expect(check(query)).not.toBeNull();
+    }
+  });
+});
+
+describe('sample endpoint', () => {
+  function clientWith(body: unknown) {
+    const requests: string[] = [];
+    const client = new KimDBClient({
+      url: 'ws://localhost:40000/ws',
+      fetch: async (input) => {
+        requests.push(String(input));
+        return new Response(JSON.stringify(body));
+      },
+    });
+    return { client, requests };
+  }
+
+  it('should request n documents and return them', async () => {
+    const { client, requests } = clientWith({ success: true, collection: 'users', count: 1, data: [{ id: 'u1', name: 'Kim', _version: 2 }] });
+
+    expect(await client.sample('users', 3)).toEqual([{ id: 'u1', name: 'Kim', _version: 2 }]);
+    expect(new URL(requests[0]).pathname).toBe('/api/sample/users');
+    expect(new URL(requests[0]).searchParams.get('n')).toBe('3');
+  });
+
+  it('should reject invalid n without calling the server', async () => {
+    const { client, requests } = clientWith({ success: true, data: [] });
+
+    for (const n of [-1, 0, 2.5, 1001, NaN]) {
+      await expect(client.sample('users', n)).rejects.toThrow('n must be an integer between 1 and 1000');
+    }
+    expect(requests).toEqual([]);
+  });
+
+  it('should validate the same way in the fake client', async () => {
+    const fake = new FakeKimDBClient();
+    await fake.save('users', 'u1', { name: 'Kim' });
+    await fake.save('users', 'u2', { name: 'Lee' });
+
+    expect(await fake.sample('users', 5)).toHaveLength(2);
+    await expect(fake.sample('users', 0)).rejects.toThrow('n must be an integer between 1 and 1000');
+    await expect(fake.sample('users', 1.5)).rejects.toThrow('n must be an integer between 1 and 1000');
+  });
+});
+
+describe('KimDatabase.sampleDocuments', () => {
+  let dir: string;
+  let db: KimDatabase;
+
+  beforeEach(() => {
+    dir = mkdtempSync(join(tmpdir(), 'kimdb-'));
+    db = new KimDatabase({ dataDir: dir } as Config);
+    for (let i = 0; i < 5; i++) db.saveDocument('users', `u${i}`, JSON.stringify({ i }));
+    db.deleteDocument('users', 'u0');
+  });
+
+  afterEach(() => {
+    db.close();
+    rmSync(dir, { recursive: true, force: true });
+  });
+
+  it('should return at most n live documents without repeats', () => {
+    const ids = db.sampleDocuments('users', 3).map(r => r.id);
+    expect(ids).toHaveLength(3);
+    expect(new Set(ids).size).toBe(3);
+    expect(ids).not.toContain('u0');
+
+    expect(db.sampleDocuments('users', 1000).map(r => r.id).sort()).toEqual(['u1', 'u2', 'u3', 'u4']);
+  });
+});
@@ -0,0 +1,103 @@
+/**
+ * Server SQL Unit Tests
+ */
+
+import { describe, it, expect, beforeEach, afterEach } from 'vitest';
+import { mkdtempSync, rmSync } from 'fs';
+import { join } from 'path';
+import { tmpdir } from 'os';
+import { KimDatabase } from '../src/server/database.js';
+import { executeSQL } from '../src/server/sql.js';
+import { buildUpdateWhere, buildDeleteWhere, buildCountWhere } from '../src/client/sql.js';
+import { collator } from '../src/shared/collation.js';
+import type { Config } from '../src/server/config.js';
+
+describe('executeSQL', () => {
+  let dir: string;
+  let db: KimDatabase;
+
+  beforeEach(() => {
+    dir = mkdtempSync(join(tmpdir(), 'kimdb-'));
+    db = new KimDatabase({ dataDir: dir } as Config);
+    executeSQL(db, 'INSERT INTO tasks (title, status, owner) VALUES (?, ?, ?)', ['a', 'open', 'kim'], 'tasks');
+    executeSQL(db, 'INSERT INTO tasks (title, status, owner) VALUES (?, ?, ?)', ['b', 'open', 'lee'], 'tasks');
+    executeSQL(db, 'INSERT INTO tasks (title, status, owner) VALUES (?, ?, ?)', ['c', 'done', 'kim'], 'tasks');
+  });
+
+  afterEach(() => {
+    db.close();
+    rmSync(dir, { recursive: true, force: true });
+  });
+
+  it('should insert values with increasing numeric ids', () => {
+    const result = executeSQL(db, "INSERT INTO tasks (title, status) VALUES ('d', ?)", ['open'], 'tasks');
+    expect(result).toEqual({ row: { title: 'd', status: 'open', id: 4 }, lastrowid: 4 });
+    expect(JSON.parse(db.getDocument('tasks', '4')!.data)).toEqual({ title: 'd', status: 'open', id: 4 });
+  });
+
+  it('should filter SELECT by WHERE with AND/OR, ORDER BY and LIMIT', () => {
+    const select = (sql: string, params: unknown[] = []) =>
+      executeSQL(db, sql, params, 'tasks').rows!.map((r) => (r as { title: string }).title);
+
+    expect(select('SELECT * FROM tasks WHERE owner = ? AND status = ?', ['kim', 'open'])).toEqual(['a']);
+    expect(select("SELECT * FROM tasks WHERE status = 'done' OR owner = 'lee' ORDER BY title DESC")).toEqual(['c', 'b']);
+    expect(select('SELECT * FROM tasks WHERE title != ? ORDER BY owner, title DESC LIMIT 2', ['b'])).toEqual(['c', 'a']);
+    expect(executeSQL(db, 'SELECT title FROM tasks WHERE id = 2', [], 'tasks').rows).toEqual([{ title: 'b' }]);
+  });
+
+  it('should answer the COUNT(*) AS cnt query that subscribeCount sends', () => {
+    const count = (filter: Record<string, unknown>) => {
+      const { sql, params } = buildCountWhere('tasks', filter);
+      return executeSQL(db, sql, params, 'tasks').rows;
+    };
+    expect(count({})).toEqual([{ cnt: 3, 'COUNT(*)': 3 }]);
+    expect(count({ status: 'open' })).toEqual([{ cnt: 2, 'COUNT(*)': 2 }]);
+    expect(count({ status: 'open', owner: 'kim' })).toEqual([{ cnt: 1, 'COUNT(*)': 1 }]);
+    expect(executeSQL(db, 'SELECT COUNT(*) FROM tasks WHERE owner = ?', ['nobody'], 'tasks').rows).toEqual([{ 'COUNT(*)': 0 }]);
+  });
+
+  it('should compare and sort strings with the request collation', () => {
+    for (const title of ['Item10', 'item2', 'ITEM1']) {
+      executeSQL(db, 'INSERT INTO tasks (title, status) VALUES (?, ?)', [title, 'later'], 'tasks');
+    }
+    const by = collator({ caseInsensitive: true, numeric: true });
+    const titles = (sql: string, params: unknown[], c: Intl.Collator | null) =>
+      executeSQL(db, sql, params, 'tasks', c).rows!.map((r) => (r as { title: string }).title);
+
+    const sql = 'SELECT * FROM tasks WHERE status = ? ORDER BY status, title';
+    expect(titles(sql, ['LATER'], by)).toEqual(['ITEM1', 'item2', 'Item10']);
+    expect(titles(sql, ['LATER'], null)).toEqual([]);
+    expect(titles(sql, ['later'], null)).toEqual(['ITEM1', 'Item10', 'item2']);
+    expect(titles('SELECT * FROM tasks WHERE title >= ? ORDER BY title DESC', ['item2'], by)).toEqual(['Item10', 'item2']);
+  });
+
+  it('should update only matching documents with WHERE params bound before SET params', () => {
+    const { sql, params } = buildUpdateWhere('tasks', { owner: 'kim', status: 'open' }, { status: 'closed' });
+    expect(executeSQL(db, sql, params, 'tasks')).toEqual({ updated: 1 });
+
+    expect(JSON.parse(db.getDocument('tasks', '1')!.data)).toMatchObject({ title: 'a', status: 'closed' });
+    expect(db.getDocument('tasks', '1')!._version).toBe(2);
+    expect(JSON.parse(db.getDocument('tasks', '2')!.data)).toMatchObject({ status: 'open' });
+    expect(JSON.parse(db.getDocument('tasks', '3')!.data)).toMatchObject({ status: 'done' });
+  });
+
+  it('should soft delete matching documents and drop them from the index', () => {
+    const { sql, params } = buildDeleteWhere('tasks', { owner: 'kim' });
+    expect(executeSQL(db, sql, params, 'tasks')).toEqual({ deleted: 2 });
+
+    expect(db.getDocument('tasks', '1')).toBeUndefined();
+    expect(db.getDocument('tasks', '3')).toBeUndefined();
+    expect(JSON.parse(db.getDocument('tasks', '_index')!.data)).toEqual({ ids: ['2'], next_id: 4 });
+    expect(executeSQL(db, 'SELECT * FROM tasks', [], 'tasks').rowcount).toBe(1);
+  });
+
+  it('should roll back a DELETE when run inside rehearse', () => {
+    const result = db.rehearse(() => executeSQL(db, 'DELETE FROM tasks WHERE status = ?', ['open'], 'tasks'));
+    expect(result).toEqual({ deleted: 2 });
+    expect(executeSQL(db, 'SELECT * FROM tasks', [], 'tasks').rowcount).toBe(3);
+  });
+
+  it('should reject statements outside the supported subset', () => {
+    expect(() => executeSQL(db, 'DROP TABLE tasks', [], 'tasks')).toThrow('Unsupported SQL: DROP TABLE tasks');
+  });
+});
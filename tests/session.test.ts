@@ -0,0 +1,113 @@
+/**
+ * Read-Your-Writes Session Unit Tests
+ */
+
+import { describe, it, expect } from 'vitest';
+import { KimDBClient } from '../src/client/index.js';
+import { KimDBHttpError } from '../src/client/errors.js';
+import { linearBackoff } from '../src/client/backoff.js';
+import { ConsistentSession, StaleReadError, type SessionToken } from '../src/client/session.js';
+
+type Stored = { data: Record<string, unknown>; _version: number };
+
+/** 쓰기는 primary에, 읽기는 lag번 읽은 뒤에야 따라잡는 replica에서 처리하는 서버 */
+function laggingCluster(lag: number) {
+  const primary = new Map<string, Stored>();
+  let replica = new Map<string, Stored>();
+  let behind = 0;
+  const cluster = { gets: 0, fetch: null as unknown as typeof fetch };
+
+  const json = (body: unknown, status = 200) => new Response(JSON.stringify(body), { status });
+  cluster.fetch = (async (input: RequestInfo | URL, init?: RequestInit) => {
+    const [, , , collection, id] = new URL(String(input)).pathname.split('/');
+    const method = init?.method ?? 'GET';
+
+    if (method === 'GET') {
+      cluster.gets++;
+      if (behind-- <= 0) replica = new Map(primary);
+      if (!id) {
+        const data = [...replica].map(([docId, doc]) => ({ id: docId, ...doc.data, _version: doc._version }));
+        return json({ success: true, collection, count: data.length, data });
+      }
+      const doc = replica.get(id);
+      return doc ? json({ id, data: doc.data, _version: doc._version }) : json({ error: 'Document not found' }, 404);
+    }
+
+    behind = lag;
+    if (method === 'DELETE') {
+      primary.delete(id);
+      return json({ success: true });
+    }
+    const body = JSON.parse(String(init!.body)) as { data: Record<string, unknown> };
+    const _version = (primary.get(id)?._version ?? 0) + 1;
+    primary.set(id, { data: body.data, _version });
+    return json({ success: true, id, _version });
+  }) as typeof fetch;
+
+  return cluster;
+}
+
+function sessionOn(cluster: ReturnType<typeof laggingCluster>, token?: SessionToken) {
+  const client = new KimDBClient({ url: 'ws://localhost:40000/ws', fetch: cluster.fetch });
+  return new ConsistentSession(client, { token, backoff: linearBackoff(1, 20) });
+}
+
+describe('ConsistentSession', () => {
+  it('should reread until the replica shows the version this session wrote', async () => {
+    const cluster = laggingCluster(2);
+    const session = sessionOn(cluster);
+    await session.save('docs', 'd1', { title: 'v1' });
+    await session.update('docs', 'd1', { title: 'v2' });
+
+    const doc = await session.getDoc('docs', 'd1');
+    expect(doc).toMatchObject({ data: { title: 'v2' }, _version: 2 });
+    expect(cluster.gets).toBe(3);
+    expect(session.token).toEqual({ docs: { d1: 2 } });
+  });
+
+  it('should not return a document this session deleted', async () => {
+    const cluster = laggingCluster(2);
+    const session = sessionOn(cluster);
+    await session.save('docs', 'd1', { title: 'v1' });
+    await session.save('docs', 'd2', { title: 'keep' });
+    await session.remove('docs', 'd1');
+
+    expect((await session.list('docs')).data.map(d => d.id)).toEqual(['d2']);
+    expect(cluster.gets).toBe(3);
+    await expect(session.getDoc('docs', 'd1')).rejects.toMatchObject({ status: 404 });
+    expect(session.token).toEqual({ docs: { d1: null, d2: 1 } });
+  });
+
+  it('should return the first response for documents outside the token', async () => {
+    const cluster = laggingCluster(5);
+    const writer = sessionOn(cluster);
+    await writer.save('docs', 'd1', { title: 'v1' });
+
+    const reader = sessionOn(cluster);
+    await expect(reader.getDoc('docs', 'd1')).rejects.toBeInstanceOf(KimDBHttpError);
+    expect(cluster.gets).toBe(1);
+  });
+
+  it('should carry a token into another session', async () => {
+    const cluster = laggingCluster(3);
+    const writer = sessionOn(cluster);
+    await writer.save('docs', 'd1', { title: 'v1' });
+
+    const reader = sessionOn(cluster, JSON.parse(JSON.stringify(writer.token)));
+    expect(await reader.getDoc('docs', 'd1')).toMatchObject({ _version: 1 });
+    expect(cluster.gets).toBe(4);
+  });
+
+  it('should give up with StaleReadError when the backoff stops', async () => {
+    const cluster = laggingCluster(100);
+    const client = new KimDBClient({ url: 'ws://localhost:40000/ws', fetch: cluster.fetch });
+    const session = new ConsistentSession(client, { backoff: linearBackoff(1, 2) });
+    await session.save('docs', 'd1', { title: 'v1' });
+
+    const error = await session.getDoc('docs', 'd1').catch(e => e);
+    expect(error).toBeInstanceOf(StaleReadError);
+    expect(error).toMatchObject({ collection: 'docs', id: 'd1', expected: 1, seen: null });
+    expect(error.message).toBe('Stale read on docs/d1: expected _version >= 1, saw no document');
+    expect(cluster.gets).toBe(3);
+  });
+});
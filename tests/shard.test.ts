@@ -0,0 +1,88 @@
+/**
+ * Client-side Sharding Unit Tests
+ */
+
+import { describe, it, expect } from 'vitest';
+import { ShardedClient, hashStrategy, rangeStrategy } from '../src/client/shard.js';
+import { FakeKimDBClient } from '../src/client/fake.js';
+import { KVStore } from '../src/client/kv.js';
+import { listAll } from '../src/client/page.js';
+
+function setup(): { a: FakeKimDBClient; b: FakeKimDBClient; c: FakeKimDBClient; sharded: ShardedClient } {
+  const a = new FakeKimDBClient();
+  const b = new FakeKimDBClient();
+  const c = new FakeKimDBClient();
+  const sharded = new ShardedClient([a, b, c], {
+    strategy: rangeStrategy(['h', 'p']),
+    collections: { settings: 2 },
+  });
+  return { a, b, c, sharded };
+}
+
+describe('ShardedClient', () => {
+  it('should route documents by key range and pinned collection', async () => {
+    const { a, b, c, sharded } = setup();
+    await sharded.save('users', 'alice', { age: 30 });
+    await sharded.save('users', 'kim', { age: 41 });
+    await sharded.save('users', 'zoe', { age: 25 });
+    await sharded.save('settings', 'alice', { theme: 'dark' });
+
+    expect((await a.list('users')).data.map(d => d.id)).toEqual(['alice']);
+    expect((await b.list('users')).data.map(d => d.id)).toEqual(['kim']);
+    expect((await c.list('users')).data.map(d => d.id)).toEqual(['zoe']);
+    expect((await c.getDoc('settings', 'alice')).data).toEqual({ theme: 'dark' });
+    expect((await sharded.getDoc('users', 'kim')).data).toEqual({ age: 41 });
+    expect(await sharded.collections()).toEqual(['settings', 'users']);
+  });
+
+  it('should scatter-gather lists and SELECT with order and limit', async () => {
+    const { sharded } = setup();
+    for (const [id, age] of [['alice', 30], ['kim', 41], ['zoe', 25], ['bob', 19]] as const) {
+      await sharded.save('users', id, { age });
+    }
+
+    expect((await sharded.list('users')).count).toBe(4);
+    const res = await sharded.sql('users', 'SELECT * FROM users ORDER BY age DESC LIMIT 3');
+    expect(res.rows?.map(r => (r as { id: string }).id)).toEqual(['kim', 'alice', 'zoe']);
+    expect(await sharded.sample('users', 2)).toHaveLength(2);
+    await expect(sharded.sql('users', "INSERT INTO users (name) VALUES ('x')")).rejects.toThrow('cannot be routed');
+  });
+
+  it('should page across shards in shard order', async () => {
+    const { sharded } = setup();
+    for (const id of ['alice', 'bob', 'kim', 'zoe', 'yuna']) await sharded.save('users', id, {});
+
+    const first = await sharded.listPage('users', { limit: 3 });
+    expect(first.data.map(d => d.id)).toEqual(['alice', 'bob', 'kim']);
+    expect(first).toMatchObject({ total: 5, hasMore: true, nextSkip: 3 });
+    const second = await first.nextPage(sharded);
+    expect(second!.data.map(d => d.id)).toEqual(['zoe', 'yuna']);
+    expect(second!.hasMore).toBe(false);
+  });
+
+  it('should report more pages from list and reach every document with listAll', async () => {
+    const { sharded } = setup();
+    for (const prefix of ['a', 'k', 'z']) {
+      for (let i = 0; i < 600; i++) await sharded.save('users', `${prefix}${i}`, {});
+    }
+
+    const first = await sharded.list('users');
+    expect(first).toMatchObject({ count: 1000, total: 1800, hasMore: true, nextSkip: 1000 });
+    expect(await listAll(sharded, 'users')).toHaveLength(1800);
+  });
+
+  it('should create with a client-side ID on the owning shard', async () => {
+    const fakes = [new FakeKimDBClient(), new FakeKimDBClient()];
+    const sharded = new ShardedClient(fakes);
+    const { id } = await sharded.create('events', { kind: 'click' });
+    const owner = fakes[hashStrategy(2)('events', id)];
+    expect((await owner.getDoc('events', id)).data).toEqual({ kind: 'click' });
+  });
+
+  it('should work as a REST client for helpers', async () => {
+    const { sharded } = setup();
+    const kv = new KVStore(sharded, 'sessions');
+    await kv.set('zeta', 1);
+    expect(await kv.get('zeta')).toBe(1);
+  });
+});
@@ -0,0 +1,95 @@
+/**
+ * Shared Session Unit Tests
+ */
+
+import { describe, it, expect } from 'vitest';
+import { acquireSession } from '../src/client/shared.js';
+
+/** 'connected'를 보내고 받은 메시지를 기록하는 WebSocket */
+class RecordingSocket {
+  static instances: RecordingSocket[] = [];
+  readyState = 0;
+  binaryType = 'blob';
+  sent: Array<Record<string, unknown>> = [];
+  closed = false;
+  onopen: (() => void) | null = null;
+  onmessage: ((event: { data: string }) => void) | null = null;
+  onerror: (() => void) | null = null;
+  onclose: (() => void) | null = null;
+
+  constructor(readonly url: string) {
+    RecordingSocket.instances.push(this);
+    setTimeout(() => {
+      this.readyState = 1;
+      this.onopen?.();
+      this.onmessage?.({ data: JSON.stringify({ type: 'connected', clientId: 'c1', serverId: 's1' }) });
+    }, 0);
+  }
+
+  send(frame: string): void {
+    this.sent.push(JSON.parse(frame));
+  }
+
+  close(): void {
+    if (this.readyState === 3) return;
+    this.readyState = 3;
+    this.closed = true;
+    setTimeout(() => this.onclose?.(), 0);
+  }
+}
+
+function options(url: string) {
+  return { url, WebSocketImpl: RecordingSocket as unknown as typeof WebSocket };
+}
+
+function subscriptionMessages(socket: RecordingSocket): string[] {
+  return socket.sent.filter(m => m.type === 'subscribe' || m.type === 'unsubscribe').map(m => `${m.type}:${m.collection}`);
+}
+
+describe('acquireSession', () => {
+  it('should share one connection and unsubscribe only when the last session lets go', async () => {
+    RecordingSocket.instances = [];
+    const a = await acquireSession(options('ws://shared-1/ws'));
+    const b = await acquireSession(options('ws://shared-1/ws'));
+    expect(a.client).toBe(b.client);
+    expect(RecordingSocket.instances).toHaveLength(1);
+    const socket = RecordingSocket.instances[0];
+
+    a.subscribe('users');
+    a.subscribe('users');
+    b.subscribe('users');
+    expect(subscriptionMessages(socket)).toEqual(['subscribe:users']);
+
+    a.unsubscribe('users');
+    expect(subscriptionMessages(socket)).toEqual(['subscribe:users']);
+    b.unsubscribe('users');
+    expect(subscriptionMessages(socket)).toEqual(['subscribe:users', 'unsubscribe:users']);
+    a.release();
+    b.release();
+  });
+
+  it('should unsubscribe and disconnect when the session count reaches zero', async () => {
+    RecordingSocket.instances = [];
+    const a = await acquireSession(options('ws://shared-2/ws'));
+    const b = await acquireSession(options('ws://shared-2/ws'));
+    const socket = RecordingSocket.instances[0];
+    a.subscribe('orders');
+    b.subscribe('orders');
+    b.subscribe('events');
+
+    b.release();
+    b.release();
+    expect(subscriptionMessages(socket)).toEqual(['subscribe:orders', 'subscribe:events', 'unsubscribe:events']);
+    expect(socket.closed).toBe(false);
+
+    a.release();
+    expect(subscriptionMessages(socket).at(-1)).toBe('unsubscribe:orders');
+    expect(socket.closed).toBe(true);
+
+    // 풀에서 빠졌으므로 다음 세션은 새 연결
+    const c = await acquireSession(options('ws://shared-2/ws'));
+    expect(c.client).not.toBe(a.client);
+    expect(RecordingSocket.instances).toHaveLength(2);
+    c.release();
+  });
+});
@@ -0,0 +1,106 @@
+/**
+ * Document Snapshot Unit Tests
+ */
+
+import { describe, it, expect, beforeEach, afterEach } from 'vitest';
+import { mkdtempSync, rmSync } from 'fs';
+import { join } from 'path';
+import { tmpdir } from 'os';
+import { CRDTDocument, VectorClock } from '../src/crdt/index.js';
+import { KimDatabase } from '../src/server/database.js';
+import { snapshotLabelError, restoreState } from '../src/shared/snapshots.js';
+import type { Config } from '../src/server/config.js';
+
+/** 저장/전송된 것처럼 직렬화한 상태 (toJSON은 내부 배열을 공유함) */
+const stateOf = (doc: CRDTDocument) => JSON.parse(JSON.stringify(doc.toJSON()));
+
+describe('restoreState', () => {
+  it('should bring back map fields, lists and rich text in the same document object', () => {
+    const doc = new CRDTDocument('server', 'd1');
+    doc.set('title', 'v1');
+    doc.listInsert('tags', 0, 'a');
+    doc.richInsert('body', 0, 'x');
+    const saved = stateOf(doc);
+
+    doc.set('title', 'v2');
+    doc.listInsert('tags', 1, 'b');
+    doc.richInsert('body', 1, 'y');
+    doc.set('extra', true);
+
+    const restored = restoreState(doc, saved);
+    expect(restored).toBe(doc);
+    expect(doc.toObject()).toEqual({ title: 'v1', tags: ['a'] });
+    expect(doc.richGetText('body')).toBe('x');
+  });
+
+  it('should move version and vector clock forward so later edits win', () => {
+    const doc = new CRDTDocument('server', 'd1');
+    doc.set('title', 'v1');
+    const saved = stateOf(doc);
+    doc.set('title', 'v2');
+    doc.set('title', 'v3');
+    const version = doc.version;
+    const clock = new VectorClock('server', doc.clock.clock);
+
+    restoreState(doc, saved);
+    expect(doc.version).toBe(version + 1);
+    expect(doc.clock.compare(clock)).toBe(1);
+
+    doc.set('title', 'after');
+    expect(doc.get('title')).toBe('after');
+  });
+
+  it('should not reapply ops that were applied before the restore', () => {
+    const doc = new CRDTDocument('server', 'd1');
+    const saved = stateOf(doc);
+    const remote = new CRDTDocument('c2', 'd1');
+    const op = remote.set('title', 'late');
+    expect(doc.applyRemote(op)).toBe(true);
+
+    restoreState(doc, saved);
+    expect(doc.applyRemote(op)).toBe(false);
+    expect(doc.get('title')).toBeUndefined();
+  });
+
+  it('should validate labels', () => {
+    expect(snapshotLabelError('release-1')).toBeNull();
+    expect(snapshotLabelError('')).toMatch('label must be');
+    expect(snapshotLabelError('x'.repeat(101))).toMatch('label must be');
+    expect(snapshotLabelError(7)).toMatch('label must be');
+  });
+});
+
+describe('KimDatabase snapshots', () => {
+  let dir: string;
+  let db: KimDatabase;
+
+  beforeEach(() => {
+    dir = mkdtempSync(join(tmpdir(), 'kimdb-'));
+    db = new KimDatabase({ dataDir: dir } as Config);
+  });
+
+  afterEach(() => {
+    db.close();
+    rmSync(dir, { recursive: true, force: true });
+  });
+
+  it('should store, list newest first and load snapshot states per document', () => {
+    const state = { version: 3, root: {} };
+    expect(db.createSnapshot('docs', 'd1', 'first', state, 1000)).toEqual({ label: 'first', version: 3, createdAt: 1000 });
+    db.createSnapshot('docs', 'd1', 'second', { version: 5 }, 2000);
+    db.createSnapshot('docs', 'd2', 'other', { version: 1 }, 3000);
+
+    expect(db.listSnapshots('docs', 'd1')).toEqual([
+      { label: 'second', version: 5, createdAt: 2000 },
+      { label: 'first', version: 3, createdAt: 1000 },
+    ]);
+    expect(db.getSnapshotState('docs', 'd1', 'first')).toEqual(state);
+    expect(db.getSnapshotState('docs', 'd2', 'first')).toBeNull();
+  });
+
+  it('should refuse to overwrite an existing label', () => {
+    db.createSnapshot('docs', 'd1', 'first', { version: 1 }, 1000);
+    expect(db.createSnapshot('docs', 'd1', 'first', { version: 9 }, 2000)).toBeNull();
+    expect(db.getSnapshotState('docs', 'd1', 'first')).toEqual({ version: 1 });
+  });
+});
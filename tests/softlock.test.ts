@@ -0,0 +1,57 @@
+/**
+ * Editing Claim Unit Tests
+ */
+
+import { describe, it, expect, vi } from 'vitest';
+import { Awareness } from '../src/client/awareness.js';
+import { EditingClaim, type Editor } from '../src/client/softlock.js';
+
+function remote(nodeId: string, awareness: Record<string, unknown>) {
+  return { type: 'presence_updated', collection: 'docs', docId: 'd1', nodeId, user: { awareness } };
+}
+
+describe('EditingClaim', () => {
+  it('should announce, refresh and release the editing state', () => {
+    vi.useFakeTimers();
+    const sent: unknown[] = [];
+    const claim = new EditingClaim(new Awareness('docs', 'd1', msg => sent.push(msg)), 'Kim', 1000);
+
+    expect(sent).toHaveLength(1);
+    expect(sent[0]).toMatchObject({ type: 'presence_update', user: { awareness: { editing: { name: 'Kim' } } } });
+    vi.advanceTimersByTime(2500);
+    expect(sent).toHaveLength(3);
+
+    claim.release();
+    expect(claim.held).toBe(false);
+    expect(sent[3]).toEqual({ type: 'presence_update', user: { awareness: {} } });
+    vi.advanceTimersByTime(5000);
+    expect(sent).toHaveLength(4);
+    vi.useRealTimers();
+  });
+
+  it('should report other editors and contention once', () => {
+    const awareness = new Awareness('docs', 'd1', () => {});
+    const claim = new EditingClaim(awareness, 'Kim', 1000);
+    const contention: Editor[][] = [];
+    const changes: string[][] = [];
+    claim.onContention = editors => contention.push(editors);
+    claim.onChange = editors => changes.push(editors.map(e => e.name));
+
+    awareness.handleMessage(remote('n2', { editing: { name: 'Lee', since: 200 } }));
+    awareness.handleMessage(remote('n3', { editing: { name: 'Park', since: 100 } }));
+    awareness.handleMessage(remote('n4', { typing: true }));
+    expect(claim.editors().map(e => e.name)).toEqual(['Park', 'Lee']);
+    expect(contention).toHaveLength(1);
+    expect(contention[0][0]).toEqual({ nodeId: 'n2', name: 'Lee', since: 200 });
+
+    // 연결이 끊긴 편집자는 presence_left로 빠짐
+    awareness.handleMessage({ type: 'presence_left', collection: 'docs', docId: 'd1', nodeId: 'n2' });
+    awareness.handleMessage({ type: 'presence_left', collection: 'docs', docId: 'd1', nodeId: 'n3' });
+    expect(claim.editors()).toEqual([]);
+    expect(changes.at(-1)).toEqual([]);
+
+    awareness.handleMessage(remote('n5', { editing: { name: 'Choi', since: 300 } }));
+    expect(contention).toHaveLength(2);
+    claim.release();
+  });
+});
@@ -0,0 +1,92 @@
+/**
+ * Sort Specification Unit Tests
+ */
+
+import { describe, it, expect } from 'vitest';
+import { asc, desc, orderByClause, parseOrderBy, compareBy } from '../src/client/sort.js';
+import { fieldsOf } from '../src/client/filter.js';
+import { buildSelectWhere, SQLValidationError } from '../src/client/sql.js';
+import { FakeKimDBClient } from '../src/client/fake.js';
+import { KimDBClient } from '../src/client/index.js';
+import { checkCollation } from '../src/shared/collation.js';
+
+const User = fieldsOf<{ name: string; age: number }>();
+
+describe('SortSpec', () => {
+  it('should serialize keys in priority order with explicit directions', () => {
+    expect(orderByClause('SELECT', [desc('age'), asc('name')])).toBe('age DESC, name ASC');
+    expect(buildSelectWhere('users', { team: 'x' }, { sort: [User.age.desc(), User.name.asc()] }).sql)
+      .toBe('SELECT * FROM users WHERE team = ? ORDER BY age DESC, name ASC');
+  });
+
+  it('should reject empty, invalid and duplicate keys', () => {
+    expect(() => orderByClause('SELECT', [])).toThrow(SQLValidationError);
+    expect(() => orderByClause('SELECT', asc('bad-name'))).toThrow('Invalid field name');
+    expect(() => orderByClause('SELECT', { field: 'age', direction: 'down' as 'asc' })).toThrow('Invalid sort direction');
+    expect(() => orderByClause('SELECT', [asc('age'), desc('age')])).toThrow('Duplicate sort field');
+  });
+
+  it('should parse ORDER BY lists and compare by later keys on ties', () => {
+    const keys = parseOrderBy('SELECT * FROM users ORDER BY age DESC, name LIMIT 3');
+    expect(keys).toEqual([desc('age'), asc('name')]);
+    expect(parseOrderBy('SELECT * FROM users')).toBeNull();
+
+    const rows = [{ name: 'b', age: 30 }, { name: 'c', age: 20 }, { name: 'a', age: 30 }];
+    expect(rows.sort(compareBy(keys!)).map(r => r.name)).toEqual(['a', 'b', 'c']);
+  });
+
+  it('should sort find() results by multiple keys in FakeKimDBClient', async () => {
+    const client = new FakeKimDBClient();
+    await client.save('users', 'u1', { name: 'kim', age: 30, team: 'x' });
+    await client.save('users', 'u2', { name: 'lee', age: 40, team: 'x' });
+    await client.save('users', 'u3', { name: 'ahn', age: 30, team: 'x' });
+
+    const { sql, params } = buildSelectWhere('users', { team: 'x' }, { sort: [desc('age'), asc('name')] });
+    const res = await client.sql('users', sql, params);
+    expect(res.rows?.map(r => (r as { name: string }).name)).toEqual(['lee', 'ahn', 'kim']);
+  });
+});
+
+describe('collation', () => {
+  const names = ['item10', 'Banana', 'item2', 'apple'].map((name, i) => ({ id: `d${i}`, name }));
+
+  it('should order strings case-insensitively and numerically when asked', () => {
+    const keys = [asc('name')];
+    expect([...names].sort(compareBy(keys)).map(r => r.name)).toEqual(['Banana', 'apple', 'item10', 'item2']);
+    expect([...names].sort(compareBy(keys, { locale: 'en', caseInsensitive: true, numeric: true })).map(r => r.name))
+      .toEqual(['apple', 'Banana', 'item2', 'item10']);
+  });
+
+  it('should apply to WHERE equality and ORDER BY in FakeKimDBClient', async () => {
+    const client = new FakeKimDBClient();
+    for (const { id, name } of names) await client.save('items', id, { name, kind: id === 'd1' ? 'Fruit' : 'fruit' });
+
+    const res = await client.sql('items', 'SELECT * FROM items WHERE kind = ? ORDER BY name', ['FRUIT'], {
+      collation: { caseInsensitive: true, numeric: true },
+    });
+    expect(res.rows?.map(r => (r as { name: string }).name)).toEqual(['apple', 'Banana', 'item2', 'item10']);
+    expect((await client.sql('items', 'SELECT * FROM items WHERE kind = ?', ['FRUIT'])).rows).toEqual([]);
+  });
+
+  it('should send collation with find() and reject invalid options before sending', async () => {
+    const bodies: unknown[] = [];
+    const client = new KimDBClient({
+      url: 'ws://localhost:40000/ws',
+      fetch: async (_input, init) => {
+        bodies.push(JSON.parse(String(init?.body)));
+        return new Response(JSON.stringify({ success: true, rows: [] }));
+      },
+    });
+
+    await client.find('users', { team: 'x' }, { sort: asc('name'), collation: { locale: 'ko', caseInsensitive: true } });
+    expect(bodies[0]).toMatchObject({
+      sql: 'SELECT * FROM users WHERE team = ? ORDER BY name ASC',
+      collation: { locale: 'ko', caseInsensitive: true },
+    });
+
+    expect(checkCollation({ locale: 'not a locale!' })).toMatch('Invalid collation locale');
+    await expect(client.sql('users', 'SELECT * FROM users', [], { collation: { numeric: 'yes' as unknown as boolean } }))
+      .rejects.toThrow(SQLValidationError);
+    expect(bodies).toHaveLength(1);
+  });
+});
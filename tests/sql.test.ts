@@ -0,0 +1,74 @@
+/**
+ * Client SQL Check Unit Tests
+ */
+
+import { describe, it, expect } from 'vitest';
+import {
+  parseStatement,
+  validateStatement,
+  buildUpdateWhere,
+  buildDeleteWhere,
+  buildCountWhere,
+  SQLValidationError,
+} from '../src/client/sql.js';
+
+describe('parseStatement', () => {
+  it('should detect type, table and placeholders', () => {
+    const parsed = parseStatement('SELECT * FROM users WHERE age > ? AND name = ?');
+    expect(parsed).toEqual({ type: 'SELECT', table: 'users', placeholders: 2 });
+  });
+
+  it('should ignore placeholders inside string literals', () => {
+    expect(parseStatement("SELECT * FROM t WHERE q = 'why?' AND x = ?").placeholders).toBe(1);
+    expect(parseStatement("SELECT * FROM t WHERE q = 'it''s?'").placeholders).toBe(0);
+  });
+
+  it('should cache parsed statements by text', () => {
+    const sql = 'DELETE FROM logs WHERE ts < ?';
+    expect(parseStatement(sql)).toBe(parseStatement(sql));
+  });
+
+  it('should reject unsupported or malformed statements', () => {
+    expect(() => parseStatement('DROP TABLE users')).toThrow(SQLValidationError);
+    expect(() => parseStatement('UPDATE users WHERE id = ?')).toThrow(SQLValidationError);
+    expect(() => parseStatement("SELECT * FROM t WHERE q = 'open")).toThrow(SQLValidationError);
+  });
+});
+
+describe('validateStatement', () => {
+  it('should check parameter counts', () => {
+    expect(() => validateStatement('INSERT INTO t (a, b) VALUES (?, ?)', [1])).toThrow(/Expected 2 parameters/);
+    expect(validateStatement('INSERT INTO t (a, b) VALUES (?, ?)', [1, 2]).type).toBe('INSERT');
+  });
+});
+
+describe('buildUpdateWhere / buildDeleteWhere', () => {
+  it('should order WHERE params before SET params', () => {
+    expect(buildUpdateWhere('tasks', { status: 'open', owner: 'kim' }, { status: 'closed' })).toEqual({
+      sql: 'UPDATE tasks SET status = ? WHERE status = ? AND owner = ?',
+      params: ['open', 'kim', 'closed'],
+    });
+    expect(buildDeleteWhere('tasks', { status: 'done' })).toEqual({
+      sql: 'DELETE FROM tasks WHERE status = ?',
+      params: ['done'],
+    });
+  });
+
+  it('should reject empty filters and invalid fields', () => {
+    expect(() => buildDeleteWhere('tasks', {})).toThrow(SQLValidationError);
+    expect(() => buildUpdateWhere('tasks', { a: 1 }, {})).toThrow(SQLValidationError);
+    expect(() => buildUpdateWhere('tasks', { 'a = 1 OR b': 1 }, { c: 1 })).toThrow(/Invalid field name/);
+  });
+});
+
+describe('buildCountWhere', () => {
+  it('should count the whole collection for an empty filter', () => {
+    expect(buildCountWhere('tasks', {})).toEqual({ sql: 'SELECT COUNT(*) AS cnt FROM tasks', params: [] });
+    expect(buildCountWhere('tasks', { status: 'open' })).toEqual({
+      sql: 'SELECT COUNT(*) AS cnt FROM tasks WHERE status = ?',
+      params: ['open'],
+    });
+    const { sql, params } = buildCountWhere('tasks', { status: 'open', owner: 'kim' });
+    expect(validateStatement(sql, params).table).toBe('tasks');
+  });
+});
@@ -0,0 +1,131 @@
+/**
+ * Collaborative Text Unit Tests
+ */
+
+import { describe, it, expect, beforeEach } from 'vitest';
+import { CRDTDocument } from '../src/crdt/index.js';
+import { CollaborativeText } from '../src/client/text.js';
+
+/** 원격 문서에서 만든 op를 로컬 문서에 적용하고 편집기에 알림 (클라이언트의 crdt_sync 처리와 같은 순서) */
+function deliver(local: CRDTDocument, text: CollaborativeText, ops: unknown[]): void {
+  for (const op of ops) local.applyRemote(op);
+  text.handleRemoteOps(ops);
+}
+
+function typeInto(doc: CRDTDocument, path: string, index: number, chars: string): unknown[] {
+  return [...chars].map((char, i) => doc.richInsert(path, index + i, char));
+}
+
+describe('CollaborativeText', () => {
+  let local: CRDTDocument;
+  let remote: CRDTDocument;
+  let sent: unknown[];
+  let text: CollaborativeText;
+
+  beforeEach(() => {
+    local = new CRDTDocument('c1', 'd1');
+    remote = new CRDTDocument('c2', 'd1');
+    sent = [];
+    text = new CollaborativeText(local, 'body', (op) => sent.push(op));
+    deliver(local, text, typeInto(remote, 'body', 0, 'hello'));
+    text.cursor = 2;
+  });
+
+  describe('handleRemoteOps', () => {
+    it('should shift the cursor right for remote inserts before it', () => {
+      deliver(local, text, typeInto(remote, 'body', 0, 'ab'));
+      expect(text.toString()).toBe('abhello');
+      expect(text.cursor).toBe(4);
+    });
+
+    it('should keep the cursor for remote inserts at or after it', () => {
+      deliver(local, text, typeInto(remote, 'body', 2, 'X'));
+      expect(text.toString()).toBe('heXllo');
+      expect(text.cursor).toBe(2);
+
+      deliver(local, text, typeInto(remote, 'body', 6, '!'));
+      expect(text.toString()).toBe('heXllo!');
+      expect(text.cursor).toBe(2);
+    });
+
+    it('should shift the cursor left for remote deletes before it', () => {
+      deliver(local, text, [remote.richDelete('body', 0)]);
+      expect(text.toString()).toBe('ello');
+      expect(text.cursor).toBe(1);
+    });
+
+    it('should keep the cursor for remote deletes at or after it', () => {
+      deliver(local, text, [remote.richDelete('body', 2)]);
+      expect(text.toString()).toBe('helo');
+      expect(text.cursor).toBe(2);
+
+      deliver(local, text, [remote.richDelete('body', 3)]);
+      expect(text.toString()).toBe('hel');
+      expect(text.cursor).toBe(2);
+    });
+
+    it('should ignore ops on other paths and non-text ops', () => {
+      let changes = 0;
+      text.onChange = () => changes++;
+
+      deliver(local, text, [...typeInto(remote, 'title', 0, 'ab'), remote.set('title', 'x'), remote.listInsert('body', 0, 'y')]);
+      expect(text.cursor).toBe(2);
+      expect(changes).toBe(0);
+
+      deliver(local, text, typeInto(remote, 'body', 0, 'a'));
+      expect(changes).toBe(1);
+    });
+  });
+
+  describe('local edits', () => {
+    it('should send one op per inserted character and move the cursor for inserts at or before it', () => {
+      text.insertAt(2, 'ab');
+      expect(text.toString()).toBe('heabllo');
+      expect(sent).toHaveLength(2);
+      expect(sent[0]).toMatchObject({ type: 'rga_insert', richType: 'insert', path: 'body' });
+      expect(text.cursor).toBe(4);
+
+      text.insertAt(7, '!');
+      expect(text.toString()).toBe('heabllo!');
+      expect(text.cursor).toBe(4);
+    });
+
+    it('should delete up to the end of the text and clamp the cursor into the deleted range', () => {
+      text.cursor = 3;
+      text.deleteAt(1, 3);
+      expect(text.toString()).toBe('ho');
+      expect(sent).toHaveLength(3);
+      expect(text.cursor).toBe(1);
+
+      text.deleteAt(1, 10);
+      expect(text.toString()).toBe('h');
+      expect(sent).toHaveLength(4);
+      expect(text.cursor).toBe(1);
+    });
+
+    it('should keep the cursor for deletes after it', () => {
+      text.deleteAt(3, 2);
+      expect(text.toString()).toBe('hel');
+      expect(text.cursor).toBe(2);
+    });
+
+    it('should format a range and replicate it to other replicas', () => {
+      text.format(1, 3, { bold: true });
+      expect(sent).toHaveLength(2);
+      expect(sent[0]).toMatchObject({ type: 'rich_format', path: 'body', format: { bold: true } });
+      expect(text.toDelta()).toEqual([
+        { insert: 'h' },
+        { insert: 'el', attributes: { bold: true } },
+        { insert: 'lo' },
+      ]);
+
+      for (const op of sent) remote.applyRemote(op);
+      expect(remote.richGetDelta('body')).toEqual(text.toDelta());
+    });
+
+    it('should report the visible length', () => {
+      text.deleteAt(0);
+      expect(text.length).toBe(4);
+    });
+  });
+});
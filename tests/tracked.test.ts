@@ -0,0 +1,40 @@
+/**
+ * Tracked Document Unit Tests
+ */
+
+import { describe, it, expect, vi } from 'vitest';
+import { loadTracked } from '../src/client/tracked.js';
+import { FakeKimDBClient } from '../src/client/fake.js';
+
+describe('TrackedDocument', () => {
+  it('should send only the fields changed since load', async () => {
+    const fake = new FakeKimDBClient();
+    await fake.save('users', 'u1', { name: 'kim', age: 30, tags: ['a'], profile: { city: 'Seoul', zip: '01' }, note: 'x' });
+    const update = vi.spyOn(fake, 'update');
+
+    const user = await loadTracked(fake, 'users', 'u1');
+    expect(await user.save()).toBe(false);
+    expect(update).not.toHaveBeenCalled();
+
+    user.value.age = 31;
+    (user.value.profile as { city: string }).city = 'Busan';
+    delete user.value.note;
+    expect(user.changedFields().sort()).toEqual(['age', 'note', 'profile']);
+
+    expect(await user.save()).toBe(true);
+    expect(update).toHaveBeenCalledWith('users', 'u1', { age: 31, profile: { city: 'Busan', zip: '01' }, note: null });
+    expect(user.dirty).toBe(false);
+    expect(user.version).toBe(2);
+    expect((await fake.getDoc('users', 'u1')).data).toMatchObject({ name: 'kim', age: 31, note: null });
+  });
+
+  it('should discard unsaved changes on reset', async () => {
+    const fake = new FakeKimDBClient();
+    await fake.save('users', 'u1', { name: 'kim' });
+    const user = await loadTracked(fake, 'users', 'u1');
+    user.value.name = 'lee';
+    user.reset();
+    expect(user.value).toEqual({ name: 'kim' });
+    expect(user.dirty).toBe(false);
+  });
+});
@@ -0,0 +1,157 @@
+/**
+ * Transaction Unit Tests
+ */
+
+import { describe, it, expect } from 'vitest';
+import { KimDBClient } from '../src/client/index.js';
+import { VersionConflictError } from '../src/client/errors.js';
+import { linearBackoff } from '../src/client/backoff.js';
+
+type Stored = { data: Record<string, unknown>; _version: number };
+
+/** 서버 문서 저장소 (REST getDoc과 update_batch가 같이 씀) */
+const store = new Map<string, Stored>();
+
+/** update_batch를 서버와 같은 규칙으로 처리하는 소켓 (expectedVersion 불일치면 전체 롤백) */
+class BatchSocket {
+  readyState = 0;
+  binaryType = 'blob';
+  onopen: (() => void) | null = null;
+  onmessage: ((event: { data: string }) => void) | null = null;
+  onerror: (() => void) | null = null;
+  onclose: (() => void) | null = null;
+
+  constructor(readonly url: string) {
+    setTimeout(() => {
+      this.readyState = 1;
+      this.onopen?.();
+      this.reply({ type: 'connected', clientId: 'c1', serverId: 's1' });
+    }, 0);
+  }
+
+  send(frame: string): void {
+    const msg = JSON.parse(frame);
+    if (msg.type !== 'update_batch') return;
+
+    const staged = new Map(store);
+    for (const op of msg.ops) {
+      const key = `${op.collection}/${op.id}`;
+      const current = staged.get(key);
+      const version = current?._version ?? 0;
+      if (op.expectedVersion !== undefined && op.expectedVersion !== version) {
+        this.reply({
+          type: 'error',
+          message: `Batch rolled back: Version conflict on ${key}`,
+          code: 'version_conflict',
+          collection: op.collection,
+          id: op.id,
+          expected: op.expectedVersion,
+          _version: version,
+          requestId: msg.requestId,
+        });
+        return;
+      }
+      staged.set(key, { data: { ...current?.data, ...op.data }, _version: version + 1 });
+    }
+    for (const [key, value] of staged) store.set(key, value);
+    const results = msg.ops.map((op: { collection: string; id: string }) => ({
+      collection: op.collection,
+      id: op.id,
+      _version: store.get(`${op.collection}/${op.id}`)!._version,
+    }));
+    this.reply({ type: 'batch_ack', results, requestId: msg.requestId });
+  }
+
+  close(): void {
+    if (this.readyState === 3) return;
+    this.readyState = 3;
+    setTimeout(() => this.onclose?.(), 0);
+  }
+
+  private reply(msg: Record<string, unknown>): void {
+    setTimeout(() => this.onmessage?.({ data: JSON.stringify(msg) }), 0);
+  }
+}
+
+async function connectedClient(): Promise<KimDBClient> {
+  const client = new KimDBClient({
+    url: 'ws://localhost:40000/ws',
+    WebSocketImpl: BatchSocket as unknown as typeof WebSocket,
+    fetch: async (input) => {
+      const [collection, id] = new URL(String(input)).pathname.split('/').slice(-2);
+      const doc = store.get(`${collection}/${id}`);
+      if (!doc) return new Response('{"error":"Not found"}', { status: 404 });
+      return new Response(JSON.stringify({ success: true, id, data: doc.data, _version: doc._version }));
+    },
+  });
+  await client.connect();
+  return client;
+}
+
+describe('runTransaction', () => {
+  it('should retry on conflict until both concurrent transfers commit', async () => {
+    store.clear();
+    store.set('accounts/a', { data: { balance: 100 }, _version: 1 });
+    store.set('accounts/b', { data: { balance: 0 }, _version: 1 });
+    const client = await connectedClient();
+
+    let runs = 0;
+    const transfer = (amount: number) => client.runTransaction(async (tx) => {
+      runs++;
+      const a = await tx.get<{ balance: number }>('accounts', 'a');
+      const b = await tx.get<{ balance: number }>('accounts', 'b');
+      tx.set('accounts', 'a', { balance: a!.balance - amount });
+      tx.set('accounts', 'b', { balance: b!.balance + amount });
+      return amount;
+    }, { backoff: linearBackoff(1, 10) });
+
+    expect(await Promise.all([transfer(30), transfer(20)])).toEqual([30, 20]);
+    expect(runs).toBe(3);
+    expect(store.get('accounts/a')).toEqual({ data: { balance: 50 }, _version: 3 });
+    expect(store.get('accounts/b')).toEqual({ data: { balance: 50 }, _version: 3 });
+    client.disconnect();
+  });
+
+  it('should give up after maxAttempts and leave every document untouched', async () => {
+    store.clear();
+    store.set('counters/hot', { data: { n: 0 }, _version: 1 });
+    const client = await connectedClient();
+
+    let runs = 0;
+    const result = client.runTransaction(async (tx) => {
+      runs++;
+      const hot = await tx.get<{ n: number }>('counters', 'hot');
+      // 다른 클라이언트가 매번 끼어듦
+      store.set('counters/hot', { data: { n: 100 }, _version: store.get('counters/hot')!._version + 1 });
+      tx.set('counters', 'other', { touched: true });
+      tx.set('counters', 'hot', { n: hot!.n + 1 });
+    }, { maxAttempts: 3, backoff: linearBackoff(1, 10) });
+
+    const error = await result.catch((e: unknown) => e);
+    expect(error).toBeInstanceOf(VersionConflictError);
+    expect((error as VersionConflictError).currentVersion).toBe(4);
+    expect(runs).toBe(3);
+    expect(store.has('counters/other')).toBe(false);
+    client.disconnect();
+  });
+
+  it('should treat a missing document as version 0 and reject reads after writes', async () => {
+    store.clear();
+    const client = await connectedClient();
+
+    const created = await client.runTransaction(async (tx) => {
+      const existing = await tx.get('users', 'u1');
+      if (!existing) tx.set('users', 'u1', { name: 'Kim' });
+      return existing === null;
+    });
+    expect(created).toBe(true);
+    expect(store.get('users/u1')).toEqual({ data: { name: 'Kim' }, _version: 1 });
+
+    await expect(client.runTransaction(async (tx) => {
+      tx.set('users', 'u2', { name: 'Lee' });
+      await tx.get('users', 'u1');
+    })).rejects.toThrow('Transaction reads must come before writes');
+    expect(store.has('users/u2')).toBe(false);
+    client.disconnect();
+  });
+});
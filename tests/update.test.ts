@@ -0,0 +1,111 @@
+/**
+ * Update Operator Unit Tests
+ */
+
+import { describe, it, expect } from 'vitest';
+import { applyUpdateOps, UpdateOpError } from '../src/shared/update-ops.js';
+import { updateOf } from '../src/client/update.js';
+import { KimDBClient } from '../src/client/index.js';
+
+interface User {
+  name: string;
+  tags: string[];
+  roles: Array<{ id: number; scope?: string }>;
+  profile?: { homeCity: string; geo?: { lat: number } };
+}
+
+describe('applyUpdateOps', () => {
+  const doc = { name: 'kim', tags: ['a', 'b', 'a'], roles: [{ id: 1, scope: 'x' }], profile: { homeCity: 'Seoul' } };
+
+  it('should apply array and nested operators in order without mutating the input', () => {
+    const ops = updateOf<User>()
+      .push('tags', 'c')
+      .addUnique('tags', 'b', 'd')
+      .pull('tags', 'a')
+      .pull('roles', { scope: 'x', id: 1 })
+      .set('profile.geo.lat', 37.5)
+      .unset('profile.homeCity')
+      .ops;
+
+    const next = applyUpdateOps(doc, ops);
+    expect(next).toEqual({ name: 'kim', tags: ['b', 'c', 'd'], roles: [], profile: { geo: { lat: 37.5 } } });
+    expect(doc.tags).toEqual(['a', 'b', 'a']);
+  });
+
+  it('should create missing arrays and ignore removals on missing paths', () => {
+    expect(applyUpdateOps({}, [
+      { op: 'push', path: 'a.list', values: [1] },
+      { op: 'pull', path: 'x.y', values: [1] },
+      { op: 'unset', path: 'x.y.z' },
+    ])).toEqual({ a: { list: [1] } });
+  });
+
+  it('should reject type mismatches and unsafe paths', () => {
+    expect(() => applyUpdateOps(doc, [{ op: 'push', path: 'name', values: [1] }])).toThrow('name is not an array');
+    expect(() => applyUpdateOps(doc, [{ op: 'set', path: 'name.first', value: 'k' }])).toThrow(UpdateOpError);
+    expect(() => applyUpdateOps(doc, [{ op: 'set', path: '__proto__.polluted', value: 1 }])).toThrow('Invalid path');
+    expect(() => updateOf().set('a..b', 1)).toThrow('Invalid path');
+    expect(({} as Record<string, unknown>).polluted).toBeUndefined();
+  });
+
+  it('should increment numbers, starting missing fields at zero', () => {
+    const next = applyUpdateOps({ stats: { views: 2 } }, updateOf<{ stats: { views: number; likes?: number } }>()
+      .inc('stats.views')
+      .inc('stats.likes', 5)
+      .inc('stats.views', -3)
+      .ops);
+    expect(next).toEqual({ stats: { views: 0, likes: 5 } });
+    expect(() => applyUpdateOps(doc, [{ op: 'inc', path: 'name', by: 1 }])).toThrow('name is not a number');
+    expect(() => applyUpdateOps(doc, [{ op: 'inc', path: 'n', by: NaN }])).toThrow(UpdateOpError);
+  });
+});
+
+describe('client.modify', () => {
+  it('should PATCH ops with field names converted', async () => {
+    const bodies: unknown[] = [];
+    const client = new KimDBClient({
+      url: 'ws://localhost:40000/ws',
+      fieldNaming: 'snake_case',
+      fetch: async (_input, init) => {
+        bodies.push({ method: init?.method, ...JSON.parse(String(init?.body)) });
+        return new Response(JSON.stringify({ success: true, id: 'u1', _version: 3 }));
+      },
+    });
+
+    const res = await client.modify('users', 'u1', updateOf<User>().set('profile.homeCity', 'Busan').push('roles', { id: 2 }));
+    expect(res._version).toBe(3);
+    expect(bodies).toEqual([{
+      method: 'PATCH',
+      ops: [
+        { op: 'set', path: 'profile.home_city', value: 'Busan' },
+        { op: 'push', path: 'roles', values: [{ id: 2 }] },
+      ],
+    }]);
+  });
+
+  it('should send counter and set helpers as ops', async () => {
+    const bodies: unknown[] = [];
+    const expected: unknown[] = [];
+    const client = new KimDBClient({
+      url: 'ws://localhost:40000/ws',
+      fieldNaming: 'snake_case',
+      fetch: async (_input, init) => {
+        bodies.push(JSON.parse(String(init?.body)).ops);
+        expected.push((init?.headers as Record<string, string>)['X-KimDB-Expected-Version']);
+        return new Response(JSON.stringify({ success: true, id: 'u1', _version: 4 }));
+      },
+    });
+
+    await client.increment('users', 'u1', 'loginCount');
+    await client.increment('users', 'u1', 'credits', -10);
+    await client.addToSet('users', 'u1', 'tags', ['a', 'b']);
+    await client.removeFromSet('users', 'u1', 'tags', ['a'], { expectedVersion: 4 });
+    expect(bodies).toEqual([
+      [{ op: 'inc', path: 'login_count', by: 1 }],
+      [{ op: 'inc', path: 'credits', by: -10 }],
+      [{ op: 'addUnique', path: 'tags', values: ['a', 'b'] }],
+      [{ op: 'pull', path: 'tags', values: ['a'] }],
+    ]);
+    expect(expected).toEqual([undefined, undefined, undefined, '4']);
+  });
+});
@@ -0,0 +1,48 @@
+/**
+ * Consistency Verification Unit Tests
+ */
+
+import { describe, it, expect } from 'vitest';
+import { createHash } from 'node:crypto';
+import { verifyCollection, type VerifyClient } from '../src/client/verify.js';
+import { checksumTree } from '../src/shared/checksum.js';
+
+const sha256 = (text: string) => createHash('sha256').update(text).digest('hex');
+
+function server(docs: Record<string, unknown>): VerifyClient & { calls: number } {
+  const fake = {
+    calls: 0,
+    async checksum(_collection: string, buckets?: string[]) {
+      fake.calls++;
+      const tree = await checksumTree(Object.entries(docs), sha256);
+      if (!buckets) return { count: Object.keys(docs).length, root: tree.root, buckets: tree.buckets };
+      return { docs: Object.assign({}, ...buckets.map(b => tree.docs[b] ?? {})) };
+    },
+  };
+  return fake as VerifyClient & { calls: number };
+}
+
+describe('verifyCollection', () => {
+  it('matches with one request when data is identical (key order ignored)', async () => {
+    const remote = server({ a: { n: 1, s: 'x' }, b: { list: [1, 2] } });
+    const result = await verifyCollection(remote, 'items', { a: { s: 'x', n: 1 }, b: { list: [1, 2] } });
+    expect(result).toEqual({ consistent: true, changed: [], missingLocal: [], missingRemote: [] });
+    expect(remote.calls).toBe(1);
+  });
+
+  it('reports changed, server-only and local-only documents', async () => {
+    const remote = server({ a: { n: 1 }, b: { n: 2 }, c: { n: 3 } });
+    const local = new Map<string, unknown>([['a', { n: 1 }], ['b', { n: 20 }], ['d', { n: 4 }]]);
+    const result = await verifyCollection(remote, 'items', local);
+    expect(result.consistent).toBe(false);
+    expect(result.changed).toEqual(['b']);
+    expect(result.missingLocal).toEqual(['c']);
+    expect(result.missingRemote).toEqual(['d']);
+  });
+
+  it('encodes field names before hashing', async () => {
+    const remote = server({ a: { user_name: 'kim' } });
+    const result = await verifyCollection(remote, 'items', { a: { userName: 'kim' } }, 'snake_case');
+    expect(result.consistent).toBe(true);
+  });
+});
@@ -0,0 +1,110 @@
+/**
+ * Version Check Unit Tests
+ */
+
+import { describe, it, expect, beforeEach, afterEach } from 'vitest';
+import { mkdtempSync, rmSync } from 'fs';
+import { join } from 'path';
+import { tmpdir } from 'os';
+import { compareVersions, versionsRequestError } from '../src/shared/versions.js';
+import { KimDBClient } from '../src/client/index.js';
+import { KimDatabase } from '../src/server/database.js';
+import type { Config } from '../src/server/config.js';
+
+describe('compareVersions', () => {
+  it('should split ids into stale, missing and up to date', () => {
+    const current = new Map([['a', 1], ['b', 3]]);
+    expect(compareVersions({ a: 1, b: 2, c: 5 }, current)).toEqual({
+      stale: [{ id: 'b', _version: 3 }],
+      missing: ['c'],
+      upToDate: 1,
+    });
+  });
+
+  it('should report a newer client version as stale too', () => {
+    expect(compareVersions({ a: 9 }, new Map([['a', 2]])).stale).toEqual([{ id: 'a', _version: 2 }]);
+  });
+});
+
+describe('versionsRequestError', () => {
+  it('should require an object of at most 1000 ids', () => {
+    expect(versionsRequestError({ a: 1 })).toBeNull();
+    expect(versionsRequestError({})).toBeNull();
+    expect(versionsRequestError(undefined)).toBe('versions is required');
+    expect(versionsRequestError([1])).toBe('versions is required');
+    expect(versionsRequestError('a')).toBe('versions is required');
+
+    const many = Object.fromEntries(Array.from({ length: 1001 }, (_, i) => [`d${i}`, 1]));
+    expect(versionsRequestError(many)).toBe('At most 1000 ids per request');
+  });
+});
+
+describe('checkVersions', () => {
+  let dir: string;
+  let db: KimDatabase;
+
+  beforeEach(() => {
+    dir = mkdtempSync(join(tmpdir(), 'kimdb-'));
+    db = new KimDatabase({ dataDir: dir } as Config);
+  });
+
+  afterEach(() => {
+    db.close();
+    rmSync(dir, { recursive: true, force: true });
+  });
+
+  /** TS 서버의 POST /api/c/:collection/versions 처리와 같은 순서로 db에 묻는 fetch */
+  function serverFetch(requests: number[]): typeof fetch {
+    return (async (input: RequestInfo | URL, init?: RequestInit) => {
+      const [, , , collection, action] = new URL(String(input)).pathname.split('/');
+      expect(action).toBe('versions');
+      const { versions } = JSON.parse(String(init!.body));
+      const problem = versionsRequestError(versions);
+      if (problem) return new Response(JSON.stringify({ error: problem }), { status: 400 });
+      requests.push(Object.keys(versions).length);
+
+      const current = db.getVersions(collection, Object.keys(versions));
+      return new Response(JSON.stringify({ success: true, collection, ...compareVersions(versions, current) }));
+    }) as typeof fetch;
+  }
+
+  it('should look up ids across 500-id query chunks', () => {
+    const ids = Array.from({ length: 1200 }, (_, i) => `d${i}`);
+    for (const id of ids) db.saveDocument('docs', id, JSON.stringify({}));
+    db.saveDocument('docs', 'd700', JSON.stringify({ edited: true }));
+    db.deleteDocument('docs', 'd1100');
+
+    const versions = db.getVersions('docs', [...ids, 'nope']);
+    expect(versions.size).toBe(1199);
+    expect(versions.get('d0')).toBe(1);
+    expect(versions.get('d700')).toBe(2);
+    expect(versions.has('d1100')).toBe(false);
+    expect(versions.has('nope')).toBe(false);
+  });
+
+  it('should send 1000 ids per request and merge the results', async () => {
+    const ids = Array.from({ length: 2500 }, (_, i) => `d${i}`);
+    for (const id of ids) db.saveDocument('docs', id, JSON.stringify({}));
+    db.saveDocument('docs', 'd5', JSON.stringify({ edited: true }));
+    db.saveDocument('docs', 'd1999', JSON.stringify({ edited: true }));
+    db.deleteDocument('docs', 'd2400');
+
+    const requests: number[] = [];
+    const client = new KimDBClient({ url: 'ws://localhost:40000/ws', fetch: serverFetch(requests) });
+    const local = Object.fromEntries(ids.map(id => [id, 1]));
+
+    expect(await client.checkVersions('docs', local)).toEqual({
+      stale: [{ id: 'd5', _version: 2 }, { id: 'd1999', _version: 2 }],
+      missing: ['d2400'],
+    });
+    expect(requests).toEqual([1000, 1000, 500]);
+  });
+
+  it('should not send a request for an empty version map', async () => {
+    const requests: number[] = [];
+    const client = new KimDBClient({ url: 'ws://localhost:40000/ws', fetch: serverFetch(requests) });
+
+    expect(await client.checkVersions('docs', {})).toEqual({ stale: [], missing: [] });
+    expect(requests).toEqual([]);
+  });
+});